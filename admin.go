@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/gopheracademy/manager/auth"
+	"github.com/gopheracademy/manager/validation"
+)
+
+const createAdminUsage = `usage: manager create-admin [flags] email
+
+Grants the email super-admin, or organiser of a conference with -conference, in the
+configured database.
+
+`
+
+// createAdmin implements the create-admin subcommand and returns the exit code.
+func createAdmin(args []string) int {
+	fs, config := commandFlags("create-admin", createAdminUsage)
+	conference := fs.Uint("conference", 0, "ID of the conference to grant organiser of, instead of super-admin")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	// sessions hold the email lowercased, the grant must too to be used.
+	email := auth.NormalizeEmail(fs.Arg(0))
+	if !validation.Matches("email", email) {
+		fmt.Fprintf(os.Stderr, "%q is not an email\n", email)
+		return 2
+	}
+	cfg, err := config()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	db, err := openDatabase(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	grant := auth.Grant{Email: email, Role: auth.RoleSuperAdmin}
+	if *conference != 0 {
+		grant.Role = auth.RoleOrganiser
+		grant.ConferenceID = uint32(*conference)
+	}
+	if err := auth.NewSQLRoleStore(db).Grant(context.Background(), grant); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if grant.ConferenceID != 0 {
+		fmt.Printf("granted %s %s of conference %d\n", email, grant.Role, grant.ConferenceID)
+	} else {
+		fmt.Printf("granted %s %s\n", email, grant.Role)
+	}
+	return 0
+}
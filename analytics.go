@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/gopheracademy/manager/errs"
+	"github.com/gopheracademy/manager/log"
+	"github.com/gopheracademy/manager/store"
+	"github.com/gopheracademy/manager/ticketing"
+)
+
+type analyticsService struct {
+	logger log.Factory
+	// tickets and events are nil without a database, every call then fails with
+	// errs.Unavailable.
+	tickets ticketing.SalesStore
+	events  *store.EventRepository
+}
+
+func newAnalyticsService(logger log.Factory, tickets ticketing.SalesStore, events *store.EventRepository) *analyticsService {
+	return &analyticsService{logger: logger, tickets: tickets, events: events}
+}
+
+func (s analyticsService) EventSales(ctx context.Context, r EventSalesRequest) (*EventSalesResponse, error) {
+	loggerFor(s.logger, ctx).Info("analyticsService.EventSales", zap.Uint32("conference", r.ConferenceID), zap.Uint32("event", r.EventID))
+	if s.tickets == nil || s.events == nil {
+		return nil, errs.New(errs.Unavailable, "analytics need a database")
+	}
+	// organisers are scoped to their conference so the events of others are not found.
+	event, err := s.events.Read(ctx, r.EventID)
+	if err != nil {
+		return nil, err
+	}
+	if event == nil || event.ConferenceID != r.ConferenceID {
+		return nil, errs.New(errs.NotFound, "event %d not found", r.EventID)
+	}
+	report, err := ticketing.EventSales(ctx, s.tickets, r.EventID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	resp := &EventSalesResponse{
+		AsOf:     uint64(report.AsOf.Unix()),
+		TimeZone: report.Location.String(),
+		Slots:    make([]SlotSales, 0, len(report.Slots)),
+		Total:    slotSalesFrom(report.Total, report.Daily),
+		Promos:   make([]PromoUsage, 0, len(report.Promos)),
+	}
+	for _, s := range report.Slots {
+		resp.Slots = append(resp.Slots, slotSalesFrom(s, report.SlotDaily[s.Slot.ID]))
+	}
+	for _, p := range report.Promos {
+		resp.Promos = append(resp.Promos, PromoUsage{
+			Code:     p.Code,
+			Payments: p.Payments,
+			Claims:   p.Claims,
+			Discount: p.Discount,
+		})
+	}
+	return resp, nil
+}
+
+func slotSalesFrom(s ticketing.SlotSales, daily []ticketing.DailySales) SlotSales {
+	converted := SlotSales{
+		SlotID:    s.Slot.ID,
+		Name:      s.Slot.Name,
+		Capacity:  s.Capacity,
+		Sold:      s.Sold,
+		Held:      s.Held,
+		Remaining: s.Remaining,
+		Gross:     s.Gross,
+		Discounts: s.Discounts,
+		Net:       s.Net,
+		Velocity:  s.Velocity,
+		SellOut:   unixOrZero(s.SellOut),
+		SellsOut:  s.SellsOut,
+		SalesEnd:  unixOrZero(s.SalesEnd),
+		Daily:     make([]DailySales, 0, len(daily)),
+	}
+	for _, d := range daily {
+		converted.Daily = append(converted.Daily, DailySales{
+			Date:  d.Day.Format("2006-01-02"),
+			Sold:  d.Sold,
+			Gross: d.Gross,
+			Net:   d.Net,
+		})
+	}
+	return converted
+}
+
+// unixOrZero returns the Unix timestamp of t, 0 for the zero time.
+func unixOrZero(t time.Time) uint64 {
+	if t.IsZero() {
+		return 0
+	}
+	return uint64(t.Unix())
+}
@@ -0,0 +1,91 @@
+package apidocs
+
+// explorerPage lists the operations of the OpenAPI document and lets you call them from
+// the browser, with the session of the logged in user.
+const explorerPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Show Runner API</title>
+<style>
+  body { font-family: sans-serif; max-width: 60em; margin: 2em auto; color: #222; }
+  details { border: 1px solid #ddd; border-radius: 4px; margin: .5em 0; padding: .5em 1em; }
+  summary { cursor: pointer; font-family: monospace; font-size: 1.1em; }
+  textarea { width: 100%; height: 10em; font-family: monospace; }
+  pre { background: #f6f6f6; padding: .5em; overflow: auto; }
+  .roles { color: #666; font-size: .9em; }
+</style>
+</head>
+<body>
+<h1>Show Runner API</h1>
+<p id="description"></p>
+<p>The raw document is at <a href="` + SpecPath + `">` + SpecPath + `</a>.</p>
+<div id="operations"></div>
+<script>
+'use strict';
+
+function resolve(spec, schema) {
+  return schema && schema.$ref ? spec.components.schemas[schema.$ref.split('/').pop()] : schema;
+}
+
+// example builds a request skeleton from a schema.
+function example(spec, schema, depth) {
+  schema = resolve(spec, schema);
+  if (!schema || depth > 4) { return null; }
+  switch (schema.type) {
+    case 'object':
+      const o = {};
+      for (const [name, property] of Object.entries(schema.properties || {})) {
+        o[name] = example(spec, property, depth + 1);
+      }
+      return o;
+    case 'array': return [];
+    case 'integer': case 'number': return 0;
+    case 'boolean': return false;
+    default: return '';
+  }
+}
+
+function element(tag, props, ...children) {
+  const e = Object.assign(document.createElement(tag), props);
+  e.append(...children);
+  return e;
+}
+
+async function main() {
+  const spec = await (await fetch('` + SpecPath + `')).json();
+  document.getElementById('description').textContent = spec.info.description;
+  const operations = document.getElementById('operations');
+  for (const [path, item] of Object.entries(spec.paths).sort()) {
+    const op = item.post;
+    const schema = op.requestBody.content['application/json'].schema;
+    const body = element('textarea', { value: JSON.stringify(example(spec, schema, 0), null, 2) });
+    const result = element('pre');
+    const send = element('button', { textContent: 'Send' });
+    send.onclick = async () => {
+      const response = await fetch(path, {
+        method: 'POST',
+        credentials: 'same-origin',
+        headers: { 'Content-Type': 'application/json', 'Accept': 'application/json' },
+        body: body.value,
+      });
+      const text = await response.text();
+      let pretty = text;
+      try { pretty = JSON.stringify(JSON.parse(text), null, 2); } catch (e) {}
+      result.textContent = response.status + ' ' + response.statusText + '\n\n' + pretty;
+    };
+    const access = op.security.length === 0 ? 'open to anyone' :
+      'roles: super-admin' + (op['x-roles'] || []).map((r) => ', ' + r).join('');
+    operations.append(element('details', {},
+      element('summary', { textContent: 'POST ' + path }),
+      element('p', { textContent: op.summary || '' }),
+      element('p', { className: 'roles', textContent: access }),
+      body, send, result));
+  }
+}
+
+main();
+</script>
+</body>
+</html>
+`
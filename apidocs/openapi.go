@@ -0,0 +1,208 @@
+// Package apidocs serves an OpenAPI 3 description of the oto services, generated from
+// def into openapi.gen.go, along with a page to explore and call them.
+package apidocs
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gopheracademy/manager/validation"
+)
+
+const (
+	// PathPrefix is where the documentation is served from.
+	PathPrefix = "/api/docs/"
+	// SpecPath is the well-known path of the OpenAPI document.
+	SpecPath = "/api/openapi.json"
+)
+
+// Document is the root of an OpenAPI 3 document, only what we use is modeled.
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+}
+
+// Info describes the API.
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// PathItem holds the operations of a path, oto only uses POST.
+type PathItem struct {
+	Post *Operation `json:"post,omitempty"`
+}
+
+// Operation is a call to a service method.
+type Operation struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []map[string][]string `json:"security"`
+	// Roles are those allowed to call the operation, on top of super-admin.
+	Roles []string `json:"x-roles,omitempty"`
+}
+
+// RequestBody is the body of an operation.
+type RequestBody struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+// Response is a possible answer to an operation.
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// MediaType holds the schema of a body.
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Schema describes a type.
+type Schema struct {
+	Ref         string             `json:"$ref,omitempty"`
+	Type        string             `json:"type,omitempty"`
+	Format      string             `json:"format,omitempty"`
+	Description string             `json:"description,omitempty"`
+	Properties  map[string]*Schema `json:"properties,omitempty"`
+	Required    []string           `json:"required,omitempty"`
+	Items       *Schema            `json:"items,omitempty"`
+	Enum        []string           `json:"enum,omitempty"`
+	MinLength   *int               `json:"minLength,omitempty"`
+	MaxLength   *int               `json:"maxLength,omitempty"`
+	Minimum     *float64           `json:"minimum,omitempty"`
+	Maximum     *float64           `json:"maximum,omitempty"`
+	Pattern     string             `json:"pattern,omitempty"`
+}
+
+// Components are the definitions referenced from operations.
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes"`
+}
+
+// SecurityScheme describes how calls are authenticated.
+type SecurityScheme struct {
+	Type        string `json:"type"`
+	In          string `json:"in"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// sessionSecurity is what operations not open to anyone require.
+var sessionSecurity = []map[string][]string{{"session": {}}}
+
+// errorSchema describes the body of failed calls, see errs.Response.
+var errorSchema = &Schema{
+	Type:        "object",
+	Description: "The body of failed calls, the status code matches the code.",
+	Required:    []string{"error", "code"},
+	Properties: map[string]*Schema{
+		"error": {Type: "string", Description: "What went wrong, meant for humans."},
+		"code": {Type: "string", Enum: []string{"internal", "not_found", "invalid_argument", "conflict",
+			"unauthenticated", "permission_denied", "unavailable"}},
+		"fields": {
+			Type:        "array",
+			Description: "The invalid fields of invalid_argument errors.",
+			Items: &Schema{
+				Type:     "object",
+				Required: []string{"field", "description"},
+				Properties: map[string]*Schema{
+					"field":       {Type: "string", Description: "Path of the field, ie conference.events[0].name"},
+					"description": {Type: "string"},
+				},
+			},
+		},
+	},
+}
+
+func jsonContent(schema *Schema) map[string]*MediaType {
+	return map[string]*MediaType{"application/json": {Schema: schema}}
+}
+
+func ref(name string) *Schema {
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func floatPtr(f float64) *float64 {
+	return &f
+}
+
+// primitive completes the schema of a field of the Go type: numbers get the OpenAPI type
+// and format their values fit in, unsigned ones a minimum of 0 unless they have one, and
+// patterns are dropped from them as they only apply to strings.
+func primitive(goType string, s *Schema) *Schema {
+	switch goType {
+	case "float32":
+		s.Type, s.Format = "number", "float"
+	case "float64":
+		s.Type, s.Format = "number", "double"
+	case "int8", "int16", "int32", "uint8", "uint16":
+		s.Type, s.Format = "integer", "int32"
+	case "int", "int64", "uint", "uint32", "uint64":
+		// uint32 overflows int32.
+		s.Type, s.Format = "integer", "int64"
+	default:
+		return s
+	}
+	s.Pattern = ""
+	if strings.HasPrefix(goType, "uint") && s.Minimum == nil {
+		s.Minimum = floatPtr(0)
+	}
+	return s
+}
+
+// pattern returns the regular expression of a named validation pattern.
+func pattern(name string) string {
+	if re, ok := validation.Patterns[name]; ok {
+		return re.String()
+	}
+	return ""
+}
+
+// Handler serves the OpenAPI document at SpecPath and the explorer under PathPrefix.
+type Handler struct {
+	spec []byte
+}
+
+// NewHandler returns a Handler, the document is rendered once.
+func NewHandler() (*Handler, error) {
+	spec, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return &Handler{spec: spec}, nil
+}
+
+// ServeHTTP implements http.Handler
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	switch r.URL.Path {
+	case SpecPath:
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Write(h.spec)
+	case PathPrefix:
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(explorerPage))
+	default:
+		http.NotFound(w, r)
+	}
+}
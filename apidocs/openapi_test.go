@@ -0,0 +1,71 @@
+package apidocs
+
+import (
+	"bytes"
+	"go/format"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// TestGenerated needs oto, as generate.sh does.
+func TestGenerated(t *testing.T) {
+	if _, err := exec.LookPath("oto"); err != nil {
+		t.Skip("oto is not installed")
+	}
+	out := filepath.Join(t.TempDir(), "openapi.gen.go")
+	cmd := exec.Command("oto", "-template", "../templates/openapi.go.plush", "-out", out, "-pkg", "apidocs", "../def")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("oto: %v\n%s", err, output)
+	}
+	generated, err := ioutil.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the generated literal must compile, ie fields are not set twice.
+	formatted, err := format.Source(generated)
+	if err != nil {
+		t.Fatalf("formatting the generated document: %v", err)
+	}
+	committed, err := ioutil.ReadFile("openapi.gen.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(formatted, committed) {
+		t.Error("openapi.gen.go is not what def generates, run generate.sh")
+	}
+}
+
+func TestPrimitive(t *testing.T) {
+	for _, tt := range []struct {
+		goType, jsType string
+		min            *float64
+		wantType       string
+		wantFormat     string
+		wantMin        *float64
+	}{
+		{"uint32", "number", nil, "integer", "int64", floatPtr(0)},
+		{"uint64", "number", floatPtr(1), "integer", "int64", floatPtr(1)},
+		{"uint16", "number", nil, "integer", "int32", floatPtr(0)},
+		{"int", "number", nil, "integer", "int64", nil},
+		{"int32", "number", nil, "integer", "int32", nil},
+		{"float64", "number", nil, "number", "double", nil},
+		{"string", "string", nil, "string", "slug", nil},
+	} {
+		format := ""
+		if tt.jsType == "string" {
+			format = "slug"
+		}
+		s := primitive(tt.goType, &Schema{Type: tt.jsType, Format: format, Minimum: tt.min, Pattern: "^x$"})
+		if s.Type != tt.wantType || s.Format != tt.wantFormat {
+			t.Errorf("%s is %s %s, want %s %s", tt.goType, s.Type, s.Format, tt.wantType, tt.wantFormat)
+		}
+		if (s.Minimum == nil) != (tt.wantMin == nil) || (s.Minimum != nil && *s.Minimum != *tt.wantMin) {
+			t.Errorf("%s has minimum %v, want %v", tt.goType, s.Minimum, tt.wantMin)
+		}
+		if wantPattern := tt.jsType == "string"; (s.Pattern != "") != wantPattern {
+			t.Errorf("%s has pattern %q", tt.goType, s.Pattern)
+		}
+	}
+}
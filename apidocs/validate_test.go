@@ -0,0 +1,28 @@
+//go:build go1.20
+// +build go1.20
+
+package apidocs
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// TestDocumentIsValid validates the document generated from def against OpenAPI 3.0.3,
+// the validator needs a later Go than the server.
+func TestDocumentIsValid(t *testing.T) {
+	spec, err := json.Marshal(document)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := openapi3.NewLoader().LoadFromData(spec)
+	if err != nil {
+		t.Fatalf("loading the document: %v", err)
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		t.Errorf("the document is not valid OpenAPI: %v", err)
+	}
+}
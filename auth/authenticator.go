@@ -0,0 +1,253 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"github.com/gopheracademy/manager/log"
+	"github.com/gopheracademy/manager/mailer"
+)
+
+const (
+	// PathPrefix is where the authentication endpoints are served from.
+	PathPrefix = "/auth/"
+	// SessionCookie is the name of the cookie carrying the session.
+	SessionCookie = "showrunner_session"
+)
+
+// Options configure an Authenticator.
+type Options struct {
+	// Secret signs login links and sessions, changing it logs everyone out.
+	Secret []byte
+	// BaseURL is the public URL of the server, used to build login links.
+	BaseURL string
+	// LinkTTL is how long a login link is valid for.
+	LinkTTL time.Duration
+	// SessionTTL is how long a session lasts before having to log in again.
+	SessionTTL time.Duration
+	// Insecure allows the session cookie over plain HTTP, for development only.
+	Insecure bool
+	// Sender delivers the login links.
+	Sender mailer.Sender
+}
+
+// Authenticator implements passwordless login, users ask for a link sent to their email
+// which, once followed, starts a session carried in a signed cookie.
+//
+//	POST /auth/login {"email": "..."} sends a login link.
+//	GET  /auth/callback?token=... is the login link, it sets the session cookie.
+//	POST /auth/logout ends the session.
+//	GET  /auth/me returns the current identity.
+type Authenticator struct {
+	opts   Options
+	signer tokenSigner
+	router *mux.Router
+	logger log.Factory
+
+	// usedLinks holds the nonces of login links already followed until they expire, so
+	// links can be used only once; this is per instance which is enough given their TTL.
+	usedLinksLock sync.Mutex
+	usedLinks     map[string]time.Time
+}
+
+// NewAuthenticator returns an Authenticator, it fails if the options are incomplete.
+func NewAuthenticator(opts Options, logger log.Factory) (*Authenticator, error) {
+	if len(opts.Secret) < 32 {
+		return nil, fmt.Errorf("the authentication secret must be at least 32 bytes long")
+	}
+	if opts.Sender == nil {
+		return nil, fmt.Errorf("a mail sender is required to deliver login links")
+	}
+	if _, err := url.Parse(opts.BaseURL); err != nil || opts.BaseURL == "" {
+		return nil, fmt.Errorf("invalid base URL %q", opts.BaseURL)
+	}
+	if opts.LinkTTL == 0 {
+		opts.LinkTTL = 15 * time.Minute
+	}
+	if opts.SessionTTL == 0 {
+		opts.SessionTTL = 30 * 24 * time.Hour
+	}
+	a := &Authenticator{
+		opts:      opts,
+		signer:    tokenSigner{key: opts.Secret},
+		router:    mux.NewRouter(),
+		logger:    logger,
+		usedLinks: map[string]time.Time{},
+	}
+	a.router.HandleFunc(PathPrefix+"login", a.handleLogin).Methods(http.MethodPost)
+	a.router.HandleFunc(PathPrefix+"callback", a.handleCallback).Methods(http.MethodGet)
+	a.router.HandleFunc(PathPrefix+"logout", a.handleLogout).Methods(http.MethodPost)
+	a.router.HandleFunc(PathPrefix+"me", a.handleMe).Methods(http.MethodGet)
+	return a, nil
+}
+
+// ServeHTTP implements http.Handler
+func (a *Authenticator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a.router.ServeHTTP(w, r)
+}
+
+// Middleware adds the identity of the session, if any, to the request context, it does
+// not reject anonymous requests, that is up to the handlers.
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id := a.identity(r); id != nil {
+			r = r.WithContext(WithIdentity(r.Context(), id))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (a *Authenticator) identity(r *http.Request) *Identity {
+	cookie, err := r.Cookie(SessionCookie)
+	if err != nil {
+		return nil
+	}
+	c, err := a.signer.verify(purposeSession, cookie.Value, time.Now())
+	if err != nil {
+		return nil
+	}
+	return &Identity{Email: c.Email}
+}
+
+// SendLoginLink emails a login link to the passed address.
+func (a *Authenticator) SendLoginLink(ctx context.Context, email string) error {
+	addr, err := mail.ParseAddress(email)
+	if err != nil {
+		return fmt.Errorf("invalid email: %w", err)
+	}
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("generating nonce: %w", err)
+	}
+	token, err := a.signer.sign(purposeLogin, claims{
+		Email:   NormalizeEmail(addr.Address),
+		Expires: time.Now().Add(a.opts.LinkTTL).Unix(),
+		Nonce:   base64.RawURLEncoding.EncodeToString(nonce),
+	})
+	if err != nil {
+		return fmt.Errorf("signing login link: %w", err)
+	}
+	link := strings.TrimSuffix(a.opts.BaseURL, "/") + PathPrefix + "callback?token=" + url.QueryEscape(token)
+	err = a.opts.Sender.Send(ctx, mailer.Message{
+		To:      addr.Address,
+		Subject: "Your login link",
+		Text: fmt.Sprintf("Follow this link to log in, it can be used once and expires in %s.\n\n%s\n\n"+
+			"If you did not ask for it you can ignore this email.\n", a.opts.LinkTTL, link),
+	})
+	if err != nil {
+		return fmt.Errorf("sending login link: %w", err)
+	}
+	return nil
+}
+
+// Login exchanges a login link token for a session token.
+func (a *Authenticator) Login(token string) (string, *Identity, error) {
+	now := time.Now()
+	c, err := a.signer.verify(purposeLogin, token, now)
+	if err != nil {
+		return "", nil, err
+	}
+	if !a.useLink(c.Nonce, time.Unix(c.Expires, 0), now) {
+		return "", nil, ErrInvalidToken
+	}
+	session, err := a.signer.sign(purposeSession, claims{
+		Email:   c.Email,
+		Expires: now.Add(a.opts.SessionTTL).Unix(),
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf("signing session: %w", err)
+	}
+	return session, &Identity{Email: c.Email}, nil
+}
+
+// useLink marks the link nonce as used, returns false if it already was.
+func (a *Authenticator) useLink(nonce string, expires, now time.Time) bool {
+	a.usedLinksLock.Lock()
+	defer a.usedLinksLock.Unlock()
+	for n, exp := range a.usedLinks {
+		if now.After(exp) {
+			delete(a.usedLinks, n)
+		}
+	}
+	if _, used := a.usedLinks[nonce]; used || nonce == "" {
+		return false
+	}
+	a.usedLinks[nonce] = expires
+	return true
+}
+
+func (a *Authenticator) handleLogin(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Email string `json:"email"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&request); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		return
+	}
+	if _, err := mail.ParseAddress(request.Email); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid email"})
+		return
+	}
+	if err := a.SendLoginLink(r.Context(), request.Email); err != nil {
+		a.logger.For(r.Context()).Error("sending login link", zap.Error(err))
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "could not send the login link"})
+		return
+	}
+	// same answer whether we know the address or not.
+	writeJSON(w, http.StatusAccepted, map[string]bool{"ok": true})
+}
+
+func (a *Authenticator) handleCallback(w http.ResponseWriter, r *http.Request) {
+	session, id, err := a.Login(r.URL.Query().Get("token"))
+	if err != nil {
+		http.Error(w, "This login link is invalid, expired or was already used.", http.StatusUnauthorized)
+		return
+	}
+	a.logger.For(r.Context()).Info("user logged in", zap.String("email", id.Email))
+	http.SetCookie(w, a.cookie(session, int(a.opts.SessionTTL.Seconds())))
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+func (a *Authenticator) handleLogout(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, a.cookie("", -1))
+	writeJSON(w, http.StatusOK, map[string]bool{"ok": true})
+}
+
+func (a *Authenticator) handleMe(w http.ResponseWriter, r *http.Request) {
+	id := a.identity(r)
+	if id == nil {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "not logged in"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"email": id.Email})
+}
+
+func (a *Authenticator) cookie(value string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     SessionCookie,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   !a.opts.Insecure,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
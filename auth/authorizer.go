@@ -0,0 +1,95 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gopheracademy/manager/errs"
+)
+
+// ErrUnauthenticated is returned when an operation requires a user but there is none.
+var ErrUnauthenticated = errs.New(errs.Unauthenticated, "authentication required")
+
+// Permission describes who can perform an operation.
+type Permission struct {
+	// Roles that are allowed, super admins are always allowed and RoleAttendee means
+	// any logged in user.
+	Roles []Role
+	// ConferenceID is the conference the operation acts upon, grants on other conferences
+	// do not apply; 0 means the operation is not tied to one so only global grants do.
+	ConferenceID uint32
+}
+
+// DeniedError is returned when the user lacks the permission for an operation.
+type DeniedError struct {
+	Email      string
+	Permission Permission
+}
+
+func (e *DeniedError) Error() string {
+	if e.Permission.ConferenceID != 0 {
+		return fmt.Sprintf("%s needs one of the roles %v on conference %d", e.Email, e.Permission.Roles, e.Permission.ConferenceID)
+	}
+	return fmt.Sprintf("%s needs one of the roles %v", e.Email, e.Permission.Roles)
+}
+
+// Authorizer decides if the user of a request has a permission.
+type Authorizer struct {
+	roles RoleStore
+}
+
+// NewAuthorizer returns an Authorizer reading grants from roles.
+func NewAuthorizer(roles RoleStore) *Authorizer {
+	return &Authorizer{roles: roles}
+}
+
+// Authorize returns nil if the identity in ctx has the permission, ErrUnauthenticated
+// if there is no identity or a PermissionDenied error wrapping a *DeniedError if it
+// lacks the permission.
+func (a *Authorizer) Authorize(ctx context.Context, p Permission) error {
+	id, ok := FromContext(ctx)
+	if !ok {
+		return ErrUnauthenticated
+	}
+	for _, r := range p.Roles {
+		if r == RoleAttendee {
+			return nil
+		}
+	}
+	grants, err := a.roles.GrantsFor(ctx, id.Email)
+	if err != nil {
+		return errs.Wrap(errs.Unavailable, err, "could not authorize the operation")
+	}
+	for _, g := range grants {
+		if g.ConferenceID != 0 && g.ConferenceID != p.ConferenceID {
+			continue
+		}
+		if g.Role == RoleSuperAdmin && g.ConferenceID == 0 {
+			return nil
+		}
+		for _, r := range p.Roles {
+			if g.Role == r {
+				return nil
+			}
+		}
+	}
+	return errs.Wrap(errs.PermissionDenied, &DeniedError{Email: id.Email, Permission: p},
+		"you do not have permission to perform this operation, %s", p.required())
+}
+
+// required describes the roles needed for the permission.
+func (p Permission) required() string {
+	roles := make([]string, len(p.Roles))
+	for i, r := range p.Roles {
+		roles[i] = string(r)
+	}
+	if len(roles) == 0 {
+		roles = []string{string(RoleSuperAdmin)}
+	}
+	required := "it requires the role " + strings.Join(roles, " or ")
+	if p.ConferenceID != 0 {
+		required += fmt.Sprintf(" on conference %d", p.ConferenceID)
+	}
+	return required
+}
@@ -0,0 +1,32 @@
+package auth
+
+import (
+	"context"
+	"strings"
+)
+
+// Identity is the authenticated user of a request, attendees and organisers alike are
+// identified by the email they proved to own.
+type Identity struct {
+	Email string
+}
+
+type identityKey struct{}
+
+// WithIdentity returns a copy of ctx carrying the identity.
+func WithIdentity(ctx context.Context, id *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, id)
+}
+
+// FromContext returns the identity of the current user, false if the request is not
+// authenticated.
+func FromContext(ctx context.Context) (*Identity, bool) {
+	id, ok := ctx.Value(identityKey{}).(*Identity)
+	return id, ok && id != nil
+}
+
+// NormalizeEmail returns the email as identities and grants hold it, trimmed and
+// lowercased, so an address typed in another case is that of the same user.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,172 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/ShiftLeftSecurity/gaum/db/chain"
+	"github.com/ShiftLeftSecurity/gaum/db/connection"
+)
+
+// Role is a set of capabilities a user holds, possibly limited to one conference.
+type Role string
+
+const (
+	// RoleSuperAdmin can do anything on any conference.
+	RoleSuperAdmin Role = "super-admin"
+	// RoleOrganiser manages a conference and its events.
+	RoleOrganiser Role = "organiser"
+	// RoleFinance handles payments, invoices and reports.
+	RoleFinance Role = "finance"
+	// RoleFrontDesk redeems tickets at the venue.
+	RoleFrontDesk Role = "front-desk"
+	// RoleSponsorContact represents a sponsor company.
+	RoleSponsorContact Role = "sponsor-contact"
+	// RoleAttendee is held implicitly by every logged in user.
+	RoleAttendee Role = "attendee"
+)
+
+// Valid returns true if this is a role we know of.
+func (r Role) Valid() bool {
+	switch r {
+	case RoleSuperAdmin, RoleOrganiser, RoleFinance, RoleFrontDesk, RoleSponsorContact, RoleAttendee:
+		return true
+	}
+	return false
+}
+
+// Grant gives Role to the user with Email on the conference with ConferenceID, or on
+// every conference if it is 0.
+type Grant struct {
+	ID           uint64 `gaum:"field_name:id"`
+	Email        string `gaum:"field_name:email"`
+	Role         Role   `gaum:"field_name:role"`
+	ConferenceID uint32 `gaum:"field_name:conference_id"`
+}
+
+// RoleStore persists grants, emails are compared whatever their case, see NormalizeEmail.
+type RoleStore interface {
+	// GrantsFor returns every grant held by the user.
+	GrantsFor(ctx context.Context, email string) ([]Grant, error)
+	// Grant saves the grant, granting twice is not an error.
+	Grant(ctx context.Context, g Grant) error
+	// Revoke removes the grant matching email, role and conference.
+	Revoke(ctx context.Context, g Grant) error
+}
+
+// MemoryRoleStore keeps grants in memory, for development and tests.
+type MemoryRoleStore struct {
+	lock   sync.RWMutex
+	grants map[string][]Grant
+}
+
+var _ RoleStore = &MemoryRoleStore{}
+
+// NewMemoryRoleStore returns a MemoryRoleStore holding the passed grants, ie those of the
+// configured super admins, whatever the case of their emails.
+func NewMemoryRoleStore(grants ...Grant) *MemoryRoleStore {
+	s := &MemoryRoleStore{grants: map[string][]Grant{}}
+	for _, g := range grants {
+		s.Grant(context.Background(), g)
+	}
+	return s
+}
+
+// GrantsFor implements RoleStore
+func (s *MemoryRoleStore) GrantsFor(ctx context.Context, email string) ([]Grant, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return append([]Grant(nil), s.grants[NormalizeEmail(email)]...), nil
+}
+
+// Grant implements RoleStore
+func (s *MemoryRoleStore) Grant(ctx context.Context, g Grant) error {
+	if !g.Role.Valid() {
+		return fmt.Errorf("unknown role %q", g.Role)
+	}
+	g.Email = NormalizeEmail(g.Email)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, existing := range s.grants[g.Email] {
+		if existing.Role == g.Role && existing.ConferenceID == g.ConferenceID {
+			return nil
+		}
+	}
+	s.grants[g.Email] = append(s.grants[g.Email], g)
+	return nil
+}
+
+// Revoke implements RoleStore
+func (s *MemoryRoleStore) Revoke(ctx context.Context, g Grant) error {
+	g.Email = NormalizeEmail(g.Email)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	kept := s.grants[g.Email][:0]
+	for _, existing := range s.grants[g.Email] {
+		if existing.Role != g.Role || existing.ConferenceID != g.ConferenceID {
+			kept = append(kept, existing)
+		}
+	}
+	s.grants[g.Email] = kept
+	return nil
+}
+
+const (
+	tableRoleGrant              = "role_grant"
+	roleGrantIsUniqueConstraint = "role_grant_is_unique"
+)
+
+// SQLRoleStore persists grants in a postgres-like db.
+type SQLRoleStore struct {
+	conn connection.DB
+}
+
+var _ RoleStore = &SQLRoleStore{}
+
+// NewSQLRoleStore returns a SQLRoleStore using the passed connection.
+func NewSQLRoleStore(conn connection.DB) *SQLRoleStore {
+	return &SQLRoleStore{conn: conn}
+}
+
+// GrantsFor implements RoleStore
+func (s *SQLRoleStore) GrantsFor(ctx context.Context, email string) ([]Grant, error) {
+	grants := []Grant{}
+	err := chain.New(s.conn).Select("*").From(tableRoleGrant).
+		AndWhere("email = ?", NormalizeEmail(email)).Fetch(&grants)
+	if err != nil {
+		return nil, fmt.Errorf("reading grants: %w", err)
+	}
+	return grants, nil
+}
+
+// Grant implements RoleStore
+func (s *SQLRoleStore) Grant(ctx context.Context, g Grant) error {
+	if !g.Role.Valid() {
+		return fmt.Errorf("unknown role %q", g.Role)
+	}
+	err := chain.New(s.conn).Insert(map[string]interface{}{
+		"email":         NormalizeEmail(g.Email),
+		"role":          string(g.Role),
+		"conference_id": g.ConferenceID,
+	}).Table(tableRoleGrant).
+		OnConflict(func(c *chain.OnConflict) {
+			c.OnConstraint(roleGrantIsUniqueConstraint).DoNothing()
+		}).Exec()
+	if err != nil {
+		return fmt.Errorf("saving grant: %w", err)
+	}
+	return nil
+}
+
+// Revoke implements RoleStore
+func (s *SQLRoleStore) Revoke(ctx context.Context, g Grant) error {
+	err := chain.New(s.conn).Delete().Table(tableRoleGrant).
+		AndWhere("email = ?", NormalizeEmail(g.Email)).
+		AndWhere("role = ?", string(g.Role)).
+		AndWhere("conference_id = ?", g.ConferenceID).Exec()
+	if err != nil {
+		return fmt.Errorf("revoking grant: %w", err)
+	}
+	return nil
+}
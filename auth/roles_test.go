@@ -0,0 +1,73 @@
+package auth
+
+import (
+	"context"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/gopheracademy/manager/database"
+	"github.com/gopheracademy/manager/migrations"
+)
+
+func TestMemoryRoleStore(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryRoleStore(Grant{Email: " Jane@Example.com", Role: RoleSuperAdmin})
+	if err := s.Grant(ctx, Grant{Email: "JANE@example.com", Role: RoleSuperAdmin}); err != nil {
+		t.Fatalf("Grant() = %v", err)
+	}
+	grants, err := s.GrantsFor(ctx, "jane@example.com")
+	if err != nil || len(grants) != 1 || grants[0].Email != "jane@example.com" {
+		t.Fatalf("GrantsFor() = %+v, %v; want one super-admin grant", grants, err)
+	}
+	if err := s.Revoke(ctx, Grant{Email: "Jane@Example.com", Role: RoleSuperAdmin}); err != nil {
+		t.Fatalf("Revoke() = %v", err)
+	}
+	if grants, err := s.GrantsFor(ctx, "jane@example.com"); err != nil || len(grants) != 0 {
+		t.Errorf("GrantsFor() = %+v, %v; want the grant revoked", grants, err)
+	}
+}
+
+// TestSQLRoleStore needs an empty postgres database, ie
+//
+//	createdb showrunner_test
+//	SHOWRUNNER_TEST_DATABASE_URL=postgres://localhost/showrunner_test go test ./auth
+func TestSQLRoleStore(t *testing.T) {
+	url := os.Getenv("SHOWRUNNER_TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("SHOWRUNNER_TEST_DATABASE_URL is not set")
+	}
+	db, err := database.Open(url, log.New(os.Stderr, "", log.LstdFlags))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := migrations.New(db)
+	if _, err := m.Up(); err != nil {
+		t.Fatalf("migrating: %v", err)
+	}
+	defer func() {
+		if _, err := m.Down(len(migrations.All)); err != nil {
+			t.Errorf("cleaning up: %v", err)
+		}
+		db.Close()
+	}()
+
+	ctx := context.Background()
+	s := NewSQLRoleStore(db)
+	// the grant is typed in another case than the lowercased email of sessions.
+	for _, email := range []string{" Jane@Example.com", "JANE@example.com"} {
+		if err := s.Grant(ctx, Grant{Email: email, Role: RoleSuperAdmin}); err != nil {
+			t.Fatalf("Grant() = %v", err)
+		}
+	}
+	grants, err := s.GrantsFor(ctx, "jane@example.com")
+	if err != nil || len(grants) != 1 || grants[0].Email != "jane@example.com" || grants[0].Role != RoleSuperAdmin {
+		t.Fatalf("GrantsFor() = %+v, %v; want one super-admin grant", grants, err)
+	}
+	if err := s.Revoke(ctx, Grant{Email: "Jane@Example.com", Role: RoleSuperAdmin}); err != nil {
+		t.Fatalf("Revoke() = %v", err)
+	}
+	if grants, err := s.GrantsFor(ctx, "jane@example.com"); err != nil || len(grants) != 0 {
+		t.Errorf("GrantsFor() = %+v, %v; want the grant revoked", grants, err)
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"strings"
+
+	"github.com/gopheracademy/manager/auth"
+	"github.com/gopheracademy/manager/calendar"
+	"github.com/gopheracademy/manager/errs"
+	"github.com/gopheracademy/manager/log"
+	"github.com/gopheracademy/manager/ticketing"
+)
+
+type calendarService struct {
+	logger log.Factory
+	// tickets is nil without a database, every call then fails with errs.Unavailable.
+	tickets ticketing.AttendeeStore
+	signer  *calendar.Signer
+	// baseURL is where the server is reached, the feeds are relative to it.
+	baseURL string
+}
+
+func newCalendarService(logger log.Factory, tickets ticketing.AttendeeStore, signer *calendar.Signer, baseURL string) *calendarService {
+	return &calendarService{logger: logger, tickets: tickets, signer: signer, baseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+func (s calendarService) AttendeeFeed(ctx context.Context, r AttendeeFeedRequest) (*AttendeeFeedResponse, error) {
+	id, ok := auth.FromContext(ctx)
+	if !ok {
+		return nil, errs.New(errs.Unauthenticated, "log in to get your calendar")
+	}
+	loggerFor(s.logger, ctx).Info("calendarService.AttendeeFeed")
+	if s.tickets == nil {
+		return nil, errs.New(errs.Unavailable, "attendee feeds need a database")
+	}
+	attendee, err := s.tickets.ReadAttendeeByEmail(ctx, id.Email)
+	if err != nil {
+		return nil, err
+	}
+	if attendee == nil {
+		return nil, errs.New(errs.NotFound, "%s holds no tickets", id.Email)
+	}
+	return &AttendeeFeedResponse{URL: s.baseURL + s.signer.AttendeeFeedPath(attendee.ID)}, nil
+}
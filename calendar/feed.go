@@ -0,0 +1,125 @@
+package calendar
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gopheracademy/manager/def"
+	"github.com/gopheracademy/manager/ticketing"
+)
+
+// ForEvent returns the public schedule of an event, the event itself and every slot
+// available to the public that has its own time frame (ie workshops or tutorials),
+// slots spanning the whole event are admission tickets and already represented by it.
+func ForEvent(e *def.Event, domain string) (*Calendar, error) {
+	if err := validZone(e.TimeZone); err != nil {
+		return nil, err
+	}
+	c := &Calendar{
+		Name:     e.Name,
+		TimeZone: e.TimeZone,
+	}
+	if entry, ok := eventEntry(e, domain); ok {
+		c.Events = append(c.Events, entry)
+	}
+	for _, slot := range e.Slots {
+		if !slot.AvailableToPublic || !hasOwnTimeFrame(e, slot.StartDate, slot.EndDate) {
+			continue
+		}
+		c.Events = append(c.Events, Entry{
+			UID:         fmt.Sprintf("slot-%d@%s", slot.ID, domain),
+			Summary:     slot.Name,
+			Description: slot.Description,
+			Location:    e.Location,
+			Start:       unix(slot.StartDate),
+			End:         unix(slot.EndDate),
+			TimeZone:    e.TimeZone,
+		})
+	}
+	return c, nil
+}
+
+// ForAttendee returns the personal agenda of an attendee built from the slots they
+// claimed, each event they hold a ticket for appears once plus any workshop or
+// tutorial they claimed; slots of no event appear on their own, in UTC.
+func ForAttendee(a *ticketing.Attendee, domain string) (*Calendar, error) {
+	c := &Calendar{
+		Name: fmt.Sprintf("Agenda for %s", a.Email),
+	}
+	seenEvents := map[uint32]bool{}
+	for _, claim := range a.Claims {
+		slot := claim.EventSlot
+		if slot == nil {
+			continue
+		}
+		e := slot.Event
+		if e == nil {
+			e = &def.Event{}
+		}
+		if err := validZone(e.TimeZone); err != nil {
+			return nil, err
+		}
+		if c.TimeZone == "" {
+			c.TimeZone = e.TimeZone
+		}
+		if !seenEvents[e.ID] {
+			seenEvents[e.ID] = true
+			if entry, ok := eventEntry(e, domain); ok {
+				c.Events = append(c.Events, entry)
+			}
+		}
+		if !hasOwnTimeFrame(e, slot.StartDate, slot.EndDate) {
+			continue
+		}
+		c.Events = append(c.Events, Entry{
+			UID:         fmt.Sprintf("claim-%d@%s", claim.ID, domain),
+			Summary:     slot.Name,
+			Description: fmt.Sprintf("%s\n\nTicket: %s", slot.Description, claim.TicketID),
+			Location:    e.Location,
+			Start:       unix(slot.StartDate),
+			End:         unix(slot.EndDate),
+			TimeZone:    e.TimeZone,
+		})
+	}
+	return c, nil
+}
+
+// eventEntry returns the entry for the event as a whole, events lacking dates can
+// not be placed in a calendar.
+func eventEntry(e *def.Event, domain string) (Entry, bool) {
+	if e.StartDate == 0 || e.EndDate == 0 {
+		return Entry{}, false
+	}
+	return Entry{
+		UID:      fmt.Sprintf("event-%d@%s", e.ID, domain),
+		Summary:  e.Name,
+		Location: e.Location,
+		Start:    unix(e.StartDate),
+		End:      unix(e.EndDate),
+		TimeZone: e.TimeZone,
+	}, true
+}
+
+// hasOwnTimeFrame returns true if the slot is scheduled and does not simply span the
+// whole event.
+func hasOwnTimeFrame(e *def.Event, start, end uint64) bool {
+	if start == 0 || end == 0 {
+		return false
+	}
+	return start != e.StartDate || end != e.EndDate
+}
+
+func validZone(zone string) error {
+	if zone == "" {
+		return nil
+	}
+	if _, err := time.LoadLocation(zone); err != nil {
+		return fmt.Errorf("invalid event time zone %q: %w", zone, err)
+	}
+	return nil
+}
+
+// unix converts our stored dates, seconds since Epoch, to time.
+func unix(seconds uint64) time.Time {
+	return time.Unix(int64(seconds), 0).UTC()
+}
@@ -0,0 +1,39 @@
+package calendar
+
+import (
+	"testing"
+
+	"github.com/gopheracademy/manager/def"
+	"github.com/gopheracademy/manager/ticketing"
+)
+
+func TestForAttendee(t *testing.T) {
+	event := &def.Event{ID: 1, Name: "GopherCon", StartDate: 1636000000, EndDate: 1636300000, TimeZone: "America/Denver"}
+	a := &ticketing.Attendee{Email: "ada@example.com", Claims: []ticketing.SlotClaim{
+		{ID: 1, TicketID: "t1", EventSlot: &ticketing.EventSlot{Name: "conference", Event: event, StartDate: event.StartDate, EndDate: event.EndDate}},
+		{ID: 2, TicketID: "t2", EventSlot: &ticketing.EventSlot{Name: "workshop", Event: event, StartDate: 1636000000, EndDate: 1636010000}},
+		// a slot of no event is placed on its own.
+		{ID: 3, TicketID: "t3", EventSlot: &ticketing.EventSlot{Name: "meetup", StartDate: 1636400000, EndDate: 1636410000}},
+		{ID: 4, TicketID: "t4"},
+	}}
+	c, err := ForAttendee(a, "example.com")
+	if err != nil {
+		t.Fatalf("ForAttendee() = %v", err)
+	}
+	var uids []string
+	for _, e := range c.Events {
+		uids = append(uids, e.UID)
+	}
+	want := []string{"event-1@example.com", "claim-2@example.com", "claim-3@example.com"}
+	if len(uids) != len(want) {
+		t.Fatalf("agenda has %v, want %v", uids, want)
+	}
+	for i := range want {
+		if uids[i] != want[i] {
+			t.Errorf("entry %d is %s, want %s", i, uids[i], want[i])
+		}
+	}
+	if c.TimeZone != "America/Denver" || c.Events[2].TimeZone != "" {
+		t.Errorf("agenda in %q with the meetup in %q, want it in the event zone and the meetup in UTC", c.TimeZone, c.Events[2].TimeZone)
+	}
+}
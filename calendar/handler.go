@@ -0,0 +1,131 @@
+package calendar
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"github.com/gopheracademy/manager/def"
+	"github.com/gopheracademy/manager/log"
+	"github.com/gopheracademy/manager/ticketing"
+)
+
+// PathPrefix is where the feeds are served from.
+const PathPrefix = "/calendar/"
+
+// EventFinder finds an event by the slugs of its conference and itself.
+type EventFinder interface {
+	FindEvent(ctx context.Context, conferenceSlug, eventSlug string) (*def.Event, error)
+}
+
+// AttendeeReader reads attendees along with their claims, ticketing.PurchaseStore
+// satisfies it.
+type AttendeeReader interface {
+	ReadAttendeeByID(ctx context.Context, id uint64) (*ticketing.Attendee, error)
+}
+
+// Handler serves iCalendar feeds:
+//
+//	/calendar/events/{conference}/{event}.ics the public schedule of an event.
+//	/calendar/attendees/{id}.ics?token=... the signed personal agenda of an attendee.
+type Handler struct {
+	router    *mux.Router
+	events    EventFinder
+	attendees AttendeeReader
+	signer    *Signer
+	domain    string
+	logger    log.Factory
+}
+
+// NewHandler returns a Handler, domain is used to build the entries UIDs and must not
+// change during the life of the feeds; attendees might be nil if there is no storage
+// in which case only event feeds are served.
+func NewHandler(events EventFinder, attendees AttendeeReader, signer *Signer, domain string, logger log.Factory) *Handler {
+	h := &Handler{
+		router:    mux.NewRouter(),
+		events:    events,
+		attendees: attendees,
+		signer:    signer,
+		domain:    domain,
+		logger:    logger,
+	}
+	h.router.HandleFunc(PathPrefix+"events/{conference}/{event}.ics", h.serveEvent).Methods(http.MethodGet, http.MethodHead)
+	h.router.HandleFunc(PathPrefix+"attendees/{id:[0-9]+}.ics", h.serveAttendee).Methods(http.MethodGet, http.MethodHead)
+	return h
+}
+
+// ServeHTTP implements http.Handler
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.router.ServeHTTP(w, r)
+}
+
+func (h *Handler) serveEvent(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	event, err := h.events.FindEvent(r.Context(), vars["conference"], vars["event"])
+	if err != nil {
+		h.logger.For(r.Context()).Error("finding event for calendar", zap.Error(err))
+		http.Error(w, "could not load event", http.StatusInternalServerError)
+		return
+	}
+	if event == nil {
+		http.NotFound(w, r)
+		return
+	}
+	c, err := ForEvent(event, h.domain)
+	if err != nil {
+		h.logger.For(r.Context()).Error("building event calendar", zap.Error(err))
+		http.Error(w, "could not build calendar", http.StatusInternalServerError)
+		return
+	}
+	h.write(w, r, c, "schedule.ics")
+}
+
+func (h *Handler) serveAttendee(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	// do not disclose if the attendee exists to unsigned requests.
+	if !h.signer.Verify(id, r.URL.Query().Get("token")) {
+		http.NotFound(w, r)
+		return
+	}
+	if h.attendees == nil {
+		http.Error(w, "attendee feeds are not available", http.StatusServiceUnavailable)
+		return
+	}
+	attendee, err := h.attendees.ReadAttendeeByID(r.Context(), id)
+	if err != nil {
+		h.logger.For(r.Context()).Error("reading attendee for calendar", zap.Error(err))
+		http.Error(w, "could not load attendee", http.StatusInternalServerError)
+		return
+	}
+	if attendee == nil {
+		http.NotFound(w, r)
+		return
+	}
+	c, err := ForAttendee(attendee, h.domain)
+	if err != nil {
+		h.logger.For(r.Context()).Error("building attendee calendar", zap.Error(err))
+		http.Error(w, "could not build calendar", http.StatusInternalServerError)
+		return
+	}
+	h.write(w, r, c, "agenda.ics")
+}
+
+func (h *Handler) write(w http.ResponseWriter, r *http.Request, c *Calendar, filename string) {
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("Content-Disposition", `inline; filename="`+filename+`"`)
+	// feeds are polled, let clients and proxies hold on to them for a little while.
+	w.Header().Set("Cache-Control", "private, max-age=900")
+	if r.Method == http.MethodHead {
+		return
+	}
+	if _, err := c.WriteTo(w); err != nil {
+		h.logger.For(r.Context()).Error("writing calendar", zap.Error(err))
+	}
+}
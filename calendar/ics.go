@@ -0,0 +1,295 @@
+package calendar
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// productID identifies us as the producer of the calendars as required by RFC 5545.
+	productID = "-//GopherAcademy//ShowRunner//EN"
+	// ContentType is the MIME type iCalendar feeds must be served with.
+	ContentType = "text/calendar; charset=utf-8"
+
+	utcFormat   = "20060102T150405Z"
+	localFormat = "20060102T150405"
+	// maxLineOctets is the maximum length of a content line, longer ones must be folded.
+	maxLineOctets = 75
+)
+
+// Calendar is an iCalendar (RFC 5545) object holding a set of events.
+type Calendar struct {
+	// Name is shown by most calendar clients as the subscription name.
+	Name string
+	// TimeZone is the default zone clients should display this calendar in.
+	TimeZone string
+	Events   []Entry
+}
+
+// Entry is one VEVENT in the calendar.
+type Entry struct {
+	// UID must be globally unique and stable across feed refreshes so clients update
+	// existing entries instead of duplicating them.
+	UID         string
+	Summary     string
+	Description string
+	Location    string
+	Start       time.Time
+	End         time.Time
+	// TimeZone is the IANA zone this entry is presented in, empty means UTC.
+	TimeZone string
+}
+
+// WriteTo writes the calendar in iCalendar format to w.
+func (c *Calendar) WriteTo(w io.Writer) (int64, error) {
+	cw := &contentWriter{w: bufio.NewWriter(w)}
+	now := time.Now().UTC()
+
+	cw.line("BEGIN:VCALENDAR")
+	cw.line("VERSION:2.0")
+	cw.line("PRODID:" + productID)
+	cw.line("CALSCALE:GREGORIAN")
+	cw.line("METHOD:PUBLISH")
+	if c.Name != "" {
+		cw.line("X-WR-CALNAME:" + escapeText(c.Name))
+	}
+	if c.TimeZone != "" {
+		cw.line("X-WR-TIMEZONE:" + c.TimeZone)
+	}
+
+	zones, err := c.zones()
+	if err != nil {
+		return cw.n, err
+	}
+	for _, z := range zones {
+		z.write(cw)
+	}
+
+	for _, e := range c.Events {
+		cw.line("BEGIN:VEVENT")
+		cw.line("UID:" + escapeText(e.UID))
+		cw.line("DTSTAMP:" + now.Format(utcFormat))
+		cw.line("DTSTART" + formatDate(e.Start, e.TimeZone))
+		cw.line("DTEND" + formatDate(e.End, e.TimeZone))
+		cw.line("SUMMARY:" + escapeText(e.Summary))
+		if e.Description != "" {
+			cw.line("DESCRIPTION:" + escapeText(e.Description))
+		}
+		if e.Location != "" {
+			cw.line("LOCATION:" + escapeText(e.Location))
+		}
+		cw.line("END:VEVENT")
+	}
+	cw.line("END:VCALENDAR")
+
+	if cw.err != nil {
+		return cw.n, cw.err
+	}
+	return cw.n, cw.w.Flush()
+}
+
+// zones returns a VTIMEZONE for every zone referenced by the events, covering the
+// span of time those events need.
+func (c *Calendar) zones() ([]vtimezone, error) {
+	spans := map[string][2]time.Time{}
+	for _, e := range c.Events {
+		if e.TimeZone == "" {
+			continue
+		}
+		span, ok := spans[e.TimeZone]
+		if !ok {
+			span = [2]time.Time{e.Start, e.End}
+		}
+		if e.Start.Before(span[0]) {
+			span[0] = e.Start
+		}
+		if e.End.After(span[1]) {
+			span[1] = e.End
+		}
+		spans[e.TimeZone] = span
+	}
+	names := make([]string, 0, len(spans))
+	for name := range spans {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	zones := make([]vtimezone, 0, len(names))
+	for _, name := range names {
+		z, err := newVTimezone(name, spans[name][0], spans[name][1])
+		if err != nil {
+			return nil, err
+		}
+		zones = append(zones, z)
+	}
+	return zones, nil
+}
+
+// formatDate renders the value part of a DTSTART/DTEND property, including the TZID
+// parameter when the entry has a zone.
+func formatDate(t time.Time, zone string) string {
+	if zone == "" {
+		return ":" + t.UTC().Format(utcFormat)
+	}
+	loc, err := time.LoadLocation(zone)
+	if err != nil {
+		// zones are validated when building the VTIMEZONE, this is unreachable in practice.
+		return ":" + t.UTC().Format(utcFormat)
+	}
+	return ";TZID=" + zone + ":" + t.In(loc).Format(localFormat)
+}
+
+// escapeText escapes a TEXT value as described in RFC 5545 section 3.3.11.
+func escapeText(s string) string {
+	r := strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	)
+	return r.Replace(s)
+}
+
+// contentWriter writes folded, CRLF terminated content lines and remembers the first
+// error so callers can check it once.
+type contentWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (cw *contentWriter) line(s string) {
+	if cw.err != nil {
+		return
+	}
+	for len(s) > maxLineOctets {
+		cut := maxLineOctets
+		// never split a multi-byte UTF-8 sequence.
+		for cut > 0 && s[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		cw.write(s[:cut] + "\r\n")
+		// continuation lines start with a space, which counts towards the limit.
+		s = " " + s[cut:]
+	}
+	cw.write(s + "\r\n")
+}
+
+func (cw *contentWriter) write(s string) {
+	if cw.err != nil {
+		return
+	}
+	n, err := cw.w.WriteString(s)
+	cw.n += int64(n)
+	cw.err = err
+}
+
+// vtimezone describes the observances of one zone in the span of time covered by a
+// calendar, derived from the Go time zone database.
+type vtimezone struct {
+	name        string
+	observances []observance
+}
+
+type observance struct {
+	daylight   bool
+	start      time.Time // wall clock in the offset in effect before the transition, as UTC
+	offsetFrom int
+	offsetTo   int
+	abbrev     string
+}
+
+// newVTimezone finds every offset transition for the zone between from and to, we
+// do not emit RRULEs as the zone database has no notion of them, listing the actual
+// transitions is equally valid and always correct.
+func newVTimezone(name string, from, to time.Time) (vtimezone, error) {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return vtimezone{}, fmt.Errorf("loading time zone %q: %w", name, err)
+	}
+	z := vtimezone{name: name}
+
+	// start one day early so the observance in effect for the first event is included.
+	cursor := from.Add(-24 * time.Hour).In(loc)
+	abbrev, offset := cursor.Zone()
+	z.observances = append(z.observances, observance{
+		daylight:   cursor.IsDST(),
+		start:      cursor.UTC().Add(time.Duration(offset) * time.Second),
+		offsetFrom: offset,
+		offsetTo:   offset,
+		abbrev:     abbrev,
+	})
+
+	for cursor.Before(to) {
+		next := cursor.Add(24 * time.Hour)
+		_, nextOffset := next.Zone()
+		if nextOffset != offset {
+			at := findTransition(cursor, next, offset)
+			nextAbbrev, _ := at.Zone()
+			z.observances = append(z.observances, observance{
+				daylight:   at.IsDST(),
+				start:      at.UTC().Add(time.Duration(offset) * time.Second),
+				offsetFrom: offset,
+				offsetTo:   nextOffset,
+				abbrev:     nextAbbrev,
+			})
+			offset = nextOffset
+		}
+		cursor = next
+	}
+	return z, nil
+}
+
+// findTransition bisects (lo, hi] to the first second where the zone offset is not
+// the passed one.
+func findTransition(lo, hi time.Time, offset int) time.Time {
+	for hi.Sub(lo) > time.Second {
+		mid := lo.Add(hi.Sub(lo) / 2)
+		if _, o := mid.Zone(); o == offset {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return hi
+}
+
+func (z vtimezone) write(cw *contentWriter) {
+	cw.line("BEGIN:VTIMEZONE")
+	cw.line("TZID:" + z.name)
+	for _, o := range z.observances {
+		kind := "STANDARD"
+		if o.daylight {
+			kind = "DAYLIGHT"
+		}
+		cw.line("BEGIN:" + kind)
+		// the onset is expressed in the local time in effect before it happens.
+		cw.line("DTSTART:" + o.start.Format(localFormat))
+		cw.line("TZOFFSETFROM:" + formatOffset(o.offsetFrom))
+		cw.line("TZOFFSETTO:" + formatOffset(o.offsetTo))
+		if o.abbrev != "" {
+			cw.line("TZNAME:" + escapeText(o.abbrev))
+		}
+		cw.line("END:" + kind)
+	}
+	cw.line("END:VTIMEZONE")
+}
+
+// formatOffset renders seconds east of UTC as +hhmm or +hhmmss.
+func formatOffset(seconds int) string {
+	sign := "+"
+	if seconds < 0 {
+		sign = "-"
+		seconds = -seconds
+	}
+	h, m, s := seconds/3600, seconds%3600/60, seconds%60
+	if s != 0 {
+		return fmt.Sprintf("%s%02d%02d%02d", sign, h, m, s)
+	}
+	return fmt.Sprintf("%s%02d%02d", sign, h, m)
+}
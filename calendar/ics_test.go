@@ -0,0 +1,79 @@
+package calendar
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestEscapeText(t *testing.T) {
+	got := escapeText("Lunch; bring a plate, cup\\fork\r\nand\nknife")
+	if want := `Lunch\; bring a plate\, cup\\fork\nand\nknife`; got != want {
+		t.Errorf("escapeText() = %q, want %q", got, want)
+	}
+}
+
+func TestLineFolding(t *testing.T) {
+	var out bytes.Buffer
+	cw := &contentWriter{w: bufio.NewWriter(&out)}
+	long := "DESCRIPTION:" + strings.Repeat("Gophers ¡olé! ", 20)
+	cw.line(long)
+	if err := cw.w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\r\n"), "\r\n")
+	if len(lines) < 3 {
+		t.Fatalf("folded into %d lines, want several", len(lines))
+	}
+	for i, l := range lines {
+		if len(l) > maxLineOctets || !utf8.ValidString(l) {
+			t.Errorf("line %d %q is %d octets or splits a character", i, l, len(l))
+		}
+		if i > 0 && !strings.HasPrefix(l, " ") {
+			t.Errorf("continuation line %d %q does not start with a space", i, l)
+		}
+	}
+	if unfolded := strings.Replace(strings.TrimSuffix(out.String(), "\r\n"), "\r\n ", "", -1); unfolded != long {
+		t.Errorf("unfolded to %q, want %q", unfolded, long)
+	}
+}
+
+func TestVTimezone(t *testing.T) {
+	// Denver leaves daylight saving time on Nov 7 2021 at 2:00, during the event.
+	denver, err := time.LoadLocation("America/Denver")
+	if err != nil {
+		t.Skip(err)
+	}
+	c := &Calendar{Events: []Entry{{
+		UID:      "event-1@example.com",
+		Summary:  "GopherCon",
+		Start:    time.Date(2021, 11, 5, 9, 0, 0, 0, denver),
+		End:      time.Date(2021, 11, 9, 17, 0, 0, 0, denver),
+		TimeZone: "America/Denver",
+	}}}
+	var out bytes.Buffer
+	if _, err := c.WriteTo(&out); err != nil {
+		t.Fatalf("WriteTo() = %v", err)
+	}
+	ics := out.String()
+	for _, want := range []string{
+		"BEGIN:VTIMEZONE\r\nTZID:America/Denver\r\n" +
+			"BEGIN:DAYLIGHT\r\nDTSTART:20211104T090000\r\nTZOFFSETFROM:-0600\r\nTZOFFSETTO:-0600\r\nTZNAME:MDT\r\nEND:DAYLIGHT\r\n" +
+			"BEGIN:STANDARD\r\nDTSTART:20211107T020000\r\nTZOFFSETFROM:-0600\r\nTZOFFSETTO:-0700\r\nTZNAME:MST\r\nEND:STANDARD\r\n" +
+			"END:VTIMEZONE\r\n",
+		"DTSTART;TZID=America/Denver:20211105T090000\r\n",
+		"DTEND;TZID=America/Denver:20211109T170000\r\n",
+	} {
+		if !strings.Contains(ics, want) {
+			t.Errorf("calendar %q does not contain %q", ics, want)
+		}
+	}
+
+	c.Events[0].TimeZone = "Mars/Olympus_Mons"
+	if _, err := c.WriteTo(&out); err == nil {
+		t.Error("wrote a calendar in an unknown time zone")
+	}
+}
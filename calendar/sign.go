@@ -0,0 +1,40 @@
+package calendar
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+)
+
+// Signer produces and checks the tokens that allow fetching a personal feed without
+// being logged in, calendar clients such as Google Calendar can not authenticate so the
+// URL itself must be the credential.
+type Signer struct {
+	key []byte
+}
+
+// NewSigner returns a Signer using the passed secret, rotating the secret invalidates
+// every feed URL handed out.
+func NewSigner(secret []byte) *Signer {
+	return &Signer{key: secret}
+}
+
+// Sign returns the token for the attendee feed.
+func (s *Signer) Sign(attendeeID uint64) string {
+	mac := hmac.New(sha256.New, s.key)
+	fmt.Fprintf(mac, "attendee-feed:%d", attendeeID)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Verify returns true if the token was issued for this attendee.
+func (s *Signer) Verify(attendeeID uint64, token string) bool {
+	return hmac.Equal([]byte(s.Sign(attendeeID)), []byte(token))
+}
+
+// AttendeeFeedPath returns the signed path, relative to the server root, of the feed
+// for the passed attendee.
+func (s *Signer) AttendeeFeedPath(attendeeID uint64) string {
+	return PathPrefix + "attendees/" + strconv.FormatUint(attendeeID, 10) + ".ics?token=" + s.Sign(attendeeID)
+}
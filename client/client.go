@@ -0,0 +1,164 @@
+// Package client calls the oto services of a running server, the typed clients for each
+// service (client.gen.go) are generated by generate.sh from templates/client.go.plush.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/opentracing-contrib/go-stdlib/nethttp"
+	"github.com/opentracing/opentracing-go"
+
+	"github.com/gopheracademy/manager/auth"
+	"github.com/gopheracademy/manager/errs"
+)
+
+// Options configure a Client.
+type Options struct {
+	// BaseURL is where the server is, ie https://showrunner.gophercon.com
+	BaseURL string
+	// Session is the value of the session cookie to call as a user, it can be copied
+	// from a browser that logged in.
+	Session string
+	// Tracer traces the calls and propagates the trace to the server, defaults to the
+	// global tracer.
+	Tracer opentracing.Tracer
+	// HTTPClient defaults to a client with a 30s timeout, its transport is wrapped to
+	// trace the calls.
+	HTTPClient *http.Client
+	// Retries is how many times a call is retried when it certainly was not processed,
+	// ie the server could not be reached or answered it is unavailable, defaults to 3;
+	// use a negative value to never retry. Calls that timed out or whose answer was lost
+	// are not retried, they might have been processed and calls are not idempotent.
+	Retries int
+	// Backoff is the wait before the first retry, it doubles on each retry up to
+	// maxBackoff and defaults to 200ms.
+	Backoff time.Duration
+}
+
+// maxBackoff bounds the wait between retries.
+const maxBackoff = 30 * time.Second
+
+// Client makes the calls of the typed service clients.
+type Client struct {
+	opts Options
+}
+
+// New returns a Client, it fails if the options are incomplete.
+func New(opts Options) (*Client, error) {
+	if opts.BaseURL == "" {
+		return nil, fmt.Errorf("a base URL is required")
+	}
+	opts.BaseURL = strings.TrimSuffix(opts.BaseURL, "/")
+	if opts.Tracer == nil {
+		opts.Tracer = opentracing.GlobalTracer()
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	// calls are traced by the transport, without it tracing them panics.
+	if _, ok := opts.HTTPClient.Transport.(*nethttp.Transport); !ok {
+		traced := *opts.HTTPClient
+		traced.Transport = &nethttp.Transport{RoundTripper: traced.Transport}
+		opts.HTTPClient = &traced
+	}
+	if opts.Retries == 0 {
+		opts.Retries = 3
+	}
+	if opts.Backoff <= 0 {
+		opts.Backoff = 200 * time.Millisecond
+	}
+	return &Client{opts: opts}, nil
+}
+
+// call posts request to the method of the service and decodes the answer into response,
+// failures answered by the server are returned as *errs.Error.
+func (c *Client) call(ctx context.Context, service, method string, request, response interface{}) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("encoding request: %w", err)
+	}
+	backoff := c.opts.Backoff
+	for attempt := 0; ; attempt++ {
+		var retry bool
+		retry, err = c.post(ctx, service+"."+method, body, response)
+		if err == nil || attempt >= c.opts.Retries || !retry {
+			return err
+		}
+		// full jitter so clients retrying at once do not hit the server together.
+		wait := time.Duration(rand.Int63n(int64(backoff)))
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+// post makes one call and returns whether it can be retried if it failed, that is if the
+// server certainly did not process it.
+func (c *Client) post(ctx context.Context, endpoint string, body []byte, response interface{}) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, c.opts.BaseURL+"/oto/"+endpoint, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if c.opts.Session != "" {
+		req.AddCookie(&http.Cookie{Name: auth.SessionCookie, Value: c.opts.Session})
+	}
+	req = req.WithContext(ctx)
+	req, ht := nethttp.TraceRequest(c.opts.Tracer, req, nethttp.OperationName("oto: "+endpoint))
+	defer ht.Finish()
+
+	res, err := c.opts.HTTPClient.Do(req)
+	if err != nil {
+		return notDialed(err), errs.Wrap(errs.Unavailable, err, "calling %s", endpoint)
+	}
+	defer res.Body.Close()
+	raw, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return false, errs.Wrap(errs.Unavailable, err, "reading response of %s", endpoint)
+	}
+	if res.StatusCode != http.StatusOK {
+		// the server, or a proxy before it, answers 503 for calls it did not process.
+		return res.StatusCode == http.StatusServiceUnavailable, responseError(res.StatusCode, raw)
+	}
+	if err := json.Unmarshal(raw, response); err != nil {
+		return false, fmt.Errorf("decoding response of %s: %w", endpoint, err)
+	}
+	return false, nil
+}
+
+// responseError turns the body of a failed call into an *errs.Error.
+func responseError(status int, raw []byte) error {
+	var failure errs.Response
+	if err := json.Unmarshal(raw, &failure); err != nil || failure.Code == "" {
+		kind := errs.Internal
+		switch status {
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			kind = errs.Unavailable
+		}
+		return errs.New(kind, "unexpected response %d %s", status, http.StatusText(status))
+	}
+	return &errs.Error{Kind: failure.Code, Message: failure.Error, Fields: failure.Fields}
+}
+
+// notDialed returns true if err failed a request before the connection to the server was
+// made, so nothing was sent.
+func notDialed(err error) bool {
+	var op *net.OpError
+	return errors.As(err, &op) && op.Op == "dial"
+}
@@ -0,0 +1,116 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gopheracademy/manager/errs"
+)
+
+// roundTripFunc makes requests with a func.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func newTestClient(t *testing.T, url string, opts Options) *ConferenceService {
+	t.Helper()
+	opts.BaseURL = url
+	opts.Backoff = time.Millisecond
+	c, err := New(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewConferenceService(c)
+}
+
+func TestCall(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/oto/ConferenceService.Get" {
+			t.Errorf("called %s %s", r.Method, r.URL.Path)
+		}
+		switch atomic.AddInt32(&calls, 1) {
+		case 1:
+			http.Error(w, `{"error":"could not authorize the operation","code":"unavailable"}`, http.StatusServiceUnavailable)
+		case 2:
+			http.Error(w, "upstream is restarting", http.StatusServiceUnavailable)
+		case 3:
+			w.Write([]byte(`{"Conference":{"ID":7,"Name":"GopherCon"}}`))
+		default:
+			http.Error(w, `{"error":"conference 8 not found","code":"not_found"}`, http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	s := newTestClient(t, srv.URL, Options{})
+	ctx := context.Background()
+
+	// calls the server did not process are retried.
+	res, err := s.Get(ctx, GetConferenceRequest{ID: 7})
+	if err != nil || res.Conference.ID != 7 || calls != 3 {
+		t.Fatalf("Get() = %+v, %v after %d calls; want conference 7 after 3", res, err, calls)
+	}
+	// failures of the call are returned as they were answered, and not retried.
+	_, err = s.Get(ctx, GetConferenceRequest{ID: 8})
+	var e *errs.Error
+	if !errs.Is(err, errs.NotFound) || !errors.As(err, &e) || e.Message != "conference 8 not found" || calls != 4 {
+		t.Errorf("Get() = %v after %d calls, want conference 8 not found after 4", err, calls)
+	}
+}
+
+func TestCallRetries(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		fail  error
+		calls int32
+	}{
+		// nothing was sent, the call is retried.
+		{"dial", &net.OpError{Op: "dial", Net: "tcp", Err: errs.New(errs.Unavailable, "connection refused")}, 3},
+		// the call was sent and might have been processed, it is not.
+		{"read", &net.OpError{Op: "read", Net: "tcp", Err: errs.New(errs.Unavailable, "connection reset")}, 1},
+	} {
+		var calls int32
+		s := newTestClient(t, "http://showrunner.test", Options{
+			Retries: 2,
+			HTTPClient: &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+				atomic.AddInt32(&calls, 1)
+				return nil, tc.fail
+			})},
+		})
+		if _, err := s.Create(context.Background(), CreateConferenceRequest{}); !errs.Is(err, errs.Unavailable) || calls != tc.calls {
+			t.Errorf("%s failure: Create() = %v after %d calls, want unavailable after %d", tc.name, err, calls, tc.calls)
+		}
+	}
+
+	// calls that time out are not retried.
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(100 * time.Millisecond)
+	}))
+	defer srv.Close()
+	s := newTestClient(t, srv.URL, Options{HTTPClient: &http.Client{Timeout: 10 * time.Millisecond}})
+	if _, err := s.Create(context.Background(), CreateConferenceRequest{}); err == nil || atomic.LoadInt32(&calls) != 1 {
+		t.Errorf("Create() = %v after %d calls, want a timeout after 1", err, calls)
+	}
+}
+
+func TestNew(t *testing.T) {
+	if _, err := New(Options{}); err == nil {
+		t.Error("New() without a base URL succeeded")
+	}
+	c, err := New(Options{BaseURL: "https://showrunner.test/", Backoff: -time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.opts.BaseURL != "https://showrunner.test" || c.opts.Backoff <= 0 || c.opts.Retries != 3 {
+		t.Errorf("New() options are %+v, want the defaults", c.opts)
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/gopheracademy/manager/comps"
+	"github.com/gopheracademy/manager/ticketing"
+)
+
+const compsUsage = `usage: manager comps [flags] comps.csv
+
+Gives complimentary tickets to the people of a spreadsheet, ie speakers, volunteers or
+sponsors. The CSV has a header naming an email column and optionally slot and reason
+ones, which default to -slot and -reason. Attendees that do not exist are created and
+claim the slot, paid for with a discount of its cost whose detail is the reason.
+Attendees already holding a paid claim of the slot are left alone, so importing the
+same spreadsheet again gives nobody a second ticket. With -dry-run the rows are only
+validated and what would be done reported. Exits with 3 if a row is invalid or failed.
+
+`
+
+// importComps implements the comps subcommand and returns the exit code.
+func importComps(args []string) int {
+	fs, config := commandFlags("comps", compsUsage)
+	slot := fs.Uint64("slot", 0, "ID of the slot to claim for rows without a slot")
+	reason := fs.String("reason", "", "why the comps are given, ie speaker, for rows without a reason")
+	dryRun := fs.Bool("dry-run", false, "validate the rows and report what would be done without doing it")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	cfg, err := config()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer f.Close()
+	rows, err := comps.Read(f)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "reading %s: %v\n", fs.Arg(0), err)
+		return 1
+	}
+	db, err := openDatabase(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	report, err := comps.Import(context.Background(), ticketing.NewSQLStorageFromConnection(db), rows,
+		comps.Options{SlotID: *slot, Reason: *reason, DryRun: *dryRun})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	printComps(os.Stdout, report)
+	if !report.OK() {
+		return 3
+	}
+	return 0
+}
+
+func printComps(w io.Writer, report *comps.Report) {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "line\temail\tslot\treason\toutcome\tattendee\tclaim\tdetail\t")
+	for _, r := range report.Results {
+		attendee := fmt.Sprint(r.AttendeeID)
+		if r.Created {
+			attendee = "new"
+			if r.AttendeeID != 0 {
+				attendee = fmt.Sprintf("%d (new)", r.AttendeeID)
+			}
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%d\t%s\t%s\t%s\t%d\t%s\t\n",
+			r.Line, r.Email, r.SlotID, r.Reason, r.Outcome, attendee, r.ClaimID, r.Detail)
+	}
+	tw.Flush()
+	fmt.Fprintln(w)
+
+	if report.DryRun {
+		fmt.Fprintln(w, "dry run, nothing was changed")
+	}
+	tw = tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "rows\t%d\t\n", len(report.Results))
+	for _, o := range []comps.Outcome{comps.Comped, comps.Completed, comps.Held, comps.Invalid, comps.Failed} {
+		fmt.Fprintf(tw, "%s\t%d\t\n", o, report.Count(o))
+	}
+	tw.Flush()
+}
@@ -0,0 +1,282 @@
+package comps
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"strconv"
+
+	"github.com/gopheracademy/manager/ticketing"
+)
+
+// Outcome is what importing a row did, or would do in a dry run.
+type Outcome string
+
+const (
+	// Comped rows got a claim of their slot, paid for with a discount.
+	Comped Outcome = "comped"
+	// Completed rows held a claim of their slot nobody paid for, ie left by an import
+	// that failed midway, which was paid for with a discount rather than claimed again.
+	Completed Outcome = "completed"
+	// Held rows already held a paid claim of their slot and were left alone.
+	Held Outcome = "held"
+	// Invalid rows were not imported, the Detail of their Result says why.
+	Invalid Outcome = "invalid"
+	// Failed rows were valid but storing them failed.
+	Failed Outcome = "failed"
+)
+
+// Result is the outcome of importing a row.
+type Result struct {
+	// Row is the row read, with its email as parsed and lowercased once it is valid.
+	Row
+	// SlotID and Reason are those of the row, or of the import if it has none.
+	SlotID  uint64
+	Reason  string
+	Outcome Outcome
+	// Detail explains the outcome, ie why the row is invalid.
+	Detail string
+	// Created is true if the row created the attendee, or would in a dry run.
+	Created    bool
+	AttendeeID uint64
+	// ClaimID and PaymentID are those of the comp, 0 for rows held and in dry runs.
+	ClaimID   uint64
+	PaymentID uint64
+}
+
+// Report is the outcome of an import, with a Result per row in the order they were read.
+type Report struct {
+	DryRun  bool
+	Results []Result
+}
+
+// Count returns how many rows had the outcome.
+func (r *Report) Count(o Outcome) int {
+	n := 0
+	for _, result := range r.Results {
+		if result.Outcome == o {
+			n++
+		}
+	}
+	return n
+}
+
+// OK returns true if no row was invalid or failed.
+func (r *Report) OK() bool {
+	return r.Count(Invalid) == 0 && r.Count(Failed) == 0
+}
+
+// Options configure Import.
+type Options struct {
+	// SlotID is the slot claimed for rows that do not name one.
+	SlotID uint64
+	// Reason is the detail of the discount of rows that do not give one, ie speaker.
+	Reason string
+	// DryRun validates the rows and reports what would be done without doing it.
+	DryRun bool
+}
+
+// Import comps the rows, in order; a row whose attendee already holds a claim of its
+// slot is not claimed another, and the same email and slot are only comped once per
+// spreadsheet. Rows that cannot be imported are reported as Invalid or Failed and the
+// others imported all the same, an error is only returned if ctx is done.
+func Import(ctx context.Context, store ticketing.PurchaseStore, rows []Row, opts Options) (*Report, error) {
+	i := &importer{
+		store:   store,
+		opts:    opts,
+		slots:   map[uint64]*ticketing.EventSlot{},
+		claims:  map[uint64]int{},
+		paid:    map[uint32]map[uint64]bool{},
+		created: map[string]bool{},
+		seen:    map[string]int{},
+	}
+	report := &Report{DryRun: opts.DryRun}
+	for _, row := range rows {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		result := Result{Row: row}
+		if err := i.importRow(ctx, &result); err != nil {
+			result.Outcome, result.Detail = Failed, err.Error()
+		}
+		report.Results = append(report.Results, result)
+	}
+	return report, nil
+}
+
+type importer struct {
+	store ticketing.PurchaseStore
+	opts  Options
+	// slots are those read so far, nil if they do not exist, claims the count of claims
+	// of each, comps of the import included.
+	slots  map[uint64]*ticketing.EventSlot
+	claims map[uint64]int
+	// paid are the claims paid for, by event.
+	paid map[uint32]map[uint64]bool
+	// created are the emails of the attendees created, or that would be in a dry run.
+	created map[string]bool
+	// seen is the line of the first row of each email and slot.
+	seen map[string]int
+}
+
+// importRow imports the row of r and fills in its outcome, or returns why storing it
+// failed.
+func (i *importer) importRow(ctx context.Context, r *Result) error {
+	invalid := func(format string, args ...interface{}) error {
+		r.Outcome, r.Detail = Invalid, fmt.Sprintf(format, args...)
+		return nil
+	}
+	addr, err := mail.ParseAddress(r.Email)
+	if err != nil {
+		return invalid("email %q is not valid", r.Email)
+	}
+	// the email is looked up, stored and compared as one, whatever case the sheet uses.
+	r.Email = ticketing.NormalizeEmail(addr.Address)
+	r.SlotID = i.opts.SlotID
+	if r.Slot != "" {
+		if r.SlotID, err = strconv.ParseUint(r.Slot, 10, 64); err != nil {
+			return invalid("slot %q is not an ID", r.Slot)
+		}
+	}
+	if r.SlotID == 0 {
+		return invalid("no slot is given")
+	}
+	if r.Reason = r.Row.Reason; r.Reason == "" {
+		r.Reason = i.opts.Reason
+	}
+	if r.Reason == "" {
+		return invalid("no reason is given")
+	}
+	key := r.Email + " " + strconv.FormatUint(r.SlotID, 10)
+	if line, ok := i.seen[key]; ok {
+		return invalid("duplicates line %d", line)
+	}
+	i.seen[key] = r.Line
+
+	slot, err := i.slot(ctx, r.SlotID)
+	if err != nil {
+		return err
+	}
+	if slot == nil {
+		return invalid("slot %d does not exist", r.SlotID)
+	}
+	paid, err := i.paidClaims(ctx, slot)
+	if err != nil {
+		return err
+	}
+	attendee, err := i.store.ReadAttendeeByEmail(ctx, r.Email)
+	if err != nil {
+		return fmt.Errorf("reading attendee %s: %w", r.Email, err)
+	}
+
+	// a claim of the slot the attendee already holds is never claimed again, at most
+	// paid for if nobody did.
+	var unpaid *ticketing.SlotClaim
+	if attendee != nil {
+		r.AttendeeID = attendee.ID
+		for j, c := range attendee.Claims {
+			if c.EventSlot == nil || c.EventSlot.ID != slot.ID {
+				continue
+			}
+			if paid[c.ID] {
+				r.Outcome, r.Detail = Held, fmt.Sprintf("already holds claim %d", c.ID)
+				return nil
+			}
+			if unpaid == nil {
+				unpaid = &attendee.Claims[j]
+			}
+		}
+	}
+	if unpaid == nil && i.claims[slot.ID] >= slot.Capacity {
+		return invalid("slot %d is full, its capacity is %d", slot.ID, slot.Capacity)
+	}
+	r.Created = attendee == nil && !i.created[r.Email]
+	r.Outcome = Comped
+	if unpaid != nil {
+		r.Outcome, r.Detail = Completed, fmt.Sprintf("pays for claim %d", unpaid.ID)
+	}
+	if i.opts.DryRun {
+		if unpaid == nil {
+			i.claims[slot.ID]++
+		}
+		if attendee == nil {
+			i.created[r.Email] = true
+		}
+		return nil
+	}
+
+	if attendee == nil {
+		if attendee, err = i.store.CreateAttendee(ctx, &ticketing.Attendee{Email: r.Email}); err != nil {
+			return fmt.Errorf("creating attendee %s: %w", r.Email, err)
+		}
+		r.AttendeeID = attendee.ID
+		i.created[r.Email] = true
+	}
+	claims := []ticketing.SlotClaim{}
+	if unpaid != nil {
+		claims = append(claims, *unpaid)
+	} else {
+		if claims, err = ticketing.ClaimSlots(ctx, i.store, attendee, *slot); err != nil {
+			return fmt.Errorf("claiming slot %d: %w", slot.ID, err)
+		}
+		i.claims[slot.ID]++
+	}
+	r.ClaimID = claims[0].ID
+	payment, err := ticketing.PayClaims(ctx, i.store, attendee, claims, []ticketing.FinancialInstrument{
+		&ticketing.PaymentMethodConferenceDiscount{Detail: r.Reason, Amount: slot.Cost},
+	})
+	if err != nil {
+		return fmt.Errorf("paying for claim %d: %w", r.ClaimID, err)
+	}
+	r.PaymentID = payment.ID
+	paid[r.ClaimID] = true
+	return nil
+}
+
+// slot returns the slot with the id, nil if it does not exist.
+func (i *importer) slot(ctx context.Context, id uint64) (*ticketing.EventSlot, error) {
+	if slot, ok := i.slots[id]; ok {
+		return slot, nil
+	}
+	slot, err := i.store.ReadEventSlotByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("reading slot %d: %w", id, err)
+	}
+	if slot != nil {
+		claims, err := i.store.ListClaimsForSlot(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("reading the claims of slot %d: %w", id, err)
+		}
+		i.claims[id] = len(claims)
+	}
+	i.slots[id] = slot
+	return slot, nil
+}
+
+// paidClaims returns the IDs of the claims paid for of the event of the slot, whoever
+// paid for them, ie before they were transferred.
+func (i *importer) paidClaims(ctx context.Context, slot *ticketing.EventSlot) (map[uint64]bool, error) {
+	event := eventOf(slot)
+	if paid, ok := i.paid[event]; ok {
+		return paid, nil
+	}
+	payments, err := i.store.ListClaimPaymentsForEvent(ctx, event, ticketing.Page{})
+	if err != nil {
+		return nil, fmt.Errorf("reading the payments of event %d: %w", event, err)
+	}
+	paid := map[uint64]bool{}
+	for _, p := range payments {
+		for _, c := range p.ClaimsPayed {
+			paid[c.ID] = true
+		}
+	}
+	i.paid[event] = paid
+	return paid, nil
+}
+
+func eventOf(slot *ticketing.EventSlot) uint32 {
+	if slot.Event == nil {
+		return 0
+	}
+	return slot.Event.ID
+}
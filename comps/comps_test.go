@@ -0,0 +1,146 @@
+package comps
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gopheracademy/manager/def"
+	"github.com/gopheracademy/manager/ticketing"
+)
+
+func TestRead(t *testing.T) {
+	if _, err := Read(strings.NewReader("Name,E-mail\nAda,ada@example.com\n")); err == nil {
+		t.Error("Read() of a sheet without an email column succeeded, want an error")
+	}
+	sheet := "\ufeffName,Email,Slot,Reason\n" +
+		"Ada,ada@example.com,,speaker\n" +
+		",,,\n" +
+		"Bob, bob@example.com \n"
+	rows, err := Read(strings.NewReader(sheet))
+	if err != nil {
+		t.Fatalf("Read() = %v", err)
+	}
+	want := []Row{
+		{Line: 2, Email: "ada@example.com", Reason: "speaker"},
+		{Line: 4, Email: "bob@example.com"},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("Read() = %+v, want %+v", rows, want)
+	}
+}
+
+func TestImport(t *testing.T) {
+	ctx := context.Background()
+	s := ticketing.NewMemoryStorage()
+	event := &def.Event{ID: 1, Name: "GopherCon 2021"}
+	speaker, err := s.CreateEventSlot(ctx, &ticketing.EventSlot{Event: event, Name: "speaker", Cost: 50000, Capacity: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// an attendee who bought a ticket for the slot already, typing their email in another
+	// case than the sheet, and one whose claim was not paid for.
+	buyer, err := s.CreateAttendee(ctx, &ticketing.Attendee{Email: "Buyer@Example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := ticketing.ClaimSlots(ctx, s, buyer, *speaker)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ticketing.PayClaims(ctx, s, buyer, claims, []ticketing.FinancialInstrument{
+		&ticketing.PaymentMethodMoney{PaymentRef: "ch_1", Amount: 50000},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	holder, err := s.CreateAttendee(ctx, &ticketing.Attendee{Email: "holder@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	unpaid, err := ticketing.ClaimSlots(ctx, s, holder, *speaker)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows := []Row{
+		{Line: 2, Email: "Ada <ada@example.com>"},
+		{Line: 3, Email: "buyer@example.com"},
+		{Line: 4, Email: "holder@example.com", Reason: "volunteer"},
+		{Line: 5, Email: " ADA@Example.com"},
+		{Line: 6, Email: "not an email"},
+		{Line: 7, Email: "bob@example.com", Slot: "99"},
+		{Line: 8, Email: "bob@example.com"},
+	}
+	type outcome struct {
+		Outcome Outcome
+		Created bool
+	}
+	outcomes := func(r *Report) []outcome {
+		got := make([]outcome, len(r.Results))
+		for i, result := range r.Results {
+			got[i] = outcome{result.Outcome, result.Created}
+		}
+		return got
+	}
+	opts := Options{SlotID: speaker.ID, Reason: "speaker", DryRun: true}
+	want := []outcome{
+		{Comped, true},
+		{Held, false},
+		{Completed, false},
+		{Invalid, false},
+		{Invalid, false},
+		{Invalid, false},
+		// the slot is full with the buyer, holder and ada.
+		{Invalid, false},
+	}
+	dry, err := Import(ctx, s, rows, opts)
+	if err != nil {
+		t.Fatalf("Import() = %v", err)
+	}
+	if got := outcomes(dry); !reflect.DeepEqual(got, want) {
+		t.Errorf("dry run outcomes %+v, want %+v", got, want)
+	}
+	if dry.OK() {
+		t.Error("dry run is OK with invalid rows")
+	}
+	if ada, err := s.ReadAttendeeByEmail(ctx, "ada@example.com"); err != nil || ada != nil {
+		t.Fatalf("dry run created %+v, %v", ada, err)
+	}
+
+	opts.DryRun = false
+	report, err := Import(ctx, s, rows, opts)
+	if err != nil {
+		t.Fatalf("Import() = %v", err)
+	}
+	if got := outcomes(report); !reflect.DeepEqual(got, want) {
+		t.Errorf("outcomes %+v, want %+v", got, want)
+	}
+	if d := report.Results[3]; d.Email != "ada@example.com" || d.Detail != "duplicates line 2" {
+		t.Errorf("row of the same email in another case is %+v, want a duplicate", d)
+	}
+	if c := report.Results[2]; c.ClaimID != unpaid[0].ID || c.PaymentID == 0 {
+		t.Errorf("completed %+v, want claim %d paid for", c, unpaid[0].ID)
+	}
+	payment, err := s.ReadClaimPaymentByID(ctx, report.Results[0].PaymentID)
+	if err != nil || payment == nil {
+		t.Fatalf("ReadClaimPaymentByID() = %v, %v", payment, err)
+	}
+	discount, ok := payment.Payment[0].(*ticketing.PaymentMethodConferenceDiscount)
+	if len(payment.Payment) != 1 || !ok || discount.Detail != "speaker" || discount.Amount != 50000 {
+		t.Errorf("comp paid with %+v, want a speaker discount of 50000", payment.Payment)
+	}
+
+	// importing again gives nobody another ticket.
+	again, err := Import(ctx, s, rows[:3], opts)
+	if err != nil {
+		t.Fatalf("Import() = %v", err)
+	}
+	want = []outcome{{Held, false}, {Held, false}, {Held, false}}
+	if got := outcomes(again); !reflect.DeepEqual(got, want) || !again.OK() {
+		t.Errorf("outcomes of the second import %+v, want %+v", got, want)
+	}
+	if claims, err := s.ListClaimsForSlot(ctx, speaker.ID); err != nil || len(claims) != 3 {
+		t.Errorf("slot has %d claims (%v), want 3", len(claims), err)
+	}
+}
@@ -0,0 +1,86 @@
+// Package comps gives complimentary tickets, the comps of speakers, volunteers and
+// sponsors, to the people listed in the spreadsheets we get for them.
+//
+// Spreadsheets are read as CSV into Rows; Import then creates the attendees that do not
+// exist yet and claims them a slot, paid for with a discount of its whole cost stating
+// why it was given. Importing the same spreadsheet again gives nobody a second ticket.
+package comps
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Row is a line of a spreadsheet of comps.
+type Row struct {
+	// Line is the line of the spreadsheet the row was read from, the header is line 1.
+	Line  int
+	Email string
+	// Slot is the ID of the slot to claim as written, the slot of the import if empty.
+	Slot string
+	// Reason is why the comp is given, ie speaker, the reason of the import if empty.
+	Reason string
+}
+
+// The headers of the columns Read knows, others are ignored.
+const (
+	ColumnEmail  = "email"
+	ColumnSlot   = "slot"
+	ColumnReason = "reason"
+)
+
+// Read reads the rows of a spreadsheet whose header names an email column and optionally
+// slot and reason ones, in any case and order; rows without values are skipped.
+func Read(r io.Reader) ([]Row, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("the spreadsheet is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading the header: %w", err)
+	}
+	columns := map[string]int{}
+	for i, h := range header {
+		// spreadsheets saved as UTF-8 CSV often start with a byte order mark.
+		h = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))
+		if _, ok := columns[h]; !ok {
+			columns[h] = i
+		}
+	}
+	if _, ok := columns[ColumnEmail]; !ok {
+		return nil, fmt.Errorf("the header has no %s column", ColumnEmail)
+	}
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var rows []Row
+	for line := 2; ; line++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading line %d: %w", line, err)
+		}
+		row := Row{
+			Line:   line,
+			Email:  field(record, ColumnEmail),
+			Slot:   field(record, ColumnSlot),
+			Reason: field(record, ColumnReason),
+		}
+		if row.Email == "" && row.Slot == "" && row.Reason == "" {
+			continue
+		}
+		rows = append(rows, row)
+	}
+}
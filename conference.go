@@ -5,44 +5,67 @@ import (
 
 	"github.com/opentracing/opentracing-go"
 	"github.com/uber/jaeger-lib/metrics"
+	"go.uber.org/zap"
 
+	"github.com/gopheracademy/manager/auth"
+	"github.com/gopheracademy/manager/def"
 	"github.com/gopheracademy/manager/log"
+	"github.com/gopheracademy/manager/ticketing"
 )
 
 type conferenceService struct {
 	tracer         opentracing.Tracer
 	metricsFactory metrics.Factory
 	logger         log.Factory
+	// publicCache holds what the public API serves, it must be invalidated on writes.
+	publicCache cacheInvalidator
 }
 
-func newconferenceService(tracer opentracing.Tracer, metricsFactory metrics.Factory, logger log.Factory) *conferenceService {
+type cacheInvalidator interface {
+	Invalidate()
+}
+
+func newconferenceService(tracer opentracing.Tracer, metricsFactory metrics.Factory, logger log.Factory,
+	publicCache cacheInvalidator) *conferenceService {
 	cs := &conferenceService{
 		tracer:         tracer,
 		metricsFactory: metricsFactory,
 		logger:         logger,
+		publicCache:    publicCache,
 	}
 	return cs
 }
 
+// loggerFor returns a logger from f for the request which identifies the user if any.
+func loggerFor(f log.Factory, ctx context.Context) log.Logger {
+	logger := f.For(ctx)
+	if id, ok := auth.FromContext(ctx); ok {
+		logger = logger.With(zap.String("user", id.Email))
+	}
+	return logger
+}
+
 func (c conferenceService) List(ctx context.Context, r ListConferenceRequest) (*ListConferenceResponse, error) {
-	c.logger.For(ctx).Info("conferenceService.List")
+	loggerFor(c.logger, ctx).Info("conferenceService.List")
 	resp := &ListConferenceResponse{}
 	return resp, nil
 }
 
 func (c conferenceService) Create(ctx context.Context, r CreateConferenceRequest) (*CreateConferenceResponse, error) {
-	c.logger.For(ctx).Info("conferenceService.Create")
+	loggerFor(c.logger, ctx).Info("conferenceService.Create")
+	defer c.publicCache.Invalidate()
 	resp := &CreateConferenceResponse{}
 	return resp, nil
 }
 
 func (c conferenceService) Delete(ctx context.Context, r DeleteConferenceRequest) (*DeleteConferenceResponse, error) {
-	c.logger.For(ctx).Info("conferenceService.Delete")
+	loggerFor(c.logger, ctx).Info("conferenceService.Delete")
+	defer c.publicCache.Invalidate()
 	resp := &DeleteConferenceResponse{}
 	return resp, nil
 }
 func (c conferenceService) Get(ctx context.Context, r GetConferenceRequest) (*GetConferenceResponse, error) {
-	c.logger.For(ctx).Info("conferenceService.Get")
+	loggerFor(c.logger, ctx).Info("conferenceService.Get")
 	resp := &GetConferenceResponse{
 		Conference: Conference{
 			Name: "Gophercon",
@@ -57,7 +80,7 @@ func (c conferenceService) Get(ctx context.Context, r GetConferenceRequest) (*Ge
 }
 
 func (c conferenceService) GetBySlug(ctx context.Context, r GetConferenceBySlugRequest) (*GetConferenceResponse, error) {
-	c.logger.For(ctx).Info("conferenceService.GetBySlug")
+	loggerFor(c.logger, ctx).Info("conferenceService.GetBySlug")
 	resp := &GetConferenceResponse{
 		Conference: Conference{
 			Name: "Gophercon",
@@ -70,3 +93,139 @@ func (c conferenceService) GetBySlug(ctx context.Context, r GetConferenceBySlugR
 	}
 	return resp, nil
 }
+
+// ConferenceBySlug implements public.ConferenceReader.
+func (c conferenceService) ConferenceBySlug(ctx context.Context, slug string) (*def.Conference, error) {
+	resp, err := c.GetBySlug(ctx, GetConferenceBySlugRequest{Slug: slug})
+	if err != nil {
+		return nil, err
+	}
+	conference := &def.Conference{
+		ID:     resp.Conference.ID,
+		Name:   resp.Conference.Name,
+		Slug:   resp.Conference.Slug,
+		Events: make([]def.Event, len(resp.Conference.Events)),
+	}
+	for i := range resp.Conference.Events {
+		conference.Events[i] = *toDefEvent(resp.Conference.Events[i])
+	}
+	return conference, nil
+}
+
+// FindEvent implements calendar.EventFinder, it returns nil if there is no such event.
+func (c conferenceService) FindEvent(ctx context.Context, conferenceSlug, eventSlug string) (*def.Event, error) {
+	conference, err := c.ConferenceBySlug(ctx, conferenceSlug)
+	if err != nil {
+		return nil, err
+	}
+	for i := range conference.Events {
+		if conference.Events[i].Slug == eventSlug {
+			return &conference.Events[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// toDefEvent converts the wire representation of an event into its definition.
+func toDefEvent(e Event) *def.Event {
+	event := &def.Event{
+		ID:                e.ID,
+		Name:              e.Name,
+		Slug:              e.Slug,
+		StartDate:         e.StartDate,
+		EndDate:           e.EndDate,
+		Location:          e.Location,
+		TimeZone:          e.TimeZone,
+		Live:              e.Live,
+		Slots:             make([]def.EventSlot, len(e.Slots)),
+		SponsorshipLevels: e.SponsorshipLevels,
+		Sponsors:          make([]def.Sponsor, len(e.Sponsors)),
+	}
+	for i, s := range e.Slots {
+		event.Slots[i] = def.EventSlot{
+			ID:                s.ID,
+			Name:              s.Name,
+			Description:       s.Description,
+			Cost:              s.Cost,
+			Capacity:          s.Capacity,
+			StartDate:         s.StartDate,
+			EndDate:           s.EndDate,
+			PurchaseableFrom:  s.PurchaseableFrom,
+			PurchaseableUntil: s.PurchaseableUntil,
+			AvailableToPublic: s.AvailableToPublic,
+		}
+	}
+	for i, s := range e.Sponsors {
+		event.Sponsors[i] = def.Sponsor{
+			ID:       s.ID,
+			Name:     s.Name,
+			Level:    s.Level,
+			Website:  s.Website,
+			Logo:     s.Logo,
+			Profile:  s.Profile,
+			Contacts: make([]def.SponsorContact, len(s.Contacts)),
+		}
+		for j, contact := range s.Contacts {
+			event.Sponsors[i].Contacts[j] = def.SponsorContact(contact)
+		}
+	}
+	return event
+}
+
+// publicTickets invalidates the public cache after the ticketing writes of what the
+// public API serves, ie slots; those of atomic operations once they are committed.
+type publicTickets struct {
+	ticketing.PurchaseStore
+	cache cacheInvalidator
+	// written is set by the writes of an atomic operation, nil outside of one.
+	written *bool
+}
+
+func newPublicTickets(tickets ticketing.PurchaseStore, cache cacheInvalidator) *publicTickets {
+	return &publicTickets{PurchaseStore: tickets, cache: cache}
+}
+
+func (t *publicTickets) invalidate() {
+	if t.written != nil {
+		*t.written = true
+		return
+	}
+	t.cache.Invalidate()
+}
+
+// CreateEventSlot implements ticketing.PurchaseStore
+func (t *publicTickets) CreateEventSlot(ctx context.Context, e *ticketing.EventSlot) (*ticketing.EventSlot, error) {
+	slot, err := t.PurchaseStore.CreateEventSlot(ctx, e)
+	if err == nil {
+		t.invalidate()
+	}
+	return slot, err
+}
+
+// UpdateEventSlot implements ticketing.PurchaseStore
+func (t *publicTickets) UpdateEventSlot(ctx context.Context, e *ticketing.EventSlot) error {
+	err := t.PurchaseStore.UpdateEventSlot(ctx, e)
+	if err == nil {
+		t.invalidate()
+	}
+	return err
+}
+
+// AtomicOperation implements ticketing.PurchaseStore
+func (t *publicTickets) AtomicOperation(ctx context.Context) (func() error, func() error, ticketing.PurchaseStore, error) {
+	commit, fail, atomic, err := t.PurchaseStore.AtomicOperation(ctx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	written := false
+	inner := &publicTickets{PurchaseStore: atomic, cache: t.cache, written: &written}
+	return func() error {
+		if err := commit(); err != nil {
+			return err
+		}
+		if written {
+			t.invalidate()
+		}
+		return nil
+	}, fail, inner, nil
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gopheracademy/manager/mailer"
+)
+
+// Config is what every command is configured with, it is read from the JSON file passed
+// with -config, or SHOWRUNNER_CONFIG, and each field can be overridden by its
+// environment variable.
+type Config struct {
+	// Addr is where the server listens (SHOWRUNNER_ADDR).
+	Addr string `json:"addr"`
+	// StaticDir holds the built web app (SHOWRUNNER_STATIC_DIR).
+	StaticDir string `json:"staticDir"`
+	// MetricsBackend is prometheus or expvar (SHOWRUNNER_METRICS_BACKEND).
+	MetricsBackend string `json:"metricsBackend"`
+	// DatabaseURL is the connection string of the database, features that need storage
+	// are disabled without it (SHOWRUNNER_DATABASE_URL).
+	DatabaseURL string `json:"databaseURL"`
+	// TLSCert and TLSKey are the paths of the certificate and key to serve HTTPS, plain
+	// HTTP is served if they are not set (SHOWRUNNER_TLS_CERT and SHOWRUNNER_TLS_KEY).
+	TLSCert string `json:"tlsCert"`
+	TLSKey  string `json:"tlsKey"`
+	// BaseURL is the URL users reach us at, used to build links sent by email
+	// (SHOWRUNNER_BASE_URL).
+	BaseURL string `json:"baseURL"`
+	// AuthSecret signs login links and sessions, if unset a random one is used and
+	// everyone is logged out on restart (SHOWRUNNER_AUTH_SECRET).
+	AuthSecret string `json:"authSecret"`
+	// FeedSecret signs the personal calendar feed URLs, if unset a random one is used and
+	// the URLs handed out will stop working on restart (SHOWRUNNER_FEED_SECRET).
+	FeedSecret string `json:"feedSecret"`
+	// SuperAdmins are the emails granted super-admin when running without a database
+	// (SHOWRUNNER_SUPER_ADMINS, comma separated).
+	SuperAdmins []string `json:"superAdmins"`
+	// SMTPAddr is the host:port of the SMTP server emails are sent through, with
+	// SMTPUsername and SMTPPassword if it needs them (SHOWRUNNER_SMTP_ADDR,
+	// SHOWRUNNER_SMTP_USERNAME and SHOWRUNNER_SMTP_PASSWORD).
+	SMTPAddr     string `json:"smtpAddr"`
+	SMTPUsername string `json:"smtpUsername"`
+	SMTPPassword string `json:"smtpPassword"`
+	// MailDir is a Maildir emails are delivered to instead of being sent, when there is
+	// no SMTPAddr; they are logged if neither is set (SHOWRUNNER_MAIL_DIR).
+	MailDir string `json:"mailDir"`
+	// MailFrom is the sender of the emails of conferences without their own branding
+	// (SHOWRUNNER_MAIL_FROM).
+	MailFrom string `json:"mailFrom"`
+	// Branding is how the emails sent about the tickets of each conference look, by the
+	// slug of the conference; the name of the conference is used if it has none.
+	Branding map[string]mailer.Branding `json:"branding"`
+	// DrainSeconds is how long the server keeps serving once told to stop, failing its
+	// readiness probe, so load balancers stop sending it traffic before it stops
+	// accepting connections (SHOWRUNNER_DRAIN_SECONDS).
+	DrainSeconds int `json:"drainSeconds"`
+	// Dunning is how payers are chased for the credit notes they did not cover.
+	Dunning DunningConfig `json:"dunning"`
+}
+
+// DunningConfig configures the dunning job of the server.
+type DunningConfig struct {
+	// ReminderDays are the ages, in days, of unpaid credit at which the payer is
+	// reminded; no reminders are sent if empty.
+	ReminderDays []int `json:"reminderDays"`
+	// SuspendClaims suspends the claims of orders still owed CutoffDays before their
+	// event starts, until they are paid.
+	SuspendClaims bool `json:"suspendClaims"`
+	CutoffDays    int  `json:"cutoffDays"`
+	// IntervalMinutes is how often receivables are checked.
+	IntervalMinutes int `json:"intervalMinutes"`
+}
+
+func defaultConfig() Config {
+	return Config{
+		Addr:           "0.0.0.0:8000",
+		StaticDir:      "./www/build",
+		MetricsBackend: "prometheus",
+		BaseURL:        "http://localhost:8000",
+		MailFrom:       "Show Runner <showrunner@localhost>",
+		Dunning: DunningConfig{
+			ReminderDays:    []int{30, 60, 90},
+			CutoffDays:      7,
+			IntervalMinutes: 60,
+		},
+	}
+}
+
+// loadConfig returns the defaults overridden by the file at path, if not empty, and then
+// by the environment.
+func loadConfig(path string) (Config, error) {
+	cfg := defaultConfig()
+	if path != "" {
+		raw, err := ioutil.ReadFile(path)
+		if err != nil {
+			return cfg, fmt.Errorf("reading config: %w", err)
+		}
+		if err := json.Unmarshal(raw, &cfg); err != nil {
+			return cfg, fmt.Errorf("parsing config %s: %w", path, err)
+		}
+	}
+	for name, field := range map[string]*string{
+		"SHOWRUNNER_ADDR":            &cfg.Addr,
+		"SHOWRUNNER_STATIC_DIR":      &cfg.StaticDir,
+		"SHOWRUNNER_METRICS_BACKEND": &cfg.MetricsBackend,
+		"SHOWRUNNER_DATABASE_URL":    &cfg.DatabaseURL,
+		"SHOWRUNNER_TLS_CERT":        &cfg.TLSCert,
+		"SHOWRUNNER_TLS_KEY":         &cfg.TLSKey,
+		"SHOWRUNNER_BASE_URL":        &cfg.BaseURL,
+		"SHOWRUNNER_AUTH_SECRET":     &cfg.AuthSecret,
+		"SHOWRUNNER_FEED_SECRET":     &cfg.FeedSecret,
+		"SHOWRUNNER_SMTP_ADDR":       &cfg.SMTPAddr,
+		"SHOWRUNNER_SMTP_USERNAME":   &cfg.SMTPUsername,
+		"SHOWRUNNER_SMTP_PASSWORD":   &cfg.SMTPPassword,
+		"SHOWRUNNER_MAIL_DIR":        &cfg.MailDir,
+		"SHOWRUNNER_MAIL_FROM":       &cfg.MailFrom,
+	} {
+		if v, ok := os.LookupEnv(name); ok {
+			*field = v
+		}
+	}
+	for name, field := range map[string]*[]string{
+		"SHOWRUNNER_SUPER_ADMINS": &cfg.SuperAdmins,
+	} {
+		if v, ok := os.LookupEnv(name); ok {
+			*field = nil
+			for _, item := range strings.Split(v, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					*field = append(*field, item)
+				}
+			}
+		}
+	}
+	if v, ok := os.LookupEnv("SHOWRUNNER_DRAIN_SECONDS"); ok {
+		seconds, err := strconv.Atoi(v)
+		if err != nil || seconds < 0 {
+			return cfg, fmt.Errorf("SHOWRUNNER_DRAIN_SECONDS must be a number of seconds, got %q", v)
+		}
+		cfg.DrainSeconds = seconds
+	}
+	return cfg, nil
+}
+
+// commandFlags returns the flags of a command, with -config already defined; once
+// parsed, config loads the configuration it points to.
+func commandFlags(name, usage string) (fs *flag.FlagSet, config func() (Config, error)) {
+	fs = flag.NewFlagSet(name, flag.ContinueOnError)
+	path := fs.String("config", os.Getenv("SHOWRUNNER_CONFIG"), "path of the JSON config file")
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), usage)
+		fs.PrintDefaults()
+	}
+	return fs, func() (Config, error) {
+		return loadConfig(*path)
+	}
+}
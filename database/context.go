@@ -0,0 +1,208 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ShiftLeftSecurity/gaum/db/connection"
+	"github.com/jackc/pgerrcode"
+)
+
+// WithContext returns the connection bound to ctx, gaum takes no context so statements
+// run through it are refused once ctx is done and, when ctx has a deadline, are run with
+// a postgres statement_timeout of the time left so the db cancels them when it passes.
+//
+// Statements outside of a transaction run in one of their own to scope the timeout,
+// QueryIter and BulkInsert are only refused when ctx is done.
+func WithContext(ctx context.Context, conn connection.DB) connection.DB {
+	if c, ok := conn.(*contextDB); ok {
+		conn = c.DB
+	}
+	return &contextDB{DB: conn, ctx: ctx}
+}
+
+type contextDB struct {
+	connection.DB
+	ctx context.Context
+}
+
+// run runs statement on a connection with the timeout of the context, committing the
+// transaction begun for it if any, fetch does the same for the results of queries.
+func (c *contextDB) run(statement func(conn connection.DB) error) error {
+	if err := c.ctx.Err(); err != nil {
+		return err
+	}
+	deadline, ok := c.ctx.Deadline()
+	if !ok {
+		return c.canceled(statement(c.DB))
+	}
+	conn := c.DB
+	if !conn.IsTransaction() {
+		tx, err := conn.BeginTransaction()
+		if err != nil {
+			return err
+		}
+		conn = tx
+	}
+	err := setTimeout(conn, deadline)
+	if err == nil {
+		err = statement(conn)
+	}
+	if conn == c.DB {
+		return c.canceled(err)
+	}
+	if err != nil {
+		conn.RollbackTransaction()
+		return c.canceled(err)
+	}
+	return conn.CommitTransaction()
+}
+
+// setTimeout sets the statement_timeout of the transaction to the time left until
+// deadline, a timeout of 0 would disable it.
+func setTimeout(tx connection.DB, deadline time.Time) error {
+	ms := time.Until(deadline).Milliseconds()
+	if ms < 1 {
+		ms = 1
+	}
+	if err := tx.Set(fmt.Sprintf("statement_timeout = %d", ms)); err != nil {
+		return fmt.Errorf("setting the statement timeout: %w", err)
+	}
+	return nil
+}
+
+// canceled adds the error of the context to err when the db cancelled the statement
+// because of it, a query_canceled error alone does not say why.
+func (c *contextDB) canceled(err error) error {
+	if pgErr, ok := pgError(err); ok && pgErr.Code == pgerrcode.QueryCanceled && c.ctx.Err() != nil {
+		return fmt.Errorf("%v: %w", err, c.ctx.Err())
+	}
+	return err
+}
+
+// Clone implements connection.DB
+func (c *contextDB) Clone() connection.DB {
+	return WithContext(c.ctx, c.DB.Clone())
+}
+
+// Query implements connection.DB, the results are fetched before the statement's own
+// transaction, if any, is committed.
+func (c *contextDB) Query(statement string, fields []string, args ...interface{}) (connection.ResultFetch, error) {
+	return c.query(func(conn connection.DB) (connection.ResultFetch, error) {
+		return conn.Query(statement, fields, args...)
+	})
+}
+
+// EQuery implements connection.DB
+func (c *contextDB) EQuery(statement string, fields []string, args ...interface{}) (connection.ResultFetch, error) {
+	return c.query(func(conn connection.DB) (connection.ResultFetch, error) {
+		return conn.EQuery(statement, fields, args...)
+	})
+}
+
+// QueryPrimitive implements connection.DB
+func (c *contextDB) QueryPrimitive(statement string, field string, args ...interface{}) (connection.ResultFetch, error) {
+	return c.query(func(conn connection.DB) (connection.ResultFetch, error) {
+		return conn.QueryPrimitive(statement, field, args...)
+	})
+}
+
+// EQueryPrimitive implements connection.DB
+func (c *contextDB) EQueryPrimitive(statement string, field string, args ...interface{}) (connection.ResultFetch, error) {
+	return c.query(func(conn connection.DB) (connection.ResultFetch, error) {
+		return conn.EQueryPrimitive(statement, field, args...)
+	})
+}
+
+// query defers running the query until its results are fetched, so they are fetched
+// within run.
+func (c *contextDB) query(q func(conn connection.DB) (connection.ResultFetch, error)) (connection.ResultFetch, error) {
+	if err := c.ctx.Err(); err != nil {
+		return nil, err
+	}
+	return func(receiver interface{}) error {
+		return c.run(func(conn connection.DB) error {
+			fetch, err := q(conn)
+			if err != nil {
+				return err
+			}
+			return fetch(receiver)
+		})
+	}, nil
+}
+
+// QueryIter implements connection.DB
+func (c *contextDB) QueryIter(statement string, fields []string, args ...interface{}) (connection.ResultFetchIter, error) {
+	if err := c.ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.DB.QueryIter(statement, fields, args...)
+}
+
+// EQueryIter implements connection.DB
+func (c *contextDB) EQueryIter(statement string, fields []string, args ...interface{}) (connection.ResultFetchIter, error) {
+	if err := c.ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.DB.EQueryIter(statement, fields, args...)
+}
+
+// Raw implements connection.DB
+func (c *contextDB) Raw(statement string, args []interface{}, fields ...interface{}) error {
+	return c.run(func(conn connection.DB) error {
+		return conn.Raw(statement, args, fields...)
+	})
+}
+
+// ERaw implements connection.DB
+func (c *contextDB) ERaw(statement string, args []interface{}, fields ...interface{}) error {
+	return c.run(func(conn connection.DB) error {
+		return conn.ERaw(statement, args, fields...)
+	})
+}
+
+// Exec implements connection.DB
+func (c *contextDB) Exec(statement string, args ...interface{}) error {
+	return c.run(func(conn connection.DB) error {
+		return conn.Exec(statement, args...)
+	})
+}
+
+// EExec implements connection.DB
+func (c *contextDB) EExec(statement string, args ...interface{}) error {
+	return c.run(func(conn connection.DB) error {
+		return conn.EExec(statement, args...)
+	})
+}
+
+// ExecResult implements connection.DB
+func (c *contextDB) ExecResult(statement string, args ...interface{}) (int64, error) {
+	var affected int64
+	err := c.run(func(conn connection.DB) error {
+		var err error
+		affected, err = conn.ExecResult(statement, args...)
+		return err
+	})
+	return affected, err
+}
+
+// BulkInsert implements connection.DB
+func (c *contextDB) BulkInsert(tableName string, columns []string, values [][]interface{}) error {
+	if err := c.ctx.Err(); err != nil {
+		return err
+	}
+	return c.DB.BulkInsert(tableName, columns, values)
+}
+
+// BeginTransaction implements connection.DB, the transaction is bound to the context too.
+func (c *contextDB) BeginTransaction() (connection.DB, error) {
+	if err := c.ctx.Err(); err != nil {
+		return nil, err
+	}
+	tx, err := c.DB.BeginTransaction()
+	if err != nil {
+		return nil, err
+	}
+	return &contextDB{DB: tx, ctx: c.ctx}, nil
+}
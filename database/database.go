@@ -0,0 +1,112 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/ShiftLeftSecurity/gaum/db/connection"
+	"github.com/ShiftLeftSecurity/gaum/db/logging"
+	"github.com/ShiftLeftSecurity/gaum/db/postgres"
+)
+
+// Open returns a connection to the postgres-like db indicated by the connectionString.
+func Open(connectionString string, logger *log.Logger) (*DB, error) {
+	logLevel := connection.Error
+
+	connector := postgres.Connector{
+		ConnectionString: connectionString,
+	}
+	maxConnLifetime := 1 * time.Minute
+	conns := &dialer{conns: map[net.Conn]struct{}{}}
+
+	// you could open this without the config info but I put it here so other people looking at it
+	// know where to tweak if necessary.
+	db, err := connector.Open(&connection.Information{
+		Logger:          logging.NewGoLogger(logger),
+		LogLevel:        logLevel,
+		ConnMaxLifetime: &maxConnLifetime,
+		CustomDial:      conns.dial,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("initializing db connection: %w", err)
+	}
+	return &DB{DB: db, conns: conns}, nil
+}
+
+// DB is a connection to the db which can be closed, gaum does not expose its pool so
+// DB keeps track of the connections it dials.
+type DB struct {
+	connection.DB
+	conns *dialer
+}
+
+// Ping checks the db answers before ctx is done.
+func (d *DB) Ping(ctx context.Context) error {
+	if err := WithContext(ctx, d.DB).Exec("SELECT 1"); err != nil {
+		return fmt.Errorf("pinging the database: %w", err)
+	}
+	return nil
+}
+
+// Close closes every connection to the db, it must only be called once nothing uses
+// the db anymore.
+func (d *DB) Close() error {
+	return d.conns.close()
+}
+
+// dialer dials and tracks the connections to the db.
+type dialer struct {
+	lock   sync.Mutex
+	conns  map[net.Conn]struct{}
+	closed bool
+}
+
+func (d *dialer) dial(network, addr string) (net.Conn, error) {
+	nd := &net.Dialer{
+		KeepAlive: time.Minute,
+	}
+	conn, err := nd.Dial(network, addr)
+	if err != nil {
+		return nil, err
+	}
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.closed {
+		conn.Close()
+		return nil, fmt.Errorf("the database connection is closed")
+	}
+	tracked := &trackedConn{Conn: conn, dialer: d}
+	d.conns[tracked] = struct{}{}
+	return tracked, nil
+}
+
+func (d *dialer) close() error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.closed = true
+	var firstErr error
+	for conn := range d.conns {
+		if err := conn.(*trackedConn).Conn.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		delete(d.conns, conn)
+	}
+	return firstErr
+}
+
+// trackedConn forgets itself from its dialer once closed by the pool.
+type trackedConn struct {
+	net.Conn
+	dialer *dialer
+}
+
+func (c *trackedConn) Close() error {
+	c.dialer.lock.Lock()
+	delete(c.dialer.conns, c)
+	c.dialer.lock.Unlock()
+	return c.Conn.Close()
+}
@@ -0,0 +1,45 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx"
+
+	"github.com/gopheracademy/manager/errs"
+)
+
+// Wrap annotates an error returned by the db with a formatted message, integrity
+// constraint violations (ie a duplicate key) become errs.Conflict so callers can tell
+// them from failures.
+func Wrap(err error, format string, args ...interface{}) error {
+	if pgErr, ok := pgError(err); ok && pgerrcode.IsIntegrityConstraintViolation(pgErr.Code) {
+		return errs.Wrap(errs.Conflict, err, format, args...)
+	}
+	return fmt.Errorf("%s: %w", fmt.Sprintf(format, args...), err)
+}
+
+// Violates returns true if err was caused by a violation of the named constraint, ie a
+// unique one which a retry with another value would not violate.
+func Violates(err error, constraint string) bool {
+	pgErr, ok := pgError(err)
+	return ok && pgerrcode.IsIntegrityConstraintViolation(pgErr.Code) && pgErr.ConstraintName == constraint
+}
+
+// pgError returns the postgres error err was caused by, gaum wraps errors with a version
+// of pkg/errors which does not support errors.Unwrap so their causes are followed too.
+func pgError(err error) (pgx.PgError, bool) {
+	for err != nil {
+		var pgErr pgx.PgError
+		if errors.As(err, &pgErr) {
+			return pgErr, true
+		}
+		causer, ok := err.(interface{ Cause() error })
+		if !ok {
+			return pgx.PgError{}, false
+		}
+		err = causer.Cause()
+	}
+	return pgx.PgError{}, false
+}
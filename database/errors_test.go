@@ -0,0 +1,36 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx"
+	pkgerrors "github.com/pkg/errors"
+
+	"github.com/gopheracademy/manager/errs"
+)
+
+func TestViolates(t *testing.T) {
+	unique := pgx.PgError{Code: pgerrcode.UniqueViolation, ConstraintName: "ticket_id_is_unique"}
+	for _, tc := range []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"the violation", unique, true},
+		{"wrapped by gaum", pkgerrors.Wrap(unique, "querying"), true},
+		{"wrapped again", fmt.Errorf("saving slot claim: %w", pkgerrors.Wrap(unique, "querying")), true},
+		{"of another constraint", pgx.PgError{Code: pgerrcode.UniqueViolation, ConstraintName: "slot_claim_id_is_unique"}, false},
+		{"not a violation", pgx.PgError{Code: pgerrcode.QueryCanceled}, false},
+		{"not from the db", errors.New("ticket_id_is_unique"), false},
+	} {
+		if got := Violates(tc.err, "ticket_id_is_unique"); got != tc.want {
+			t.Errorf("%s: Violates() = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+	if err := Wrap(pkgerrors.Wrap(unique, "querying"), "saving %s", "claim"); !errs.Is(err, errs.Conflict) {
+		t.Errorf("Wrap() = %v, want a conflict", err)
+	}
+}
@@ -0,0 +1,292 @@
+// Package dataexport streams the attendees and payments of an event out of ticketing, for
+// the caterers, badge printers and venues we hand them to.
+//
+// A Request picks a Dataset, which of its columns to write and Filters on their values;
+// Export reads the event a page at a time and writes the rows as CSV, JSON Lines or
+// Parquet as it goes, so no more than a page, or a row group for Parquet, is ever held in
+// memory.
+package dataexport
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/gopheracademy/manager/errs"
+	"github.com/gopheracademy/manager/ticketing"
+)
+
+// Kind is the type of the values of a column.
+type Kind int
+
+// The kinds of columns, Int ones are written as 64 bits integers.
+const (
+	String Kind = iota
+	Int
+	Bool
+)
+
+// Column is a column of a dataset.
+type Column struct {
+	Name string
+	Kind Kind
+}
+
+// Dataset is what an export is made of.
+type Dataset string
+
+const (
+	// Attendees have a row per claim of the event they hold, with its slot.
+	Attendees Dataset = "attendees"
+	// Payments have a row per instrument of the payments for claims of the event, the
+	// amounts are in cents.
+	Payments Dataset = "payments"
+)
+
+var columns = map[Dataset][]Column{
+	Attendees: {
+		{"attendee_id", Int},
+		{"email", String},
+		{"coc_accepted", Bool},
+		{"claim_id", Int},
+		{"ticket_id", String},
+		{"slot_id", Int},
+		{"slot_name", String},
+		{"redeemed", Bool},
+		{"suspended", Bool},
+	},
+	Payments: {
+		{"payment_id", Int},
+		{"attendee_id", Int},
+		{"invoice", String},
+		{"created_at", Int},
+		{"total_due", Int},
+		{"claim_ids", String},
+		{"instrument", String},
+		{"instrument_id", Int},
+		{"reference", String},
+		{"amount", Int},
+	},
+}
+
+// Columns returns every column of the dataset in the order they are written by default,
+// nil if there is no such dataset.
+func Columns(d Dataset) []Column {
+	return columns[d]
+}
+
+// Filter keeps the rows whose column is Value, compared as the value is written to CSV.
+type Filter struct {
+	Column string
+	Value  string
+}
+
+// ParseFilter parses a filter written as column=value.
+func ParseFilter(s string) (Filter, error) {
+	i := strings.Index(s, "=")
+	if i <= 0 {
+		return Filter{}, fmt.Errorf("filter %q is not column=value", s)
+	}
+	return Filter{Column: s[:i], Value: s[i+1:]}, nil
+}
+
+// Request describes an export.
+type Request struct {
+	Dataset Dataset
+	Format  Format
+	// Columns are written in this order, every column of the dataset if empty.
+	Columns []string
+	// Filters must all match for a row to be written.
+	Filters []Filter
+}
+
+// Validate returns an InvalidArgument error listing what is wrong with the request, or
+// nil if there is nothing.
+func (r *Request) Validate() error {
+	var violations []errs.FieldViolation
+	all := Columns(r.Dataset)
+	if all == nil {
+		violations = append(violations, errs.FieldViolation{Field: "dataset", Description: "must be attendees or payments"})
+	}
+	if !r.Format.valid() {
+		violations = append(violations, errs.FieldViolation{Field: "format", Description: "must be csv, jsonl or parquet"})
+	}
+	if all != nil {
+		for _, name := range r.Columns {
+			if index(all, name) < 0 {
+				violations = append(violations, errs.FieldViolation{Field: "columns", Description: fmt.Sprintf("has no column %q", name)})
+			}
+		}
+		for _, f := range r.Filters {
+			if index(all, f.Column) < 0 {
+				violations = append(violations, errs.FieldViolation{Field: "filter", Description: fmt.Sprintf("has no column %q", f.Column)})
+			}
+		}
+	}
+	if len(violations) != 0 {
+		return errs.Invalid(violations...)
+	}
+	return nil
+}
+
+// index returns the index of the column called name, -1 if there is none.
+func index(columns []Column, name string) int {
+	for i, c := range columns {
+		if c.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// PageSize is how many attendees or payments are read from the store at once.
+const PageSize = 500
+
+// Export writes the rows of the requested dataset of the event to w and returns how many
+// it wrote; w is left with a partial export if reading the event fails midway.
+func Export(ctx context.Context, store ticketing.ExportStore, eventID uint32, r Request, w io.Writer) (int, error) {
+	if err := r.Validate(); err != nil {
+		return 0, err
+	}
+	all := Columns(r.Dataset)
+	selected := make([]int, 0, len(all))
+	for _, name := range r.Columns {
+		selected = append(selected, index(all, name))
+	}
+	if len(selected) == 0 {
+		for i := range all {
+			selected = append(selected, i)
+		}
+	}
+	shown := make([]Column, len(selected))
+	for i, c := range selected {
+		shown[i] = all[c]
+	}
+	out, err := newWriter(r.Format, w, shown)
+	if err != nil {
+		return 0, fmt.Errorf("starting the export: %w", err)
+	}
+
+	rows := 0
+	values := make([]interface{}, len(selected))
+	emit := func(row []interface{}) error {
+		for _, f := range r.Filters {
+			if format(row[index(all, f.Column)]) != f.Value {
+				return nil
+			}
+		}
+		for i, c := range selected {
+			values[i] = row[c]
+		}
+		if err := out.Write(values); err != nil {
+			return fmt.Errorf("writing the export: %w", err)
+		}
+		rows++
+		return nil
+	}
+
+	page := ticketing.Page{Limit: PageSize}
+	switch r.Dataset {
+	case Attendees:
+		for {
+			attendees, err := store.ListAttendeesForEvent(ctx, eventID, page)
+			if err != nil {
+				return rows, fmt.Errorf("reading the attendees of event %d: %w", eventID, err)
+			}
+			for _, a := range attendees {
+				for _, c := range a.Claims {
+					if err := emit(attendeeRow(&a, &c)); err != nil {
+						return rows, err
+					}
+				}
+			}
+			if len(attendees) < page.Limit {
+				break
+			}
+			page.AfterID = attendees[len(attendees)-1].ID
+		}
+	case Payments:
+		for {
+			payments, err := store.ListClaimPaymentsForEvent(ctx, eventID, page)
+			if err != nil {
+				return rows, fmt.Errorf("reading the payments of event %d: %w", eventID, err)
+			}
+			for i := range payments {
+				for _, row := range paymentRows(&payments[i]) {
+					if err := emit(row); err != nil {
+						return rows, err
+					}
+				}
+			}
+			if len(payments) < page.Limit {
+				break
+			}
+			page.AfterID = payments[len(payments)-1].ID
+		}
+	}
+	if err := out.Close(); err != nil {
+		return rows, fmt.Errorf("finishing the export: %w", err)
+	}
+	return rows, nil
+}
+
+// attendeeRow returns the row of the Attendees dataset for the claim of a.
+func attendeeRow(a *ticketing.Attendee, c *ticketing.SlotClaim) []interface{} {
+	var slotID int64
+	var slotName string
+	if c.EventSlot != nil {
+		slotID, slotName = int64(c.EventSlot.ID), c.EventSlot.Name
+	}
+	return []interface{}{
+		int64(a.ID), a.Email, a.CoCAccepted,
+		int64(c.ID), c.TicketID, slotID, slotName, c.Redeemed, c.Suspended,
+	}
+}
+
+// paymentRows returns the rows of the Payments dataset for p, one with empty instrument
+// columns if it has no instruments.
+func paymentRows(p *ticketing.ClaimPayment) [][]interface{} {
+	ids := make([]string, len(p.ClaimsPayed))
+	for i, c := range p.ClaimsPayed {
+		ids[i] = strconv.FormatUint(c.ID, 10)
+	}
+	row := func(instrument string, id uint64, ref string, amount int64) []interface{} {
+		return []interface{}{
+			int64(p.ID), int64(p.AttendeeID), p.Invoice, int64(p.CreatedAt), p.TotalDue(),
+			strings.Join(ids, " "), instrument, int64(id), ref, amount,
+		}
+	}
+	if len(p.Payment) == 0 {
+		return [][]interface{}{row("", 0, "", 0)}
+	}
+	rows := make([][]interface{}, 0, len(p.Payment))
+	for _, fi := range p.Payment {
+		var id uint64
+		var ref string
+		switch m := fi.(type) {
+		case *ticketing.PaymentMethodMoney:
+			id, ref = m.ID, m.PaymentRef
+		case *ticketing.PaymentMethodConferenceDiscount:
+			id, ref = m.ID, m.Detail
+		case *ticketing.PaymentMethodCreditNote:
+			id, ref = m.ID, m.Detail
+		}
+		rows = append(rows, row(string(fi.Type()), id, ref, fi.Total()))
+	}
+	return rows
+}
+
+// format returns the value as written to CSV.
+func format(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,417 @@
+package dataexport
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io/ioutil"
+	stdlog "log"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xitongsys/parquet-go-source/buffer"
+	"github.com/xitongsys/parquet-go/reader"
+	"go.uber.org/zap"
+
+	"github.com/gopheracademy/manager/auth"
+	"github.com/gopheracademy/manager/def"
+	"github.com/gopheracademy/manager/errs"
+	"github.com/gopheracademy/manager/log"
+	"github.com/gopheracademy/manager/ticketing"
+)
+
+// newStore returns a store with an event whose conference slot was paid for by n
+// attendees, the first with a discount, and a workshop claimed by the first only.
+func newStore(t *testing.T, n int) (*ticketing.MemoryStorage, *def.Event) {
+	t.Helper()
+	ctx := context.Background()
+	s := ticketing.NewMemoryStorage()
+	event := &def.Event{ID: 1, Name: "GopherCon 2021"}
+	conference, err := s.CreateEventSlot(ctx, &ticketing.EventSlot{Event: event, Name: "conference", Cost: 50000, Capacity: n})
+	if err != nil {
+		t.Fatal(err)
+	}
+	workshop, err := s.CreateEventSlot(ctx, &ticketing.EventSlot{Event: event, Name: "workshop", Cost: 20000, Capacity: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= n; i++ {
+		attendee, err := s.CreateAttendee(ctx, &ticketing.Attendee{Email: fmt.Sprintf("gopher%d@example.com", i)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		claims, err := ticketing.ClaimSlots(ctx, s, attendee, *conference)
+		if err != nil {
+			t.Fatal(err)
+		}
+		instruments := []ticketing.FinancialInstrument{&ticketing.PaymentMethodMoney{PaymentRef: fmt.Sprintf("ch_%d", i), Amount: 50000}}
+		if i == 1 {
+			instruments = []ticketing.FinancialInstrument{
+				&ticketing.PaymentMethodConferenceDiscount{Detail: "EARLY", Amount: 10000},
+				&ticketing.PaymentMethodMoney{PaymentRef: "ch_1", Amount: 40000},
+			}
+			if _, err := ticketing.ClaimSlots(ctx, s, attendee, *workshop); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, err := ticketing.PayClaims(ctx, s, attendee, claims, instruments); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return s, event
+}
+
+func TestExportCSV(t *testing.T) {
+	s, event := newStore(t, 2)
+	var out bytes.Buffer
+	rows, err := Export(context.Background(), s, event.ID, Request{
+		Dataset: Attendees,
+		Format:  FormatCSV,
+		Columns: []string{"email", "slot_name", "redeemed"},
+	}, &out)
+	if err != nil {
+		t.Fatalf("Export() = %v", err)
+	}
+	want := "email,slot_name,redeemed\n" +
+		"gopher1@example.com,conference,false\n" +
+		"gopher1@example.com,workshop,false\n" +
+		"gopher2@example.com,conference,false\n"
+	if rows != 3 || out.String() != want {
+		t.Errorf("Export() wrote %d rows:\n%s\nwant 3:\n%s", rows, out.String(), want)
+	}
+}
+
+func TestExportJSONL(t *testing.T) {
+	s, event := newStore(t, 2)
+	var out bytes.Buffer
+	rows, err := Export(context.Background(), s, event.ID, Request{
+		Dataset: Payments,
+		Format:  FormatJSONL,
+		Columns: []string{"reference", "instrument", "amount", "total_due"},
+		Filters: []Filter{{Column: "attendee_id", Value: "1"}},
+	}, &out)
+	if err != nil {
+		t.Fatalf("Export() = %v", err)
+	}
+	want := `{"reference":"ch_1","instrument":"cash","amount":40000,"total_due":50000}` + "\n" +
+		`{"reference":"EARLY","instrument":"discount","amount":10000,"total_due":50000}` + "\n"
+	if rows != 2 || out.String() != want {
+		t.Errorf("Export() wrote %d rows:\n%s\nwant 2:\n%s", rows, out.String(), want)
+	}
+}
+
+func TestExportPages(t *testing.T) {
+	n := PageSize + 2
+	s, event := newStore(t, n)
+	for _, tc := range []struct {
+		dataset Dataset
+		rows    int
+	}{
+		// the first attendee holds a workshop and paid with a discount too.
+		{Attendees, n + 1},
+		{Payments, n + 1},
+	} {
+		var out bytes.Buffer
+		rows, err := Export(context.Background(), s, event.ID, Request{Dataset: tc.dataset, Format: FormatCSV}, &out)
+		if err != nil {
+			t.Fatalf("Export(%s) = %v", tc.dataset, err)
+		}
+		if lines := strings.Count(out.String(), "\n"); rows != tc.rows || lines != tc.rows+1 {
+			t.Errorf("Export(%s) wrote %d rows in %d lines, want %d", tc.dataset, rows, lines, tc.rows)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, r := range []Request{
+		{Dataset: "sponsors", Format: FormatCSV},
+		{Dataset: Attendees, Format: "xlsx"},
+		{Dataset: Attendees, Format: FormatCSV, Columns: []string{"email", "amount"}},
+		{Dataset: Payments, Format: FormatCSV, Filters: []Filter{{Column: "email", Value: "gopher1@example.com"}}},
+	} {
+		if err := r.Validate(); !errs.Is(err, errs.InvalidArgument) {
+			t.Errorf("Validate(%+v) = %v, want an invalid argument", r, err)
+		}
+	}
+	if _, err := ParseFilter("email"); err == nil {
+		t.Error("ParseFilter(email) succeeded, want an error")
+	}
+	if f, err := ParseFilter("reference=a=b"); err != nil || f != (Filter{Column: "reference", Value: "a=b"}) {
+		t.Errorf("ParseFilter(reference=a=b) = %+v, %v", f, err)
+	}
+}
+
+// readParquet reads the file back with a Parquet library, failing unless its schema has
+// the columns, and returns its rows as they are written to CSV.
+func readParquet(t *testing.T, b []byte, columns []Column) [][]string {
+	t.Helper()
+	file, err := buffer.NewBufferFile(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := reader.NewParquetColumnReader(file, 1)
+	if err != nil {
+		t.Fatalf("reading the footer: %v", err)
+	}
+	schema := r.Footer.GetSchema()
+	if len(schema) != len(columns)+1 {
+		t.Fatalf("schema has %d columns, want %d", len(schema)-1, len(columns))
+	}
+	// the reader renames the columns, their names in the file are kept as ExName.
+	for i, c := range columns {
+		if name := r.SchemaHandler.Infos[i+1].ExName; name != c.Name {
+			t.Errorf("column %d is %s, want %s", i, name, c.Name)
+		}
+	}
+	n := r.GetNumRows()
+	rows := make([][]string, n)
+	for i := range rows {
+		rows[i] = make([]string, len(columns))
+	}
+	for j := range columns {
+		values, _, _, err := r.ReadColumnByIndex(int64(j), n)
+		if err != nil {
+			t.Fatalf("reading column %s: %v", columns[j].Name, err)
+		}
+		if int64(len(values)) != n {
+			t.Fatalf("column %s has %d values, want %d", columns[j].Name, len(values), n)
+		}
+		for i, v := range values {
+			rows[i][j] = format(v)
+		}
+	}
+	return rows
+}
+
+func TestExportParquet(t *testing.T) {
+	s, event := newStore(t, 3)
+	for _, req := range []Request{
+		{Dataset: Attendees},
+		{Dataset: Payments},
+		{Dataset: Attendees, Columns: []string{"redeemed", "email", "slot_id"}, Filters: []Filter{{Column: "slot_name", Value: "conference"}}},
+	} {
+		// the file read back holds the rows of the CSV export.
+		var csvOut, parquetOut bytes.Buffer
+		req.Format = FormatCSV
+		if _, err := Export(context.Background(), s, event.ID, req, &csvOut); err != nil {
+			t.Fatalf("Export(%s.csv) = %v", req.Dataset, err)
+		}
+		want, err := csv.NewReader(&csvOut).ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Format = FormatParquet
+		rows, err := Export(context.Background(), s, event.ID, req, &parquetOut)
+		if err != nil {
+			t.Fatalf("Export(%s.parquet) = %v", req.Dataset, err)
+		}
+		if rows != len(want)-1 {
+			t.Errorf("Export(%s.parquet) wrote %d rows, want %d", req.Dataset, rows, len(want)-1)
+		}
+		columns := Columns(req.Dataset)
+		if req.Columns != nil {
+			columns = nil
+			for _, name := range req.Columns {
+				columns = append(columns, Column{Name: name})
+			}
+		}
+		if got := readParquet(t, parquetOut.Bytes(), columns); !reflect.DeepEqual(got, want[1:]) {
+			t.Errorf("%s %v read back %v, want %v", req.Dataset, req.Columns, got, want[1:])
+		}
+	}
+}
+
+func TestParquetRowGroups(t *testing.T) {
+	columns := []Column{{"id", Int}, {"name", String}, {"paid", Bool}}
+	var out bytes.Buffer
+	w, err := newParquetWriter(&out, columns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.groupSize = 4
+	var want [][]string
+	for i := 0; i < 9; i++ {
+		values := []interface{}{int64(i) - 3, fmt.Sprintf("gopher %d", i), i%3 == 0}
+		if err := w.Write(values); err != nil {
+			t.Fatalf("Write() = %v", err)
+		}
+		want = append(want, []string{format(values[0]), format(values[1]), format(values[2])})
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() = %v", err)
+	}
+	if got := readParquet(t, out.Bytes(), columns); !reflect.DeepEqual(got, want) {
+		t.Errorf("read back %v, want %v", got, want)
+	}
+	file, err := buffer.NewBufferFile(out.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := reader.NewParquetColumnReader(file, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if groups := r.Footer.GetRowGroups(); len(groups) != 3 || groups[2].GetNumRows() != 1 {
+		t.Errorf("wrote %d row groups, want 3 with a single row in the last", len(groups))
+	}
+}
+
+func TestThrift(t *testing.T) {
+	var th thrift
+	th.i32(1, -1)
+	th.beginStruct(20)
+	th.binary(1, "ab")
+	th.end()
+	th.beginList(21, thriftI32, 2)
+	th.varint(1)
+	th.varint(2)
+	th.end()
+	// short field headers carry the delta of their IDs, longer ones a zigzag varint.
+	want := []byte{0x15, 0x01, 0x0c, 0x28, 0x18, 0x02, 'a', 'b', 0x00, 0x19, 0x25, 0x02, 0x04, 0x00}
+	if !bytes.Equal(th.b, want) {
+		t.Errorf("encoded % x, want % x", th.b, want)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	s, event := newStore(t, 2)
+	roles := auth.NewMemoryRoleStore(auth.Grant{Email: "organiser@example.com", Role: auth.RoleOrganiser, ConferenceID: 7})
+	h := NewHandler(s, func(ctx context.Context, eventID uint32) (uint32, error) {
+		if eventID == event.ID {
+			return 7, nil
+		}
+		return 0, nil
+	}, auth.NewAuthorizer(roles), log.NewFactory(zap.NewNop()))
+
+	for _, tc := range []struct {
+		email, path string
+		status      int
+	}{
+		{"organiser@example.com", "/admin/export/events/1/attendees.csv?columns=email&filter=slot_name=workshop", http.StatusOK},
+		{"organiser@example.com", "/admin/export/events/2/attendees.csv", http.StatusNotFound},
+		{"organiser@example.com", "/admin/export/events/1/attendees.xlsx", http.StatusBadRequest},
+		{"organiser@example.com", "/admin/export/events/1/attendees.csv?filter=email", http.StatusBadRequest},
+		{"gopher1@example.com", "/admin/export/events/1/payments.jsonl", http.StatusNotFound},
+		{"", "/admin/export/events/1/payments.jsonl", http.StatusUnauthorized},
+	} {
+		r := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		if tc.email != "" {
+			r = r.WithContext(auth.WithIdentity(r.Context(), &auth.Identity{Email: tc.email}))
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != tc.status {
+			t.Errorf("%s as %q answered %d, want %d: %s", tc.path, tc.email, w.Code, tc.status, w.Body)
+		}
+		if tc.status == http.StatusOK {
+			if got, want := w.Body.String(), "email\ngopher1@example.com\n"; got != want {
+				t.Errorf("%s exported %q, want %q", tc.path, got, want)
+			}
+			if got := w.Header().Get("Content-Disposition"); got != `attachment; filename="event-1-attendees.csv"` {
+				t.Errorf("%s disposition %q", tc.path, got)
+			}
+		}
+	}
+}
+
+// failingStore fails reading attendees after the first pages.
+type failingStore struct {
+	*ticketing.MemoryStorage
+	pages int
+}
+
+func (s *failingStore) ListAttendeesForEvent(ctx context.Context, eventID uint32, page ticketing.Page) ([]ticketing.Attendee, error) {
+	if s.pages == 0 {
+		return nil, errs.New(errs.Unavailable, "the db is gone")
+	}
+	s.pages--
+	return s.MemoryStorage.ListAttendeesForEvent(ctx, eventID, page)
+}
+
+func TestHandlerFails(t *testing.T) {
+	s, event := newStore(t, PageSize+2)
+	store := &failingStore{MemoryStorage: s}
+	roles := auth.NewMemoryRoleStore(auth.Grant{Email: "organiser@example.com", Role: auth.RoleOrganiser, ConferenceID: 7})
+	h := NewHandler(store, func(ctx context.Context, eventID uint32) (uint32, error) {
+		return 7, nil
+	}, auth.NewAuthorizer(roles), log.NewFactory(zap.NewNop()))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.ServeHTTP(w, r.WithContext(auth.WithIdentity(r.Context(), &auth.Identity{Email: "organiser@example.com"})))
+	}))
+	defer srv.Close()
+	srv.Config.ErrorLog = stdlog.New(ioutil.Discard, "", 0)
+	url := fmt.Sprintf("%s/admin/export/events/%d/attendees.csv", srv.URL, event.ID)
+
+	// failing before anything is written answers the error.
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable || resp.Header.Get("Content-Disposition") != "" {
+		t.Errorf("failed export answered %d with %v, want 503 and no file", resp.StatusCode, resp.Header)
+	}
+
+	// failing midway aborts the export.
+	store.pages = 1
+	resp, err = http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if _, err := ioutil.ReadAll(resp.Body); err == nil || resp.StatusCode != http.StatusOK {
+		t.Errorf("export cut short answered %d and was read whole, want it to fail", resp.StatusCode)
+	}
+}
+
+// slowStore takes delay to read every page of attendees but the first.
+type slowStore struct {
+	*ticketing.MemoryStorage
+	delay time.Duration
+}
+
+func (s *slowStore) ListAttendeesForEvent(ctx context.Context, eventID uint32, page ticketing.Page) ([]ticketing.Attendee, error) {
+	if page.AfterID > 0 {
+		select {
+		case <-time.After(s.delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return s.MemoryStorage.ListAttendeesForEvent(ctx, eventID, page)
+}
+
+func TestHandlerExtendsWriteTimeout(t *testing.T) {
+	s, event := newStore(t, PageSize+2)
+	roles := auth.NewMemoryRoleStore(auth.Grant{Email: "organiser@example.com", Role: auth.RoleOrganiser, ConferenceID: 7})
+	h := NewHandler(&slowStore{MemoryStorage: s, delay: 300 * time.Millisecond}, func(ctx context.Context, eventID uint32) (uint32, error) {
+		return 7, nil
+	}, auth.NewAuthorizer(roles), log.NewFactory(zap.NewNop()))
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.ServeHTTP(w, r.WithContext(auth.WithIdentity(r.Context(), &auth.Identity{Email: "organiser@example.com"})))
+	}))
+	// the export takes longer than the write timeout, which other requests keep.
+	srv.Config.WriteTimeout = 100 * time.Millisecond
+	srv.Config.ConnContext = ConnContext
+	srv.Start()
+	defer srv.Close()
+
+	resp, err := http.Get(fmt.Sprintf("%s/admin/export/events/%d/attendees.csv", srv.URL, event.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("reading the export: %v", err)
+	}
+	// a header and a row per claim, the first attendee has two.
+	if rows := strings.Count(string(body), "\n"); rows != PageSize+4 {
+		t.Errorf("export has %d lines, want %d", rows, PageSize+4)
+	}
+}
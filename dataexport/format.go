@@ -0,0 +1,121 @@
+package dataexport
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Format is how the rows of an export are written.
+type Format string
+
+// The formats exports are written in.
+const (
+	// FormatCSV writes a header with the column names and a line per row.
+	FormatCSV Format = "csv"
+	// FormatJSONL writes a JSON object per row and line, with its columns in order.
+	FormatJSONL Format = "jsonl"
+	// FormatParquet writes a Parquet file with a required column per column.
+	FormatParquet Format = "parquet"
+)
+
+func (f Format) valid() bool {
+	return f == FormatCSV || f == FormatJSONL || f == FormatParquet
+}
+
+// ContentType returns the media type of exports in the format.
+func (f Format) ContentType() string {
+	switch f {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatJSONL:
+		return "application/x-ndjson"
+	}
+	return "application/vnd.apache.parquet"
+}
+
+// rowWriter writes the rows of an export, values are strings, int64 or bools as the
+// kinds of their columns; nothing might be written to the underlying writer until Close.
+type rowWriter interface {
+	Write(values []interface{}) error
+	Close() error
+}
+
+func newWriter(f Format, w io.Writer, columns []Column) (rowWriter, error) {
+	switch f {
+	case FormatCSV:
+		return newCSVWriter(w, columns)
+	case FormatJSONL:
+		return newJSONLWriter(w, columns)
+	case FormatParquet:
+		return newParquetWriter(w, columns)
+	}
+	return nil, fmt.Errorf("unknown format %q", f)
+}
+
+type csvWriter struct {
+	w      *csv.Writer
+	record []string
+}
+
+func newCSVWriter(w io.Writer, columns []Column) (*csvWriter, error) {
+	cw := &csvWriter{w: csv.NewWriter(w), record: make([]string, len(columns))}
+	for i, c := range columns {
+		cw.record[i] = c.Name
+	}
+	return cw, cw.w.Write(cw.record)
+}
+
+func (w *csvWriter) Write(values []interface{}) error {
+	for i, v := range values {
+		w.record[i] = format(v)
+	}
+	return w.w.Write(w.record)
+}
+
+func (w *csvWriter) Close() error {
+	w.w.Flush()
+	return w.w.Error()
+}
+
+// jsonlWriter writes the objects by hand, encoding/json would sort the keys of a map
+// rather than keep them in the order of the columns.
+type jsonlWriter struct {
+	w    *bufio.Writer
+	keys [][]byte
+}
+
+func newJSONLWriter(w io.Writer, columns []Column) (*jsonlWriter, error) {
+	jw := &jsonlWriter{w: bufio.NewWriter(w), keys: make([][]byte, len(columns))}
+	for i, c := range columns {
+		key, err := json.Marshal(c.Name)
+		if err != nil {
+			return nil, err
+		}
+		jw.keys[i] = append(key, ':')
+	}
+	return jw, nil
+}
+
+func (w *jsonlWriter) Write(values []interface{}) error {
+	w.w.WriteByte('{')
+	for i, v := range values {
+		if i > 0 {
+			w.w.WriteByte(',')
+		}
+		value, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		w.w.Write(w.keys[i])
+		w.w.Write(value)
+	}
+	_, err := w.w.WriteString("}\n")
+	return err
+}
+
+func (w *jsonlWriter) Close() error {
+	return w.w.Flush()
+}
@@ -0,0 +1,182 @@
+package dataexport
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"github.com/gopheracademy/manager/auth"
+	"github.com/gopheracademy/manager/errs"
+	"github.com/gopheracademy/manager/log"
+	"github.com/gopheracademy/manager/ticketing"
+)
+
+// PathPrefix is where exports are served from.
+const PathPrefix = "/admin/export/"
+
+// Timeout bounds how long an export is streamed, those that take longer are aborted and
+// better taken with manager export.
+const Timeout = 10 * time.Minute
+
+type connKey struct{}
+
+// ConnContext keeps the connection of requests in their context, for exports to write
+// for longer than the WriteTimeout of the server; set it as the ConnContext of the
+// http.Server serving the Handler.
+func ConnContext(ctx context.Context, c net.Conn) context.Context {
+	return context.WithValue(ctx, connKey{}, c)
+}
+
+// extendWriteDeadline lets the response to r be written until t rather than until the
+// WriteTimeout of the server, which is that of its connection. HTTP/2 streams share
+// their connection and keep the timeout of the server.
+func extendWriteDeadline(r *http.Request, t time.Time) error {
+	c, ok := r.Context().Value(connKey{}).(net.Conn)
+	if !ok || r.ProtoMajor != 1 {
+		return fmt.Errorf("the connection of %s requests can not be given a deadline", r.Proto)
+	}
+	return c.SetWriteDeadline(t)
+}
+
+// ConferenceOf returns the conference an event belongs to, 0 if there is no such event.
+type ConferenceOf func(ctx context.Context, eventID uint32) (uint32, error)
+
+// Authorizer authorizes the identity of a request, *auth.Authorizer satisfies it.
+type Authorizer interface {
+	Authorize(ctx context.Context, p auth.Permission) error
+}
+
+// Handler serves exports to the organisers of the conference of the event:
+//
+//	/admin/export/events/{event}/{dataset}.{format}?columns=email,slot_name&filter=slot_name=workshop
+//
+// columns are separated by commas and filter can be repeated, both are optional.
+type Handler struct {
+	router       *mux.Router
+	store        ticketing.ExportStore
+	conferenceOf ConferenceOf
+	authorizer   Authorizer
+	logger       log.Factory
+}
+
+// NewHandler returns a Handler, store might be nil if there is no storage in which case
+// every export is unavailable.
+func NewHandler(store ticketing.ExportStore, conferenceOf ConferenceOf, authorizer Authorizer, logger log.Factory) *Handler {
+	h := &Handler{
+		router:       mux.NewRouter(),
+		store:        store,
+		conferenceOf: conferenceOf,
+		authorizer:   authorizer,
+		logger:       logger,
+	}
+	h.router.HandleFunc(PathPrefix+"events/{event:[0-9]+}/{dataset}.{format}", h.serveExport).Methods(http.MethodGet)
+	return h
+}
+
+// ServeHTTP implements http.Handler
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.router.ServeHTTP(w, r)
+}
+
+func (h *Handler) serveExport(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+	eventID, err := strconv.ParseUint(vars["event"], 10, 32)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	req := Request{Dataset: Dataset(vars["dataset"]), Format: Format(vars["format"])}
+	query := r.URL.Query()
+	if columns := query.Get("columns"); columns != "" {
+		req.Columns = strings.Split(columns, ",")
+	}
+	for _, filter := range query["filter"] {
+		f, err := ParseFilter(filter)
+		if err != nil {
+			h.writeError(w, r, errs.Invalid(errs.FieldViolation{Field: "filter", Description: err.Error()}))
+			return
+		}
+		req.Filters = append(req.Filters, f)
+	}
+	if h.store == nil {
+		h.writeError(w, r, errs.New(errs.Unavailable, "exports need a database"))
+		return
+	}
+	// the event is looked up first as permissions are scoped to its conference, the
+	// events of conferences one does not organise are not found.
+	conferenceID, err := h.conferenceOf(ctx, uint32(eventID))
+	if err != nil {
+		h.writeError(w, r, fmt.Errorf("finding the conference of event %d: %w", eventID, err))
+		return
+	}
+	err = h.authorizer.Authorize(ctx, auth.Permission{Roles: []auth.Role{auth.RoleOrganiser}, ConferenceID: conferenceID})
+	if conferenceID == 0 || errs.Is(err, errs.PermissionDenied) {
+		err = errs.New(errs.NotFound, "event %d not found", eventID)
+	}
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	if err := extendWriteDeadline(r, time.Now().Add(Timeout)); err != nil {
+		h.logger.For(ctx).Info("export keeps the write timeout of the server", zap.Error(err))
+	}
+	w.Header().Set("Content-Type", req.Format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="event-%d-%s.%s"`, eventID, req.Dataset, req.Format))
+	w.Header().Set("Cache-Control", "no-store")
+	out := &countingWriter{w: w}
+	rows, err := Export(ctx, h.store, uint32(eventID), req, out)
+	if err != nil {
+		h.logger.For(ctx).Error("exporting", zap.Uint64("event", eventID), zap.Int("rows", rows), zap.Error(err))
+		if out.n == 0 {
+			w.Header().Del("Content-Disposition")
+			h.writeError(w, r, err)
+			return
+		}
+		// the status is gone with the first row, the connection is aborted so the export
+		// fails rather than being taken whole while it was cut short.
+		panic(http.ErrAbortHandler)
+	}
+	h.logger.For(ctx).Info("exported", zap.Uint64("event", eventID),
+		zap.String("dataset", string(req.Dataset)), zap.String("format", string(req.Format)), zap.Int("rows", rows))
+}
+
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// writeError answers with the status and body matching the kind of err, errors that
+// are our fault are logged as their details are not sent.
+func (h *Handler) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	status, response := errs.ResponseFor(err)
+	if status >= http.StatusInternalServerError {
+		h.logger.For(r.Context()).Error("serving export", zap.Error(err))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		h.logger.For(r.Context()).Error("writing error", zap.Error(err))
+	}
+}
@@ -0,0 +1,314 @@
+package dataexport
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// RowGroupSize is how many rows a Parquet row group holds, they are buffered until it
+// is written.
+const RowGroupSize = 10000
+
+// parquetWriter writes the smallest Parquet files readers take: every column is required,
+// so there are no definition levels, and holds a single PLAIN encoded, uncompressed data
+// page per row group; strings are UTF8 byte arrays.
+type parquetWriter struct {
+	w       io.Writer
+	offset  int64
+	columns []Column
+	// pages holds the values of the current row group per column, bools are packed
+	// into them when the group is written.
+	pages  []bytes.Buffer
+	bools  [][]bool
+	rows   int
+	total  int64
+	groups []rowGroup
+	// groupSize is how many rows a row group holds, RowGroupSize but in tests.
+	groupSize int
+}
+
+type rowGroup struct {
+	rows   int
+	chunks []columnChunk
+}
+
+type columnChunk struct {
+	offset int64
+	size   int64
+}
+
+var parquetMagic = []byte("PAR1")
+
+func newParquetWriter(w io.Writer, columns []Column) (*parquetWriter, error) {
+	pw := &parquetWriter{
+		w:         w,
+		columns:   columns,
+		pages:     make([]bytes.Buffer, len(columns)),
+		bools:     make([][]bool, len(columns)),
+		groupSize: RowGroupSize,
+	}
+	return pw, pw.write(parquetMagic)
+}
+
+func (w *parquetWriter) write(b []byte) error {
+	n, err := w.w.Write(b)
+	w.offset += int64(n)
+	return err
+}
+
+func (w *parquetWriter) Write(values []interface{}) error {
+	for i, v := range values {
+		page := &w.pages[i]
+		switch v := v.(type) {
+		case string:
+			var size [4]byte
+			binary.LittleEndian.PutUint32(size[:], uint32(len(v)))
+			page.Write(size[:])
+			page.WriteString(v)
+		case int64:
+			var value [8]byte
+			binary.LittleEndian.PutUint64(value[:], uint64(v))
+			page.Write(value[:])
+		case bool:
+			w.bools[i] = append(w.bools[i], v)
+		default:
+			return fmt.Errorf("cannot write %T to parquet", v)
+		}
+	}
+	w.rows++
+	if w.rows == w.groupSize {
+		return w.flush()
+	}
+	return nil
+}
+
+// flush writes the buffered rows as a row group.
+func (w *parquetWriter) flush() error {
+	group := rowGroup{rows: w.rows}
+	for i, c := range w.columns {
+		page := &w.pages[i]
+		if c.Kind == Bool {
+			// booleans are bit packed, the first value in the least significant bit.
+			packed := make([]byte, (len(w.bools[i])+7)/8)
+			for j, b := range w.bools[i] {
+				if b {
+					packed[j/8] |= 1 << uint(j%8)
+				}
+			}
+			page.Write(packed)
+			w.bools[i] = w.bools[i][:0]
+		}
+		header := pageHeader(page.Len(), w.rows)
+		chunk := columnChunk{offset: w.offset, size: int64(len(header) + page.Len())}
+		if err := w.write(header); err != nil {
+			return err
+		}
+		if err := w.write(page.Bytes()); err != nil {
+			return err
+		}
+		page.Reset()
+		group.chunks = append(group.chunks, chunk)
+	}
+	w.groups = append(w.groups, group)
+	w.total += int64(w.rows)
+	w.rows = 0
+	return nil
+}
+
+// Close writes the rows left and the footer.
+func (w *parquetWriter) Close() error {
+	if w.rows > 0 {
+		if err := w.flush(); err != nil {
+			return err
+		}
+	}
+	footer := w.metadata()
+	var size [4]byte
+	binary.LittleEndian.PutUint32(size[:], uint32(len(footer)))
+	for _, b := range [][]byte{footer, size[:], parquetMagic} {
+		if err := w.write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// The values of the Parquet enums we use.
+const (
+	parquetBoolean   = 0
+	parquetInt64     = 2
+	parquetByteArray = 6
+
+	parquetRequired = 0
+	parquetUTF8     = 0
+	parquetPlain    = 0
+	parquetRLE      = 3
+)
+
+func parquetType(k Kind) int32 {
+	switch k {
+	case Int:
+		return parquetInt64
+	case Bool:
+		return parquetBoolean
+	}
+	return parquetByteArray
+}
+
+// pageHeader returns the PageHeader of a data page of size bytes with n values.
+func pageHeader(size, n int) []byte {
+	var t thrift
+	t.i32(1, 0) // DATA_PAGE
+	t.i32(2, int32(size))
+	t.i32(3, int32(size))
+	t.beginStruct(5) // DataPageHeader
+	t.i32(1, int32(n))
+	t.i32(2, parquetPlain)
+	t.i32(3, parquetRLE)
+	t.i32(4, parquetRLE)
+	t.end()
+	t.end()
+	return t.b
+}
+
+// metadata returns the FileMetaData of what was written.
+func (w *parquetWriter) metadata() []byte {
+	var t thrift
+	t.i32(1, 1) // version
+	t.beginList(2, thriftStruct, len(w.columns)+1)
+	t.beginElement() // the root of the schema
+	t.binary(4, "schema")
+	t.i32(5, int32(len(w.columns)))
+	t.end()
+	for _, c := range w.columns {
+		t.beginElement()
+		t.i32(1, parquetType(c.Kind))
+		t.i32(3, parquetRequired)
+		t.binary(4, c.Name)
+		if c.Kind == String {
+			t.i32(6, parquetUTF8)
+		}
+		t.end()
+	}
+	t.i64(3, w.total)
+	t.beginList(4, thriftStruct, len(w.groups))
+	for _, g := range w.groups {
+		t.beginElement()
+		t.beginList(1, thriftStruct, len(g.chunks))
+		var size int64
+		for i, chunk := range g.chunks {
+			c := w.columns[i]
+			size += chunk.size
+			t.beginElement() // ColumnChunk
+			t.i64(2, chunk.offset)
+			t.beginStruct(3) // ColumnMetaData
+			t.i32(1, parquetType(c.Kind))
+			t.beginList(2, thriftI32, 1)
+			t.varint(parquetPlain)
+			t.beginList(3, thriftBinary, 1)
+			t.uvarint(uint64(len(c.Name)))
+			t.b = append(t.b, c.Name...)
+			t.i32(4, 0) // UNCOMPRESSED
+			t.i64(5, int64(g.rows))
+			t.i64(6, chunk.size)
+			t.i64(7, chunk.size)
+			t.i64(9, chunk.offset)
+			t.end()
+			t.end()
+		}
+		t.i64(2, size)
+		t.i64(3, int64(g.rows))
+		t.end()
+	}
+	t.binary(6, "gopheracademy manager")
+	t.end()
+	return t.b
+}
+
+// The types of the Thrift compact protocol we use.
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thrift encodes a struct with the Thrift compact protocol Parquet metadata is written
+// in, fields must be added by increasing ID and every struct ended.
+type thrift struct {
+	b []byte
+	// last is the ID of the last field of the struct being written, the IDs of those it
+	// is nested in are stacked.
+	last  int16
+	stack []int16
+}
+
+func (t *thrift) uvarint(v uint64) {
+	var buf [binary.MaxVarintLen64]byte
+	t.b = append(t.b, buf[:binary.PutUvarint(buf[:], v)]...)
+}
+
+// varint writes v zigzag encoded.
+func (t *thrift) varint(v int64) {
+	t.uvarint(uint64((v << 1) ^ (v >> 63)))
+}
+
+func (t *thrift) field(id int16, typ byte) {
+	if delta := id - t.last; delta > 0 && delta <= 15 {
+		t.b = append(t.b, byte(delta)<<4|typ)
+	} else {
+		t.b = append(t.b, typ)
+		t.varint(int64(id))
+	}
+	t.last = id
+}
+
+func (t *thrift) i32(id int16, v int32) {
+	t.field(id, thriftI32)
+	t.varint(int64(v))
+}
+
+func (t *thrift) i64(id int16, v int64) {
+	t.field(id, thriftI64)
+	t.varint(v)
+}
+
+func (t *thrift) binary(id int16, s string) {
+	t.field(id, thriftBinary)
+	t.uvarint(uint64(len(s)))
+	t.b = append(t.b, s...)
+}
+
+// beginList starts a list field of n elements, those of structs are each started with
+// beginElement while others are written as is.
+func (t *thrift) beginList(id int16, elem byte, n int) {
+	t.field(id, thriftList)
+	if n < 15 {
+		t.b = append(t.b, byte(n)<<4|elem)
+		return
+	}
+	t.b = append(t.b, 0xf0|elem)
+	t.uvarint(uint64(n))
+}
+
+func (t *thrift) beginStruct(id int16) {
+	t.field(id, thriftStruct)
+	t.beginElement()
+}
+
+func (t *thrift) beginElement() {
+	t.stack = append(t.stack, t.last)
+	t.last = 0
+}
+
+// end ends the struct being written.
+func (t *thrift) end() {
+	t.b = append(t.b, 0)
+	if n := len(t.stack); n > 0 {
+		t.last = t.stack[n-1]
+		t.stack = t.stack[:n-1]
+	}
+}
@@ -0,0 +1,77 @@
+package def
+
+// AnalyticsService reports on the ticket sales of events, for organisers to follow them
+// from the admin pages.
+type AnalyticsService interface {
+	// EventSales returns the sales of an event and of each of its slots, with their
+	// daily series, the discounts used and when they are projected to sell out.
+	// roles: ["organiser"]
+	// scope: "ConferenceID"
+	EventSales(EventSalesRequest) EventSalesResponse
+}
+
+// SlotSales are the sales of a slot, or of every slot of the event in their totals.
+type SlotSales struct {
+	// SlotID and Name are empty in the totals of the event.
+	SlotID uint64
+	Name   string
+	// Sold claims were paid for and Held ones were claimed but not paid for yet, both
+	// take capacity.
+	Capacity  int
+	Sold      int
+	Held      int
+	Remaining int
+	// Gross is the cost of the claims sold and Net what is left of it after Discounts,
+	// in cents.
+	Gross     int64
+	Discounts int64
+	Net       int64
+	// Velocity is how many claims were sold a day over the last week.
+	Velocity float64
+	// SellOut is when what remains is projected to be sold at Velocity, 0 if nothing
+	// remains or nothing sold lately; SellsOut is true if that is before SalesEnd.
+	SellOut  uint64
+	SellsOut bool
+	// SalesEnd is when the slot stops being sold, 0 if unknown.
+	SalesEnd uint64
+	// Daily are the sales of every day from the first sale until today.
+	Daily []DailySales
+}
+
+// DailySales are the sales made on a day in the time zone of the event.
+type DailySales struct {
+	// Date is formatted as 2006-01-02.
+	Date  string
+	Sold  int
+	Gross int64
+	Net   int64
+}
+
+// PromoUsage is how much a discount was used for the event, by its detail.
+type PromoUsage struct {
+	Code     string
+	Payments int
+	Claims   int
+	// Discount is what was taken off the claims of the event, in cents.
+	Discount int64
+}
+
+// EventSalesRequest is the request object for AnalyticsService.EventSales.
+type EventSalesRequest struct {
+	// required: true
+	ConferenceID uint32
+	// required: true
+	EventID uint32
+}
+
+// EventSalesResponse is the response object for AnalyticsService.EventSales.
+type EventSalesResponse struct {
+	AsOf uint64
+	// TimeZone is the one days are counted in.
+	TimeZone string
+	// Slots are ordered by ID, Total sums them.
+	Slots []SlotSales
+	Total SlotSales
+	// Promos are ordered by most used.
+	Promos []PromoUsage
+}
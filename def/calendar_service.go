@@ -0,0 +1,19 @@
+package def
+
+// CalendarService hands attendees the calendar feed of their agenda.
+type CalendarService interface {
+	// AttendeeFeed returns the feed of the agenda of the attendee logged in, signed so
+	// calendar clients can subscribe to it without logging in.
+	// roles: ["attendee"]
+	AttendeeFeed(AttendeeFeedRequest) AttendeeFeedResponse
+}
+
+// AttendeeFeedRequest is the request object for CalendarService.AttendeeFeed.
+type AttendeeFeedRequest struct {
+}
+
+// AttendeeFeedResponse is the response object for CalendarService.AttendeeFeed.
+type AttendeeFeedResponse struct {
+	// URL is the iCalendar feed, anyone holding it can read the agenda.
+	URL string
+}
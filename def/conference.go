@@ -1,41 +1,114 @@
 package def
 
+// Fields declare the rules their values follow and the generated Validate methods, in
+// Go and JS, check them before calling the service.
+//   - "required" set to true rejects empty values.
+//   - "minLength" and "maxLength" limit the length of strings, in characters.
+//   - "pattern" names a format strings must match, see validation.Patterns.
+//   - "min" and "max" limit numbers.
+//   - "before" names a field that must be later than this one, when both are set.
+//
+// Types annotated with "store" set to "table" get a table and a repository generated in
+// the store package, their "pk" field must be ID; those set to "interface" are stored by
+// hand elsewhere (ie ticketing). A list of stored types makes them rows of their own
+// table pointing back to their parent. Strings can be declared "unique".
+
 // Conference is a brand like GopherCon
+// store: "table"
 type Conference struct {
 	// pk: "true"
-	ID     uint32
-	Name   string
+	ID uint32
+	// required: true
+	// maxLength: 100
+	Name string
+	// required: true
+	// pattern: "slug"
+	// maxLength: 50
+	// unique: true
 	Slug   string
 	Events []Event
 }
 
 // Event is an instance like GopherCon 2020
+// store: "table"
 type Event struct {
-	ID        uint32
-	Name      string
-	Slug      string
+	// pk: "true"
+	ID uint32
+	// required: true
+	// maxLength: 100
+	Name string
+	// required: true
+	// pattern: "slug"
+	// maxLength: 50
+	Slug string
+	// before: "EndDate"
 	StartDate uint64
 	EndDate   uint64
 	Location  string
-	Slots     []EventSlot
+	// TimeZone is the IANA name of the zone where the event happens (ie America/Denver),
+	// dates are still Unix timestamps, this is used to present them.
+	TimeZone string
+	// Live indicates the event is published and ready for public sales.
+	Live  bool
+	Slots []EventSlot
+	// SponsorshipLevels are the names of the levels sold for this event, from the highest.
+	SponsorshipLevels []string
+	Sponsors          []Sponsor
+}
+
+// Sponsor is a company that pays the conference a fee in consideration for marketing
+// based on sponsorship level.
+// store: "table"
+type Sponsor struct {
+	// pk: "true"
+	ID uint32
+	// required: true
+	Name string
+	// Level is one of the SponsorshipLevels of the event.
+	Level   string
+	Website string
+	// Logo is the URL of the logo to display.
+	Logo string
+	// Profile is the public profile for display on the website.
+	Profile string
+	// Contacts are the people we deal with at the company, these are never public.
+	Contacts []SponsorContact
+}
+
+// SponsorContact is a person to contact at a sponsor for a given matter.
+// store: "table"
+type SponsorContact struct {
+	// pk: "true"
+	ID uint32
+	// Role is what this person is the contact for (ie marketing, recruiting or logistics)
+	Role string
+	Name string
+	// pattern: "email"
+	Email string
+	Phone string
 }
 
 // EventSlot holds information for any sellable/giftable slot we have in the event for
 // a Talk or any other activity that requires admission.
 // store: "interface"
 type EventSlot struct {
-	ID          uint32
+	ID uint32
+	// required: true
 	Name        string
 	Description string
-	Cost        int
-	Capacity    int // int should be enough even if we organize glastonbury
-	StartDate   uint64
-	EndDate     uint64
+	// min: 0
+	Cost int
+	// min: 0
+	Capacity int // int should be enough even if we organize glastonbury
+	// before: "EndDate"
+	StartDate uint64
+	EndDate   uint64
 	// DependsOn means that these two Slots need to be acquired together, user must either buy
 	// both Slots or pre-own one of the one it depends on.
 	DependsOn *EventSlot
 	// PurchaseableFrom indicates when this item is on sale, for instance early bird tickets are the first
 	// ones to go on sale.
+	// before: "PurchaseableUntil"
 	PurchaseableFrom uint64
 	// PuchaseableUntil indicates when this item stops being on sale, for instance early bird tickets can
 	// no loger be purchased N months before event.
@@ -1,12 +1,24 @@
 package def
 
+// Every service method declares who can call it and the generated handlers enforce it.
+//   - "public" set to true allows anyone, even anonymous users.
+//   - "roles" lists the auth.Role allowed, super-admin is always allowed and attendee
+//     means any logged in user; with no roles only super-admin is allowed.
+//   - "scope" names the request field holding the ID of the conference the call acts
+//     upon, roles granted on other conferences do not apply.
+
 // ConferenceService is a service for managing Conferences
 type ConferenceService interface {
 	// Greet prepares a lovely greeting.
+	// public: true
 	List(ListConferenceRequest) ListConferenceResponse
+	// public: true
 	Get(GetConferenceRequest) GetConferenceResponse
+	// public: true
 	GetBySlug(GetConferenceBySlugRequest) GetConferenceResponse
 	Create(CreateConferenceRequest) CreateConferenceResponse
+	// roles: ["organiser"]
+	// scope: "ID"
 	Delete(DeleteConferenceRequest) DeleteConferenceResponse
 }
 
@@ -36,6 +48,8 @@ type GetConferenceRequest struct {
 
 // GetConferenceBySlugRequest is the request object for ConferenceService.GetBySlug.
 type GetConferenceBySlugRequest struct {
+	// required: true
+	// pattern: "slug"
 	Slug string
 }
 
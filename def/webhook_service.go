@@ -0,0 +1,169 @@
+package def
+
+// WebhookService lets organisers post the domain events of their conference (ie ticket
+// claims and payments) to other systems like Slack, a CRM or a badge vendor.
+type WebhookService interface {
+	// Create subscribes an URL to the events of a conference.
+	// roles: ["organiser"]
+	// scope: "ConferenceID"
+	Create(CreateWebhookRequest) CreateWebhookResponse
+	// List returns the webhooks of a conference, without their secrets.
+	// roles: ["organiser"]
+	// scope: "ConferenceID"
+	List(ListWebhooksRequest) ListWebhooksResponse
+	// Delete removes a webhook along with its deliveries.
+	// roles: ["organiser"]
+	// scope: "ConferenceID"
+	Delete(DeleteWebhookRequest) DeleteWebhookResponse
+	// ListDeliveries returns the latest deliveries of a webhook.
+	// roles: ["organiser"]
+	// scope: "ConferenceID"
+	ListDeliveries(ListWebhookDeliveriesRequest) ListWebhookDeliveriesResponse
+	// GetDelivery returns a delivery with the request and response of each attempt.
+	// roles: ["organiser"]
+	// scope: "ConferenceID"
+	GetDelivery(GetWebhookDeliveryRequest) GetWebhookDeliveryResponse
+	// Redeliver delivers an event again, ie once a dead delivery was fixed on the other end.
+	// roles: ["organiser"]
+	// scope: "ConferenceID"
+	Redeliver(RedeliverWebhookRequest) RedeliverWebhookResponse
+}
+
+// Webhook posts the events of a conference to an URL, each delivery is signed with its
+// secret in the X-Showrunner-Signature header.
+// store: "interface"
+type Webhook struct {
+	ID           uint64
+	ConferenceID uint32
+	// required: true
+	// pattern: "https url"
+	// maxLength: 2000
+	URL string
+	// EventTypes are the types of the events posted (ie ticketing.ClaimCreated), all of
+	// them if empty.
+	EventTypes []string
+	// Secret signs the deliveries, one is generated if empty; it is only returned when
+	// the webhook is created.
+	// maxLength: 200
+	Secret    string
+	CreatedAt uint64
+}
+
+// WebhookDelivery is an event to post to a webhook.
+// store: "interface"
+type WebhookDelivery struct {
+	ID        uint64
+	WebhookID uint64
+	// EventID identifies the event, it is the same in every delivery of the event.
+	EventID   uint64
+	EventType string
+	// Payload is the JSON posted.
+	Payload string
+	// Status is pending until delivered, or dead once every attempt failed; dead
+	// deliveries are only attempted again when redelivered.
+	Status string
+	// Attempts counts the failed attempts.
+	Attempts      int
+	NextAttemptAt uint64
+	CreatedAt     uint64
+}
+
+// WebhookAttempt is the log of one attempt of a delivery.
+type WebhookAttempt struct {
+	ID          uint64
+	DeliveryID  uint64
+	AttemptedAt uint64
+	// RequestHeaders and ResponseHeaders hold one header per line.
+	RequestHeaders string
+	RequestBody    string
+	// ResponseStatus is 0 if no response was received, see Error.
+	ResponseStatus  int
+	ResponseHeaders string
+	// ResponseBody is truncated to 64KiB.
+	ResponseBody string
+	Error        string
+	DurationMS   int
+}
+
+// CreateWebhookRequest is the request object for WebhookService.Create.
+type CreateWebhookRequest struct {
+	// required: true
+	ConferenceID uint32
+	Webhook      Webhook
+}
+
+// CreateWebhookResponse is the response object for WebhookService.Create.
+type CreateWebhookResponse struct {
+	Webhook Webhook
+}
+
+// ListWebhooksRequest is the request object for WebhookService.List.
+type ListWebhooksRequest struct {
+	// required: true
+	ConferenceID uint32
+}
+
+// ListWebhooksResponse is the response object for WebhookService.List.
+type ListWebhooksResponse struct {
+	Webhooks []Webhook
+}
+
+// DeleteWebhookRequest is the request object for WebhookService.Delete.
+type DeleteWebhookRequest struct {
+	// required: true
+	ConferenceID uint32
+	// required: true
+	ID uint64
+}
+
+// DeleteWebhookResponse is the response object for WebhookService.Delete.
+type DeleteWebhookResponse struct {
+}
+
+// ListWebhookDeliveriesRequest is the request object for WebhookService.ListDeliveries.
+type ListWebhookDeliveriesRequest struct {
+	// required: true
+	ConferenceID uint32
+	// required: true
+	WebhookID uint64
+	// Status only returns the deliveries with this status if set.
+	Status string
+	// Limit defaults to 50.
+	// min: 0
+	// max: 500
+	Limit int
+}
+
+// ListWebhookDeliveriesResponse is the response object for WebhookService.ListDeliveries.
+type ListWebhookDeliveriesResponse struct {
+	// Deliveries are the latest first.
+	Deliveries []WebhookDelivery
+}
+
+// GetWebhookDeliveryRequest is the request object for WebhookService.GetDelivery.
+type GetWebhookDeliveryRequest struct {
+	// required: true
+	ConferenceID uint32
+	// required: true
+	ID uint64
+}
+
+// GetWebhookDeliveryResponse is the response object for WebhookService.GetDelivery.
+type GetWebhookDeliveryResponse struct {
+	Delivery WebhookDelivery
+	// Attempts are in the order they were made.
+	Attempts []WebhookAttempt
+}
+
+// RedeliverWebhookRequest is the request object for WebhookService.Redeliver.
+type RedeliverWebhookRequest struct {
+	// required: true
+	ConferenceID uint32
+	// required: true
+	ID uint64
+}
+
+// RedeliverWebhookResponse is the response object for WebhookService.Redeliver.
+type RedeliverWebhookResponse struct {
+	Delivery WebhookDelivery
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/gopheracademy/manager/log"
+	"github.com/gopheracademy/manager/mailer"
+	"github.com/gopheracademy/manager/ticketing"
+)
+
+// dunner periodically chases the receivables of ticketing, reminding payers through the
+// ticket mails and suspending the claims of overdue payments.
+type dunner struct {
+	mails    *ticketMails
+	policy   ticketing.DunningPolicy
+	interval time.Duration
+	logger   log.Factory
+
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+func newDunner(mails *ticketMails, cfg DunningConfig, logger log.Factory) *dunner {
+	interval := time.Duration(cfg.IntervalMinutes) * time.Minute
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	return &dunner{
+		mails: mails,
+		policy: ticketing.DunningPolicy{
+			ReminderDays: cfg.ReminderDays,
+			Suspend:      cfg.SuspendClaims,
+			CutoffDays:   cfg.CutoffDays,
+		},
+		interval: interval,
+		logger:   logger,
+		stop:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+}
+
+// Start chases the receivables now and then every interval until Stop is called.
+func (d *dunner) Start() {
+	go func() {
+		defer close(d.stopped)
+		ticker := time.NewTicker(d.interval)
+		defer ticker.Stop()
+		for {
+			d.run()
+			select {
+			case <-d.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop stops chasing, waiting for the run in progress.
+func (d *dunner) Stop() {
+	close(d.stop)
+	<-d.stopped
+}
+
+func (d *dunner) run() {
+	ctx, cancel := context.WithTimeout(context.Background(), d.interval)
+	defer cancel()
+	run, err := ticketing.DunReceivables(ctx, d.mails.tickets, d.policy, time.Now(), d.mails.remind)
+	if err != nil {
+		d.logger.Bg().Error("dunning receivables", zap.Error(err))
+	}
+	if run != nil && (run.Reminded != 0 || run.Suspended != 0) {
+		d.logger.Bg().Info("dunned receivables", zap.Int("receivables", run.Receivables),
+			zap.Int("reminded", run.Reminded), zap.Int("suspended", run.Suspended))
+	}
+}
+
+// remind queues the reminder to the payer, once per payment and step and once more when
+// its claims are suspended.
+func (t *ticketMails) remind(ctx context.Context, r ticketing.Reminder) error {
+	// payments made before their payer was recorded have nobody to remind.
+	if r.Payment.AttendeeID == 0 || r.EventID == 0 {
+		return nil
+	}
+	attendee, err := t.tickets.ReadAttendeeByID(ctx, r.Payment.AttendeeID)
+	if err != nil {
+		return fmt.Errorf("reading attendee %d: %w", r.Payment.AttendeeID, err)
+	}
+	if attendee == nil {
+		return fmt.Errorf("attendee %d of payment %d not found", r.Payment.AttendeeID, r.Payment.ID)
+	}
+	data := mailer.PaymentReminderData{
+		PaymentID:   r.Payment.ID,
+		Outstanding: r.Outstanding,
+		Days:        r.AgeDays,
+		Suspended:   r.Suspended,
+	}
+	for _, c := range r.Payment.ClaimsPayed {
+		if c.EventSlot != nil && c.EventSlot.Event != nil {
+			data.Event = c.EventSlot.Event.Name
+			if !r.Cutoff.IsZero() {
+				data.Cutoff = day(r.Cutoff, c.EventSlot.Event.TimeZone)
+			}
+			break
+		}
+	}
+	branding, err := t.brandingOf(ctx, r.EventID)
+	if err != nil {
+		return err
+	}
+	m, err := mailer.PaymentReminder.Render(attendee.Email, branding, data)
+	if err != nil {
+		return err
+	}
+	key := fmt.Sprintf("reminder:%d:%d", r.Payment.ID, r.Step)
+	if r.Suspended {
+		key = fmt.Sprintf("suspended:%d", r.Payment.ID)
+	}
+	return t.queue.Enqueue(ctx, key, m)
+}
+
+// day formats the date of t in the time zone of the event, in UTC if it is unknown.
+func day(t time.Time, zone string) string {
+	loc, err := time.LoadLocation(zone)
+	if err != nil || zone == "" {
+		loc = time.UTC
+	}
+	return t.In(loc).Format("Mon Jan 2 2006")
+}
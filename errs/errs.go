@@ -0,0 +1,135 @@
+// Package errs classifies the errors returned by our services so they can be reported
+// to clients with a meaningful status instead of all looking like a server failure.
+package errs
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Kind is the class of an error, it is sent to clients as the error code.
+type Kind string
+
+const (
+	// Internal is anything we did not classify, its details are not shown to clients.
+	Internal Kind = "internal"
+	// NotFound means the entity the request refers to does not exist.
+	NotFound Kind = "not_found"
+	// InvalidArgument means the request is malformed or fails validation.
+	InvalidArgument Kind = "invalid_argument"
+	// Conflict means the request clashes with the current state (ie a duplicate).
+	Conflict Kind = "conflict"
+	// Unauthenticated means the request requires a logged in user.
+	Unauthenticated Kind = "unauthenticated"
+	// PermissionDenied means the user can not perform the request.
+	PermissionDenied Kind = "permission_denied"
+	// Unavailable means a dependency is down, the request can be retried later.
+	Unavailable Kind = "unavailable"
+)
+
+// Kinds lists every kind of error.
+var Kinds = []Kind{Internal, NotFound, InvalidArgument, Conflict, Unauthenticated, PermissionDenied, Unavailable}
+
+// HTTPStatus returns the status code to answer with for errors of this kind.
+func (k Kind) HTTPStatus() int {
+	switch k {
+	case NotFound:
+		return http.StatusNotFound
+	case InvalidArgument:
+		return http.StatusBadRequest
+	case Conflict:
+		return http.StatusConflict
+	case Unauthenticated:
+		return http.StatusUnauthorized
+	case PermissionDenied:
+		return http.StatusForbidden
+	case Unavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// FieldViolation describes why a field of a request is invalid.
+type FieldViolation struct {
+	Field       string `json:"field"`
+	Description string `json:"description"`
+}
+
+// Error is an error with a Kind, its Message is meant for clients while the wrapped Err,
+// if any, is for logs.
+type Error struct {
+	Kind    Kind
+	Message string
+	Fields  []FieldViolation
+	Err     error
+}
+
+func (e *Error) Error() string {
+	msg := e.Message
+	if len(e.Fields) != 0 {
+		violations := make([]string, len(e.Fields))
+		for i, f := range e.Fields {
+			violations[i] = f.Field + " " + f.Description
+		}
+		msg += " (" + strings.Join(violations, ", ") + ")"
+	}
+	if e.Err != nil {
+		return msg + ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// Unwrap returns the wrapped error, if any.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// New returns an Error of the kind with a formatted message.
+func New(kind Kind, format string, args ...interface{}) *Error {
+	return &Error{Kind: kind, Message: fmt.Sprintf(format, args...)}
+}
+
+// Wrap returns an Error of the kind with a formatted message wrapping err.
+func Wrap(kind Kind, err error, format string, args ...interface{}) *Error {
+	return &Error{Kind: kind, Message: fmt.Sprintf(format, args...), Err: err}
+}
+
+// Invalid returns an InvalidArgument error listing the fields violations.
+func Invalid(violations ...FieldViolation) *Error {
+	return &Error{Kind: InvalidArgument, Message: "invalid request", Fields: violations}
+}
+
+// KindOf returns the kind of the first Error in the chain of err, or Internal if there
+// is none.
+func KindOf(err error) Kind {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+	return Internal
+}
+
+// Is returns true if err is of the passed kind.
+func Is(err error, kind Kind) bool {
+	return err != nil && KindOf(err) == kind
+}
+
+// Response is the body of a response to a failed request.
+type Response struct {
+	Error  string           `json:"error"`
+	Code   Kind             `json:"code"`
+	Fields []FieldViolation `json:"fields,omitempty"`
+}
+
+// ResponseFor returns the HTTP status and body to answer with for err, internal errors
+// get a generic message so we do not leak details.
+func ResponseFor(err error) (int, Response) {
+	var e *Error
+	if !errors.As(err, &e) || e.Kind == Internal || e.Kind == "" {
+		return http.StatusInternalServerError, Response{Error: "internal error", Code: Internal}
+	}
+	return e.Kind.HTTPStatus(), Response{Error: e.Message, Code: e.Kind, Fields: e.Fields}
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ShiftLeftSecurity/gaum/db/connection"
+	"github.com/gopheracademy/manager/dataexport"
+	"github.com/gopheracademy/manager/def"
+	"github.com/gopheracademy/manager/store"
+	"github.com/gopheracademy/manager/ticketing"
+)
+
+const exportUsage = `usage: manager export [flags]
+
+Writes the conferences in the configured database, with their events, sponsors and
+sponsor contacts, as JSON.
+
+With -event writes instead the -dataset of the event, as CSV, JSON Lines or Parquet:
+attendees have a row per claim of the event they hold, with its slot; payments a row
+per instrument of those for claims of the event, amounts in cents. Columns are picked,
+and ordered, with -columns and rows kept with -filter column=value, which can be
+repeated; the rows are read and written a page at a time.
+
+Columns of attendees: attendee_id, email, coc_accepted, claim_id, ticket_id, slot_id,
+slot_name, redeemed, suspended.
+Columns of payments: payment_id, attendee_id, invoice, created_at, total_due, claim_ids,
+instrument, instrument_id, reference, amount.
+
+`
+
+// filterFlags collects the filters passed with -filter.
+type filterFlags []dataexport.Filter
+
+func (f *filterFlags) String() string {
+	filters := make([]string, len(*f))
+	for i, filter := range *f {
+		filters[i] = filter.Column + "=" + filter.Value
+	}
+	return strings.Join(filters, " ")
+}
+
+func (f *filterFlags) Set(s string) error {
+	filter, err := dataexport.ParseFilter(s)
+	if err != nil {
+		return err
+	}
+	*f = append(*f, filter)
+	return nil
+}
+
+// export implements the export subcommand and returns the exit code.
+func export(args []string) int {
+	fs, config := commandFlags("export", exportUsage)
+	output := fs.String("o", "", "file to write to instead of stdout")
+	event := fs.Uint("event", 0, "ID of the event to export the -dataset of")
+	dataset := fs.String("dataset", string(dataexport.Attendees), "what to export of the event, attendees or payments")
+	format := fs.String("format", string(dataexport.FormatCSV), "format of the export of the event, csv, jsonl or parquet")
+	columns := fs.String("columns", "", "columns of the dataset to write, comma separated, every column if empty")
+	var filters filterFlags
+	fs.Var(&filters, "filter", "column=value the rows written must match, can be repeated")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return 2
+	}
+	request := dataexport.Request{
+		Dataset: dataexport.Dataset(*dataset),
+		Format:  dataexport.Format(*format),
+		Filters: filters,
+	}
+	if *columns != "" {
+		request.Columns = strings.Split(*columns, ",")
+	}
+	if *event != 0 {
+		if err := request.Validate(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+	}
+	cfg, err := config()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	db, err := openDatabase(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	// the conferences are read whole before anything is written, the datasets of
+	// events are streamed.
+	var conferences []def.Conference
+	if *event == 0 {
+		if conferences, err = exportConferences(context.Background(), db); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		defer f.Close()
+		w = f
+	}
+	if *event != 0 {
+		rows, err := dataexport.Export(context.Background(), ticketing.NewSQLStorageFromConnection(db), uint32(*event), request, w)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		fmt.Fprintf(os.Stderr, "exported %d rows\n", rows)
+		return 0
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(conferences); err != nil {
+		fmt.Fprintln(os.Stderr, fmt.Errorf("writing export: %w", err))
+		return 1
+	}
+	return 0
+}
+
+// exportConferences reads every conference along with what they hold.
+func exportConferences(ctx context.Context, db connection.DB) ([]def.Conference, error) {
+	var (
+		conferenceRepo = store.NewConferenceRepository(db)
+		eventRepo      = store.NewEventRepository(db)
+		sponsorRepo    = store.NewSponsorRepository(db)
+		contactRepo    = store.NewSponsorContactRepository(db)
+	)
+	stored, err := conferenceRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	conferences := make([]def.Conference, 0, len(stored))
+	for _, c := range stored {
+		conference := def.Conference{ID: c.ID, Name: c.Name, Slug: c.Slug}
+		events, err := eventRepo.ListByConference(ctx, c.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range events {
+			event := def.Event{
+				ID:                e.ID,
+				Name:              e.Name,
+				Slug:              e.Slug,
+				StartDate:         e.StartDate,
+				EndDate:           e.EndDate,
+				Location:          e.Location,
+				TimeZone:          e.TimeZone,
+				Live:              e.Live,
+				SponsorshipLevels: e.SponsorshipLevels,
+			}
+			sponsors, err := sponsorRepo.ListByEvent(ctx, e.ID)
+			if err != nil {
+				return nil, err
+			}
+			for _, s := range sponsors {
+				sponsor := def.Sponsor{
+					ID:      s.ID,
+					Name:    s.Name,
+					Level:   s.Level,
+					Website: s.Website,
+					Logo:    s.Logo,
+					Profile: s.Profile,
+				}
+				contacts, err := contactRepo.ListBySponsor(ctx, s.ID)
+				if err != nil {
+					return nil, err
+				}
+				for _, sc := range contacts {
+					sponsor.Contacts = append(sponsor.Contacts, def.SponsorContact{
+						ID:    sc.ID,
+						Role:  sc.Role,
+						Name:  sc.Name,
+						Email: sc.Email,
+						Phone: sc.Phone,
+					})
+				}
+				event.Sponsors = append(event.Sponsors, sponsor)
+			}
+			conference.Events = append(conference.Events, event)
+		}
+		conferences = append(conferences, conference)
+	}
+	return conferences, nil
+}
@@ -12,3 +12,30 @@ oto -template templates/client.js.plush \
 	-pkg main \
 	./def
 echo "generated client.gen.js"
+
+oto -template templates/storage.go.plush \
+	-out store/store.gen.go \
+	-pkg store \
+	./def
+gofmt -w store/store.gen.go
+echo "generated store.gen.go"
+
+oto -template templates/schema.sql.plush \
+	-out store/schema.gen.sql \
+	-pkg store \
+	./def
+echo "generated schema.gen.sql"
+
+oto -template templates/openapi.go.plush \
+	-out apidocs/openapi.gen.go \
+	-pkg apidocs \
+	./def
+gofmt -w apidocs/openapi.gen.go
+echo "generated openapi.gen.go"
+
+oto -template templates/client.go.plush \
+	-out client/client.gen.go \
+	-pkg client \
+	./def
+gofmt -w client/client.gen.go
+echo "generated client.gen.go"
@@ -4,6 +4,7 @@ go 1.15
 
 require (
 	github.com/ShiftLeftSecurity/gaum v1.0.10
+	github.com/getkin/kin-openapi v0.120.0
 	github.com/gorilla/mux v1.8.0
 	github.com/jackc/pgerrcode v0.0.0-20201024163028-a0d42d470451
 	github.com/jackc/pgx v3.6.2+incompatible
@@ -17,8 +18,11 @@ require (
 	github.com/prometheus/client_golang v1.5.1
 	github.com/satori/go.uuid v1.2.0
 	github.com/shopspring/decimal v1.2.0
+	github.com/skip2/go-qrcode v0.0.0-20200617195104-da1b6568686e
 	github.com/uber/jaeger-client-go v2.23.1+incompatible
 	github.com/uber/jaeger-lib v2.4.0+incompatible
+	github.com/xitongsys/parquet-go v1.6.2
+	github.com/xitongsys/parquet-go-source v0.0.0-20200817004010-026bad9b25d0
 	go.uber.org/zap v1.16.0
 	google.golang.org/grpc v1.33.2
 	gorm.io/gorm v1.20.6
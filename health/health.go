@@ -0,0 +1,115 @@
+// Package health serves the probes orchestrators use to decide whether to restart the
+// server (liveness) and whether to send it traffic (readiness).
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/gopheracademy/manager/log"
+)
+
+const (
+	// LivePath answers 200 as long as the process serves HTTP.
+	LivePath = "/livez"
+	// ReadyPath answers 200 when every check passes and the server is not shutting down,
+	// 503 otherwise.
+	ReadyPath = "/readyz"
+)
+
+// checkTimeout bounds each check so a hung dependency fails the probe instead of
+// timing it out.
+const checkTimeout = 2 * time.Second
+
+// Check returns an error if a dependency the server needs is not usable.
+type Check func(ctx context.Context) error
+
+// Report is the body of the probes.
+type Report struct {
+	Status string `json:"status"`
+	// Checks holds ok or the failure of each check, readiness only.
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// Handler serves LivePath and ReadyPath.
+type Handler struct {
+	lock     sync.RWMutex
+	checks   map[string]Check
+	draining int32
+	logger   log.Factory
+}
+
+// NewHandler returns a Handler without checks.
+func NewHandler(logger log.Factory) *Handler {
+	return &Handler{checks: map[string]Check{}, logger: logger}
+}
+
+// AddCheck makes readiness depend on the named check.
+func (h *Handler) AddCheck(name string, check Check) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.checks[name] = check
+}
+
+// Drain makes readiness fail from now on so traffic is sent elsewhere while the
+// server shuts down.
+func (h *Handler) Drain() {
+	atomic.StoreInt32(&h.draining, 1)
+}
+
+// ServeHTTP implements http.Handler
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case LivePath:
+		h.write(w, http.StatusOK, Report{Status: "ok"})
+	case ReadyPath:
+		status, report := h.ready(r.Context())
+		h.write(w, status, report)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (h *Handler) ready(ctx context.Context) (int, Report) {
+	if atomic.LoadInt32(&h.draining) == 1 {
+		return http.StatusServiceUnavailable, Report{Status: "shutting down"}
+	}
+	h.lock.RLock()
+	names := make([]string, 0, len(h.checks))
+	checks := make(map[string]Check, len(h.checks))
+	for name, check := range h.checks {
+		names = append(names, name)
+		checks[name] = check
+	}
+	h.lock.RUnlock()
+	sort.Strings(names)
+
+	status, report := http.StatusOK, Report{Status: "ok", Checks: map[string]string{}}
+	for _, name := range names {
+		checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+		err := checks[name](checkCtx)
+		cancel()
+		if err != nil {
+			h.logger.Bg().Error("readiness check failed", zap.String("check", name), zap.Error(err))
+			status, report.Status = http.StatusServiceUnavailable, "unavailable"
+			report.Checks[name] = err.Error()
+			continue
+		}
+		report.Checks[name] = "ok"
+	}
+	return status, report
+}
+
+func (h *Handler) write(w http.ResponseWriter, status int, report Report) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gopheracademy/manager/ticketing"
+)
+
+const ledgerUsage = `usage: manager ledger [flags]
+
+Prints the trial balance of the ticketing journal, the revenue of an event with -event
+or the receivables aged by how long they are owed with -aging, and exits with 3 if the
+journal is not balanced or the event's revenue does not reconcile with its payments.
+Amounts are in cents.
+
+`
+
+// ledger implements the ledger subcommand and returns the exit code.
+func ledger(args []string) int {
+	fs, config := commandFlags("ledger", ledgerUsage)
+	event := fs.Uint("event", 0, "ID of the event to report the revenue of")
+	aging := fs.Bool("aging", false, "report the payments whose credit notes are not covered, by age")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return 2
+	}
+	cfg, err := config()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	db, err := openDatabase(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	ctx := context.Background()
+	tickets := ticketing.NewSQLStorageFromConnection(db)
+	ok := true
+	switch {
+	case *aging:
+		report, err := ticketing.AgeReceivables(ctx, tickets, time.Now())
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		printAging(os.Stdout, report)
+	case *event != 0:
+		report, err := ticketing.EventRevenue(ctx, tickets, uint32(*event))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		ok = report.Reconciled()
+		printRevenue(os.Stdout, report)
+	default:
+		report, err := ticketing.TrialBalance(ctx, tickets)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		ok = report.Balanced()
+		printTrialBalance(os.Stdout, report)
+	}
+	if !ok {
+		return 3
+	}
+	return 0
+}
+
+func printTrialBalance(w io.Writer, report *ticketing.TrialBalanceReport) {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "account\tdebit\tcredit\tbalance\t")
+	for _, a := range report.Accounts {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t\n", a.Account, a.Debit, a.Credit, a.Balance())
+	}
+	fmt.Fprintf(tw, "total\t%d\t%d\t\t\n", report.Debits, report.Credits)
+	tw.Flush()
+	if !report.Balanced() {
+		fmt.Fprintf(w, "not balanced, debits and credits differ by %d\n", report.Debits-report.Credits)
+	}
+}
+
+func printRevenue(w io.Writer, report *ticketing.RevenueReport) {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+	for _, row := range []struct {
+		name   string
+		amount int64
+	}{
+		{"sold", report.Sold},
+		{"discounts", report.Discounts},
+		{"refunds", report.Refunds},
+		{"net", report.Net()},
+		{"fees", report.Fees},
+		{"collected", report.Collected},
+		{"outstanding", report.Outstanding},
+		{"billed", report.Billed},
+		{"owed", report.Owed},
+	} {
+		fmt.Fprintf(tw, "%s\t%d\t\n", row.name, row.amount)
+	}
+	tw.Flush()
+	if !report.Reconciled() {
+		fmt.Fprintf(w, "not reconciled, sold differs from billed by %d and outstanding from owed by %d\n",
+			report.Sold-report.Billed, report.Outstanding-report.Owed)
+	}
+}
+
+func printAging(w io.Writer, report *ticketing.AgingReport) {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "payment\tevent\tattendee\tinvoice\tdays\tbucket\toutstanding\t")
+	for _, r := range report.Receivables {
+		fmt.Fprintf(tw, "%d\t%d\t%d\t%s\t%d\t%s\t%d\t\n", r.Payment.ID, r.EventID, r.Payment.AttendeeID,
+			r.Payment.Invoice, r.AgeDays, ticketing.AgingBuckets[r.Bucket].Name, r.Outstanding)
+	}
+	tw.Flush()
+	fmt.Fprintln(w)
+	tw = tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+	for i, b := range ticketing.AgingBuckets {
+		fmt.Fprintf(tw, "%s\t%d\t\n", b.Name, report.Totals[i])
+	}
+	fmt.Fprintf(tw, "total\t%d\t\n", report.Total)
+	tw.Flush()
+}
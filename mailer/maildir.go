@@ -0,0 +1,55 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// MaildirSender delivers messages into a Maildir, as a local mail server would, so they
+// can be read with any Maildir capable client (ie mutt -f) during development and checked
+// by tests.
+type MaildirSender struct {
+	Dir string
+	// From is the address of the sender of the messages without their own, defaults to
+	// showrunner@localhost.
+	From string
+	seq  uint64
+}
+
+var _ Sender = &MaildirSender{}
+
+// Send implements Sender
+func (s *MaildirSender) Send(ctx context.Context, m Message) error {
+	for _, sub := range []string{"tmp", "new", "cur"} {
+		if err := os.MkdirAll(filepath.Join(s.Dir, sub), 0o755); err != nil {
+			return fmt.Errorf("creating maildir: %w", err)
+		}
+	}
+	from := s.From
+	if from == "" {
+		from = "showrunner@localhost"
+	}
+	raw, err := Encode(from, m)
+	if err != nil {
+		return fmt.Errorf("encoding message: %w", err)
+	}
+	host, err := os.Hostname()
+	if err != nil {
+		host = "localhost"
+	}
+	// messages are written to tmp and moved to new once complete, as the format wants.
+	name := fmt.Sprintf("%d.P%dQ%d.%s", time.Now().Unix(), os.Getpid(), atomic.AddUint64(&s.seq, 1), host)
+	tmp := filepath.Join(s.Dir, "tmp", name)
+	if err := ioutil.WriteFile(tmp, raw, 0o644); err != nil {
+		return fmt.Errorf("writing message: %w", err)
+	}
+	if err := os.Rename(tmp, filepath.Join(s.Dir, "new", name)); err != nil {
+		return fmt.Errorf("moving message in place: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,229 @@
+package mailer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ShiftLeftSecurity/gaum/db/chain"
+	"github.com/ShiftLeftSecurity/gaum/db/connection"
+	"go.uber.org/zap"
+
+	"github.com/gopheracademy/manager/log"
+	"github.com/gopheracademy/manager/poller"
+	"github.com/gopheracademy/manager/pool"
+)
+
+const (
+	tableEmail         = "email"
+	emailKeyConstraint = "email_key"
+)
+
+// The status of a queued Email.
+const (
+	StatusQueued = "queued"
+	StatusSent   = "sent"
+	// StatusFailed emails were given up on after every attempt failed.
+	StatusFailed = "failed"
+)
+
+// Email is a message in the send log, from when it is queued until it is sent or given
+// up on.
+type Email struct {
+	ID uint64 `gaum:"field_name:id"`
+	// Key identifies what the email is about (ie "ticket:42"), an email is only queued
+	// once per key.
+	Key       string `gaum:"field_name:key"`
+	Recipient string `gaum:"field_name:recipient"`
+	Subject   string `gaum:"field_name:subject"`
+	// Message is the JSON of the Message to send.
+	Message  string `gaum:"field_name:message"`
+	Status   string `gaum:"field_name:status"`
+	Attempts int    `gaum:"field_name:attempts"`
+	// NextAttemptAt, CreatedAt and SentAt are Unix timestamps, SentAt is 0 until sent.
+	NextAttemptAt int64  `gaum:"field_name:next_attempt_at"`
+	LastError     string `gaum:"field_name:last_error"`
+	CreatedAt     int64  `gaum:"field_name:created_at"`
+	SentAt        int64  `gaum:"field_name:sent_at"`
+}
+
+// Log keeps the emails of a Queue from when they are queued until they are sent or
+// given up on.
+type Log interface {
+	// Enqueue records the message to send now, unless one was already queued with the key.
+	Enqueue(ctx context.Context, key string, m Message, now time.Time) error
+	// Lease returns up to limit queued emails due at now and makes them due again at
+	// until.
+	Lease(ctx context.Context, now, until time.Time, limit int) ([]Email, error)
+	// Sent records that the email was sent.
+	Sent(ctx context.Context, id uint64, at time.Time) error
+	// Failed records a failed attempt, with the status of the email after it and when to
+	// retry if it is still queued.
+	Failed(ctx context.Context, id uint64, status string, attempts int, next time.Time, reason string) error
+}
+
+var _ Log = &SQLLog{}
+
+// SQLLog keeps the send log in a postgres-like db.
+type SQLLog struct {
+	conn connection.DB
+}
+
+// NewSQLLog returns a SQLLog using the passed connection.
+func NewSQLLog(conn connection.DB) *SQLLog {
+	return &SQLLog{conn: conn}
+}
+
+// Enqueue records the message to send now, unless one was already queued with the key.
+func (l *SQLLog) Enqueue(ctx context.Context, key string, m Message, now time.Time) error {
+	raw, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("encoding email %s: %w", key, err)
+	}
+	err = chain.New(l.conn).Insert(map[string]interface{}{
+		"key":             key,
+		"recipient":       m.To,
+		"subject":         m.Subject,
+		"message":         string(raw),
+		"status":          StatusQueued,
+		"next_attempt_at": now.Unix(),
+		"created_at":      now.Unix(),
+	}).Table(tableEmail).
+		OnConflict(func(c *chain.OnConflict) {
+			c.OnConstraint(emailKeyConstraint).DoNothing()
+		}).Exec()
+	if err != nil {
+		return fmt.Errorf("queuing email %s: %w", key, err)
+	}
+	return nil
+}
+
+// Lease returns up to limit queued emails due at now and makes them due again at until,
+// so that several queues do not send them at once.
+func (l *SQLLog) Lease(ctx context.Context, now, until time.Time, limit int) ([]Email, error) {
+	results := []Email{}
+	err := chain.New(l.conn).UpdateMap(map[string]interface{}{"next_attempt_at": until.Unix()}).
+		Table(tableEmail).
+		AndWhere("id IN (SELECT id FROM "+tableEmail+
+			" WHERE status = ? AND next_attempt_at <= ? ORDER BY id LIMIT ? FOR UPDATE SKIP LOCKED)",
+			StatusQueued, now.Unix(), limit).
+		Returning("*").Fetch(&results)
+	if err != nil {
+		return nil, fmt.Errorf("leasing emails: %w", err)
+	}
+	return results, nil
+}
+
+// Sent records that the email was sent.
+func (l *SQLLog) Sent(ctx context.Context, id uint64, at time.Time) error {
+	err := chain.New(l.conn).UpdateMap(map[string]interface{}{
+		"status":     StatusSent,
+		"sent_at":    at.Unix(),
+		"last_error": "",
+	}).Table(tableEmail).AndWhere("id = ?", id).Exec()
+	if err != nil {
+		return fmt.Errorf("marking email %d sent: %w", id, err)
+	}
+	return nil
+}
+
+// Failed records that sending the email failed, with its status after the attempt and
+// when to retry if it is still queued.
+func (l *SQLLog) Failed(ctx context.Context, id uint64, status string, attempts int, next time.Time, reason string) error {
+	err := chain.New(l.conn).UpdateMap(map[string]interface{}{
+		"status":          status,
+		"attempts":        attempts,
+		"next_attempt_at": next.Unix(),
+		"last_error":      reason,
+	}).Table(tableEmail).AndWhere("id = ?", id).Exec()
+	if err != nil {
+		return fmt.Errorf("recording failed email %d: %w", id, err)
+	}
+	return nil
+}
+
+// QueueOptions configure a Queue, the zero value of each is replaced by its default;
+// Batch, Backoff and MaxAttempts default to 20, 1m and 8 for emails.
+type QueueOptions struct {
+	poller.Options
+}
+
+// Queue sends the emails queued in the send log with a Sender, using the workers of a
+// pool and retrying those that fail.
+type Queue struct {
+	*poller.Poller
+	log    Log
+	sender Sender
+	logger log.Factory
+}
+
+// NewQueue returns a Queue sending the emails of l through sender on the workers of p,
+// call Start to begin sending and Stop, before stopping the pool, to end.
+func NewQueue(l Log, sender Sender, p *pool.Pool, opts QueueOptions, logger log.Factory) *Queue {
+	if opts.Batch == 0 {
+		opts.Batch = 20
+	}
+	if opts.Backoff == 0 {
+		opts.Backoff = time.Minute
+	}
+	if opts.MaxAttempts == 0 {
+		opts.MaxAttempts = 8
+	}
+	q := &Queue{
+		log:    l,
+		sender: sender,
+		logger: logger,
+	}
+	q.Poller = poller.New("the email queue", q.lease, p, opts.Options, logger)
+	return q
+}
+
+// Enqueue queues the message to be sent once per key, it is sent even if it fails now as
+// long as the key was recorded.
+func (q *Queue) Enqueue(ctx context.Context, key string, m Message) error {
+	return q.log.Enqueue(ctx, key, m, time.Now())
+}
+
+// lease leases the emails due and returns their sending.
+func (q *Queue) lease(ctx context.Context, now, until time.Time, limit int) ([]poller.Job, error) {
+	emails, err := q.log.Lease(ctx, now, until, limit)
+	if err != nil {
+		return nil, err
+	}
+	jobs := make([]poller.Job, len(emails))
+	for i := range emails {
+		e := emails[i]
+		jobs[i] = func(ctx context.Context) { q.send(ctx, e) }
+	}
+	return jobs, nil
+}
+
+// send sends the email and records the outcome.
+func (q *Queue) send(ctx context.Context, e Email) {
+	logger := q.logger.Bg().With(zap.Uint64("email", e.ID), zap.String("key", e.Key))
+
+	var m Message
+	err := json.Unmarshal([]byte(e.Message), &m)
+	if err != nil {
+		err = fmt.Errorf("decoding message: %w", err)
+	} else {
+		err = q.sender.Send(ctx, m)
+	}
+	if err == nil {
+		if err := q.log.Sent(ctx, e.ID, time.Now()); err != nil {
+			logger.Error("recording sent email", zap.Error(err))
+		}
+		return
+	}
+	attempts := e.Attempts + 1
+	next, retry := q.Retry(attempts)
+	status := StatusQueued
+	if !retry {
+		status = StatusFailed
+	}
+	logger.Error("sending email", zap.Int("attempts", attempts), zap.String("status", status), zap.Error(err))
+	if err := q.log.Failed(ctx, e.ID, status, attempts, next, err.Error()); err != nil {
+		logger.Error("recording failed email", zap.Error(err))
+	}
+}
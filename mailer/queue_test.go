@@ -0,0 +1,218 @@
+package mailer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/gopheracademy/manager/database"
+	mlog "github.com/gopheracademy/manager/log"
+	"github.com/gopheracademy/manager/migrations"
+	"github.com/gopheracademy/manager/poller"
+	"github.com/gopheracademy/manager/pool"
+)
+
+// memoryLog keeps the send log in memory, queuing and leasing like SQLLog.
+type memoryLog struct {
+	lock   sync.Mutex
+	emails map[uint64]*Email
+	keys   map[string]bool
+}
+
+func newMemoryLog() *memoryLog {
+	return &memoryLog{emails: map[uint64]*Email{}, keys: map[string]bool{}}
+}
+
+func (l *memoryLog) Enqueue(ctx context.Context, key string, m Message, now time.Time) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.keys[key] {
+		return nil
+	}
+	raw, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	l.keys[key] = true
+	id := uint64(len(l.emails) + 1)
+	l.emails[id] = &Email{ID: id, Key: key, Recipient: m.To, Subject: m.Subject, Message: string(raw),
+		Status: StatusQueued, NextAttemptAt: now.Unix(), CreatedAt: now.Unix()}
+	return nil
+}
+
+func (l *memoryLog) Lease(ctx context.Context, now, until time.Time, limit int) ([]Email, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	var leased []Email
+	for _, e := range l.emails {
+		if e.Status == StatusQueued && e.NextAttemptAt <= now.Unix() {
+			e.NextAttemptAt = until.Unix()
+			leased = append(leased, *e)
+		}
+	}
+	sort.Slice(leased, func(i, j int) bool { return leased[i].ID < leased[j].ID })
+	if len(leased) > limit {
+		leased = leased[:limit]
+	}
+	return leased, nil
+}
+
+func (l *memoryLog) Sent(ctx context.Context, id uint64, at time.Time) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.emails[id].Status, l.emails[id].SentAt, l.emails[id].LastError = StatusSent, at.Unix(), ""
+	return nil
+}
+
+func (l *memoryLog) Failed(ctx context.Context, id uint64, status string, attempts int, next time.Time, reason string) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	e := l.emails[id]
+	e.Status, e.Attempts, e.NextAttemptAt, e.LastError = status, attempts, next.Unix(), reason
+	return nil
+}
+
+func (l *memoryLog) email(id uint64) Email {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return *l.emails[id]
+}
+
+// due makes the email due now, as if its backoff passed.
+func (l *memoryLog) due(id uint64) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.emails[id].NextAttemptAt = time.Now().Unix()
+}
+
+// senderFunc sends messages with a func.
+type senderFunc func(ctx context.Context, m Message) error
+
+func (f senderFunc) Send(ctx context.Context, m Message) error {
+	return f(ctx, m)
+}
+
+func newTestQueue(t *testing.T, l Log, sender Sender, opts QueueOptions) *Queue {
+	t.Helper()
+	p := pool.New(2)
+	t.Cleanup(p.Stop)
+	return NewQueue(l, sender, p, opts, mlog.NewFactory(zap.NewNop()))
+}
+
+func TestQueueSends(t *testing.T) {
+	l := newMemoryLog()
+	var lock sync.Mutex
+	var sent []Message
+	q := newTestQueue(t, l, senderFunc(func(ctx context.Context, m Message) error {
+		lock.Lock()
+		defer lock.Unlock()
+		sent = append(sent, m)
+		return nil
+	}), QueueOptions{})
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if err := q.Enqueue(ctx, "ticket:1", Message{To: "ada@example.com", Subject: "Your ticket"}); err != nil {
+			t.Fatalf("Enqueue() = %v", err)
+		}
+	}
+
+	q.Poll()
+	if len(sent) != 1 || sent[0].To != "ada@example.com" || sent[0].Subject != "Your ticket" {
+		t.Fatalf("sent %+v, want the ticket once", sent)
+	}
+	if e := l.email(1); e.Status != StatusSent || e.SentAt == 0 {
+		t.Errorf("email is %+v, want it sent", e)
+	}
+	// sent emails are not leased again.
+	l.due(1)
+	q.Poll()
+	if len(sent) != 1 {
+		t.Errorf("sent email sent %d times", len(sent))
+	}
+}
+
+func TestQueueRetries(t *testing.T) {
+	l := newMemoryLog()
+	calls := 0
+	q := newTestQueue(t, l, senderFunc(func(ctx context.Context, m Message) error {
+		calls++
+		return errors.New("mailbox full")
+	}), QueueOptions{Options: poller.Options{Backoff: time.Minute, MaxAttempts: 2}})
+	if err := q.Enqueue(context.Background(), "order:1", Message{To: "ada@example.com"}); err != nil {
+		t.Fatalf("Enqueue() = %v", err)
+	}
+
+	for attempt, status := range []string{StatusQueued, StatusFailed} {
+		start := time.Now()
+		q.Poll()
+		e := l.email(1)
+		if e.Status != status || e.Attempts != attempt+1 || e.LastError != "mailbox full" {
+			t.Fatalf("after attempt %d the email is %+v, want it %s", attempt+1, e, status)
+		}
+		if status == StatusQueued && time.Unix(e.NextAttemptAt, 0).Before(start.Add(time.Minute-time.Second)) {
+			t.Errorf("attempt %d retries at %v, want a minute later", attempt+1, time.Unix(e.NextAttemptAt, 0))
+		}
+		l.due(1)
+	}
+	// failed emails are not sent again.
+	q.Poll()
+	if calls != 2 {
+		t.Errorf("failed email sent %d times, want 2", calls)
+	}
+}
+
+// TestSQLLog needs an empty postgres database, ie
+//
+//	createdb showrunner_test
+//	SHOWRUNNER_TEST_DATABASE_URL=postgres://localhost/showrunner_test go test ./mailer
+func TestSQLLog(t *testing.T) {
+	url := os.Getenv("SHOWRUNNER_TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("SHOWRUNNER_TEST_DATABASE_URL is not set")
+	}
+	db, err := database.Open(url, log.New(os.Stderr, "", log.LstdFlags))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := migrations.New(db)
+	if _, err := m.Up(); err != nil {
+		t.Fatalf("migrating: %v", err)
+	}
+	defer func() {
+		if _, err := m.Down(len(migrations.All)); err != nil {
+			t.Errorf("cleaning up: %v", err)
+		}
+		db.Close()
+	}()
+
+	ctx := context.Background()
+	l := NewSQLLog(db)
+	now := time.Now()
+	for _, subject := range []string{"Your ticket", "Your ticket again"} {
+		if err := l.Enqueue(ctx, "ticket:1", Message{To: "ada@example.com", Subject: subject}, now); err != nil {
+			t.Fatalf("Enqueue() = %v", err)
+		}
+	}
+	emails, err := l.Lease(ctx, now, now.Add(time.Minute), 10)
+	if err != nil || len(emails) != 1 || emails[0].Subject != "Your ticket" || emails[0].Status != StatusQueued {
+		t.Fatalf("Lease() = %+v, %v; want the ticket queued once", emails, err)
+	}
+	if leased, err := l.Lease(ctx, now, now.Add(time.Minute), 10); err != nil || len(leased) != 0 {
+		t.Fatalf("Lease() = %+v, %v; want nothing, the ticket is leased", leased, err)
+	}
+	if err := l.Failed(ctx, emails[0].ID, StatusFailed, 8, now, "mailbox full"); err != nil {
+		t.Fatalf("Failed() = %v", err)
+	}
+	later := now.Add(2 * time.Minute)
+	if leased, err := l.Lease(ctx, later, later.Add(time.Minute), 10); err != nil || len(leased) != 0 {
+		t.Errorf("Lease() = %+v, %v; want nothing, the ticket failed", leased, err)
+	}
+}
@@ -0,0 +1,183 @@
+// Package mailer renders and sends emails: Senders deliver a Message through SMTP, into a
+// Maildir or the log, Templates render the emails sent to buyers and attendees, and a
+// Queue sends them in the background, retrying failures and logging every email sent.
+package mailer
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/gopheracademy/manager/log"
+)
+
+// Message is an email ready to be sent.
+type Message struct {
+	// From overrides the address of the sender, ie to use the name of a conference.
+	From    string
+	To      string
+	Subject string
+	// Text is the plain text body, all messages must have one.
+	Text string
+	// HTML is the optional HTML alternative of the body.
+	HTML string
+	// Attachments are sent along, those with a ContentID can be shown by the HTML body
+	// as "cid:<ContentID>".
+	Attachments []Attachment
+}
+
+// Attachment is a file sent with a message.
+type Attachment struct {
+	Filename    string
+	ContentType string
+	ContentID   string
+	Data        []byte
+}
+
+// Sender delivers messages.
+type Sender interface {
+	Send(ctx context.Context, m Message) error
+}
+
+// LogSender logs messages instead of sending them, useful for local development.
+type LogSender struct {
+	Logger log.Factory
+}
+
+var _ Sender = &LogSender{}
+
+// Send implements Sender
+func (s *LogSender) Send(ctx context.Context, m Message) error {
+	s.Logger.For(ctx).Info("email not sent, logging it instead",
+		zap.String("to", m.To),
+		zap.String("subject", m.Subject),
+		zap.String("text", m.Text))
+	return nil
+}
+
+// Encode renders the message in RFC 5322 format, as multipart/alternative if it has an
+// HTML body and wrapped in multipart/related if it has attachments. from is used unless
+// the message has its own.
+func Encode(from string, m Message) ([]byte, error) {
+	if m.From != "" {
+		from = m.From
+	}
+	var b strings.Builder
+	header := func(k, v string) {
+		fmt.Fprintf(&b, "%s: %s\r\n", k, v)
+	}
+	header("From", from)
+	header("To", m.To)
+	header("Subject", mime.QEncoding.Encode("utf-8", m.Subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+
+	contentType, body, err := encodeBody(m)
+	if err != nil {
+		return nil, err
+	}
+	if len(m.Attachments) != 0 {
+		contentType, body, err = encodeRelated(contentType, body, m.Attachments)
+		if err != nil {
+			return nil, err
+		}
+	}
+	header("Content-Type", contentType)
+	if !strings.HasPrefix(contentType, "multipart/") {
+		header("Content-Transfer-Encoding", "8bit")
+	}
+	b.WriteString("\r\n")
+	b.WriteString(body)
+	return []byte(b.String()), nil
+}
+
+// encodeBody returns the content type and the encoded text and HTML bodies of m.
+func encodeBody(m Message) (string, string, error) {
+	if m.HTML == "" {
+		return "text/plain; charset=utf-8", m.Text, nil
+	}
+	var body strings.Builder
+	mw := multipart.NewWriter(&body)
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", m.Text},
+		{"text/html; charset=utf-8", m.HTML},
+	} {
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"8bit"},
+		})
+		if err != nil {
+			return "", "", err
+		}
+		if _, err := w.Write([]byte(part.content)); err != nil {
+			return "", "", err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return "", "", err
+	}
+	return "multipart/alternative; boundary=" + mw.Boundary(), body.String(), nil
+}
+
+// encodeRelated wraps a body of the content type with the attachments.
+func encodeRelated(contentType, content string, attachments []Attachment) (string, string, error) {
+	var body strings.Builder
+	mw := multipart.NewWriter(&body)
+	header := textproto.MIMEHeader{"Content-Type": {contentType}}
+	if !strings.HasPrefix(contentType, "multipart/") {
+		header.Set("Content-Transfer-Encoding", "8bit")
+	}
+	w, err := mw.CreatePart(header)
+	if err != nil {
+		return "", "", err
+	}
+	if _, err := w.Write([]byte(content)); err != nil {
+		return "", "", err
+	}
+	for _, a := range attachments {
+		disposition := "attachment"
+		if a.ContentID != "" {
+			disposition = "inline"
+		}
+		header := textproto.MIMEHeader{
+			"Content-Type":              {a.ContentType},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {mime.FormatMediaType(disposition, map[string]string{"filename": a.Filename})},
+		}
+		if a.ContentID != "" {
+			header.Set("Content-ID", "<"+a.ContentID+">")
+		}
+		w, err := mw.CreatePart(header)
+		if err != nil {
+			return "", "", err
+		}
+		if err := writeBase64(w, a.Data); err != nil {
+			return "", "", err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return "", "", err
+	}
+	return "multipart/related; boundary=" + mw.Boundary(), body.String(), nil
+}
+
+// writeBase64 writes data base64 encoded in lines of 76 characters, as RFC 2045 wants.
+func writeBase64(w io.Writer, data []byte) error {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		if _, err := io.WriteString(w, encoded[:76]+"\r\n"); err != nil {
+			return err
+		}
+		encoded = encoded[76:]
+	}
+	_, err := io.WriteString(w, encoded+"\r\n")
+	return err
+}
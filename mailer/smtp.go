@@ -0,0 +1,56 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+)
+
+// SMTPSender sends messages through an SMTP server, upgrading the connection with
+// STARTTLS when the server offers it.
+type SMTPSender struct {
+	// Addr is the host:port of the server.
+	Addr string
+	// Username and Password authenticate with PLAIN auth when Username is set, net/smtp
+	// refuses to send them over a connection that is not encrypted unless to localhost.
+	Username string
+	Password string
+	// From is the address of the sender of the messages without their own.
+	From string
+}
+
+var _ Sender = &SMTPSender{}
+
+// Send implements Sender
+func (s *SMTPSender) Send(ctx context.Context, m Message) error {
+	raw, err := Encode(s.From, m)
+	if err != nil {
+		return fmt.Errorf("encoding message: %w", err)
+	}
+	from := s.From
+	if m.From != "" {
+		from = m.From
+	}
+	sender, err := mail.ParseAddress(from)
+	if err != nil {
+		return fmt.Errorf("parsing sender %q: %w", from, err)
+	}
+	recipient, err := mail.ParseAddress(m.To)
+	if err != nil {
+		return fmt.Errorf("parsing recipient %q: %w", m.To, err)
+	}
+	var auth smtp.Auth
+	if s.Username != "" {
+		host, _, err := net.SplitHostPort(s.Addr)
+		if err != nil {
+			return fmt.Errorf("parsing SMTP address %q: %w", s.Addr, err)
+		}
+		auth = smtp.PlainAuth("", s.Username, s.Password, host)
+	}
+	if err := smtp.SendMail(s.Addr, auth, sender.Address, []string{recipient.Address}, raw); err != nil {
+		return fmt.Errorf("sending message to %s: %w", recipient.Address, err)
+	}
+	return nil
+}
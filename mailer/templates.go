@@ -0,0 +1,292 @@
+package mailer
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/skip2/go-qrcode"
+)
+
+// Branding is how the emails of a conference look and who they come from.
+type Branding struct {
+	// Name is the name of the conference, it is the only one required.
+	Name string
+	// From is the sender of the emails, ie "GopherCon <tickets@gophercon.com>", the
+	// default of the Sender is used if empty.
+	From string
+	// LogoURL is shown atop the HTML emails.
+	LogoURL string
+	// Color is the CSS color of the header of HTML emails, defaults to the gopher blue.
+	Color   string
+	Website string
+	// CoCURL is the code of conduct attendees accept.
+	CoCURL string
+}
+
+func (b Branding) color() string {
+	if b.Color == "" {
+		return "#00add8"
+	}
+	return b.Color
+}
+
+// Item is a line of an order or refund, Cost is in cents.
+type Item struct {
+	Name string
+	Cost int64
+}
+
+// OrderData is rendered by OrderConfirmation.
+type OrderData struct {
+	Event     string
+	PaymentID uint64
+	Items     []Item
+	Total     int64
+	// Fulfilled is false while part of the order is owed, ie paid by invoice.
+	Fulfilled bool
+}
+
+// TicketData is rendered by Ticket, the QR code encoding TicketID is attached to the
+// message.
+type TicketData struct {
+	Event    string
+	Slot     string
+	TicketID string
+	Email    string
+	// When and Where describe the slot, ie "Aug 3 2021, 9:00 MDT" and "Denver, CO".
+	When  string
+	Where string
+}
+
+// TransferData is rendered by TransferNotice, for the attendee receiving the tickets.
+type TransferData struct {
+	Event string
+	From  string
+	Slots []string
+}
+
+// RefundData is rendered by RefundReceipt.
+type RefundData struct {
+	Event    string
+	RefundID uint64
+	Items    []Item
+	Total    int64
+	Reason   string
+}
+
+// PaymentReminderData is rendered by PaymentReminder.
+type PaymentReminderData struct {
+	Event     string
+	PaymentID uint64
+	// Outstanding is what is left to pay, in cents.
+	Outstanding int64
+	// Days is how long ago the order was made.
+	Days int
+	// Cutoff is when the tickets are suspended unless paid, ie "Jul 27 2021", empty if
+	// they are not; Suspended is true once it passed.
+	Cutoff    string
+	Suspended bool
+}
+
+// Template renders one kind of email, in text and HTML, from a branding and the data of
+// the kind.
+type Template struct {
+	name    string
+	subject *texttemplate.Template
+	text    *texttemplate.Template
+	html    *htmltemplate.Template
+	// attach returns the attachments of the message, if any.
+	attach func(data interface{}) ([]Attachment, error)
+}
+
+// The emails sent to buyers and attendees, the doc of each names its data.
+var (
+	// OrderConfirmation is sent when an order is paid or invoiced, with OrderData.
+	OrderConfirmation = newTemplate("order confirmation",
+		`Your {{.Data.Event}} order #{{.Data.PaymentID}}`,
+		`Thank you for your order!
+
+{{range .Data.Items}}  {{.Name}}  {{money .Cost}}
+{{end}}
+  Total  {{money .Data.Total}}
+{{if not .Data.Fulfilled}}
+Part of this order is still owed, you will receive your invoice separately.
+{{end}}
+Your tickets follow in separate emails{{if not .Data.Fulfilled}} once it is paid{{end}}.`,
+		`<p>Thank you for your order!</p>
+<table width="100%" cellpadding="4">
+{{range .Data.Items}}<tr><td>{{.Name}}</td><td align="right">{{money .Cost}}</td></tr>
+{{end}}<tr><td><strong>Total</strong></td><td align="right"><strong>{{money .Data.Total}}</strong></td></tr>
+</table>
+{{if not .Data.Fulfilled}}<p>Part of this order is still owed, you will receive your invoice separately.</p>{{end}}
+<p>Your tickets follow in separate emails{{if not .Data.Fulfilled}} once it is paid{{end}}.</p>`, nil)
+
+	// Ticket is sent for each claim once its order is paid, with TicketData.
+	Ticket = newTemplate("ticket",
+		`Your ticket for {{.Data.Event}}: {{.Data.Slot}}`,
+		`This is your ticket for {{.Data.Slot}} at {{.Data.Event}}.
+{{if .Data.When}}
+When: {{.Data.When}}{{end}}{{if .Data.Where}}
+Where: {{.Data.Where}}{{end}}
+
+Ticket: {{.Data.TicketID}}
+Attendee: {{.Data.Email}}
+
+Show the attached QR code at the registration desk.{{if .Branding.CoCURL}}
+Remember to accept our code of conduct before the event: {{.Branding.CoCURL}}{{end}}`,
+		`<p>This is your ticket for <strong>{{.Data.Slot}}</strong> at {{.Data.Event}}.</p>
+{{if .Data.When}}<p>When: {{.Data.When}}</p>{{end}}{{if .Data.Where}}<p>Where: {{.Data.Where}}</p>{{end}}
+<p><img src="cid:ticket-qr" alt="Ticket {{.Data.TicketID}}" width="256" height="256"></p>
+<p>Ticket: <code>{{.Data.TicketID}}</code><br>Attendee: {{.Data.Email}}</p>
+<p>Show this QR code at the registration desk.</p>
+{{if .Branding.CoCURL}}<p>Remember to accept our <a href="{{.Branding.CoCURL}}">code of conduct</a> before the event.</p>{{end}}`,
+		ticketQR)
+
+	// TransferNotice is sent to whoever received transferred tickets, with TransferData.
+	TransferNotice = newTemplate("transfer notice",
+		`{{.Data.From}} sent you tickets for {{.Data.Event}}`,
+		`{{.Data.From}} transferred these tickets for {{.Data.Event}} to you:
+
+{{range .Data.Slots}}  {{.}}
+{{end}}
+They are yours now, sign in with this email to see them.`,
+		`<p>{{.Data.From}} transferred these tickets for {{.Data.Event}} to you:</p>
+<ul>{{range .Data.Slots}}<li>{{.}}</li>{{end}}</ul>
+<p>They are yours now, sign in with this email to see them.</p>`, nil)
+
+	// RefundReceipt is sent when an order is refunded, with RefundData.
+	RefundReceipt = newTemplate("refund receipt",
+		`Your {{.Data.Event}} refund #{{.Data.RefundID}}`,
+		`We refunded you:
+
+{{range .Data.Items}}  {{.Name}}  {{money .Cost}}
+{{end}}
+  Total  {{money .Data.Total}}
+{{if .Data.Reason}}
+Reason: {{.Data.Reason}}
+{{end}}
+The refund may take a few days to show on your statement.`,
+		`<p>We refunded you:</p>
+<table width="100%" cellpadding="4">
+{{range .Data.Items}}<tr><td>{{.Name}}</td><td align="right">{{money .Cost}}</td></tr>
+{{end}}<tr><td><strong>Total</strong></td><td align="right"><strong>{{money .Data.Total}}</strong></td></tr>
+</table>
+{{if .Data.Reason}}<p>Reason: {{.Data.Reason}}</p>{{end}}
+<p>The refund may take a few days to show on your statement.</p>`, nil)
+
+	// PaymentReminder is sent while an order paid on credit is owed, with
+	// PaymentReminderData.
+	PaymentReminder = newTemplate("payment reminder",
+		`{{if .Data.Suspended}}Your {{.Data.Event}} tickets are suspended{{else}}Payment reminder for your {{.Data.Event}} order #{{.Data.PaymentID}}{{end}}`,
+		`Your order #{{.Data.PaymentID}} for {{.Data.Event}}, made {{.Data.Days}} days ago, still has {{money .Data.Outstanding}} to pay.
+{{if .Data.Suspended}}
+Its tickets were suspended on {{.Data.Cutoff}} and cannot be used until it is paid.
+{{else if .Data.Cutoff}}
+Its tickets will be suspended on {{.Data.Cutoff}} unless it is paid by then.
+{{end}}
+If you already paid, please ignore this email.`,
+		`<p>Your order #{{.Data.PaymentID}} for {{.Data.Event}}, made {{.Data.Days}} days ago, still has <strong>{{money .Data.Outstanding}}</strong> to pay.</p>
+{{if .Data.Suspended}}<p>Its tickets were suspended on {{.Data.Cutoff}} and cannot be used until it is paid.</p>
+{{else if .Data.Cutoff}}<p>Its tickets will be suspended on {{.Data.Cutoff}} unless it is paid by then.</p>
+{{end}}<p>If you already paid, please ignore this email.</p>`, nil)
+)
+
+// layout wraps the HTML of every email in the branding of the conference.
+const layout = `<!DOCTYPE html>
+<html><body style="margin:0;padding:0;font-family:sans-serif;color:#333">
+<table width="100%" cellpadding="16" style="background:{{.Branding | color}}"><tr><td>
+{{if .Branding.LogoURL}}<img src="{{.Branding.LogoURL}}" alt="{{.Branding.Name}}" height="48">{{else}}<h1 style="color:#fff;margin:0">{{.Branding.Name}}</h1>{{end}}
+</td></tr></table>
+<div style="padding:16px">{{template "content" .}}</div>
+<p style="padding:16px;color:#888;font-size:small">{{.Branding.Name}}{{if .Branding.Website}} &middot; <a href="{{.Branding.Website}}">{{.Branding.Website}}</a>{{end}}</p>
+</body></html>`
+
+// textFooter signs every text email.
+const textFooter = `
+
+--
+{{.Branding.Name}}{{if .Branding.Website}}
+{{.Branding.Website}}{{end}}
+`
+
+var funcs = map[string]interface{}{
+	"money": money,
+	"color": Branding.color,
+}
+
+// newTemplate parses the templates of a kind of email, it panics if they are invalid as
+// they are constants.
+func newTemplate(name, subject, text, html string, attach func(interface{}) ([]Attachment, error)) *Template {
+	t := &Template{
+		name:    name,
+		subject: texttemplate.Must(texttemplate.New(name).Funcs(funcs).Parse(subject)),
+		text:    texttemplate.Must(texttemplate.New(name).Funcs(funcs).Parse(text + textFooter)),
+		html:    htmltemplate.Must(htmltemplate.New(name).Funcs(funcs).Parse(layout)),
+		attach:  attach,
+	}
+	htmltemplate.Must(t.html.New("content").Parse(html))
+	return t
+}
+
+// Render returns the message to send to, data must be the type the template names.
+func (t *Template) Render(to string, branding Branding, data interface{}) (Message, error) {
+	values := struct {
+		Branding Branding
+		Data     interface{}
+	}{branding, data}
+	var subject, text, html bytes.Buffer
+	if err := t.subject.Execute(&subject, values); err != nil {
+		return Message{}, fmt.Errorf("rendering the subject of the %s: %w", t.name, err)
+	}
+	if err := t.text.Execute(&text, values); err != nil {
+		return Message{}, fmt.Errorf("rendering the %s: %w", t.name, err)
+	}
+	if err := t.html.Execute(&html, values); err != nil {
+		return Message{}, fmt.Errorf("rendering the HTML %s: %w", t.name, err)
+	}
+	m := Message{
+		From:    branding.From,
+		To:      to,
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    text.String(),
+		HTML:    html.String(),
+	}
+	if t.attach != nil {
+		attachments, err := t.attach(data)
+		if err != nil {
+			return Message{}, fmt.Errorf("attaching to the %s: %w", t.name, err)
+		}
+		m.Attachments = attachments
+	}
+	return m, nil
+}
+
+// money formats cents, ie 50000 as 500.00.
+func money(cents int64) string {
+	sign := ""
+	if cents < 0 {
+		sign, cents = "-", -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)
+}
+
+// ticketQR attaches the QR code of the ticket, shown inline by the HTML body.
+func ticketQR(data interface{}) ([]Attachment, error) {
+	ticket, ok := data.(TicketData)
+	if !ok {
+		return nil, fmt.Errorf("ticket data is a %T", data)
+	}
+	png, err := qrcode.Encode(ticket.TicketID, qrcode.Medium, 256)
+	if err != nil {
+		return nil, fmt.Errorf("encoding the QR code: %w", err)
+	}
+	return []Attachment{{
+		Filename:    "ticket-" + ticket.TicketID + ".png",
+		ContentType: "image/png",
+		ContentID:   "ticket-qr",
+		Data:        png,
+	}}, nil
+}
@@ -0,0 +1,67 @@
+package mailer
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	branding := Branding{Name: "GopherCon", From: "GopherCon <tickets@gophercon.com>", Website: "https://gophercon.com"}
+	m, err := OrderConfirmation.Render("ada@example.com", branding, OrderData{
+		Event:     "GopherCon <2021>",
+		PaymentID: 42,
+		Items:     []Item{{Name: "conference", Cost: 50000}, {Name: "workshop", Cost: 20050}},
+		Total:     70050,
+	})
+	if err != nil {
+		t.Fatalf("Render() = %v", err)
+	}
+	if m.To != "ada@example.com" || m.From != branding.From || m.Subject != "Your GopherCon <2021> order #42" {
+		t.Errorf("rendered %q from %q to %q", m.Subject, m.From, m.To)
+	}
+	for _, want := range []string{"workshop  200.50", "Total  700.50", "still owed", "once it is paid", "--\nGopherCon\nhttps://gophercon.com"} {
+		if !strings.Contains(m.Text, want) {
+			t.Errorf("text %q does not contain %q", m.Text, want)
+		}
+	}
+	// the HTML is escaped and branded, with the default color.
+	for _, want := range []string{"<h1 style=\"color:#fff;margin:0\">GopherCon</h1>", "background:#00add8", "<strong>700.50</strong>"} {
+		if !strings.Contains(m.HTML, want) {
+			t.Errorf("HTML %q does not contain %q", m.HTML, want)
+		}
+	}
+
+	m, err = Ticket.Render("ada@example.com", branding, TicketData{
+		Event:    "GopherCon 2021",
+		Slot:     "conference",
+		TicketID: "9b2d7c6e",
+		Email:    "ada@example.com",
+		When:     "Tue Aug 3 2021, 09:00 MDT",
+	})
+	if err != nil {
+		t.Fatalf("Render() = %v", err)
+	}
+	if !strings.Contains(m.Text, "When: Tue Aug 3 2021, 09:00 MDT") || strings.Contains(m.Text, "Where:") {
+		t.Errorf("text %q does not describe only when the slot is", m.Text)
+	}
+	if len(m.Attachments) != 1 || m.Attachments[0].ContentID != "ticket-qr" || !strings.Contains(m.HTML, "cid:ticket-qr") {
+		t.Fatalf("attachments %+v, want the QR code shown by the HTML", m.Attachments)
+	}
+	if _, err := png.Decode(bytes.NewReader(m.Attachments[0].Data)); err != nil {
+		t.Errorf("the QR code is not a PNG: %v", err)
+	}
+
+	if _, err := Ticket.Render("ada@example.com", branding, OrderData{}); err == nil {
+		t.Error("rendered a ticket from order data")
+	}
+}
+
+func TestMoney(t *testing.T) {
+	for cents, want := range map[int64]string{0: "0.00", 5: "0.05", 50000: "500.00", -1999: "-19.99"} {
+		if got := money(cents); got != want {
+			t.Errorf("money(%d) = %q, want %q", cents, got, want)
+		}
+	}
+}
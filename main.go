@@ -1,33 +1,61 @@
 package main
 
 import (
-	"encoding/json"
-	"math/rand"
+	"crypto/rand"
+	"fmt"
+	stdlog "log"
+	mathrand "math/rand"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
-	"github.com/gopheracademy/manager/log"
-	"github.com/gopheracademy/manager/tracing"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/ShiftLeftSecurity/gaum/db/connection"
+	"github.com/gopheracademy/manager/database"
 	jexpvar "github.com/uber/jaeger-lib/metrics/expvar"
 	jprom "github.com/uber/jaeger-lib/metrics/prometheus"
 
-	"github.com/pacedotdev/oto/otohttp"
 	"github.com/uber/jaeger-lib/metrics"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
-var (
-	metricsBackend string = "prometheus"
-	logger         *zap.Logger
-	metricsFactory metrics.Factory
+const usage = `usage: manager [command] [flags]
+
+Commands are:
+
+  serve           runs the server, the default.
+  migrate         changes the schema of the database.
+  seed            loads demo conferences, events and slots into the database.
+  export          writes the conferences in the database as JSON, or the attendees or
+                  payments of an event as CSV, JSON Lines or Parquet.
+  ledger          prints the trial balance of the journal, the revenue of an event or
+                  the receivables by age.
+  reconcile       matches a payment provider's export with the payments recorded and
+                  posts its fees to the ledger.
+  comps           gives complimentary tickets to the people of a spreadsheet.
+  create-admin    grants an email super-admin, or organiser of a conference.
+
+Every command reads the JSON file passed with -config, or SHOWRUNNER_CONFIG, then
+overrides it with SHOWRUNNER_* environment variables; run manager [command] -h to
+see the flags of a command.
+`
+
+// commands are the subcommands, each returns the exit code.
+var commands = map[string]func(args []string) int{
+	"serve":        serve,
+	"migrate":      migrate,
+	"seed":         seed,
+	"export":       export,
+	"ledger":       ledger,
+	"reconcile":    reconcileExport,
+	"comps":        importComps,
+	"create-admin": createAdmin,
+}
 
-	basepath string
-	jaegerUI string
-)
+// calendarDomain is used to build globally unique IDs for calendar entries.
+const calendarDomain = "showrunner.gophercon.com"
 
 // spaHandler implements the http.Handler interface, so we can use it
 // to respond to HTTP requests. The path to the static directory and
@@ -73,55 +101,71 @@ func (h spaHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 }
 
 func main() {
-	onInitialize()
-	zapLogger := logger.With(zap.String("service", "showrunner"))
-	logg := log.NewFactory(zapLogger)
-	mytracer := tracing.Init("showrunner", metricsFactory, logg)
-	tracedRouter := tracing.NewServeMux(mytracer)
-	tracedRouter.Mux.HandleFunc("/api/health", func(w http.ResponseWriter, r *http.Request) {
-		// an example API handler
-		json.NewEncoder(w).Encode(map[string]bool{"ok": true})
-	})
-	conferenceService := newconferenceService(mytracer, metricsFactory, logg)
-	server := otohttp.NewServer()
-
-	RegisterConferenceService(metricsFactory.Namespace(metrics.NSOptions{Name: "conference.service"}), mytracer,
-		logg, server, conferenceService)
-
-	tracedRouter.Handle("/oto/", server)
-	spa := spaHandler{staticPath: "./www/build", indexPath: "index.html"}
-
-	tracedRouter.Mux.Handle("/metrics", promhttp.Handler()) // Prometheus
-	tracedRouter.Mux.PathPrefix("/").Handler(spa)
-
-	srv := &http.Server{
-		Handler: tracedRouter,
-		Addr:    "0.0.0.0:8000",
-		// Good practice: enforce timeouts for servers you create!
-		WriteTimeout: 15 * time.Second,
-		ReadTimeout:  15 * time.Second,
-	}
-
-	zapLogger.Fatal(srv.ListenAndServe().Error())
+	mathrand.Seed(int64(time.Now().Nanosecond()))
+	os.Exit(run(os.Args[1:]))
+}
 
+// run runs the command named by the first argument, serve if there is none.
+func run(args []string) int {
+	if len(args) == 0 {
+		return serve(nil)
+	}
+	switch args[0] {
+	case "help", "-h", "-help", "--help":
+		fmt.Print(usage)
+		return 0
+	}
+	// flags without a command are those of serve, ie manager -addr :8080
+	if strings.HasPrefix(args[0], "-") {
+		return serve(args)
+	}
+	command, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprint(os.Stderr, usage)
+		return 2
+	}
+	return command(args[1:])
 }
 
-// onInitialize is called before the command is executed.
-func onInitialize() {
-	rand.Seed(int64(time.Now().Nanosecond()))
-	logger, _ = zap.NewDevelopment(
+// newLogger returns the logger of the server.
+func newLogger() *zap.Logger {
+	logger, _ := zap.NewDevelopment(
 		zap.AddStacktrace(zapcore.FatalLevel),
 		zap.AddCallerSkip(1),
 	)
+	return logger
+}
 
-	switch metricsBackend {
+// newMetricsFactory returns the metrics factory of the named backend.
+func newMetricsFactory(backend string) (metrics.Factory, error) {
+	switch backend {
 	case "expvar":
-		metricsFactory = jexpvar.NewFactory(10) // 10 buckets for histograms
-		logger.Info("Using expvar as metrics backend")
+		return jexpvar.NewFactory(10), nil // 10 buckets for histograms
 	case "prometheus":
-		metricsFactory = jprom.New().Namespace(metrics.NSOptions{Name: "showrunner", Tags: nil})
-		logger.Info("Using Prometheus as metrics backend")
+		return jprom.New().Namespace(metrics.NSOptions{Name: "showrunner", Tags: nil}), nil
 	default:
-		logger.Fatal("unsupported metrics backend " + metricsBackend)
+		return nil, fmt.Errorf("unsupported metrics backend %q", backend)
+	}
+}
+
+// openDatabase connects the administration commands to the configured database.
+func openDatabase(cfg Config) (connection.DB, error) {
+	if cfg.DatabaseURL == "" {
+		return nil, fmt.Errorf("no database configured, set SHOWRUNNER_DATABASE_URL or databaseURL in the config")
+	}
+	return database.Open(cfg.DatabaseURL, stdlog.New(os.Stderr, "", stdlog.LstdFlags))
+}
+
+// secret returns the value of the named secret or, if it is not set, a random one
+// which will not survive a restart.
+func secret(logger *zap.Logger, name, value string) []byte {
+	if value != "" {
+		return []byte(value)
+	}
+	logger.Info(name + " is not set, using a random secret, what it signs will not survive a restart")
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		logger.Fatal("generating secret", zap.Error(err))
 	}
+	return key
 }
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/gopheracademy/manager/migrations"
+)
+
+const migrateUsage = `usage: manager migrate [flags] [command]
+
+Changes the schema of the configured database, commands are:
+
+  up          applies every pending migration, the default.
+  down [n]    reverts the last n migrations applied, 1 by default.
+  status      lists the migrations and whether they are applied.
+
+`
+
+// migrate implements the migrate subcommand and returns the exit code.
+func migrate(args []string) int {
+	fs, config := commandFlags("migrate", migrateUsage)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	args = fs.Args()
+	command := "up"
+	if len(args) > 0 {
+		command = args[0]
+	}
+	steps, maxArgs := 1, 1
+	switch command {
+	case "up", "status":
+	case "down":
+		maxArgs = 2
+		if len(args) == 2 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				fs.Usage()
+				return 2
+			}
+			steps = n
+		}
+	default:
+		fs.Usage()
+		return 2
+	}
+	if len(args) > maxArgs {
+		fs.Usage()
+		return 2
+	}
+	cfg, err := config()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	db, err := openDatabase(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	m := migrations.New(db)
+
+	var done []migrations.Migration
+	switch command {
+	case "up":
+		done, err = m.Up()
+	case "down":
+		done, err = m.Down(steps)
+	case "status":
+		return migrationStatus(m)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	for _, mig := range done {
+		fmt.Printf("%s %d %s\n", command, mig.Version, mig.Name)
+	}
+	if len(done) == 0 {
+		fmt.Println("nothing to do")
+	}
+	return 0
+}
+
+func migrationStatus(m *migrations.Migrator) int {
+	applied, err := m.Applied()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	isApplied := make(map[int64]bool, len(applied))
+	for _, v := range applied {
+		isApplied[v] = true
+	}
+	for _, mig := range migrations.All {
+		state := "pending"
+		if isApplied[mig.Version] {
+			state = "applied"
+		}
+		fmt.Printf("%4d %-8s %s\n", mig.Version, state, mig.Name)
+	}
+	return 0
+}
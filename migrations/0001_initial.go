@@ -0,0 +1,151 @@
+package migrations
+
+// initial is the schema of conferences, generated in store/schema.gen.sql, and of
+// ticketing, which used to live in ticketing.sql; it fixes the latter as it never
+// created the event table, stored emails as numbers and dates as timestamps while
+// ticketing uses Unix seconds.
+var initial = Migration{
+	Version: 1,
+	Name:    "initial",
+	Up: `
+CREATE TABLE conference (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    slug VARCHAR(50) NOT NULL UNIQUE
+);
+
+CREATE TABLE event (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    slug VARCHAR(50) NOT NULL,
+    start_date BIGINT,
+    end_date BIGINT,
+    location TEXT,
+    time_zone TEXT,
+    live BOOLEAN,
+    sponsorship_levels TEXT[],
+    conference_id BIGINT NOT NULL REFERENCES conference(id) ON DELETE CASCADE
+);
+CREATE INDEX event_conference_id ON event(conference_id);
+
+CREATE TABLE sponsor (
+    id BIGSERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    level TEXT,
+    website TEXT,
+    logo TEXT,
+    profile TEXT,
+    event_id BIGINT NOT NULL REFERENCES event(id) ON DELETE CASCADE
+);
+CREATE INDEX sponsor_event_id ON sponsor(event_id);
+
+CREATE TABLE sponsor_contact (
+    id BIGSERIAL PRIMARY KEY,
+    role TEXT,
+    name TEXT,
+    email TEXT,
+    phone TEXT,
+    sponsor_id BIGINT NOT NULL REFERENCES sponsor(id) ON DELETE CASCADE
+);
+CREATE INDEX sponsor_contact_sponsor_id ON sponsor_contact(sponsor_id);
+
+CREATE TABLE event_slot (
+    id BIGSERIAL PRIMARY KEY,
+    event_id BIGINT,
+    name VARCHAR(100),
+    description TEXT,
+    cost INTEGER,
+    capacity INT,
+    start_date BIGINT,
+    end_date BIGINT,
+    depends_on_id BIGINT,
+    purchaseable_from BIGINT,
+    purchaseable_until BIGINT,
+    available_to_public BOOLEAN,
+    FOREIGN KEY(depends_on_id) REFERENCES event_slot(id),
+    FOREIGN KEY(event_id) REFERENCES event(id)
+);
+
+CREATE TABLE slot_claim (
+    id BIGSERIAL PRIMARY KEY,
+    event_slot_id BIGINT,
+    ticket_id VARCHAR(100) CONSTRAINT ticket_id_is_unique UNIQUE,
+    redeemed BOOLEAN,
+    FOREIGN KEY(event_slot_id) REFERENCES event_slot(id)
+);
+
+CREATE TABLE attendee (
+    id BIGSERIAL PRIMARY KEY,
+    email VARCHAR(320) NOT NULL,
+    coc_accepted BOOLEAN
+);
+
+CREATE TABLE attendee_to_slot_claims (
+    attendee_id BIGINT,
+    slot_claim_id BIGINT CONSTRAINT slot_claim_id_is_unique UNIQUE,
+    FOREIGN KEY (attendee_id) REFERENCES attendee(id),
+    FOREIGN KEY (slot_claim_id) REFERENCES slot_claim(id)
+);
+
+CREATE TABLE claim_payment (
+    id BIGSERIAL PRIMARY KEY,
+    invoice TEXT -- just in case we need to store the whole thing.
+);
+
+CREATE TABLE payment_method_money (
+    id BIGSERIAL PRIMARY KEY,
+    amount INTEGER,
+    ref VARCHAR(250)
+);
+
+CREATE TABLE payment_method_money_to_claim_payment (
+    payment_method_money_id BIGINT,
+    claim_payment_id BIGINT,
+    FOREIGN KEY (payment_method_money_id) REFERENCES payment_method_money(id),
+    FOREIGN KEY (claim_payment_id) REFERENCES claim_payment(id)
+);
+
+CREATE TABLE payment_method_credit_note (
+    id BIGSERIAL PRIMARY KEY,
+    amount INTEGER,
+    detail VARCHAR(250)
+);
+
+CREATE TABLE payment_method_credit_note_to_claim_payment (
+    payment_method_credit_note_id BIGINT,
+    claim_payment_id BIGINT,
+    FOREIGN KEY (payment_method_credit_note_id) REFERENCES payment_method_credit_note(id),
+    FOREIGN KEY (claim_payment_id) REFERENCES claim_payment(id)
+);
+
+CREATE TABLE payment_method_event_discount (
+    id BIGSERIAL PRIMARY KEY,
+    amount INTEGER,
+    detail VARCHAR(250)
+);
+
+CREATE TABLE payment_method_event_discount_to_claim_payment (
+    payment_method_event_discount_id BIGINT,
+    claim_payment_id BIGINT,
+    FOREIGN KEY (payment_method_event_discount_id) REFERENCES payment_method_event_discount(id),
+    FOREIGN KEY (claim_payment_id) REFERENCES claim_payment(id)
+);
+`,
+	Down: `
+DROP TABLE payment_method_event_discount_to_claim_payment;
+DROP TABLE payment_method_event_discount;
+DROP TABLE payment_method_credit_note_to_claim_payment;
+DROP TABLE payment_method_credit_note;
+DROP TABLE payment_method_money_to_claim_payment;
+DROP TABLE payment_method_money;
+DROP TABLE claim_payment;
+DROP TABLE attendee_to_slot_claims;
+DROP TABLE attendee;
+DROP TABLE slot_claim;
+DROP TABLE event_slot;
+DROP TABLE sponsor_contact;
+DROP TABLE sponsor;
+DROP TABLE event;
+DROP TABLE conference;
+`,
+}
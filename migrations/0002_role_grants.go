@@ -0,0 +1,19 @@
+package migrations
+
+// roleGrants holds the roles granted to users, see auth.SQLRoleStore.
+var roleGrants = Migration{
+	Version: 2,
+	Name:    "role grants",
+	Up: `
+CREATE TABLE role_grant (
+    id BIGSERIAL PRIMARY KEY,
+    email VARCHAR(320) NOT NULL,
+    role VARCHAR(50) NOT NULL,
+    conference_id BIGINT NOT NULL DEFAULT 0, -- 0 means the role applies to every conference.
+    CONSTRAINT role_grant_is_unique UNIQUE (email, role, conference_id)
+);
+`,
+	Down: `
+DROP TABLE role_grant;
+`,
+}
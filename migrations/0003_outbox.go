@@ -0,0 +1,23 @@
+package migrations
+
+// outbox holds the domain events waiting to be delivered, see the outbox package.
+var outbox = Migration{
+	Version: 3,
+	Name:    "outbox",
+	Up: `
+CREATE TABLE outbox (
+    id BIGSERIAL PRIMARY KEY,
+    type VARCHAR(100) NOT NULL,
+    payload TEXT NOT NULL,
+    created_at BIGINT NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at BIGINT NOT NULL,
+    delivered_at BIGINT NOT NULL DEFAULT 0, -- 0 until delivered.
+    last_error TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX outbox_due ON outbox(next_attempt_at) WHERE delivered_at = 0;
+`,
+	Down: `
+DROP TABLE outbox;
+`,
+}
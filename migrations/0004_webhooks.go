@@ -0,0 +1,50 @@
+package migrations
+
+// webhooks holds the webhooks organisers configure, what is delivered to them and the log
+// of each attempt, see the webhooks package.
+var webhooks = Migration{
+	Version: 4,
+	Name:    "webhooks",
+	Up: `
+CREATE TABLE webhook (
+    id BIGSERIAL PRIMARY KEY,
+    conference_id BIGINT NOT NULL REFERENCES conference(id) ON DELETE CASCADE,
+    url VARCHAR(2000) NOT NULL,
+    event_types TEXT NOT NULL DEFAULT '', -- comma separated, every type if empty.
+    secret VARCHAR(200) NOT NULL,
+    created_at BIGINT NOT NULL
+);
+CREATE INDEX webhook_conference ON webhook(conference_id);
+CREATE TABLE webhook_delivery (
+    id BIGSERIAL PRIMARY KEY,
+    webhook_id BIGINT NOT NULL REFERENCES webhook(id) ON DELETE CASCADE,
+    event_id BIGINT NOT NULL,
+    event_type VARCHAR(100) NOT NULL,
+    payload TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at BIGINT NOT NULL,
+    created_at BIGINT NOT NULL,
+    CONSTRAINT webhook_delivery_event UNIQUE (webhook_id, event_id)
+);
+CREATE INDEX webhook_delivery_due ON webhook_delivery(next_attempt_at) WHERE status = 'pending';
+CREATE TABLE webhook_attempt (
+    id BIGSERIAL PRIMARY KEY,
+    delivery_id BIGINT NOT NULL REFERENCES webhook_delivery(id) ON DELETE CASCADE,
+    attempted_at BIGINT NOT NULL,
+    request_headers TEXT NOT NULL,
+    request_body TEXT NOT NULL,
+    response_status INTEGER NOT NULL,
+    response_headers TEXT NOT NULL,
+    response_body TEXT NOT NULL,
+    error TEXT NOT NULL,
+    duration_ms INTEGER NOT NULL
+);
+CREATE INDEX webhook_attempt_delivery ON webhook_attempt(delivery_id);
+`,
+	Down: `
+DROP TABLE webhook_attempt;
+DROP TABLE webhook_delivery;
+DROP TABLE webhook;
+`,
+}
@@ -0,0 +1,28 @@
+package migrations
+
+// emails is the queue and log of the emails sent, see mailer.Queue.
+var emails = Migration{
+	Version: 5,
+	Name:    "emails",
+	Up: `
+CREATE TABLE email (
+    id BIGSERIAL PRIMARY KEY,
+    key VARCHAR(200) NOT NULL, -- identifies what the email is about, it is sent once.
+    recipient VARCHAR(320) NOT NULL,
+    subject TEXT NOT NULL,
+    message TEXT NOT NULL, -- the JSON of the mailer.Message.
+    status VARCHAR(20) NOT NULL DEFAULT 'queued',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at BIGINT NOT NULL,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at BIGINT NOT NULL,
+    sent_at BIGINT NOT NULL DEFAULT 0, -- 0 until sent.
+    CONSTRAINT email_key UNIQUE (key)
+);
+CREATE INDEX email_due ON email(next_attempt_at) WHERE status = 'queued';
+CREATE INDEX email_recipient ON email(recipient);
+`,
+	Down: `
+DROP TABLE email;
+`,
+}
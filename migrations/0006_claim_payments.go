@@ -0,0 +1,35 @@
+package migrations
+
+// claimPayments records who made payments and which claims they pay for, and indexes what
+// reading them back joins on.
+var claimPayments = Migration{
+	Version: 6,
+	Name:    "claim_payments",
+	Up: `
+ALTER TABLE claim_payment ADD COLUMN attendee_id BIGINT REFERENCES attendee(id);
+CREATE INDEX claim_payment_attendee ON claim_payment(attendee_id);
+
+CREATE TABLE slot_claim_to_claim_payment (
+    slot_claim_id BIGINT NOT NULL,
+    claim_payment_id BIGINT NOT NULL,
+    CONSTRAINT slot_claim_to_claim_payment_pk PRIMARY KEY (claim_payment_id, slot_claim_id),
+    FOREIGN KEY (slot_claim_id) REFERENCES slot_claim(id),
+    FOREIGN KEY (claim_payment_id) REFERENCES claim_payment(id)
+);
+CREATE INDEX slot_claim_to_claim_payment_claim ON slot_claim_to_claim_payment(slot_claim_id);
+CREATE INDEX slot_claim_event_slot ON slot_claim(event_slot_id);
+CREATE INDEX attendee_to_slot_claims_attendee ON attendee_to_slot_claims(attendee_id);
+CREATE INDEX payment_method_money_to_claim_payment_payment ON payment_method_money_to_claim_payment(claim_payment_id);
+CREATE INDEX payment_method_credit_note_to_claim_payment_payment ON payment_method_credit_note_to_claim_payment(claim_payment_id);
+CREATE INDEX payment_method_event_discount_to_claim_payment_payment ON payment_method_event_discount_to_claim_payment(claim_payment_id);
+`,
+	Down: `
+DROP INDEX payment_method_event_discount_to_claim_payment_payment;
+DROP INDEX payment_method_credit_note_to_claim_payment_payment;
+DROP INDEX payment_method_money_to_claim_payment_payment;
+DROP INDEX attendee_to_slot_claims_attendee;
+DROP INDEX slot_claim_event_slot;
+DROP TABLE slot_claim_to_claim_payment;
+ALTER TABLE claim_payment DROP COLUMN attendee_id;
+`,
+}
@@ -0,0 +1,34 @@
+package migrations
+
+// journal is the double-entry journal ticketing posts money movements to, entries are
+// only appended and their lines are balanced before they are inserted.
+var journal = Migration{
+	Version: 7,
+	Name:    "journal",
+	Up: `
+CREATE TABLE journal_entry (
+    id BIGSERIAL PRIMARY KEY,
+    kind VARCHAR(20) NOT NULL,
+    event_id BIGINT NOT NULL DEFAULT 0,
+    claim_payment_id BIGINT REFERENCES claim_payment(id),
+    memo TEXT NOT NULL DEFAULT '',
+    posted_at BIGINT NOT NULL
+);
+CREATE INDEX journal_entry_event ON journal_entry(event_id);
+CREATE INDEX journal_entry_claim_payment ON journal_entry(claim_payment_id);
+
+CREATE TABLE journal_line (
+    id BIGSERIAL PRIMARY KEY,
+    journal_entry_id BIGINT NOT NULL REFERENCES journal_entry(id),
+    account VARCHAR(40) NOT NULL,
+    debit BIGINT NOT NULL DEFAULT 0,
+    credit BIGINT NOT NULL DEFAULT 0,
+    CONSTRAINT journal_line_one_side CHECK ((debit > 0 AND credit = 0) OR (debit = 0 AND credit > 0))
+);
+CREATE INDEX journal_line_entry ON journal_line(journal_entry_id);
+`,
+	Down: `
+DROP TABLE journal_line;
+DROP TABLE journal_entry;
+`,
+}
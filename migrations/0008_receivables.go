@@ -0,0 +1,20 @@
+package migrations
+
+// receivables records when payments were made, so the credit they extend can be aged,
+// and lets claims of overdue payments be suspended; payments made before are dated by
+// their journal entry, or now if they have none.
+var receivables = Migration{
+	Version: 8,
+	Name:    "receivables",
+	Up: `
+ALTER TABLE claim_payment ADD COLUMN created_at BIGINT NOT NULL DEFAULT 0;
+UPDATE claim_payment SET created_at = COALESCE(
+    (SELECT MIN(posted_at) FROM journal_entry WHERE journal_entry.claim_payment_id = claim_payment.id),
+    CAST(EXTRACT(EPOCH FROM now()) AS BIGINT));
+ALTER TABLE slot_claim ADD COLUMN suspended BOOLEAN NOT NULL DEFAULT false;
+`,
+	Down: `
+ALTER TABLE slot_claim DROP COLUMN suspended;
+ALTER TABLE claim_payment DROP COLUMN created_at;
+`,
+}
@@ -0,0 +1,19 @@
+package migrations
+
+// outboxStatus gives outbox events a status so those whose deliveries kept failing are
+// given up on, events delivered before are marked so.
+var outboxStatus = Migration{
+	Version: 9,
+	Name:    "outbox status",
+	Up: `
+ALTER TABLE outbox ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'pending';
+UPDATE outbox SET status = 'delivered' WHERE delivered_at <> 0;
+DROP INDEX outbox_due;
+CREATE INDEX outbox_due ON outbox(next_attempt_at) WHERE status = 'pending';
+`,
+	Down: `
+DROP INDEX outbox_due;
+CREATE INDEX outbox_due ON outbox(next_attempt_at) WHERE delivered_at = 0;
+ALTER TABLE outbox DROP COLUMN status;
+`,
+}
@@ -0,0 +1,18 @@
+package migrations
+
+// grantEmails lowercases the emails of role grants, sessions hold them lowercased so
+// those granted in another case were never used; grants that then repeat are dropped.
+var grantEmails = Migration{
+	Version: 10,
+	Name:    "grant emails",
+	Up: `
+DELETE FROM role_grant g USING role_grant o
+    WHERE lower(trim(g.email)) = lower(trim(o.email))
+    AND g.role = o.role AND g.conference_id = o.conference_id AND g.id > o.id;
+UPDATE role_grant SET email = lower(trim(email));
+`,
+	Down: `
+-- the case emails were granted in is not kept, there is nothing to revert.
+SELECT 1;
+`,
+}
@@ -0,0 +1,30 @@
+package migrations
+
+// attendeeEmails makes the email of attendees unique whatever its case, checkouts made at
+// once could create two attendees for one; those created before are merged into the
+// first of them, which gets their claims and payments, and emails are kept lowercased
+// like ticketing.NormalizeEmail returns them.
+var attendeeEmails = Migration{
+	Version: 11,
+	Name:    "attendee emails",
+	Up: `
+CREATE TEMPORARY TABLE attendee_merge ON COMMIT DROP AS
+    SELECT id, first FROM (
+        SELECT id, min(id) OVER (PARTITION BY lower(trim(email))) AS first FROM attendee
+    ) a WHERE id <> first;
+UPDATE attendee_to_slot_claims c SET attendee_id = m.first
+    FROM attendee_merge m WHERE c.attendee_id = m.id;
+UPDATE claim_payment p SET attendee_id = m.first
+    FROM attendee_merge m WHERE p.attendee_id = m.id;
+UPDATE attendee a SET coc_accepted = true
+    FROM attendee_merge m JOIN attendee merged ON merged.id = m.id
+    WHERE a.id = m.first AND merged.coc_accepted;
+DELETE FROM attendee a USING attendee_merge m WHERE a.id = m.id;
+UPDATE attendee SET email = lower(trim(email)) WHERE email <> lower(trim(email));
+CREATE UNIQUE INDEX attendee_email_is_unique ON attendee (lower(email));
+`,
+	Down: `
+-- attendees merged are not split again.
+DROP INDEX attendee_email_is_unique;
+`,
+}
@@ -0,0 +1,232 @@
+// Package migrations versions the database schema, each Migration is applied once and
+// recorded in the schema_migrations table; run them with "manager migrate".
+package migrations
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/ShiftLeftSecurity/gaum/db/chain"
+	"github.com/ShiftLeftSecurity/gaum/db/connection"
+)
+
+// Migration changes the schema from the previous version to Version.
+type Migration struct {
+	Version int64
+	Name    string
+	// Up applies the migration, Down reverts it; both run in a transaction.
+	Up   string
+	Down string
+}
+
+// All are the migrations in the order they apply, append new ones at the end and never
+// change one that was released.
+var All = []Migration{
+	initial,
+	roleGrants,
+	outbox,
+	webhooks,
+	emails,
+	claimPayments,
+	journal,
+	receivables,
+	outboxStatus,
+	grantEmails,
+	attendeeEmails,
+}
+
+// Latest returns the version of the last migration.
+func Latest() int64 {
+	return All[len(All)-1].Version
+}
+
+const (
+	tableSchemaMigrations = "schema_migrations"
+	// lockID serializes migrations run at once by several instances.
+	lockID = 7413
+)
+
+// Migrator applies migrations to a database.
+type Migrator struct {
+	conn       connection.DB
+	migrations []Migration
+}
+
+// New returns a Migrator applying All to conn.
+func New(conn connection.DB) *Migrator {
+	return &Migrator{conn: conn, migrations: All}
+}
+
+// Applied returns the versions already applied, in order; it only reads the database,
+// which has none applied until Up created the schema_migrations table.
+func (m *Migrator) Applied() ([]int64, error) {
+	return applied(m.conn)
+}
+
+func applied(conn connection.DB) ([]int64, error) {
+	tables := []int64{}
+	err := chain.New(conn).Select("count(*)").From("information_schema.tables").
+		AndWhere("table_schema = current_schema()").
+		AndWhere("table_name = ?", tableSchemaMigrations).FetchIntoPrimitive(&tables)
+	if err != nil {
+		return nil, fmt.Errorf("looking for %s: %w", tableSchemaMigrations, err)
+	}
+	versions := []int64{}
+	if len(tables) == 0 || tables[0] == 0 {
+		return versions, nil
+	}
+	err = chain.New(conn).Select("version").From(tableSchemaMigrations).
+		OrderBy(chain.Asc("version")).FetchIntoPrimitive(&versions)
+	if err != nil {
+		return nil, fmt.Errorf("reading applied migrations: %w", err)
+	}
+	return versions, nil
+}
+
+// Pending returns the migrations not yet applied, in order.
+func (m *Migrator) Pending() ([]Migration, error) {
+	versions, err := m.Applied()
+	if err != nil {
+		return nil, err
+	}
+	return pending(m.migrations, versions), nil
+}
+
+func pending(migrations []Migration, applied []int64) []Migration {
+	done := make(map[int64]bool, len(applied))
+	for _, v := range applied {
+		done[v] = true
+	}
+	var p []Migration
+	for _, mig := range migrations {
+		if !done[mig.Version] {
+			p = append(p, mig)
+		}
+	}
+	return p
+}
+
+// Check returns an error if there are migrations not yet applied, or applied ones this
+// version does not know of, ie once a later version migrated the database; the server
+// must not run against a schema it does not know. Like Applied it only reads the database.
+func (m *Migrator) Check() error {
+	versions, err := m.Applied()
+	if err != nil {
+		return err
+	}
+	if p := pending(m.migrations, versions); len(p) != 0 {
+		return fmt.Errorf("the database schema is behind, %d migrations are pending starting with %d %s, "+
+			"run manager migrate", len(p), p[0].Version, p[0].Name)
+	}
+	if u := unknown(m.migrations, versions); len(u) != 0 {
+		return fmt.Errorf("the database schema is ahead, migration %d is applied but unknown to this version", u[0])
+	}
+	return nil
+}
+
+// unknown returns the applied versions that are none of the migrations, in order.
+func unknown(migrations []Migration, applied []int64) []int64 {
+	known := make(map[int64]bool, len(migrations))
+	for _, mig := range migrations {
+		known[mig.Version] = true
+	}
+	var u []int64
+	for _, v := range applied {
+		if !known[v] {
+			u = append(u, v)
+		}
+	}
+	return u
+}
+
+// Up applies every pending migration and returns them.
+func (m *Migrator) Up() ([]Migration, error) {
+	if err := m.conn.Exec(`CREATE TABLE IF NOT EXISTS ` + tableSchemaMigrations + ` (
+    version BIGINT PRIMARY KEY,
+    name TEXT NOT NULL,
+    applied_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+)`); err != nil {
+		return nil, fmt.Errorf("creating %s: %w", tableSchemaMigrations, err)
+	}
+	var done []Migration
+	err := m.atomically(func(tx connection.DB) error {
+		versions, err := applied(tx)
+		if err != nil {
+			return err
+		}
+		for _, mig := range pending(m.migrations, versions) {
+			if err := tx.Exec(mig.Up); err != nil {
+				return fmt.Errorf("applying migration %d %s: %w", mig.Version, mig.Name, err)
+			}
+			err := chain.New(tx).Insert(map[string]interface{}{
+				"version": mig.Version,
+				"name":    mig.Name,
+			}).Table(tableSchemaMigrations).Exec()
+			if err != nil {
+				return fmt.Errorf("recording migration %d: %w", mig.Version, err)
+			}
+			done = append(done, mig)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return done, nil
+}
+
+// Down reverts the last steps applied migrations and returns them.
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	byVersion := make(map[int64]Migration, len(m.migrations))
+	for _, mig := range m.migrations {
+		byVersion[mig.Version] = mig
+	}
+	var done []Migration
+	err := m.atomically(func(tx connection.DB) error {
+		versions, err := applied(tx)
+		if err != nil {
+			return err
+		}
+		sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+		for i := 0; i < steps && i < len(versions); i++ {
+			mig, ok := byVersion[versions[i]]
+			if !ok {
+				return fmt.Errorf("migration %d is applied but unknown to this version", versions[i])
+			}
+			if err := tx.Exec(mig.Down); err != nil {
+				return fmt.Errorf("reverting migration %d %s: %w", mig.Version, mig.Name, err)
+			}
+			err := chain.New(tx).Delete().Table(tableSchemaMigrations).
+				AndWhere("version = ?", mig.Version).Exec()
+			if err != nil {
+				return fmt.Errorf("unrecording migration %d: %w", mig.Version, err)
+			}
+			done = append(done, mig)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return done, nil
+}
+
+// atomically runs f in a transaction holding the migrations lock.
+func (m *Migrator) atomically(f func(tx connection.DB) error) error {
+	tx, err := m.conn.BeginTransaction()
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	if err := tx.Exec(fmt.Sprintf("SELECT pg_advisory_xact_lock(%d)", lockID)); err != nil {
+		tx.RollbackTransaction()
+		return fmt.Errorf("locking migrations: %w", err)
+	}
+	if err := f(tx); err != nil {
+		tx.RollbackTransaction()
+		return err
+	}
+	if err := tx.CommitTransaction(); err != nil {
+		return fmt.Errorf("committing migrations: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package migrations
+
+import (
+	"log"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/ShiftLeftSecurity/gaum/db/chain"
+
+	"github.com/gopheracademy/manager/database"
+)
+
+func TestMigrationsAreOrdered(t *testing.T) {
+	var previous int64
+	for _, m := range All {
+		if m.Version <= previous {
+			t.Errorf("migration %d %s comes after %d", m.Version, m.Name, previous)
+		}
+		if m.Name == "" || m.Up == "" || m.Down == "" {
+			t.Errorf("migration %d must have a name, an up and a down", m.Version)
+		}
+		previous = m.Version
+	}
+	if Latest() != previous {
+		t.Errorf("Latest() = %d, want %d", Latest(), previous)
+	}
+}
+
+func TestPending(t *testing.T) {
+	migrations := []Migration{{Version: 1}, {Version: 2}, {Version: 3}}
+	for _, tc := range []struct {
+		applied []int64
+		want    []int64
+	}{
+		{applied: nil, want: []int64{1, 2, 3}},
+		{applied: []int64{1}, want: []int64{2, 3}},
+		{applied: []int64{1, 3}, want: []int64{2}},
+		{applied: []int64{1, 2, 3}, want: nil},
+	} {
+		var got []int64
+		for _, m := range pending(migrations, tc.applied) {
+			got = append(got, m.Version)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("pending with %v applied = %v, want %v", tc.applied, got, tc.want)
+		}
+	}
+}
+
+func TestUnknown(t *testing.T) {
+	migrations := []Migration{{Version: 1}, {Version: 2}}
+	if got := unknown(migrations, []int64{1, 2}); len(got) != 0 {
+		t.Errorf("unknown with every migration known = %v, want none", got)
+	}
+	if got := unknown(migrations, []int64{1, 2, 3, 4}); !reflect.DeepEqual(got, []int64{3, 4}) {
+		t.Errorf("unknown with later migrations applied = %v, want [3 4]", got)
+	}
+}
+
+// TestApplyToEmptyDatabase needs an empty postgres database, ie
+//
+//	createdb showrunner_test
+//	SHOWRUNNER_TEST_DATABASE_URL=postgres://localhost/showrunner_test go test ./migrations
+func TestApplyToEmptyDatabase(t *testing.T) {
+	url := os.Getenv("SHOWRUNNER_TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("SHOWRUNNER_TEST_DATABASE_URL is not set")
+	}
+	db, err := database.Open(url, log.New(os.Stderr, "", log.LstdFlags))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := New(db)
+	applied, err := m.Applied()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != 0 {
+		t.Fatalf("the database is not empty, it has migrations %v applied", applied)
+	}
+	if err := m.Check(); err == nil {
+		t.Error("Check() passed on an empty database")
+	}
+	// checking must not change the database, readiness probes run it.
+	tables := []int64{}
+	err = chain.New(db).Select("count(*)").From("information_schema.tables").
+		AndWhere("table_name = ?", tableSchemaMigrations).FetchIntoPrimitive(&tables)
+	if err != nil || len(tables) != 1 || tables[0] != 0 {
+		t.Fatalf("Check() created %s (%v, %v)", tableSchemaMigrations, tables, err)
+	}
+
+	done, err := m.Up()
+	if err != nil {
+		t.Fatalf("Up() = %v", err)
+	}
+	if len(done) != len(All) {
+		t.Errorf("Up() applied %d migrations, want %d", len(done), len(All))
+	}
+	if err := m.Check(); err != nil {
+		t.Errorf("Check() after Up() = %v", err)
+	}
+	if done, err := m.Up(); err != nil || len(done) != 0 {
+		t.Errorf("second Up() = %v, %v; want nothing done", done, err)
+	}
+
+	// every migration must revert cleanly so they can be applied again.
+	done, err = m.Down(len(All))
+	if err != nil {
+		t.Fatalf("Down() = %v", err)
+	}
+	if len(done) != len(All) || done[0].Version != Latest() {
+		t.Errorf("Down() reverted %d migrations, want all from the latest", len(done))
+	}
+	if _, err := m.Up(); err != nil {
+		t.Fatalf("Up() after Down() = %v", err)
+	}
+	if _, err := m.Down(len(All)); err != nil {
+		t.Fatalf("cleaning up: %v", err)
+	}
+}
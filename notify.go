@@ -0,0 +1,275 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gopheracademy/manager/mailer"
+	"github.com/gopheracademy/manager/outbox"
+	"github.com/gopheracademy/manager/store"
+	"github.com/gopheracademy/manager/ticketing"
+)
+
+// ticketMails emails buyers and attendees about their tickets as ticketing events are
+// delivered from the outbox; emails are queued once per key so events delivered again do
+// not send them twice.
+type ticketMails struct {
+	queue       *mailer.Queue
+	tickets     ticketing.PurchaseStore
+	events      *store.EventRepository
+	conferences *store.ConferenceRepository
+	// branding is by conference slug.
+	branding map[string]mailer.Branding
+	from     string
+}
+
+var _ outbox.Subscriber = &ticketMails{}
+
+// Handle implements outbox.Subscriber
+func (t *ticketMails) Handle(ctx context.Context, e outbox.Event) error {
+	switch e.Type {
+	case ticketing.EventPaymentRecorded:
+		var payment ticketing.PaymentRecorded
+		if err := e.Decode(&payment); err != nil {
+			return err
+		}
+		if err := t.order(ctx, payment); err != nil {
+			return err
+		}
+		return t.paidTickets(ctx, payment)
+	case ticketing.EventPaymentRefunded:
+		var refund ticketing.PaymentRefunded
+		if err := e.Decode(&refund); err != nil {
+			return err
+		}
+		return t.refund(ctx, refund)
+	case ticketing.EventClaimsTransferred:
+		var transfer ticketing.ClaimsTransferred
+		if err := e.Decode(&transfer); err != nil {
+			return err
+		}
+		return t.transfer(ctx, e.ID, transfer)
+	}
+	return nil
+}
+
+// paidTickets sends the tickets of the payment to whoever paid, once nothing of it is
+// owed; payments by invoice get theirs when the credit is covered.
+func (t *ticketMails) paidTickets(ctx context.Context, payment ticketing.PaymentRecorded) error {
+	if !payment.Fulfilled || payment.EventID == 0 {
+		return nil
+	}
+	paid, payer, err := t.payment(ctx, payment.PaymentID)
+	if err != nil || payer == nil {
+		return err
+	}
+	for _, c := range paid.ClaimsPayed {
+		if err := t.ticket(ctx, payer.Email, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *ticketMails) ticket(ctx context.Context, to string, claim *ticketing.SlotClaim) error {
+	if claim.EventSlot == nil {
+		return fmt.Errorf("slot of claim %d not found", claim.ID)
+	}
+	slot, err := t.tickets.ReadEventSlotByID(ctx, claim.EventSlot.ID)
+	if err != nil {
+		return fmt.Errorf("reading slot %d: %w", claim.EventSlot.ID, err)
+	}
+	if slot == nil || slot.Event == nil {
+		return fmt.Errorf("slot %d of claim %d not found", claim.EventSlot.ID, claim.ID)
+	}
+	branding, err := t.brandingOf(ctx, slot.Event.ID)
+	if err != nil {
+		return err
+	}
+	m, err := mailer.Ticket.Render(to, branding, mailer.TicketData{
+		Event:    slot.Event.Name,
+		Slot:     slot.Name,
+		TicketID: claim.TicketID,
+		Email:    to,
+		When:     when(slot.StartDate, slot.Event.TimeZone),
+		Where:    slot.Event.Location,
+	})
+	if err != nil {
+		return err
+	}
+	return t.queue.Enqueue(ctx, fmt.Sprintf("ticket:%d", claim.ID), m)
+}
+
+func (t *ticketMails) order(ctx context.Context, payment ticketing.PaymentRecorded) error {
+	// payments covering credit are not orders, the buyer already got theirs.
+	if payment.AttendeeID == 0 || payment.EventID == 0 {
+		return nil
+	}
+	attendee, err := t.tickets.ReadAttendeeByID(ctx, payment.AttendeeID)
+	if err != nil {
+		return fmt.Errorf("reading attendee %d: %w", payment.AttendeeID, err)
+	}
+	if attendee == nil {
+		return fmt.Errorf("attendee %d of payment %d not found", payment.AttendeeID, payment.PaymentID)
+	}
+	slots, err := t.slots(ctx, payment.SlotIDs)
+	if err != nil {
+		return err
+	}
+	data := mailer.OrderData{
+		PaymentID: payment.PaymentID,
+		Total:     payment.TotalDue,
+		Fulfilled: payment.Fulfilled,
+	}
+	for _, slot := range slots {
+		data.Event = slot.Event.Name
+		data.Items = append(data.Items, mailer.Item{Name: slot.Name, Cost: slot.Cost})
+	}
+	branding, err := t.brandingOf(ctx, payment.EventID)
+	if err != nil {
+		return err
+	}
+	m, err := mailer.OrderConfirmation.Render(attendee.Email, branding, data)
+	if err != nil {
+		return err
+	}
+	return t.queue.Enqueue(ctx, fmt.Sprintf("order:%d", payment.PaymentID), m)
+}
+
+func (t *ticketMails) transfer(ctx context.Context, eventID uint64, transfer ticketing.ClaimsTransferred) error {
+	if transfer.EventID == 0 {
+		return nil
+	}
+	slots, err := t.slots(ctx, transfer.SlotIDs)
+	if err != nil {
+		return err
+	}
+	data := mailer.TransferData{From: transfer.SourceEmail}
+	for _, slot := range slots {
+		data.Event = slot.Event.Name
+		data.Slots = append(data.Slots, slot.Name)
+	}
+	branding, err := t.brandingOf(ctx, transfer.EventID)
+	if err != nil {
+		return err
+	}
+	m, err := mailer.TransferNotice.Render(transfer.TargetEmail, branding, data)
+	if err != nil {
+		return err
+	}
+	// the same claims can be transferred more than once, the outbox event is the transfer.
+	return t.queue.Enqueue(ctx, fmt.Sprintf("transfer:%d", eventID), m)
+}
+
+func (t *ticketMails) refund(ctx context.Context, refund ticketing.PaymentRefunded) error {
+	if refund.EventID == 0 {
+		return nil
+	}
+	_, payer, err := t.payment(ctx, refund.PaymentID)
+	if err != nil || payer == nil {
+		return err
+	}
+	event, err := t.events.Read(ctx, refund.EventID)
+	if err != nil {
+		return err
+	}
+	if event == nil {
+		return fmt.Errorf("event %d not found", refund.EventID)
+	}
+	branding, err := t.brandingOf(ctx, refund.EventID)
+	if err != nil {
+		return err
+	}
+	m, err := mailer.RefundReceipt.Render(payer.Email, branding, mailer.RefundData{
+		Event:    event.Name,
+		RefundID: refund.RefundID,
+		Items:    []mailer.Item{{Name: fmt.Sprintf("Order #%d", refund.PaymentID), Cost: refund.Amount}},
+		Total:    refund.Amount,
+		Reason:   refund.Memo,
+	})
+	if err != nil {
+		return err
+	}
+	return t.queue.Enqueue(ctx, fmt.Sprintf("refund:%d", refund.RefundID), m)
+}
+
+// payment reads the payment and who paid it, the payer is nil for payments made
+// before payers were recorded.
+func (t *ticketMails) payment(ctx context.Context, id uint64) (*ticketing.ClaimPayment, *ticketing.Attendee, error) {
+	payment, err := t.tickets.ReadClaimPaymentByID(ctx, id)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading payment %d: %w", id, err)
+	}
+	if payment == nil {
+		return nil, nil, fmt.Errorf("payment %d not found", id)
+	}
+	if payment.AttendeeID == 0 {
+		return payment, nil, nil
+	}
+	payer, err := t.tickets.ReadAttendeeByID(ctx, payment.AttendeeID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading attendee %d: %w", payment.AttendeeID, err)
+	}
+	if payer == nil {
+		return nil, nil, fmt.Errorf("attendee %d of payment %d not found", payment.AttendeeID, id)
+	}
+	return payment, payer, nil
+}
+
+// slots reads the slots with the IDs, skipping unknown IDs (0).
+func (t *ticketMails) slots(ctx context.Context, ids []uint64) ([]ticketing.EventSlot, error) {
+	var slots []ticketing.EventSlot
+	for _, id := range ids {
+		if id == 0 {
+			continue
+		}
+		slot, err := t.tickets.ReadEventSlotByID(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("reading slot %d: %w", id, err)
+		}
+		if slot == nil || slot.Event == nil {
+			return nil, fmt.Errorf("slot %d not found", id)
+		}
+		slots = append(slots, *slot)
+	}
+	return slots, nil
+}
+
+// brandingOf returns the branding of the conference of the event.
+func (t *ticketMails) brandingOf(ctx context.Context, eventID uint32) (mailer.Branding, error) {
+	event, err := t.events.Read(ctx, eventID)
+	if err != nil {
+		return mailer.Branding{}, err
+	}
+	if event == nil {
+		return mailer.Branding{}, fmt.Errorf("event %d not found", eventID)
+	}
+	conference, err := t.conferences.Read(ctx, event.ConferenceID)
+	if err != nil {
+		return mailer.Branding{}, err
+	}
+	if conference == nil {
+		return mailer.Branding{}, fmt.Errorf("conference %d not found", event.ConferenceID)
+	}
+	branding := t.branding[conference.Slug]
+	if branding.Name == "" {
+		branding.Name = conference.Name
+	}
+	if branding.From == "" {
+		branding.From = t.from
+	}
+	return branding, nil
+}
+
+// when formats a Unix timestamp in the time zone of the event, in UTC if it is unknown.
+func when(unix uint64, zone string) string {
+	if unix == 0 {
+		return ""
+	}
+	loc, err := time.LoadLocation(zone)
+	if err != nil || zone == "" {
+		loc = time.UTC
+	}
+	return time.Unix(int64(unix), 0).In(loc).Format("Mon Jan 2 2006, 15:04 MST")
+}
@@ -0,0 +1,115 @@
+package outbox
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/gopheracademy/manager/log"
+	"github.com/gopheracademy/manager/poller"
+	"github.com/gopheracademy/manager/pool"
+)
+
+// AllEvents subscribes to every type of event.
+const AllEvents = "*"
+
+// Subscriber reacts to events in process, it must be idempotent as an event is delivered
+// again if any of its subscribers failed.
+type Subscriber interface {
+	Handle(ctx context.Context, e Event) error
+}
+
+// SubscriberFunc adapts a function to Subscriber.
+type SubscriberFunc func(ctx context.Context, e Event) error
+
+// Handle implements Subscriber
+func (f SubscriberFunc) Handle(ctx context.Context, e Event) error {
+	return f(ctx, e)
+}
+
+// Options configure a Dispatcher, the zero value of each is replaced by its default.
+type Options struct {
+	poller.Options
+}
+
+// Dispatcher delivers the events of the outbox using the workers of a pool.
+type Dispatcher struct {
+	*poller.Poller
+	store  Store
+	opts   Options
+	logger log.Factory
+
+	lock        sync.RWMutex
+	subscribers map[string][]Subscriber
+}
+
+// NewDispatcher returns a Dispatcher delivering the events of store on the workers of p,
+// call Start to begin delivering and Stop, before stopping the pool, to end.
+func NewDispatcher(store Store, p *pool.Pool, opts Options, logger log.Factory) *Dispatcher {
+	d := &Dispatcher{
+		store:       store,
+		opts:        opts,
+		logger:      logger,
+		subscribers: map[string][]Subscriber{},
+	}
+	d.Poller = poller.New("the outbox", d.lease, p, opts.Options, logger)
+	return d
+}
+
+// Subscribe delivers the events of the type, or AllEvents, to s.
+func (d *Dispatcher) Subscribe(eventType string, s Subscriber) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.subscribers[eventType] = append(d.subscribers[eventType], s)
+}
+
+// lease leases the events due and returns their deliveries.
+func (d *Dispatcher) lease(ctx context.Context, now, until time.Time, limit int) ([]poller.Job, error) {
+	events, err := d.store.Lease(now, until, limit)
+	if err != nil {
+		return nil, err
+	}
+	jobs := make([]poller.Job, len(events))
+	for i := range events {
+		e := events[i]
+		jobs[i] = func(ctx context.Context) { d.deliver(ctx, e) }
+	}
+	return jobs, nil
+}
+
+// deliver hands the event to its subscribers and records the outcome.
+func (d *Dispatcher) deliver(ctx context.Context, e Event) {
+	d.lock.RLock()
+	subscribers := append(append([]Subscriber{}, d.subscribers[e.Type]...), d.subscribers[AllEvents]...)
+	d.lock.RUnlock()
+
+	var failures []string
+	for _, s := range subscribers {
+		if err := s.Handle(ctx, e); err != nil {
+			failures = append(failures, err.Error())
+		}
+	}
+
+	logger := d.logger.Bg().With(zap.Uint64("event", e.ID), zap.String("type", e.Type))
+	if len(failures) == 0 {
+		if err := d.store.Delivered(e.ID, time.Now()); err != nil {
+			logger.Error("recording delivery", zap.Error(err))
+		}
+		return
+	}
+	attempts := e.Attempts + 1
+	next, retry := d.Retry(attempts)
+	status := StatusPending
+	if !retry {
+		status = StatusDead
+	}
+	reason := strings.Join(failures, "; ")
+	logger.Error("delivering event", zap.Int("attempts", attempts), zap.String("status", status),
+		zap.Time("retry", next), zap.String("reason", reason))
+	if err := d.store.Failed(e.ID, status, attempts, next, reason); err != nil {
+		logger.Error("recording failed delivery", zap.Error(err))
+	}
+}
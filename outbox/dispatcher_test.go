@@ -0,0 +1,223 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/gopheracademy/manager/database"
+	mlog "github.com/gopheracademy/manager/log"
+	"github.com/gopheracademy/manager/migrations"
+	"github.com/gopheracademy/manager/poller"
+	"github.com/gopheracademy/manager/pool"
+)
+
+// memoryStore keeps the outbox in memory, leasing like SQLStore.
+type memoryStore struct {
+	lock   sync.Mutex
+	events map[uint64]*Event
+}
+
+func newMemoryStore(events ...Event) *memoryStore {
+	s := &memoryStore{events: map[uint64]*Event{}}
+	for i := range events {
+		e := events[i]
+		e.ID = uint64(i + 1)
+		s.events[e.ID] = &e
+	}
+	return s
+}
+
+func (s *memoryStore) Lease(now, until time.Time, limit int) ([]Event, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	var leased []Event
+	for _, e := range s.events {
+		if e.Status == StatusPending && e.NextAttemptAt <= now.Unix() {
+			e.NextAttemptAt = until.Unix()
+			leased = append(leased, *e)
+		}
+	}
+	sort.Slice(leased, func(i, j int) bool { return leased[i].ID < leased[j].ID })
+	if len(leased) > limit {
+		leased = leased[:limit]
+	}
+	return leased, nil
+}
+
+func (s *memoryStore) Delivered(id uint64, at time.Time) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.events[id].Status, s.events[id].DeliveredAt, s.events[id].LastError = StatusDelivered, at.Unix(), ""
+	return nil
+}
+
+func (s *memoryStore) Failed(id uint64, status string, attempts int, next time.Time, reason string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	e := s.events[id]
+	e.Status, e.Attempts, e.NextAttemptAt, e.LastError = status, attempts, next.Unix(), reason
+	return nil
+}
+
+func (s *memoryStore) event(id uint64) Event {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return *s.events[id]
+}
+
+// due makes the event due now, as if its backoff passed.
+func (s *memoryStore) due(id uint64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.events[id].NextAttemptAt = time.Now().Unix()
+}
+
+func newTestEvent(t *testing.T, eventType string) Event {
+	t.Helper()
+	e, err := NewEvent(eventType, map[string]int{"claimID": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func newTestDispatcher(t *testing.T, s Store, opts Options) *Dispatcher {
+	t.Helper()
+	p := pool.New(2)
+	t.Cleanup(p.Stop)
+	return NewDispatcher(s, p, opts, mlog.NewFactory(zap.NewNop()))
+}
+
+func TestDispatcherDelivers(t *testing.T) {
+	var lock sync.Mutex
+	var handled, all []string
+	s := newMemoryStore(newTestEvent(t, "claim.created"), newTestEvent(t, "payment.recorded"))
+	d := newTestDispatcher(t, s, Options{})
+	d.Subscribe("claim.created", SubscriberFunc(func(ctx context.Context, e Event) error {
+		lock.Lock()
+		defer lock.Unlock()
+		handled = append(handled, e.Type)
+		return nil
+	}))
+	d.Subscribe(AllEvents, SubscriberFunc(func(ctx context.Context, e Event) error {
+		lock.Lock()
+		defer lock.Unlock()
+		all = append(all, e.Type)
+		return nil
+	}))
+
+	d.Poll()
+	sort.Strings(all)
+	if len(handled) != 1 || handled[0] != "claim.created" || len(all) != 2 || all[0] != "claim.created" || all[1] != "payment.recorded" {
+		t.Errorf("handled %v and %v for all events, want each event once where it is wanted", handled, all)
+	}
+	for id := uint64(1); id <= 2; id++ {
+		if e := s.event(id); e.Status != StatusDelivered || e.DeliveredAt == 0 {
+			t.Errorf("event %d is %s, want it delivered", id, e.Status)
+		}
+	}
+	// delivered events are not leased again.
+	s.due(1)
+	d.Poll()
+	if len(handled) != 1 {
+		t.Errorf("delivered event handled %d times", len(handled))
+	}
+}
+
+func TestDispatcherRetries(t *testing.T) {
+	s := newMemoryStore(newTestEvent(t, "claim.created"))
+	d := newTestDispatcher(t, s, Options{Options: poller.Options{Backoff: time.Minute, MaxBackoff: 90 * time.Second, MaxAttempts: 3}})
+	calls := 0
+	d.Subscribe(AllEvents, SubscriberFunc(func(ctx context.Context, e Event) error {
+		calls++
+		return errors.New("printer on fire")
+	}))
+
+	for attempt, want := range []struct {
+		status  string
+		backoff time.Duration
+	}{
+		{StatusPending, time.Minute},
+		{StatusPending, 90 * time.Second},
+		{StatusDead, 90 * time.Second},
+	} {
+		start := time.Now()
+		d.Poll()
+		e := s.event(1)
+		if e.Status != want.status || e.Attempts != attempt+1 || e.LastError != "printer on fire" {
+			t.Fatalf("after attempt %d the event is %+v, want it %s", attempt+1, e, want.status)
+		}
+		if next := time.Unix(e.NextAttemptAt, 0); next.Before(start.Add(want.backoff-time.Second)) || next.After(time.Now().Add(want.backoff)) {
+			t.Errorf("attempt %d retries at %v, want %v later", attempt+1, next, want.backoff)
+		}
+		// the lease of a failed event ends at its retry.
+		d.Poll()
+		if calls != attempt+1 {
+			t.Fatalf("event handled %d times before its retry, want %d", calls, attempt+1)
+		}
+		s.due(1)
+	}
+	// dead events are not leased again.
+	d.Poll()
+	if calls != 3 {
+		t.Errorf("dead event handled %d times, want 3", calls)
+	}
+}
+
+// TestSQLStore needs an empty postgres database, ie
+//
+//	createdb showrunner_test
+//	SHOWRUNNER_TEST_DATABASE_URL=postgres://localhost/showrunner_test go test ./outbox
+func TestSQLStore(t *testing.T) {
+	url := os.Getenv("SHOWRUNNER_TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("SHOWRUNNER_TEST_DATABASE_URL is not set")
+	}
+	db, err := database.Open(url, log.New(os.Stderr, "", log.LstdFlags))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := migrations.New(db)
+	if _, err := m.Up(); err != nil {
+		t.Fatalf("migrating: %v", err)
+	}
+	defer func() {
+		if _, err := m.Down(len(migrations.All)); err != nil {
+			t.Errorf("cleaning up: %v", err)
+		}
+		db.Close()
+	}()
+	if err := Append(db, newTestEvent(t, "claim.created"), newTestEvent(t, "payment.recorded")); err != nil {
+		t.Fatalf("Append() = %v", err)
+	}
+
+	s := NewSQLStore(db)
+	now := time.Now()
+	first, err := s.Lease(now, now.Add(time.Minute), 1)
+	if err != nil || len(first) != 1 || first[0].Type != "claim.created" || first[0].Status != StatusPending {
+		t.Fatalf("Lease() = %+v, %v; want the first event", first, err)
+	}
+	second, err := s.Lease(now, now.Add(time.Minute), 10)
+	if err != nil || len(second) != 1 || second[0].Type != "payment.recorded" {
+		t.Fatalf("Lease() = %+v, %v; want the second event, the first is leased", second, err)
+	}
+	if err := s.Delivered(first[0].ID, now); err != nil {
+		t.Fatalf("Delivered() = %v", err)
+	}
+	if err := s.Failed(second[0].ID, StatusDead, 10, now, "gone"); err != nil {
+		t.Fatalf("Failed() = %v", err)
+	}
+	// once their lease ends, delivered and dead events are not leased again.
+	later := now.Add(2 * time.Minute)
+	if events, err := s.Lease(later, later.Add(time.Minute), 10); err != nil || len(events) != 0 {
+		t.Errorf("Lease() = %+v, %v; want nothing to deliver", events, err)
+	}
+}
@@ -0,0 +1,148 @@
+// Package outbox delivers domain events to whoever reacts to them (email, badge printing,
+// analytics) without coupling them to the operations emitting the events.
+//
+// Events are appended to the outbox table in the transaction of the operation emitting
+// them, so they are only delivered if the operation committed, and a Dispatcher then
+// delivers them, at least once, to subscribers; the webhooks package subscribes one
+// which delivers them to the webhooks of each conference.
+package outbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ShiftLeftSecurity/gaum/db/chain"
+	"github.com/ShiftLeftSecurity/gaum/db/connection"
+)
+
+const tableOutbox = "outbox"
+
+// The status of an Event.
+const (
+	StatusPending   = "pending"
+	StatusDelivered = "delivered"
+	// StatusDead events failed every delivery, they are only delivered again once their
+	// status is set back to pending by hand.
+	StatusDead = "dead"
+)
+
+// Event is something that happened, its Payload is the JSON of a type documented along
+// with its Type, ie ticketing.ClaimCreated.
+type Event struct {
+	ID      uint64 `gaum:"field_name:id" json:"id"`
+	Type    string `gaum:"field_name:type" json:"type"`
+	Payload string `gaum:"field_name:payload" json:"-"`
+	// CreatedAt is the Unix timestamp of when the event happened.
+	CreatedAt int64  `gaum:"field_name:created_at" json:"createdAt"`
+	Status    string `gaum:"field_name:status" json:"-"`
+	// Attempts counts the failed deliveries.
+	Attempts int `gaum:"field_name:attempts" json:"-"`
+	// NextAttemptAt is the Unix timestamp of the next delivery.
+	NextAttemptAt int64 `gaum:"field_name:next_attempt_at" json:"-"`
+	// DeliveredAt is the Unix timestamp of the delivery, 0 until delivered.
+	DeliveredAt int64 `gaum:"field_name:delivered_at" json:"-"`
+	// LastError is why the last delivery failed.
+	LastError string `gaum:"field_name:last_error" json:"-"`
+}
+
+// NewEvent returns an event of the type happening now, payload is encoded as JSON.
+func NewEvent(eventType string, payload interface{}) (Event, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return Event{}, fmt.Errorf("encoding %s event: %w", eventType, err)
+	}
+	now := time.Now().Unix()
+	return Event{Type: eventType, Payload: string(raw), CreatedAt: now, Status: StatusPending, NextAttemptAt: now}, nil
+}
+
+// Decode decodes the payload of the event into v.
+func (e Event) Decode(v interface{}) error {
+	if err := json.Unmarshal([]byte(e.Payload), v); err != nil {
+		return fmt.Errorf("decoding %s event %d: %w", e.Type, e.ID, err)
+	}
+	return nil
+}
+
+// Append writes events to the outbox through conn, pass the transaction of the operation
+// emitting them.
+func Append(conn connection.DB, events ...Event) error {
+	for _, e := range events {
+		err := chain.New(conn).Insert(map[string]interface{}{
+			"type":            e.Type,
+			"payload":         e.Payload,
+			"created_at":      e.CreatedAt,
+			"next_attempt_at": e.NextAttemptAt,
+		}).Table(tableOutbox).Exec()
+		if err != nil {
+			return fmt.Errorf("appending %s event to the outbox: %w", e.Type, err)
+		}
+	}
+	return nil
+}
+
+// Store reads and updates the events to deliver.
+type Store interface {
+	// Lease returns up to limit pending events due at now and makes them due again at
+	// until, so that several dispatchers do not deliver them at once.
+	Lease(now, until time.Time, limit int) ([]Event, error)
+	// Delivered records that the event was delivered.
+	Delivered(id uint64, at time.Time) error
+	// Failed records that delivering the event failed, with its status after the attempt
+	// and when to retry if it is still pending.
+	Failed(id uint64, status string, attempts int, next time.Time, reason string) error
+}
+
+// SQLStore keeps the outbox in a postgres-like db.
+type SQLStore struct {
+	conn connection.DB
+}
+
+var _ Store = &SQLStore{}
+
+// NewSQLStore returns a SQLStore using the passed connection.
+func NewSQLStore(conn connection.DB) *SQLStore {
+	return &SQLStore{conn: conn}
+}
+
+// Lease implements Store
+func (s *SQLStore) Lease(now, until time.Time, limit int) ([]Event, error) {
+	results := []Event{}
+	err := chain.New(s.conn).UpdateMap(map[string]interface{}{"next_attempt_at": until.Unix()}).
+		Table(tableOutbox).
+		AndWhere("id IN (SELECT id FROM "+tableOutbox+
+			" WHERE status = ? AND next_attempt_at <= ? ORDER BY id LIMIT ? FOR UPDATE SKIP LOCKED)",
+			StatusPending, now.Unix(), limit).
+		Returning("*").Fetch(&results)
+	if err != nil {
+		return nil, fmt.Errorf("leasing outbox events: %w", err)
+	}
+	return results, nil
+}
+
+// Delivered implements Store
+func (s *SQLStore) Delivered(id uint64, at time.Time) error {
+	err := chain.New(s.conn).UpdateMap(map[string]interface{}{
+		"status":       StatusDelivered,
+		"delivered_at": at.Unix(),
+		"last_error":   "",
+	}).Table(tableOutbox).AndWhere("id = ?", id).Exec()
+	if err != nil {
+		return fmt.Errorf("marking outbox event %d delivered: %w", id, err)
+	}
+	return nil
+}
+
+// Failed implements Store
+func (s *SQLStore) Failed(id uint64, status string, attempts int, next time.Time, reason string) error {
+	err := chain.New(s.conn).UpdateMap(map[string]interface{}{
+		"status":          status,
+		"attempts":        attempts,
+		"next_attempt_at": next.Unix(),
+		"last_error":      reason,
+	}).Table(tableOutbox).AndWhere("id = ?", id).Exec()
+	if err != nil {
+		return fmt.Errorf("recording failed delivery of outbox event %d: %w", id, err)
+	}
+	return nil
+}
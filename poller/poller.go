@@ -0,0 +1,147 @@
+// Package poller works through what is queued in a table to be done in the background,
+// ie outbox events, webhook deliveries and emails: it polls what is due, leases it so
+// several instances do not do it at once, does it on the workers of a pool and has what
+// failed retried with exponential backoff until it failed too many times.
+package poller
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/gopheracademy/manager/log"
+	"github.com/gopheracademy/manager/pool"
+)
+
+// Options configure a Poller, the zero value of each is replaced by its default.
+type Options struct {
+	// Interval is how often what is due is polled, defaults to 1s.
+	Interval time.Duration
+	// Batch is how many are leased per poll, defaults to 50.
+	Batch int
+	// Lease is how long doing one may take before it is leased again, defaults to 1m.
+	Lease time.Duration
+	// Backoff is the wait before the first retry, it doubles on each retry up to
+	// MaxBackoff; they default to 10s and 1h.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// MaxAttempts is how many failed attempts make one be given up on, defaults to 10.
+	MaxAttempts int
+}
+
+// Job does one of what was leased, ctx is done when its lease ends.
+type Job func(ctx context.Context)
+
+// LeaseFunc returns the jobs of up to limit of what is due at now and makes them due
+// again at until, so they are retried if they are not done by then.
+type LeaseFunc func(ctx context.Context, now, until time.Time, limit int) ([]Job, error)
+
+// Poller runs the jobs leased every Interval on the workers of a pool.
+type Poller struct {
+	name   string
+	lease  LeaseFunc
+	pool   *pool.Pool
+	opts   Options
+	logger log.Factory
+
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+// New returns a Poller running the jobs of lease on the workers of p, name says what is
+// polled in its logs; call Start to begin polling.
+func New(name string, lease LeaseFunc, p *pool.Pool, opts Options, logger log.Factory) *Poller {
+	if opts.Interval == 0 {
+		opts.Interval = time.Second
+	}
+	if opts.Batch == 0 {
+		opts.Batch = 50
+	}
+	if opts.Lease == 0 {
+		opts.Lease = time.Minute
+	}
+	if opts.Backoff == 0 {
+		opts.Backoff = 10 * time.Second
+	}
+	if opts.MaxBackoff == 0 {
+		opts.MaxBackoff = time.Hour
+	}
+	if opts.MaxAttempts == 0 {
+		opts.MaxAttempts = 10
+	}
+	return &Poller{
+		name:    name,
+		lease:   lease,
+		pool:    p,
+		opts:    opts,
+		logger:  logger,
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+}
+
+// Start polls until Stop is called.
+func (p *Poller) Start() {
+	go func() {
+		defer close(p.stopped)
+		ticker := time.NewTicker(p.opts.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-p.stop:
+				return
+			case <-ticker.C:
+				p.Poll()
+			}
+		}
+	}()
+}
+
+// Stop stops polling and waits for the jobs in progress, call it before stopping the
+// pool.
+func (p *Poller) Stop() {
+	close(p.stop)
+	<-p.stopped
+}
+
+// Poll leases what is due and returns once its jobs are done.
+func (p *Poller) Poll() {
+	now := time.Now()
+	jobs, err := p.lease(context.Background(), now, now.Add(p.opts.Lease), p.opts.Batch)
+	if err != nil {
+		p.logger.Bg().Error("polling "+p.name, zap.Error(err))
+		return
+	}
+	var inflight sync.WaitGroup
+	for i := range jobs {
+		job := jobs[i]
+		inflight.Add(1)
+		p.pool.Execute(func() {
+			defer inflight.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), p.opts.Lease)
+			defer cancel()
+			job(ctx)
+		})
+	}
+	inflight.Wait()
+}
+
+// Retry returns when to retry what failed attempts times, and false if it failed too
+// many times to be retried.
+func (p *Poller) Retry(attempts int) (time.Time, bool) {
+	return time.Now().Add(p.backoff(attempts)), attempts < p.opts.MaxAttempts
+}
+
+// backoff returns the wait before retrying what failed attempts times.
+func (p *Poller) backoff(attempts int) time.Duration {
+	wait := p.opts.Backoff
+	for i := 1; i < attempts && wait < p.opts.MaxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > p.opts.MaxBackoff {
+		wait = p.opts.MaxBackoff
+	}
+	return wait
+}
@@ -0,0 +1,96 @@
+package poller
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/gopheracademy/manager/log"
+	"github.com/gopheracademy/manager/pool"
+)
+
+func TestPoll(t *testing.T) {
+	p := pool.New(2)
+	defer p.Stop()
+	var lock sync.Mutex
+	var done []int
+	var leased time.Duration
+	fail := false
+	poller := New("jobs", func(ctx context.Context, now, until time.Time, limit int) ([]Job, error) {
+		if fail {
+			return nil, errors.New("db is down")
+		}
+		leased = until.Sub(now)
+		var jobs []Job
+		for i := 0; i < limit; i++ {
+			i := i
+			jobs = append(jobs, func(ctx context.Context) {
+				if _, ok := ctx.Deadline(); !ok {
+					t.Errorf("job %d has no deadline", i)
+				}
+				lock.Lock()
+				defer lock.Unlock()
+				done = append(done, i)
+			})
+		}
+		return jobs, nil
+	}, p, Options{Batch: 3, Lease: time.Minute}, log.NewFactory(zap.NewNop()))
+
+	poller.Poll()
+	if len(done) != 3 || leased != time.Minute {
+		t.Errorf("Poll() did %d jobs leased for %v, want 3 for 1m", len(done), leased)
+	}
+	fail = true
+	poller.Poll()
+	if len(done) != 3 {
+		t.Errorf("Poll() did %d jobs after failing to lease, want 3", len(done))
+	}
+}
+
+func TestStartStop(t *testing.T) {
+	p := pool.New(1)
+	defer p.Stop()
+	polled := make(chan struct{}, 1)
+	finished := false
+	poller := New("jobs", func(ctx context.Context, now, until time.Time, limit int) ([]Job, error) {
+		return []Job{func(ctx context.Context) {
+			select {
+			case polled <- struct{}{}:
+			default:
+			}
+			time.Sleep(10 * time.Millisecond)
+			finished = true
+		}}, nil
+	}, p, Options{Interval: time.Millisecond}, log.NewFactory(zap.NewNop()))
+	poller.Start()
+	<-polled
+	poller.Stop()
+	if !finished {
+		t.Error("Stop() returned before the job in progress finished")
+	}
+}
+
+func TestRetry(t *testing.T) {
+	poller := New("jobs", nil, nil, Options{Backoff: 10 * time.Second, MaxBackoff: time.Minute, MaxAttempts: 5},
+		log.NewFactory(zap.NewNop()))
+	for attempts, want := range map[int]time.Duration{
+		1: 10 * time.Second,
+		2: 20 * time.Second,
+		3: 40 * time.Second,
+		4: time.Minute,
+		9: time.Minute,
+	} {
+		start := time.Now()
+		next, retry := poller.Retry(attempts)
+		if wait := next.Sub(start); wait < want || wait > want+time.Second {
+			t.Errorf("Retry(%d) waits %v, want %v", attempts, wait, want)
+		}
+		if retry != (attempts < 5) {
+			t.Errorf("Retry(%d) retries %v, want %v", attempts, retry, attempts < 5)
+		}
+	}
+}
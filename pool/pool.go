@@ -15,18 +15,28 @@
 
 package pool
 
+import "sync"
+
 // Pool is a simple worker pool
 type Pool struct {
-	jobs chan func()
-	stop chan struct{}
+	jobs     chan func()
+	stop     chan struct{}
+	stopOnce sync.Once
+	workers  sync.WaitGroup
 }
 
 // New creates a new pool with the given number of workers
 func New(workers int) *Pool {
 	jobs := make(chan func())
 	stop := make(chan struct{})
+	p := &Pool{
+		jobs: jobs,
+		stop: stop,
+	}
+	p.workers.Add(workers)
 	for i := 0; i < workers; i++ {
 		go func() {
+			defer p.workers.Done()
 			for {
 				select {
 				case job := <-jobs:
@@ -37,10 +47,7 @@ func New(workers int) *Pool {
 			}
 		}()
 	}
-	return &Pool{
-		jobs: jobs,
-		stop: stop,
-	}
+	return p
 }
 
 // Execute enqueues the job to be executed by one of the workers in the pool
@@ -48,9 +55,11 @@ func (p *Pool) Execute(job func()) {
 	p.jobs <- job
 }
 
-// Stop halts all the workers
+// Stop halts all the workers, it returns once the jobs they were running are done and
+// can be called more than once. Jobs must not be enqueued after Stop.
 func (p *Pool) Stop() {
-	if p.stop != nil {
+	p.stopOnce.Do(func() {
 		close(p.stop)
-	}
+	})
+	p.workers.Wait()
 }
@@ -0,0 +1,290 @@
+package public
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"github.com/gopheracademy/manager/def"
+	"github.com/gopheracademy/manager/log"
+)
+
+// PathPrefix is where the public API is served from.
+const PathPrefix = "/api/public/"
+
+// ConferenceReader reads a conference with all its events, it returns nil if there is
+// no conference for the slug.
+type ConferenceReader interface {
+	ConferenceBySlug(ctx context.Context, slug string) (*def.Conference, error)
+}
+
+var errNotFound = errors.New("not found")
+
+// Handler serves the read-only API consumed by the marketing website, it exposes only
+// what can be shown to anyone over cacheable GET requests:
+//
+//	/api/public/conferences/{conference}/events published events of a conference.
+//	/api/public/conferences/{conference}/events/{event} a published event.
+//	/api/public/conferences/{conference}/events/{event}/slots slots on sale to the public.
+//	/api/public/conferences/{conference}/events/{event}/sponsors sponsors by level.
+//	/api/public/conferences/{conference}/events/{event}/schedule the event schedule.
+type Handler struct {
+	router      *mux.Router
+	conferences ConferenceReader
+	cache       *Cache
+	logger      log.Factory
+}
+
+// NewHandler returns a Handler reading from conferences and caching in cache, which
+// must be invalidated by whoever writes conferences.
+func NewHandler(conferences ConferenceReader, cache *Cache, logger log.Factory) *Handler {
+	h := &Handler{
+		router:      mux.NewRouter(),
+		conferences: conferences,
+		cache:       cache,
+		logger:      logger,
+	}
+	r := h.router.PathPrefix(PathPrefix+"conferences/{conference}").
+		Methods(http.MethodGet, http.MethodHead).Subrouter()
+	r.HandleFunc("/events", h.serve(h.events))
+	r.HandleFunc("/events/{event}", h.serve(h.event))
+	r.HandleFunc("/events/{event}/slots", h.serve(h.slots))
+	r.HandleFunc("/events/{event}/sponsors", h.serve(h.sponsors))
+	r.HandleFunc("/events/{event}/schedule", h.serve(h.schedule))
+	return h
+}
+
+// ServeHTTP implements http.Handler
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.router.ServeHTTP(w, r)
+}
+
+// Event is the public view of an event.
+type Event struct {
+	Name      string `json:"name"`
+	Slug      string `json:"slug"`
+	StartDate uint64 `json:"startDate"`
+	EndDate   uint64 `json:"endDate"`
+	Location  string `json:"location"`
+	TimeZone  string `json:"timeZone"`
+}
+
+// Slot is the public view of an event slot on sale.
+type Slot struct {
+	ID                uint32 `json:"id"`
+	Name              string `json:"name"`
+	Description       string `json:"description"`
+	Cost              int    `json:"cost"`
+	StartDate         uint64 `json:"startDate"`
+	EndDate           uint64 `json:"endDate"`
+	PurchaseableFrom  uint64 `json:"purchaseableFrom"`
+	PurchaseableUntil uint64 `json:"purchaseableUntil"`
+}
+
+// SponsorLevel holds the sponsors of one level.
+type SponsorLevel struct {
+	Level    string    `json:"level"`
+	Sponsors []Sponsor `json:"sponsors"`
+}
+
+// Sponsor is the public profile of a sponsor.
+type Sponsor struct {
+	Name    string `json:"name"`
+	Website string `json:"website"`
+	Logo    string `json:"logo"`
+	Profile string `json:"profile"`
+}
+
+// Schedule lists what happens during an event in chronological order.
+type Schedule struct {
+	TimeZone string         `json:"timeZone"`
+	Items    []ScheduleItem `json:"items"`
+}
+
+// ScheduleItem is one activity of the schedule.
+type ScheduleItem struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	StartDate   uint64 `json:"startDate"`
+	EndDate     uint64 `json:"endDate"`
+}
+
+// serve returns a handler that renders what build returns, from the cache if possible.
+func (h *Handler) serve(build func(*http.Request) (interface{}, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.URL.Path
+		e, generation := h.cache.get(key)
+		if e == nil {
+			v, err := build(r)
+			if errors.Is(err, errNotFound) {
+				writeError(w, http.StatusNotFound, "not found")
+				return
+			}
+			if err != nil {
+				h.logger.For(r.Context()).Error("building public response", zap.String("path", key), zap.Error(err))
+				writeError(w, http.StatusInternalServerError, "internal error")
+				return
+			}
+			body, err := json.Marshal(v)
+			if err != nil {
+				h.logger.For(r.Context()).Error("encoding public response", zap.String("path", key), zap.Error(err))
+				writeError(w, http.StatusInternalServerError, "internal error")
+				return
+			}
+			e = h.cache.put(key, body, generation)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("ETag", e.etag)
+		w.Header().Set("Cache-Control", "public, max-age=60")
+		// the marketing site is not necessarily served by us.
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		// ServeContent takes care of If-None-Match and If-Modified-Since.
+		http.ServeContent(w, r, "", e.modified, bytes.NewReader(e.body))
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
+
+// publishedEvent returns the event addressed by the request if it is live.
+func (h *Handler) publishedEvent(r *http.Request) (*def.Event, error) {
+	vars := mux.Vars(r)
+	c, err := h.conferences.ConferenceBySlug(r.Context(), vars["conference"])
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, errNotFound
+	}
+	for i := range c.Events {
+		if e := &c.Events[i]; e.Slug == vars["event"] && e.Live {
+			return e, nil
+		}
+	}
+	return nil, errNotFound
+}
+
+func (h *Handler) events(r *http.Request) (interface{}, error) {
+	c, err := h.conferences.ConferenceBySlug(r.Context(), mux.Vars(r)["conference"])
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, errNotFound
+	}
+	events := []Event{}
+	for i := range c.Events {
+		if c.Events[i].Live {
+			events = append(events, publicEvent(&c.Events[i]))
+		}
+	}
+	return events, nil
+}
+
+func (h *Handler) event(r *http.Request) (interface{}, error) {
+	e, err := h.publishedEvent(r)
+	if err != nil {
+		return nil, err
+	}
+	return publicEvent(e), nil
+}
+
+func (h *Handler) slots(r *http.Request) (interface{}, error) {
+	e, err := h.publishedEvent(r)
+	if err != nil {
+		return nil, err
+	}
+	slots := []Slot{}
+	for _, s := range e.Slots {
+		if !s.AvailableToPublic {
+			continue
+		}
+		slots = append(slots, Slot{
+			ID:                s.ID,
+			Name:              s.Name,
+			Description:       s.Description,
+			Cost:              s.Cost,
+			StartDate:         s.StartDate,
+			EndDate:           s.EndDate,
+			PurchaseableFrom:  s.PurchaseableFrom,
+			PurchaseableUntil: s.PurchaseableUntil,
+		})
+	}
+	return slots, nil
+}
+
+func (h *Handler) sponsors(r *http.Request) (interface{}, error) {
+	e, err := h.publishedEvent(r)
+	if err != nil {
+		return nil, err
+	}
+	byLevel := map[string][]Sponsor{}
+	for _, s := range e.Sponsors {
+		byLevel[s.Level] = append(byLevel[s.Level], Sponsor{
+			Name:    s.Name,
+			Website: s.Website,
+			Logo:    s.Logo,
+			Profile: s.Profile,
+		})
+	}
+	levels := []SponsorLevel{}
+	for _, level := range e.SponsorshipLevels {
+		if sponsors, ok := byLevel[level]; ok {
+			levels = append(levels, SponsorLevel{Level: level, Sponsors: sponsors})
+			delete(byLevel, level)
+		}
+	}
+	// sponsors with a level the event does not list go last, in a stable order.
+	unlisted := make([]string, 0, len(byLevel))
+	for level := range byLevel {
+		unlisted = append(unlisted, level)
+	}
+	sort.Strings(unlisted)
+	for _, level := range unlisted {
+		levels = append(levels, SponsorLevel{Level: level, Sponsors: byLevel[level]})
+	}
+	return levels, nil
+}
+
+func (h *Handler) schedule(r *http.Request) (interface{}, error) {
+	e, err := h.publishedEvent(r)
+	if err != nil {
+		return nil, err
+	}
+	schedule := Schedule{TimeZone: e.TimeZone, Items: []ScheduleItem{}}
+	for _, s := range e.Slots {
+		if !s.AvailableToPublic || s.StartDate == 0 {
+			continue
+		}
+		schedule.Items = append(schedule.Items, ScheduleItem{
+			Name:        s.Name,
+			Description: s.Description,
+			StartDate:   s.StartDate,
+			EndDate:     s.EndDate,
+		})
+	}
+	sort.SliceStable(schedule.Items, func(i, j int) bool {
+		return schedule.Items[i].StartDate < schedule.Items[j].StartDate
+	})
+	return schedule, nil
+}
+
+func publicEvent(e *def.Event) Event {
+	return Event{
+		Name:      e.Name,
+		Slug:      e.Slug,
+		StartDate: e.StartDate,
+		EndDate:   e.EndDate,
+		Location:  e.Location,
+		TimeZone:  e.TimeZone,
+	}
+}
@@ -0,0 +1,114 @@
+package public
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// Cache holds rendered responses of the public API, it is invalidated as a whole on any
+// write since public documents overlap (ie a slot change alters both the slots and the
+// schedule of an event) and writes are rare compared to reads.
+// Entries also expire after a TTL so changes made by other instances eventually show,
+// and are bounded in number since anyone can request any path; responses that fail,
+// ie not found, are never cached.
+type Cache struct {
+	ttl  time.Duration
+	size int
+
+	mu         sync.RWMutex
+	entries    map[string]*entry
+	modified   time.Time
+	generation uint64
+}
+
+type entry struct {
+	body     []byte
+	etag     string
+	modified time.Time
+	expires  time.Time
+}
+
+// NewCache returns an empty Cache holding at most size entries which live at most ttl.
+func NewCache(ttl time.Duration, size int) *Cache {
+	return &Cache{
+		ttl:      ttl,
+		size:     size,
+		entries:  map[string]*entry{},
+		modified: time.Now().UTC(),
+	}
+}
+
+// Invalidate drops every cached response, it must be called after any write to data
+// exposed publicly.
+func (c *Cache) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = map[string]*entry{}
+	c.modified = time.Now().UTC()
+	c.generation++
+}
+
+// get returns the entry for key if present, otherwise the current generation which
+// must be passed to put once the content is built.
+func (c *Cache) get(key string) (*entry, uint64) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	e, ok := c.entries[key]
+	if !ok || time.Now().After(e.expires) {
+		return nil, c.generation
+	}
+	return e, c.generation
+}
+
+// put stores the body for key, the modification time is that of the last write we
+// know of, which is the earliest the content could have changed, or the time we noticed
+// a change made by another instance.
+// If the cache was invalidated since generation the body might be stale so it is not
+// stored, but an entry is returned anyway to serve the ongoing request.
+func (c *Cache) put(key string, body []byte, generation uint64) *entry {
+	sum := sha256.Sum256(body)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e := &entry{
+		body:     body,
+		etag:     `"` + hex.EncodeToString(sum[:16]) + `"`,
+		modified: c.modified,
+		expires:  time.Now().Add(c.ttl),
+	}
+	if prev, ok := c.entries[key]; ok {
+		// the entry expired, if it changed it was written somewhere else.
+		if prev.etag == e.etag {
+			e.modified = prev.modified
+		} else {
+			e.modified = time.Now().UTC()
+		}
+	}
+	if generation == c.generation {
+		if _, ok := c.entries[key]; !ok && len(c.entries) >= c.size {
+			c.evict()
+		}
+		c.entries[key] = e
+	}
+	return e
+}
+
+// evict drops the expired entries, or the one expiring first if none did, to make room
+// for another; it must be called with the lock held.
+func (c *Cache) evict() {
+	now := time.Now()
+	var first string
+	for key, e := range c.entries {
+		if now.After(e.expires) {
+			delete(c.entries, key)
+			continue
+		}
+		if first == "" || e.expires.Before(c.entries[first].expires) {
+			first = key
+		}
+	}
+	if len(c.entries) >= c.size {
+		delete(c.entries, first)
+	}
+}
@@ -0,0 +1,97 @@
+package public
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/gopheracademy/manager/def"
+	"github.com/gopheracademy/manager/log"
+)
+
+func TestCache(t *testing.T) {
+	c := NewCache(time.Minute, 2)
+	for i := 0; i < 3; i++ {
+		key := fmt.Sprint(i)
+		_, generation := c.get(key)
+		c.put(key, []byte(key), generation)
+		if i == 0 {
+			c.entries[key].expires = time.Now().Add(time.Second)
+		}
+	}
+	if len(c.entries) != 2 {
+		t.Fatalf("cache holds %d entries, want at most 2", len(c.entries))
+	}
+	if e, _ := c.get("0"); e != nil {
+		t.Error("the entry expiring first was not evicted")
+	}
+	if e, _ := c.get("2"); e == nil || string(e.body) != "2" {
+		t.Errorf("got %+v, want the last entry put", e)
+	}
+
+	// what was built before an invalidation is served but not cached.
+	_, generation := c.get("3")
+	c.Invalidate()
+	if e := c.put("3", []byte("stale"), generation); e == nil || string(e.body) != "stale" {
+		t.Errorf("put() = %+v, want the stale body to serve", e)
+	}
+	if e, _ := c.get("3"); e != nil || len(c.entries) != 0 {
+		t.Errorf("cached %+v built before the invalidation", e)
+	}
+}
+
+// conferenceReader reads one conference and counts the reads.
+type conferenceReader struct {
+	conference def.Conference
+	reads      int
+}
+
+func (r *conferenceReader) ConferenceBySlug(ctx context.Context, slug string) (*def.Conference, error) {
+	r.reads++
+	if slug != r.conference.Slug {
+		return nil, nil
+	}
+	return &r.conference, nil
+}
+
+func TestHandlerCaches(t *testing.T) {
+	conferences := &conferenceReader{conference: def.Conference{Slug: "gophercon", Events: []def.Event{
+		{Name: "GopherCon 2021", Slug: "2021", Live: true},
+	}}}
+	cache := NewCache(time.Minute, 10)
+	h := NewHandler(conferences, cache, log.NewFactory(zap.NewNop()))
+	get := func(path string) int {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, PathPrefix+path, nil))
+		return w.Code
+	}
+
+	for i := 0; i < 2; i++ {
+		if status := get("conferences/gophercon/events/2021"); status != http.StatusOK {
+			t.Fatalf("answered %d, want 200", status)
+		}
+	}
+	if conferences.reads != 1 {
+		t.Errorf("read the conference %d times, want it cached", conferences.reads)
+	}
+	cache.Invalidate()
+	get("conferences/gophercon/events/2021")
+	if conferences.reads != 2 {
+		t.Errorf("read the conference %d times, want it read again once invalidated", conferences.reads)
+	}
+
+	// misses are not cached.
+	for i := 0; i < 2; i++ {
+		if status := get("conferences/gopherconf/events/2021"); status != http.StatusNotFound {
+			t.Fatalf("answered %d, want 404", status)
+		}
+	}
+	if conferences.reads != 4 || len(cache.entries) != 1 {
+		t.Errorf("read the conference %d times and cached %d entries, want misses read each time", conferences.reads, len(cache.entries))
+	}
+}
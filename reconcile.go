@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/gopheracademy/manager/reconcile"
+	"github.com/gopheracademy/manager/ticketing"
+)
+
+const reconcileUsage = `usage: manager reconcile [flags] export.csv
+
+Matches the charges of a payment provider's balance or payout export with the money
+recorded for payments, by reference, and posts the fees the provider took on matched
+charges to the ledger, once per transaction. Exports are read as Stripe writes them,
+or with -columns for other providers, ie -columns ref=Reference,amount=Gross,fee=Fee.
+Exits with 3 if a charge is missing on either side, duplicated or of another amount.
+Amounts are in cents.
+
+`
+
+// reconcileExport implements the reconcile subcommand and returns the exit code.
+func reconcileExport(args []string) int {
+	fs, config := commandFlags("reconcile", reconcileUsage)
+	mapping := fs.String("columns", "", "field=header pairs, comma separated, of the columns of the export; fields are id, type, ref, amount, fee, net, created and payout")
+	cents := fs.Bool("cents", false, "amounts of the export are in cents, not decimals, with -columns")
+	provider := fs.String("provider", "stripe", "name of the provider, in the memo of the fees posted")
+	dryRun := fs.Bool("dry-run", false, "report the fees without posting them")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	columns := reconcile.Stripe
+	if *mapping != "" {
+		var err error
+		if columns, err = reconcile.ParseColumns(*mapping, *cents); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+	}
+	cfg, err := config()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer f.Close()
+	rows, err := reconcile.Read(f, columns)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "reading %s: %v\n", fs.Arg(0), err)
+		return 1
+	}
+	db, err := openDatabase(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	report, err := reconcile.Reconcile(context.Background(), ticketing.NewSQLStorageFromConnection(db), rows,
+		reconcile.Options{Provider: *provider, DryRun: *dryRun})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	printReconciliation(os.Stdout, report, *dryRun)
+	if !report.Reconciled() {
+		return 3
+	}
+	return 0
+}
+
+func printReconciliation(w io.Writer, report *reconcile.Report, dryRun bool) {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "issue\tline\tref\tprovider\tpayment\trecorded\t")
+	for _, m := range report.Mismatched {
+		fmt.Fprintf(tw, "mismatched\t%d\t%s\t%d\t%d\t%d\t\n", m.Line, m.Ref, m.Amount, m.Money.ClaimPaymentID, m.Money.Amount)
+	}
+	for _, d := range report.Duplicates {
+		for _, r := range d.Rows {
+			fmt.Fprintf(tw, "duplicated\t%d\t%s\t%d\t\t\t\n", r.Line, d.Ref, r.Amount)
+		}
+		for _, m := range d.Money {
+			fmt.Fprintf(tw, "duplicated\t\t%s\t\t%d\t%d\t\n", d.Ref, m.ClaimPaymentID, m.Amount)
+		}
+	}
+	for _, r := range report.Unrecorded {
+		fmt.Fprintf(tw, "unrecorded\t%d\t%s\t%d\t\t\t\n", r.Line, r.Ref, r.Amount)
+	}
+	for _, m := range report.Missing {
+		fmt.Fprintf(tw, "missing\t\t%s\t\t%d\t%d\t\n", m.PaymentRef, m.ClaimPaymentID, m.Amount)
+	}
+	tw.Flush()
+	fmt.Fprintln(w)
+
+	fees := "fees posted"
+	if dryRun {
+		fees = "fees to post"
+	}
+	tw = tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+	for _, row := range []struct {
+		name  string
+		count int
+	}{
+		{"rows", report.Rows},
+		{"matched", len(report.Matched)},
+		{"mismatched", len(report.Mismatched)},
+		{"duplicated", len(report.Duplicates)},
+		{"unrecorded", len(report.Unrecorded)},
+		{"missing", len(report.Missing)},
+		{"payouts", len(report.Payouts)},
+		{"skipped", len(report.Skipped)},
+		{fees, len(report.Fees)},
+	} {
+		fmt.Fprintf(tw, "%s\t%d\t\n", row.name, row.count)
+	}
+	fmt.Fprintf(tw, "fee total\t%d\t\n", report.FeesTotal)
+	tw.Flush()
+}
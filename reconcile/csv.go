@@ -0,0 +1,251 @@
+// Package reconcile matches the balance and payout exports of payment providers with the
+// money recorded by ticketing, so finance does not have to do it by hand.
+//
+// Exports are read as CSV, either as Stripe writes them or through a mapping of their
+// columns, into Rows; Reconcile then matches the charges among them to the
+// PaymentMethodMoney with their reference and records the fees the provider took in the
+// ledger.
+package reconcile
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Row is a transaction of a provider export, amounts are in cents.
+type Row struct {
+	// Line is the line of the export the row was read from, the header is line 1.
+	Line int
+	// ID is the provider's ID of the transaction, ie txn_1.
+	ID string
+	// Type is the kind of transaction, ie charge or payout, lower cased; rows of exports
+	// without types are charges.
+	Type string
+	// Ref is what the charge is recorded with, the PaymentRef of our money.
+	Ref     string
+	Amount  int64
+	Fee     int64
+	Net     int64
+	Created time.Time
+	// Payout is the payout the transaction was paid out in, if known.
+	Payout string
+}
+
+// The types of rows Reconcile knows, those of other types are skipped.
+const (
+	TypeCharge  = "charge"
+	TypePayment = "payment"
+	TypePayout  = "payout"
+)
+
+// IsCharge returns true if the row is money taken for us, to match with our records.
+func (r Row) IsCharge() bool {
+	return r.Type == "" || r.Type == TypeCharge || r.Type == TypePayment
+}
+
+// Columns maps the fields of a Row to the columns of an export, by header; each field
+// lists the headers it may have, the first found is used, case aside. Ref and Amount
+// are required, the other columns are read when the export has them.
+type Columns struct {
+	ID      []string
+	Type    []string
+	Ref     []string
+	Amount  []string
+	Fee     []string
+	Net     []string
+	Created []string
+	Payout  []string
+	// Cents is true if amounts are in cents, they are decimals in the currency otherwise
+	// (ie 500.00).
+	Cents bool
+}
+
+// Stripe are the columns of the balance history and payout reconciliation exports of
+// Stripe.
+var Stripe = Columns{
+	ID:      []string{"id", "balance_transaction_id"},
+	Type:    []string{"Type", "reporting_category"},
+	Ref:     []string{"Source", "source_id", "charge_id"},
+	Amount:  []string{"Amount", "gross"},
+	Fee:     []string{"Fee"},
+	Net:     []string{"Net"},
+	Created: []string{"Created (UTC)", "created_utc", "created"},
+	Payout:  []string{"Transfer", "automatic_payout_id", "payout_id"},
+}
+
+// ParseColumns returns the Columns described by mapping, ie
+// "ref=Reference,amount=Gross,fee=Fee", with the fields id, type, ref, amount, fee, net,
+// created and payout.
+func ParseColumns(mapping string, cents bool) (Columns, error) {
+	c := Columns{Cents: cents}
+	fields := map[string]*[]string{
+		"id":      &c.ID,
+		"type":    &c.Type,
+		"ref":     &c.Ref,
+		"amount":  &c.Amount,
+		"fee":     &c.Fee,
+		"net":     &c.Net,
+		"created": &c.Created,
+		"payout":  &c.Payout,
+	}
+	for _, pair := range strings.Split(mapping, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[1]) == "" {
+			return Columns{}, fmt.Errorf("column mapping %q is not field=header", pair)
+		}
+		field, ok := fields[strings.ToLower(strings.TrimSpace(parts[0]))]
+		if !ok {
+			return Columns{}, fmt.Errorf("unknown field %q in the column mapping", parts[0])
+		}
+		*field = []string{strings.TrimSpace(parts[1])}
+	}
+	if len(c.Ref) == 0 || len(c.Amount) == 0 {
+		return Columns{}, fmt.Errorf("the column mapping needs at least ref and amount")
+	}
+	return c, nil
+}
+
+// Read reads the rows of an export with a header, mapped by columns.
+func Read(r io.Reader, columns Columns) ([]Row, error) {
+	records := csv.NewReader(r)
+	records.FieldsPerRecord = -1
+	header, err := records.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading the header: %w", err)
+	}
+	index := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if _, ok := index[name]; !ok {
+			index[name] = i
+		}
+	}
+	find := func(names []string) int {
+		for _, name := range names {
+			if i, ok := index[strings.ToLower(name)]; ok {
+				return i
+			}
+		}
+		return -1
+	}
+	var (
+		id      = find(columns.ID)
+		kind    = find(columns.Type)
+		ref     = find(columns.Ref)
+		amount  = find(columns.Amount)
+		fee     = find(columns.Fee)
+		net     = find(columns.Net)
+		created = find(columns.Created)
+		payout  = find(columns.Payout)
+	)
+	if ref < 0 || amount < 0 {
+		return nil, fmt.Errorf("the export lacks a reference or an amount column, it has %s", strings.Join(header, ", "))
+	}
+
+	var rows []Row
+	for line := 2; ; line++ {
+		record, err := records.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading line %d: %w", line, err)
+		}
+		field := func(i int) string {
+			if i < 0 || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+		row := Row{
+			Line:   line,
+			ID:     field(id),
+			Type:   strings.ToLower(field(kind)),
+			Ref:    field(ref),
+			Payout: field(payout),
+		}
+		for _, a := range []struct {
+			column int
+			into   *int64
+		}{{amount, &row.Amount}, {fee, &row.Fee}, {net, &row.Net}} {
+			if field(a.column) == "" {
+				continue
+			}
+			if *a.into, err = parseAmount(field(a.column), columns.Cents); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+		}
+		if net < 0 {
+			row.Net = row.Amount - row.Fee
+		}
+		if field(created) != "" {
+			if row.Created, err = parseTime(field(created)); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+		}
+		rows = append(rows, row)
+	}
+}
+
+// parseAmount parses an amount in cents or, exactly, a decimal in the currency.
+func parseAmount(s string, cents bool) (int64, error) {
+	s = strings.ReplaceAll(s, ",", "")
+	if cents {
+		v, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("amount %q is not a number of cents", s)
+		}
+		return v, nil
+	}
+	negative := strings.HasPrefix(s, "-")
+	whole, fraction := strings.TrimPrefix(s, "-"), ""
+	if i := strings.IndexByte(whole, '.'); i >= 0 {
+		whole, fraction = whole[:i], whole[i+1:]
+	}
+	if len(fraction) > 2 {
+		return 0, fmt.Errorf("amount %q has fractions of cents", s)
+	}
+	units, err := strconv.ParseUint("0"+whole, 10, 63)
+	if err != nil {
+		return 0, fmt.Errorf("amount %q is not a decimal", s)
+	}
+	var c uint64
+	if fraction != "" {
+		if c, err = strconv.ParseUint(fraction+strings.Repeat("0", 2-len(fraction)), 10, 64); err != nil {
+			return 0, fmt.Errorf("amount %q is not a decimal", s)
+		}
+	}
+	v := int64(units)*100 + int64(c)
+	if negative {
+		v = -v
+	}
+	return v, nil
+}
+
+// timeLayouts are the layouts dates of exports are read with, in UTC unless they say.
+var timeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// parseTime parses the date of a row, in one of timeLayouts or as a Unix timestamp.
+func parseTime(s string) (time.Time, error) {
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	if unix, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(unix, 0).UTC(), nil
+	}
+	return time.Time{}, fmt.Errorf("date %q is not in a known format", s)
+}
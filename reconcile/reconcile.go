@@ -0,0 +1,188 @@
+package reconcile
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gopheracademy/manager/ticketing"
+)
+
+// Match is a charge of the provider matched with the money we recorded for it.
+type Match struct {
+	Row
+	Money ticketing.RecordedMoney
+}
+
+// Duplicate is a reference the provider charged or we recorded more than once, its
+// rows and money are not matched.
+type Duplicate struct {
+	Ref   string
+	Rows  []Row
+	Money []ticketing.RecordedMoney
+}
+
+// Report is the outcome of reconciling an export with our records.
+type Report struct {
+	Rows    int
+	Matched []Match
+	// Mismatched are charges whose amount is not that of the money recorded for them.
+	Mismatched []Match
+	Duplicates []Duplicate
+	// Unrecorded are charges of the provider we have no money for.
+	Unrecorded []Row
+	// Missing is money we recorded the provider did not report, among that of payments
+	// made between the first and last charge of the export when it has dates.
+	Missing []ticketing.RecordedMoney
+	// Payouts are the payouts of the export and Skipped the rows neither charges nor
+	// payouts, ie refunds or adjustments.
+	Payouts []Row
+	Skipped []Row
+	// Fees are the fees of matched charges posted to the ledger by this run, those
+	// posted by a previous one are not posted again.
+	Fees      []Match
+	FeesTotal int64
+}
+
+// Reconciled returns true if every charge matched its money and the other way around.
+func (r *Report) Reconciled() bool {
+	return len(r.Mismatched) == 0 && len(r.Duplicates) == 0 &&
+		len(r.Unrecorded) == 0 && len(r.Missing) == 0
+}
+
+// Options configure Reconcile.
+type Options struct {
+	// Provider names the provider in the memo of the fees posted, ie stripe.
+	Provider string
+	// DryRun reports the fees without posting them.
+	DryRun bool
+}
+
+// Reconcile matches the charges among rows with the money recorded in store by their
+// reference, and posts the fee of each matched one to the ledger, once per transaction
+// of the provider.
+func Reconcile(ctx context.Context, store ticketing.PurchaseStore, rows []Row, opts Options) (*Report, error) {
+	if opts.Provider == "" {
+		opts.Provider = "provider"
+	}
+	report := &Report{Rows: len(rows)}
+	charges := map[string][]Row{}
+	// refs are in the order of the export, so reports are too.
+	var refs []string
+	var from, to time.Time
+	for _, row := range rows {
+		switch {
+		case row.Type == TypePayout:
+			report.Payouts = append(report.Payouts, row)
+			continue
+		case !row.IsCharge():
+			report.Skipped = append(report.Skipped, row)
+			continue
+		case row.Ref == "":
+			report.Unrecorded = append(report.Unrecorded, row)
+			continue
+		}
+		if _, ok := charges[row.Ref]; !ok {
+			refs = append(refs, row.Ref)
+		}
+		charges[row.Ref] = append(charges[row.Ref], row)
+		if !row.Created.IsZero() {
+			if from.IsZero() || row.Created.Before(from) {
+				from = row.Created
+			}
+			if row.Created.After(to) {
+				to = row.Created
+			}
+		}
+	}
+
+	// the money of the charges, and that of payments made between their dates which
+	// could be missing, without dates every payment is expected in the export.
+	var paidFrom, paidTo uint64
+	if !to.IsZero() {
+		paidFrom, paidTo = uint64(from.Unix()), uint64(to.Unix())
+	}
+	recorded, err := store.ListRecordedMoney(ctx, refs, paidFrom, paidTo)
+	if err != nil {
+		return nil, fmt.Errorf("reading recorded money: %w", err)
+	}
+	money := map[string][]ticketing.RecordedMoney{}
+	for _, m := range recorded {
+		if m.PaymentRef != "" {
+			money[m.PaymentRef] = append(money[m.PaymentRef], m)
+		}
+	}
+
+	for _, ref := range refs {
+		theirs, ours := charges[ref], money[ref]
+		switch {
+		case len(ours) == 0:
+			report.Unrecorded = append(report.Unrecorded, theirs...)
+		case len(theirs) > 1 || len(ours) > 1:
+			report.Duplicates = append(report.Duplicates, Duplicate{Ref: ref, Rows: theirs, Money: ours})
+		case theirs[0].Amount != ours[0].Amount:
+			report.Mismatched = append(report.Mismatched, Match{Row: theirs[0], Money: ours[0]})
+		default:
+			report.Matched = append(report.Matched, Match{Row: theirs[0], Money: ours[0]})
+		}
+	}
+	for _, m := range recorded {
+		if m.PaymentRef == "" || len(charges[m.PaymentRef]) != 0 {
+			continue
+		}
+		if paidTo != 0 && (m.PaidAt < paidFrom || m.PaidAt > paidTo) {
+			continue
+		}
+		report.Missing = append(report.Missing, m)
+	}
+
+	for _, match := range report.Matched {
+		if match.Fee <= 0 {
+			continue
+		}
+		posted, err := postFee(ctx, store, match, opts)
+		if err != nil {
+			return nil, err
+		}
+		if posted {
+			report.Fees = append(report.Fees, match)
+			report.FeesTotal += match.Fee
+		}
+	}
+	return report, nil
+}
+
+// postFee posts the fee of the matched charge unless it was already, it returns true if
+// it was not.
+func postFee(ctx context.Context, store ticketing.PurchaseStore, match Match, opts Options) (bool, error) {
+	memo := fmt.Sprintf("%s fee %s", opts.Provider, match.Ref)
+	if match.ID != "" {
+		memo = fmt.Sprintf("%s fee %s", opts.Provider, match.ID)
+	}
+	if opts.DryRun {
+		entries, err := store.ListJournalEntriesForPayment(ctx, match.Money.ClaimPaymentID)
+		if err != nil {
+			return false, fmt.Errorf("reading the journal of payment %d: %w", match.Money.ClaimPaymentID, err)
+		}
+		for _, e := range entries {
+			if e.Kind == ticketing.EntryFee && e.Memo == memo {
+				return false, nil
+			}
+		}
+		return true, nil
+	}
+	payment, err := store.ReadClaimPaymentByID(ctx, match.Money.ClaimPaymentID)
+	if err != nil {
+		return false, fmt.Errorf("reading payment %d: %w", match.Money.ClaimPaymentID, err)
+	}
+	if payment == nil {
+		return false, fmt.Errorf("payment %d of %s not found", match.Money.ClaimPaymentID, match.Ref)
+	}
+	// RecordFee checks the memo holding the lock of the payment, so runs at once post the
+	// fee once.
+	recorded, err := ticketing.RecordFee(ctx, store, payment, match.Fee, memo)
+	if err != nil {
+		return false, fmt.Errorf("recording the fee of %s: %w", match.Ref, err)
+	}
+	return recorded, nil
+}
@@ -0,0 +1,203 @@
+package reconcile
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gopheracademy/manager/def"
+	"github.com/gopheracademy/manager/ticketing"
+)
+
+func TestReadStripe(t *testing.T) {
+	export := "\ufeffid,Type,Source,Amount,Fee,Net,Currency,Created (UTC),Transfer\n" +
+		"txn_1,charge,ch_1,500.00,14.80,485.20,usd,2021-03-01 10:00,po_1\n" +
+		"txn_2,payout,po_1,-485.20,0.00,-485.20,usd,2021-03-03 00:00,\n"
+	rows, err := Read(strings.NewReader(export), Stripe)
+	if err != nil {
+		t.Fatalf("Read() = %v", err)
+	}
+	want := []Row{
+		{Line: 2, ID: "txn_1", Type: TypeCharge, Ref: "ch_1", Amount: 50000, Fee: 1480, Net: 48520,
+			Created: time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC), Payout: "po_1"},
+		{Line: 3, ID: "txn_2", Type: TypePayout, Ref: "po_1", Amount: -48520, Net: -48520,
+			Created: time.Date(2021, 3, 3, 0, 0, 0, 0, time.UTC)},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("Read() = %+v, want %+v", rows, want)
+	}
+}
+
+func TestReadColumns(t *testing.T) {
+	columns, err := ParseColumns("ref=Reference, amount=Gross, fee=Commission", true)
+	if err != nil {
+		t.Fatalf("ParseColumns() = %v", err)
+	}
+	rows, err := Read(strings.NewReader("Reference,Gross,Commission\npay_1,\"1,000\",30\n"), columns)
+	if err != nil {
+		t.Fatalf("Read() = %v", err)
+	}
+	want := []Row{{Line: 2, Ref: "pay_1", Amount: 1000, Fee: 30, Net: 970}}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("Read() = %+v, want %+v", rows, want)
+	}
+
+	for _, mapping := range []string{"amount=Gross", "ref", "ref=Reference,amount=Gross,color=Colour"} {
+		if _, err := ParseColumns(mapping, false); err == nil {
+			t.Errorf("ParseColumns(%q) succeeded, want an error", mapping)
+		}
+	}
+	if _, err := Read(strings.NewReader("Reference,Total\npay_1,10\n"), columns); err == nil {
+		t.Error("Read() of an export without an amount column succeeded, want an error")
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	for _, tc := range []struct {
+		in    string
+		cents bool
+		want  int64
+		valid bool
+	}{
+		{"500", false, 50000, true},
+		{"500.5", false, 50050, true},
+		{"-0.05", false, -5, true},
+		{"1,234.56", false, 123456, true},
+		{"1234", true, 1234, true},
+		{"1.234", false, 0, false},
+		{"12.3.4", false, 0, false},
+		{"1.5", true, 0, false},
+		{"ten", false, 0, false},
+	} {
+		got, err := parseAmount(tc.in, tc.cents)
+		if (err == nil) != tc.valid || got != tc.want {
+			t.Errorf("parseAmount(%q, %v) = %d, %v, want %d, valid %v", tc.in, tc.cents, got, err, tc.want, tc.valid)
+		}
+	}
+}
+
+// pay pays for a claim of the slot with money of each ref, of each amount.
+func pay(t *testing.T, s ticketing.PurchaseStore, slot *ticketing.EventSlot, email string,
+	money map[string]int64) *ticketing.ClaimPayment {
+	t.Helper()
+	ctx := context.Background()
+	attendee, err := s.CreateAttendee(ctx, &ticketing.Attendee{Email: email})
+	if err != nil {
+		t.Fatalf("CreateAttendee() = %v", err)
+	}
+	claims, err := ticketing.ClaimSlots(ctx, s, attendee, *slot)
+	if err != nil {
+		t.Fatalf("ClaimSlots() = %v", err)
+	}
+	var instruments []ticketing.FinancialInstrument
+	for ref, amount := range money {
+		instruments = append(instruments, &ticketing.PaymentMethodMoney{PaymentRef: ref, Amount: amount})
+	}
+	payment, err := ticketing.PayClaims(ctx, s, attendee, claims, instruments)
+	if err != nil {
+		t.Fatalf("PayClaims() = %v", err)
+	}
+	return payment
+}
+
+func TestReconcile(t *testing.T) {
+	ctx := context.Background()
+	s := ticketing.NewMemoryStorage()
+	slot, err := s.CreateEventSlot(ctx, &ticketing.EventSlot{
+		Event: &def.Event{ID: 1, Name: "GopherCon"}, Name: "conference", Cost: 50000, Capacity: 10,
+	})
+	if err != nil {
+		t.Fatalf("CreateEventSlot() = %v", err)
+	}
+	matched := pay(t, s, slot, "matched@example.com", map[string]int64{"ch_matched": 50000})
+	pay(t, s, slot, "mismatched@example.com", map[string]int64{"ch_mismatched": 50000})
+	pay(t, s, slot, "missing@example.com", map[string]int64{"ch_missing": 50000})
+	pay(t, s, slot, "twice@example.com", map[string]int64{"ch_twice": 50000})
+
+	day := func(days int) string { return time.Now().AddDate(0, 0, days).UTC().Format(time.RFC3339) }
+	export := "id,Type,Source,Amount,Fee,Created (UTC)\n" +
+		fmt.Sprintf("txn_1,charge,ch_matched,500.00,14.80,%s\n", day(-1)) +
+		fmt.Sprintf("txn_2,charge,ch_mismatched,450.00,13.35,%s\n", day(0)) +
+		fmt.Sprintf("txn_3,charge,ch_unknown,20.00,0.88,%s\n", day(0)) +
+		fmt.Sprintf("txn_4,charge,ch_twice,500.00,14.80,%s\n", day(0)) +
+		fmt.Sprintf("txn_5,charge,ch_twice,500.00,14.80,%s\n", day(0)) +
+		fmt.Sprintf("txn_6,refund,ch_matched,-100.00,0.00,%s\n", day(0)) +
+		fmt.Sprintf("txn_7,payout,po_1,-485.20,0.00,%s\n", day(1))
+	rows, err := Read(strings.NewReader(export), Stripe)
+	if err != nil {
+		t.Fatalf("Read() = %v", err)
+	}
+
+	report, err := Reconcile(ctx, s, rows, Options{Provider: "stripe", DryRun: true})
+	if err != nil {
+		t.Fatalf("Reconcile() = %v", err)
+	}
+	refs := func(rows []Row) []string {
+		var refs []string
+		for _, r := range rows {
+			refs = append(refs, r.Ref)
+		}
+		return refs
+	}
+	if len(report.Matched) != 1 || report.Matched[0].Ref != "ch_matched" || report.Matched[0].Money.ClaimPaymentID != matched.ID {
+		t.Errorf("matched %+v, want ch_matched of payment %d", report.Matched, matched.ID)
+	}
+	if len(report.Mismatched) != 1 || report.Mismatched[0].Ref != "ch_mismatched" {
+		t.Errorf("mismatched %+v, want ch_mismatched", report.Mismatched)
+	}
+	if got := refs(report.Unrecorded); !reflect.DeepEqual(got, []string{"ch_unknown"}) {
+		t.Errorf("unrecorded %v, want ch_unknown", got)
+	}
+	if len(report.Duplicates) != 1 || report.Duplicates[0].Ref != "ch_twice" || len(report.Duplicates[0].Rows) != 2 {
+		t.Errorf("duplicates %+v, want ch_twice twice", report.Duplicates)
+	}
+	if len(report.Missing) != 1 || report.Missing[0].PaymentRef != "ch_missing" {
+		t.Errorf("missing %+v, want ch_missing", report.Missing)
+	}
+	if len(report.Payouts) != 1 || len(report.Skipped) != 1 {
+		t.Errorf("%d payouts and %d skipped, want 1 of each", len(report.Payouts), len(report.Skipped))
+	}
+	if report.Reconciled() {
+		t.Error("Reconciled() = true, want false")
+	}
+	if len(report.Fees) != 1 || report.FeesTotal != 1480 {
+		t.Errorf("fees %+v totalling %d, want the fee of ch_matched", report.Fees, report.FeesTotal)
+	}
+	if entries, _ := s.ListJournalEntriesForPayment(ctx, matched.ID); len(entries) != 1 {
+		t.Errorf("a dry run posted %d entries, want only the order", len(entries)-1)
+	}
+
+	// fees are posted once, however many times the export is reconciled.
+	for run := 0; run < 2; run++ {
+		report, err := Reconcile(ctx, s, rows, Options{Provider: "stripe"})
+		if err != nil {
+			t.Fatalf("Reconcile() = %v", err)
+		}
+		if want := 1 - run; len(report.Fees) != want {
+			t.Errorf("run %d posted %d fees, want %d", run, len(report.Fees), want)
+		}
+	}
+	entries, err := s.ListJournalEntriesForPayment(ctx, matched.ID)
+	if err != nil {
+		t.Fatalf("ListJournalEntriesForPayment() = %v", err)
+	}
+	var fees []ticketing.JournalEntry
+	for _, e := range entries {
+		if e.Kind == ticketing.EntryFee {
+			fees = append(fees, e)
+		}
+	}
+	if len(fees) != 1 || fees[0].Memo != "stripe fee txn_1" {
+		t.Errorf("fee entries %+v, want one for txn_1", fees)
+	}
+	balance, err := ticketing.TrialBalance(ctx, s)
+	if err != nil {
+		t.Fatalf("TrialBalance() = %v", err)
+	}
+	if !balance.Balanced() {
+		t.Errorf("journal not balanced after posting fees: %+v", balance)
+	}
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/gopheracademy/manager/def"
+	"github.com/gopheracademy/manager/errs"
+	"github.com/gopheracademy/manager/store"
+	"github.com/gopheracademy/manager/ticketing"
+)
+
+const seedUsage = `usage: manager seed [flags]
+
+Loads demo conferences, events and slots into the configured database, which must be
+migrated; seeding twice fails as the conferences already exist.
+
+`
+
+// seed implements the seed subcommand and returns the exit code.
+func seed(args []string) int {
+	fs, config := commandFlags("seed", seedUsage)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return 2
+	}
+	cfg, err := config()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	db, err := openDatabase(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	tx, err := db.BeginTransaction()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, fmt.Errorf("beginning transaction: %w", err))
+		return 1
+	}
+	for _, conference := range demoConferences(time.Now()) {
+		if err := seedConference(context.Background(), store.NewConferenceRepository(tx),
+			store.NewEventRepository(tx), ticketing.NewSQLStorageFromConnection(tx), conference); err != nil {
+			tx.RollbackTransaction()
+			if errs.Is(err, errs.Conflict) {
+				err = fmt.Errorf("%w, was the database already seeded?", err)
+			}
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		fmt.Printf("seeded %s with %d events\n", conference.Name, len(conference.Events))
+	}
+	if err := tx.CommitTransaction(); err != nil {
+		fmt.Fprintln(os.Stderr, fmt.Errorf("committing: %w", err))
+		return 1
+	}
+	return 0
+}
+
+func seedConference(ctx context.Context, conferences *store.ConferenceRepository, events *store.EventRepository,
+	slots ticketing.PurchaseStore, conference def.Conference) error {
+	stored, err := conferences.Create(ctx, &store.Conference{Name: conference.Name, Slug: conference.Slug})
+	if err != nil {
+		return err
+	}
+	for _, event := range conference.Events {
+		storedEvent, err := events.Create(ctx, &store.Event{
+			Name:              event.Name,
+			Slug:              event.Slug,
+			StartDate:         event.StartDate,
+			EndDate:           event.EndDate,
+			Location:          event.Location,
+			TimeZone:          event.TimeZone,
+			Live:              event.Live,
+			SponsorshipLevels: event.SponsorshipLevels,
+			ConferenceID:      stored.ID,
+		})
+		if err != nil {
+			return err
+		}
+		byName := map[string]*ticketing.EventSlot{}
+		for _, slot := range event.Slots {
+			var dependsOn *ticketing.EventSlot
+			if slot.DependsOn != nil {
+				// demo slots point at the slot they depend on by name, it is seeded first.
+				dependsOn = byName[slot.DependsOn.Name]
+			}
+			created, err := slots.CreateEventSlot(ctx, &ticketing.EventSlot{
+				Event:             &def.Event{ID: storedEvent.ID},
+				Name:              slot.Name,
+				Description:       slot.Description,
+				Cost:              int64(slot.Cost),
+				Capacity:          slot.Capacity,
+				StartDate:         slot.StartDate,
+				EndDate:           slot.EndDate,
+				DependsOn:         dependsOn,
+				PurchaseableFrom:  slot.PurchaseableFrom,
+				PurchaseableUntil: slot.PurchaseableUntil,
+				AvailableToPublic: slot.AvailableToPublic,
+			})
+			if err != nil {
+				return fmt.Errorf("seeding slot %s of %s: %w", slot.Name, event.Name, err)
+			}
+			byName[slot.Name] = created
+		}
+	}
+	return nil
+}
+
+// demoConferences returns the demo data, its events happen in the months after now so
+// tickets are on sale.
+func demoConferences(now time.Time) []def.Conference {
+	day := func(months, days, hour int) uint64 {
+		d := time.Date(now.Year(), now.Month(), 1, hour, 0, 0, 0, time.UTC)
+		return uint64(d.AddDate(0, months, days).Unix())
+	}
+	levels := []string{"Diamond", "Platinum", "Gold", "Silver"}
+	conference := func(name, slug, location, zone string, months int) def.Conference {
+		year := time.Unix(int64(day(months, 0, 0)), 0).UTC().Year()
+		return def.Conference{
+			Name: name,
+			Slug: slug,
+			Events: []def.Event{{
+				Name:              fmt.Sprintf("%s %d", name, year),
+				Slug:              fmt.Sprintf("%s-%d", slug, year),
+				StartDate:         day(months, 0, 9),
+				EndDate:           day(months, 2, 18),
+				Location:          location,
+				TimeZone:          zone,
+				Live:              true,
+				SponsorshipLevels: levels,
+				Slots: []def.EventSlot{
+					{
+						Name:              "Early bird",
+						Description:       "Conference ticket at a discount for those who commit early.",
+						Cost:              50000,
+						Capacity:          300,
+						StartDate:         day(months, 1, 9),
+						EndDate:           day(months, 2, 18),
+						PurchaseableFrom:  day(0, 0, 0),
+						PurchaseableUntil: day(months-1, 0, 0),
+						AvailableToPublic: true,
+					},
+					{
+						Name:              "Conference",
+						Description:       "Two days of talks.",
+						Cost:              80000,
+						Capacity:          1200,
+						StartDate:         day(months, 1, 9),
+						EndDate:           day(months, 2, 18),
+						PurchaseableFrom:  day(0, 0, 0),
+						PurchaseableUntil: day(months, 1, 0),
+						AvailableToPublic: true,
+					},
+					{
+						Name:              "Workshop day",
+						Description:       "A full day of hands-on workshops, requires a conference ticket.",
+						Cost:              40000,
+						Capacity:          200,
+						StartDate:         day(months, 0, 9),
+						EndDate:           day(months, 0, 17),
+						DependsOn:         &def.EventSlot{Name: "Conference"},
+						PurchaseableFrom:  day(0, 0, 0),
+						PurchaseableUntil: day(months, -1, 0),
+						AvailableToPublic: true,
+					},
+					{
+						Name:              "Sponsor",
+						Description:       "Conference ticket included with sponsorships.",
+						Capacity:          100,
+						StartDate:         day(months, 1, 9),
+						EndDate:           day(months, 2, 18),
+						PurchaseableFrom:  day(0, 0, 0),
+						PurchaseableUntil: day(months, 1, 0),
+					},
+				},
+			}},
+		}
+	}
+	return []def.Conference{
+		conference("GopherCon", "gophercon", "San Diego, CA", "America/Los_Angeles", 4),
+		conference("GopherCon EU", "gophercon-eu", "Berlin, Germany", "Europe/Berlin", 7),
+	}
+}
@@ -0,0 +1,344 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/gopheracademy/manager/apidocs"
+	"github.com/gopheracademy/manager/auth"
+	"github.com/gopheracademy/manager/calendar"
+	"github.com/gopheracademy/manager/database"
+	"github.com/gopheracademy/manager/dataexport"
+	"github.com/gopheracademy/manager/health"
+	"github.com/gopheracademy/manager/log"
+	"github.com/gopheracademy/manager/mailer"
+	"github.com/gopheracademy/manager/migrations"
+	"github.com/gopheracademy/manager/outbox"
+	"github.com/gopheracademy/manager/pool"
+	"github.com/gopheracademy/manager/public"
+	"github.com/gopheracademy/manager/store"
+	"github.com/gopheracademy/manager/ticketing"
+	"github.com/gopheracademy/manager/tracing"
+	"github.com/gopheracademy/manager/webhooks"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/pacedotdev/oto/otohttp"
+	"github.com/uber/jaeger-lib/metrics"
+	"go.uber.org/zap"
+)
+
+const serveUsage = `usage: manager serve [flags]
+
+Runs the server, flags override the config.
+
+`
+
+// serve implements the serve subcommand and returns the exit code.
+func serve(args []string) int {
+	fs, config := commandFlags("serve", serveUsage)
+	addr := fs.String("addr", "", "address to listen on (default 0.0.0.0:8000)")
+	staticDir := fs.String("static", "", "directory of the built web app (default ./www/build)")
+	metricsBackend := fs.String("metrics", "", "metrics backend, prometheus or expvar (default prometheus)")
+	databaseURL := fs.String("database", "", "connection string of the database")
+	tlsCert := fs.String("tls-cert", "", "certificate file to serve HTTPS")
+	tlsKey := fs.String("tls-key", "", "key file of the certificate")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return 2
+	}
+	cfg, err := config()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	for flagValue, field := range map[*string]*string{
+		addr:           &cfg.Addr,
+		staticDir:      &cfg.StaticDir,
+		metricsBackend: &cfg.MetricsBackend,
+		databaseURL:    &cfg.DatabaseURL,
+		tlsCert:        &cfg.TLSCert,
+		tlsKey:         &cfg.TLSKey,
+	} {
+		if *flagValue != "" {
+			*field = *flagValue
+		}
+	}
+	if (cfg.TLSCert == "") != (cfg.TLSKey == "") {
+		fmt.Fprintln(os.Stderr, "both a TLS certificate and key are needed to serve HTTPS")
+		return 2
+	}
+
+	logger := newLogger()
+	metricsFactory, err := newMetricsFactory(cfg.MetricsBackend)
+	if err != nil {
+		logger.Fatal("initializing metrics", zap.Error(err))
+	}
+	logger.Info("Using " + cfg.MetricsBackend + " as metrics backend")
+
+	zapLogger := logger.With(zap.String("service", "showrunner"))
+	logg := log.NewFactory(zapLogger)
+	var cleanup shutdown
+	mytracer, tracerCloser := tracing.Init("showrunner", metricsFactory, logg)
+	cleanup.add("flushing traces", tracerCloser.Close)
+	tracedRouter := tracing.NewServeMux(mytracer)
+	// probes are not traced, they would drown the traces worth looking at.
+	probes := health.NewHandler(logg)
+	tracedRouter.Mux.Handle(health.LivePath, probes)
+	tracedRouter.Mux.Handle(health.ReadyPath, probes)
+	publicCache := public.NewCache(5*time.Minute, publicCacheSize)
+	conferenceService := newconferenceService(mytracer, metricsFactory, logg, publicCache)
+	server := otohttp.NewServer()
+
+	var sender mailer.Sender = &mailer.LogSender{Logger: logg}
+	switch {
+	case cfg.SMTPAddr != "":
+		sender = &mailer.SMTPSender{Addr: cfg.SMTPAddr, Username: cfg.SMTPUsername, Password: cfg.SMTPPassword, From: cfg.MailFrom}
+	case cfg.MailDir != "":
+		sender = &mailer.MaildirSender{Dir: cfg.MailDir, From: cfg.MailFrom}
+	}
+
+	feedSigner := calendar.NewSigner(secret(logger, "SHOWRUNNER_FEED_SECRET", cfg.FeedSecret))
+	var (
+		attendees    calendar.AttendeeReader
+		roles        auth.RoleStore
+		webhookStore *webhooks.SQLStore
+		analytics    = newAnalyticsService(logg, nil, nil)
+		calendars    = newCalendarService(logg, nil, feedSigner, cfg.BaseURL)
+		exports      ticketing.ExportStore
+		conferenceOf dataexport.ConferenceOf
+	)
+	if cfg.DatabaseURL != "" {
+		db, err := database.Open(cfg.DatabaseURL, zap.NewStdLog(zapLogger))
+		if err != nil {
+			zapLogger.Fatal("connecting to the database", zap.Error(err))
+		}
+		cleanup.add("closing the database", db.Close)
+		// nothing is served nor delivered against a schema this version does not know, and
+		// the instance is not ready anymore once a later version migrated the database.
+		if err := migrations.New(db).Check(); err != nil {
+			zapLogger.Fatal("checking the database schema", zap.Error(err))
+		}
+		probes.AddCheck("database", db.Ping)
+		probes.AddCheck("schema", func(ctx context.Context) error {
+			return migrations.New(database.WithContext(ctx, db)).Check()
+		})
+		tickets := newPublicTickets(ticketing.NewSQLStorageFromConnection(db), publicCache)
+		attendees = tickets
+		roles = auth.NewSQLRoleStore(db)
+
+		// domain events emitted by ticketing are delivered from the outbox.
+		outboxPool := pool.New(outboxWorkers)
+		cleanup.add("stopping the outbox workers", func() error { outboxPool.Stop(); return nil })
+		dispatcher := outbox.NewDispatcher(outbox.NewSQLStore(db), outboxPool, outbox.Options{}, logg)
+		// webhooks are configured by organisers and get their own deliveries, signed,
+		// retried and logged one by one, so a failing one does not hold the others back.
+		webhookStore = webhooks.NewSQLStore(db)
+		events := store.NewEventRepository(db)
+		events.OnWrite = publicCache.Invalidate
+		conferences := store.NewConferenceRepository(db)
+		conferences.OnWrite = publicCache.Invalidate
+		analytics = newAnalyticsService(logg, tickets, events)
+		calendars = newCalendarService(logg, tickets, feedSigner, cfg.BaseURL)
+		exports = tickets
+		conferenceOf = func(ctx context.Context, eventID uint32) (uint32, error) {
+			e, err := events.Read(ctx, eventID)
+			if err != nil || e == nil {
+				return 0, err
+			}
+			return e.ConferenceID, nil
+		}
+		dispatcher.Subscribe(outbox.AllEvents, webhooks.NewFanout(webhookStore, webhooks.ConferenceOf(conferenceOf)))
+		// emails about tickets are queued as the events are delivered and sent apart, so
+		// a slow mail server does not hold the outbox back.
+		mailPool := pool.New(mailWorkers)
+		cleanup.add("stopping the mail workers", func() error { mailPool.Stop(); return nil })
+		mailQueue := mailer.NewQueue(mailer.NewSQLLog(db), sender, mailPool, mailer.QueueOptions{}, logg)
+		mails := &ticketMails{
+			queue:       mailQueue,
+			tickets:     tickets,
+			events:      events,
+			conferences: conferences,
+			branding:    cfg.Branding,
+			from:        cfg.MailFrom,
+		}
+		dispatcher.Subscribe(outbox.AllEvents, mails)
+		mailQueue.Start()
+		cleanup.add("stopping the mail queue", func() error { mailQueue.Stop(); return nil })
+		// payers of orders on credit are reminded through the same queue.
+		dunning := newDunner(mails, cfg.Dunning, logg)
+		dunning.Start()
+		cleanup.add("stopping the dunning job", func() error { dunning.Stop(); return nil })
+		webhookPool := pool.New(webhookWorkers)
+		cleanup.add("stopping the webhook workers", func() error { webhookPool.Stop(); return nil })
+		webhookSender := webhooks.NewSender(webhookStore, webhookPool, webhooks.Options{}, mytracer, logg)
+		webhookSender.Start()
+		cleanup.add("stopping the webhook sender", func() error { webhookSender.Stop(); return nil })
+		dispatcher.Start()
+		cleanup.add("stopping the outbox dispatcher", func() error { dispatcher.Stop(); return nil })
+	} else {
+		// without a database the only grants are those of the super admins we are told of,
+		// the store lowercases their emails as sessions hold them.
+		var grants []auth.Grant
+		for _, email := range cfg.SuperAdmins {
+			grants = append(grants, auth.Grant{Email: email, Role: auth.RoleSuperAdmin})
+		}
+		roles = auth.NewMemoryRoleStore(grants...)
+	}
+
+	authorizer := auth.NewAuthorizer(roles)
+	RegisterConferenceService(metricsFactory.Namespace(metrics.NSOptions{Name: "conference.service"}), mytracer,
+		logg, authorizer, server, conferenceService)
+	RegisterWebhookService(metricsFactory.Namespace(metrics.NSOptions{Name: "webhook.service"}), mytracer,
+		logg, authorizer, server, newWebhookService(logg, webhookStore))
+	RegisterAnalyticsService(metricsFactory.Namespace(metrics.NSOptions{Name: "analytics.service"}), mytracer,
+		logg, authorizer, server, analytics)
+	RegisterCalendarService(metricsFactory.Namespace(metrics.NSOptions{Name: "calendar.service"}), mytracer,
+		logg, authorizer, server, calendars)
+
+	authenticator, err := auth.NewAuthenticator(auth.Options{
+		Secret:   secret(logger, "SHOWRUNNER_AUTH_SECRET", cfg.AuthSecret),
+		BaseURL:  cfg.BaseURL,
+		Insecure: strings.HasPrefix(cfg.BaseURL, "http://"),
+		Sender:   sender,
+	}, logg)
+	if err != nil {
+		zapLogger.Fatal("initializing authentication", zap.Error(err))
+	}
+	tracedRouter.Handle(auth.PathPrefix, authenticator)
+	tracedRouter.Handle("/oto/", authenticator.Middleware(server))
+	// exports stream whole events, they have dataexport.Timeout rather than requestTimeout.
+	tracedRouter.Handle(dataexport.PathPrefix, authenticator.Middleware(dataexport.NewHandler(exports, conferenceOf, authorizer, logg)))
+
+	tracedRouter.Handle(public.PathPrefix, public.NewHandler(conferenceService, publicCache, logg))
+	tracedRouter.Handle(calendar.PathPrefix,
+		calendar.NewHandler(conferenceService, attendees, feedSigner, calendarDomain, logg))
+
+	docs, err := apidocs.NewHandler()
+	if err != nil {
+		zapLogger.Fatal("rendering the OpenAPI document", zap.Error(err))
+	}
+	tracedRouter.Handle(apidocs.SpecPath, docs)
+	tracedRouter.Handle(apidocs.PathPrefix, docs)
+
+	spa := spaHandler{staticPath: cfg.StaticDir, indexPath: "index.html"}
+
+	tracedRouter.Mux.Handle("/metrics", promhttp.Handler()) // Prometheus
+	tracedRouter.Mux.PathPrefix("/").Handler(spa)
+
+	srv := &http.Server{
+		Handler: withDeadline(tracedRouter),
+		Addr:    cfg.Addr,
+		// Good practice: enforce timeouts for servers you create! Exports extend the write
+		// deadline of their connection, found in its context.
+		WriteTimeout: requestTimeout,
+		ReadTimeout:  15 * time.Second,
+		ConnContext:  dataexport.ConnContext,
+		// HTTP/2 is not served, its streams keep the write timeout whatever the deadline
+		// of their connection so exports could not extend it.
+		TLSNextProto: map[string]func(*http.Server, *tls.Conn, http.Handler){},
+	}
+
+	serving := make(chan error, 1)
+	go func() {
+		zapLogger.Info("listening", zap.String("addr", cfg.Addr), zap.Bool("tls", cfg.TLSCert != ""))
+		if cfg.TLSCert != "" {
+			serving <- srv.ListenAndServeTLS(cfg.TLSCert, cfg.TLSKey)
+		} else {
+			serving <- srv.ListenAndServe()
+		}
+	}()
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	code := 0
+	select {
+	case err := <-serving:
+		zapLogger.Error("serving", zap.Error(err))
+		code = 1
+	case sig := <-signals:
+		zapLogger.Info("shutting down", zap.String("signal", sig.String()),
+			zap.Int("drainSeconds", cfg.DrainSeconds))
+		probes.Drain()
+		time.Sleep(time.Duration(cfg.DrainSeconds) * time.Second)
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		// in-flight requests, ie purchases, are finished before anything is released.
+		if err := srv.Shutdown(ctx); err != nil {
+			zapLogger.Error("draining connections", zap.Error(err))
+			code = 1
+		}
+	}
+	if !cleanup.run(zapLogger) {
+		code = 1
+	}
+	return code
+}
+
+// outboxWorkers is how many outbox events are delivered at once.
+const outboxWorkers = 4
+
+// webhookWorkers is how many webhook deliveries are attempted at once.
+const webhookWorkers = 8
+
+// mailWorkers is how many emails are sent at once.
+const mailWorkers = 2
+
+// publicCacheSize is how many responses of the public API are cached.
+const publicCacheSize = 1000
+
+// shutdownTimeout bounds how long in-flight requests are waited for on shutdown.
+const shutdownTimeout = 20 * time.Second
+
+// requestTimeout bounds how long a request is handled.
+const requestTimeout = 15 * time.Second
+
+// withDeadline gives the context of requests a deadline after requestTimeout, or
+// dataexport.Timeout for exports, so the queries run for them are cancelled when it
+// passes.
+func withDeadline(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		timeout := requestTimeout
+		if strings.HasPrefix(r.URL.Path, dataexport.PathPrefix) {
+			timeout = dataexport.Timeout
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		h.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// shutdown releases what serve started, ie pools and connections, once it stopped
+// serving.
+type shutdown []shutdownStep
+
+type shutdownStep struct {
+	name  string
+	close func() error
+}
+
+// add registers what to run on shutdown, it runs before what was added earlier.
+func (s *shutdown) add(name string, close func() error) {
+	*s = append(*s, shutdownStep{name: name, close: close})
+}
+
+// run releases everything, it returns false if something failed.
+func (s shutdown) run(logger *zap.Logger) bool {
+	ok := true
+	for i := len(s) - 1; i >= 0; i-- {
+		if err := s[i].close(); err != nil {
+			logger.Error(s[i].name, zap.Error(err))
+			ok = false
+		}
+	}
+	return ok
+}
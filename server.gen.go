@@ -4,13 +4,36 @@ package main
 
 import (
 	"context"
+	"github.com/gopheracademy/manager/auth"
+	"github.com/gopheracademy/manager/errs"
 	"github.com/gopheracademy/manager/log"
+	"github.com/gopheracademy/manager/tracing"
+	"github.com/gopheracademy/manager/validation"
 	"github.com/opentracing/opentracing-go"
 	"github.com/pacedotdev/oto/otohttp"
 	"github.com/uber/jaeger-lib/metrics"
+	"go.uber.org/zap"
 	"net/http"
+	"time"
 )
 
+// AnalyticsService reports on the ticket sales of events, for organisers to follow
+// them from the admin pages.
+type AnalyticsService interface {
+
+	// EventSales returns the sales of an event and of each of its slots, with their
+	// daily series, the discounts used and when they are projected to sell out.
+	EventSales(context.Context, EventSalesRequest) (*EventSalesResponse, error)
+}
+
+// CalendarService hands attendees the calendar feed of their agenda.
+type CalendarService interface {
+
+	// AttendeeFeed returns the feed of the agenda of the attendee logged in, signed so
+	// calendar clients can subscribe to it without logging in.
+	AttendeeFeed(context.Context, AttendeeFeedRequest) (*AttendeeFeedResponse, error)
+}
+
 // ConferenceService is a service for managing Conferences
 type ConferenceService interface {
 	Create(context.Context, CreateConferenceRequest) (*CreateConferenceResponse, error)
@@ -21,123 +44,781 @@ type ConferenceService interface {
 	List(context.Context, ListConferenceRequest) (*ListConferenceResponse, error)
 }
 
+// WebhookService lets organisers post the domain events of their conference
+// (ie ticket claims and payments) to other systems like Slack, a CRM or a badge
+// vendor.
+type WebhookService interface {
+
+	// Create subscribes an URL to the events of a conference.
+	Create(context.Context, CreateWebhookRequest) (*CreateWebhookResponse, error)
+	// Delete removes a webhook along with its deliveries.
+	Delete(context.Context, DeleteWebhookRequest) (*DeleteWebhookResponse, error)
+	// GetDelivery returns a delivery with the request and response of each attempt.
+	GetDelivery(context.Context, GetWebhookDeliveryRequest) (*GetWebhookDeliveryResponse, error)
+	// List returns the webhooks of a conference, without their secrets.
+	List(context.Context, ListWebhooksRequest) (*ListWebhooksResponse, error)
+	// ListDeliveries returns the latest deliveries of a webhook.
+	ListDeliveries(context.Context, ListWebhookDeliveriesRequest) (*ListWebhookDeliveriesResponse, error)
+	// Redeliver delivers an event again, ie once a dead delivery was fixed on the
+	// other end.
+	Redeliver(context.Context, RedeliverWebhookRequest) (*RedeliverWebhookResponse, error)
+}
+
+type analyticsServiceServer struct {
+	server           *otohttp.Server
+	tracer           opentracing.Tracer
+	metricsFactory   metrics.Factory
+	logger           log.Factory
+	authorizer       *auth.Authorizer
+	analyticsService AnalyticsService
+}
+
+// Register adds the AnalyticsService to the otohttp.Server.
+func RegisterAnalyticsService(metricsFactory metrics.Factory, tracer opentracing.Tracer, logger log.Factory, authorizer *auth.Authorizer, server *otohttp.Server, analyticsService AnalyticsService) {
+	handler := &analyticsServiceServer{
+		server:           server,
+		tracer:           tracer,
+		logger:           logger,
+		metricsFactory:   metricsFactory,
+		authorizer:       authorizer,
+		analyticsService: analyticsService,
+	}
+	server.Register("AnalyticsService", "EventSales",
+		handler.observe(tracing.NewMethodMetrics(metricsFactory, "AnalyticsService", "EventSales"), handler.handleEventSales))
+}
+
+// observe turns handle into an http.HandlerFunc which answers with the error handle
+// returns, if any, and records the call in m.
+func (s *analyticsServiceServer) observe(m *tracing.MethodMetrics, handle func(http.ResponseWriter, *http.Request) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		err := handle(w, r)
+		if err != nil {
+			s.writeError(w, r, err)
+		}
+		m.Observe(start, err)
+	}
+}
+
+// writeError answers with the status and body matching the kind of err, errors that
+// are our fault are logged as their details are not sent.
+func (s *analyticsServiceServer) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	status, response := errs.ResponseFor(err)
+	if status >= http.StatusInternalServerError {
+		s.logger.For(r.Context()).Error("AnalyticsService failed", zap.Error(err))
+	}
+	if err := otohttp.Encode(w, r, status, response); err != nil {
+		s.server.OnErr(w, r, err)
+	}
+}
+
+func (s *analyticsServiceServer) handleEventSales(w http.ResponseWriter, r *http.Request) error {
+	s.logger.For(r.Context()).Info("AnalyticsService.EventSales")
+
+	var request EventSalesRequest
+	if err := otohttp.Decode(r, &request); err != nil {
+		return errs.Wrap(errs.InvalidArgument, err, "malformed request")
+	}
+	if err := s.authorizer.Authorize(r.Context(), auth.Permission{
+		Roles:        []auth.Role{"organiser"},
+		ConferenceID: uint32(request.ConferenceID),
+	}); err != nil {
+		return err
+	}
+	if err := request.Validate(); err != nil {
+		return err
+	}
+	response, err := s.analyticsService.EventSales(r.Context(), request)
+	if err != nil {
+		return err
+	}
+	if err := otohttp.Encode(w, r, http.StatusOK, response); err != nil {
+		// the response may be partially written, we can only report it.
+		s.server.OnErr(w, r, err)
+	}
+	return nil
+}
+
+type calendarServiceServer struct {
+	server          *otohttp.Server
+	tracer          opentracing.Tracer
+	metricsFactory  metrics.Factory
+	logger          log.Factory
+	authorizer      *auth.Authorizer
+	calendarService CalendarService
+}
+
+// Register adds the CalendarService to the otohttp.Server.
+func RegisterCalendarService(metricsFactory metrics.Factory, tracer opentracing.Tracer, logger log.Factory, authorizer *auth.Authorizer, server *otohttp.Server, calendarService CalendarService) {
+	handler := &calendarServiceServer{
+		server:          server,
+		tracer:          tracer,
+		logger:          logger,
+		metricsFactory:  metricsFactory,
+		authorizer:      authorizer,
+		calendarService: calendarService,
+	}
+	server.Register("CalendarService", "AttendeeFeed",
+		handler.observe(tracing.NewMethodMetrics(metricsFactory, "CalendarService", "AttendeeFeed"), handler.handleAttendeeFeed))
+}
+
+// observe turns handle into an http.HandlerFunc which answers with the error handle
+// returns, if any, and records the call in m.
+func (s *calendarServiceServer) observe(m *tracing.MethodMetrics, handle func(http.ResponseWriter, *http.Request) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		err := handle(w, r)
+		if err != nil {
+			s.writeError(w, r, err)
+		}
+		m.Observe(start, err)
+	}
+}
+
+// writeError answers with the status and body matching the kind of err, errors that
+// are our fault are logged as their details are not sent.
+func (s *calendarServiceServer) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	status, response := errs.ResponseFor(err)
+	if status >= http.StatusInternalServerError {
+		s.logger.For(r.Context()).Error("CalendarService failed", zap.Error(err))
+	}
+	if err := otohttp.Encode(w, r, status, response); err != nil {
+		s.server.OnErr(w, r, err)
+	}
+}
+
+func (s *calendarServiceServer) handleAttendeeFeed(w http.ResponseWriter, r *http.Request) error {
+	s.logger.For(r.Context()).Info("CalendarService.AttendeeFeed")
+
+	var request AttendeeFeedRequest
+	if err := otohttp.Decode(r, &request); err != nil {
+		return errs.Wrap(errs.InvalidArgument, err, "malformed request")
+	}
+	if err := s.authorizer.Authorize(r.Context(), auth.Permission{
+		Roles:        []auth.Role{"attendee"},
+		ConferenceID: 0,
+	}); err != nil {
+		return err
+	}
+	if err := request.Validate(); err != nil {
+		return err
+	}
+	response, err := s.calendarService.AttendeeFeed(r.Context(), request)
+	if err != nil {
+		return err
+	}
+	if err := otohttp.Encode(w, r, http.StatusOK, response); err != nil {
+		// the response may be partially written, we can only report it.
+		s.server.OnErr(w, r, err)
+	}
+	return nil
+}
+
 type conferenceServiceServer struct {
 	server            *otohttp.Server
 	tracer            opentracing.Tracer
 	metricsFactory    metrics.Factory
 	logger            log.Factory
+	authorizer        *auth.Authorizer
 	conferenceService ConferenceService
 }
 
 // Register adds the ConferenceService to the otohttp.Server.
-func RegisterConferenceService(metricsFactory metrics.Factory, tracer opentracing.Tracer, logger log.Factory, server *otohttp.Server, conferenceService ConferenceService) {
+func RegisterConferenceService(metricsFactory metrics.Factory, tracer opentracing.Tracer, logger log.Factory, authorizer *auth.Authorizer, server *otohttp.Server, conferenceService ConferenceService) {
 	handler := &conferenceServiceServer{
 		server:            server,
 		tracer:            tracer,
 		logger:            logger,
 		metricsFactory:    metricsFactory,
+		authorizer:        authorizer,
 		conferenceService: conferenceService,
 	}
-	server.Register("ConferenceService", "Create", handler.handleCreate)
-	server.Register("ConferenceService", "Delete", handler.handleDelete)
-	server.Register("ConferenceService", "Get", handler.handleGet)
-	server.Register("ConferenceService", "GetBySlug", handler.handleGetBySlug)
-	server.Register("ConferenceService", "List", handler.handleList)
+	server.Register("ConferenceService", "Create",
+		handler.observe(tracing.NewMethodMetrics(metricsFactory, "ConferenceService", "Create"), handler.handleCreate))
+	server.Register("ConferenceService", "Delete",
+		handler.observe(tracing.NewMethodMetrics(metricsFactory, "ConferenceService", "Delete"), handler.handleDelete))
+	server.Register("ConferenceService", "Get",
+		handler.observe(tracing.NewMethodMetrics(metricsFactory, "ConferenceService", "Get"), handler.handleGet))
+	server.Register("ConferenceService", "GetBySlug",
+		handler.observe(tracing.NewMethodMetrics(metricsFactory, "ConferenceService", "GetBySlug"), handler.handleGetBySlug))
+	server.Register("ConferenceService", "List",
+		handler.observe(tracing.NewMethodMetrics(metricsFactory, "ConferenceService", "List"), handler.handleList))
 }
 
-func (s *conferenceServiceServer) handleCreate(w http.ResponseWriter, r *http.Request) {
+// observe turns handle into an http.HandlerFunc which answers with the error handle
+// returns, if any, and records the call in m.
+func (s *conferenceServiceServer) observe(m *tracing.MethodMetrics, handle func(http.ResponseWriter, *http.Request) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		err := handle(w, r)
+		if err != nil {
+			s.writeError(w, r, err)
+		}
+		m.Observe(start, err)
+	}
+}
+
+// writeError answers with the status and body matching the kind of err, errors that
+// are our fault are logged as their details are not sent.
+func (s *conferenceServiceServer) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	status, response := errs.ResponseFor(err)
+	if status >= http.StatusInternalServerError {
+		s.logger.For(r.Context()).Error("ConferenceService failed", zap.Error(err))
+	}
+	if err := otohttp.Encode(w, r, status, response); err != nil {
+		s.server.OnErr(w, r, err)
+	}
+}
+
+func (s *conferenceServiceServer) handleCreate(w http.ResponseWriter, r *http.Request) error {
 	s.logger.For(r.Context()).Info("ConferenceService.Create")
 
 	var request CreateConferenceRequest
 	if err := otohttp.Decode(r, &request); err != nil {
-		s.server.OnErr(w, r, err)
-		return
+		return errs.Wrap(errs.InvalidArgument, err, "malformed request")
+	}
+	if err := s.authorizer.Authorize(r.Context(), auth.Permission{
+		Roles:        []auth.Role{},
+		ConferenceID: 0,
+	}); err != nil {
+		return err
+	}
+	if err := request.Validate(); err != nil {
+		return err
 	}
 	response, err := s.conferenceService.Create(r.Context(), request)
 	if err != nil {
-		s.server.OnErr(w, r, err)
-		return
+		return err
 	}
 	if err := otohttp.Encode(w, r, http.StatusOK, response); err != nil {
+		// the response may be partially written, we can only report it.
 		s.server.OnErr(w, r, err)
-		return
 	}
+	return nil
 }
 
-func (s *conferenceServiceServer) handleDelete(w http.ResponseWriter, r *http.Request) {
+func (s *conferenceServiceServer) handleDelete(w http.ResponseWriter, r *http.Request) error {
 	s.logger.For(r.Context()).Info("ConferenceService.Delete")
 
 	var request DeleteConferenceRequest
 	if err := otohttp.Decode(r, &request); err != nil {
-		s.server.OnErr(w, r, err)
-		return
+		return errs.Wrap(errs.InvalidArgument, err, "malformed request")
+	}
+	if err := s.authorizer.Authorize(r.Context(), auth.Permission{
+		Roles:        []auth.Role{"organiser"},
+		ConferenceID: uint32(request.ID),
+	}); err != nil {
+		return err
+	}
+	if err := request.Validate(); err != nil {
+		return err
 	}
 	response, err := s.conferenceService.Delete(r.Context(), request)
 	if err != nil {
-		s.server.OnErr(w, r, err)
-		return
+		return err
 	}
 	if err := otohttp.Encode(w, r, http.StatusOK, response); err != nil {
+		// the response may be partially written, we can only report it.
 		s.server.OnErr(w, r, err)
-		return
 	}
+	return nil
 }
 
-func (s *conferenceServiceServer) handleGet(w http.ResponseWriter, r *http.Request) {
+func (s *conferenceServiceServer) handleGet(w http.ResponseWriter, r *http.Request) error {
 	s.logger.For(r.Context()).Info("ConferenceService.Get")
 
 	var request GetConferenceRequest
 	if err := otohttp.Decode(r, &request); err != nil {
-		s.server.OnErr(w, r, err)
-		return
+		return errs.Wrap(errs.InvalidArgument, err, "malformed request")
+	}
+	if err := request.Validate(); err != nil {
+		return err
 	}
 	response, err := s.conferenceService.Get(r.Context(), request)
 	if err != nil {
-		s.server.OnErr(w, r, err)
-		return
+		return err
 	}
 	if err := otohttp.Encode(w, r, http.StatusOK, response); err != nil {
+		// the response may be partially written, we can only report it.
 		s.server.OnErr(w, r, err)
-		return
 	}
+	return nil
 }
 
-func (s *conferenceServiceServer) handleGetBySlug(w http.ResponseWriter, r *http.Request) {
+func (s *conferenceServiceServer) handleGetBySlug(w http.ResponseWriter, r *http.Request) error {
 	s.logger.For(r.Context()).Info("ConferenceService.GetBySlug")
 
 	var request GetConferenceBySlugRequest
 	if err := otohttp.Decode(r, &request); err != nil {
-		s.server.OnErr(w, r, err)
-		return
+		return errs.Wrap(errs.InvalidArgument, err, "malformed request")
+	}
+	if err := request.Validate(); err != nil {
+		return err
 	}
 	response, err := s.conferenceService.GetBySlug(r.Context(), request)
 	if err != nil {
-		s.server.OnErr(w, r, err)
-		return
+		return err
 	}
 	if err := otohttp.Encode(w, r, http.StatusOK, response); err != nil {
+		// the response may be partially written, we can only report it.
 		s.server.OnErr(w, r, err)
-		return
 	}
+	return nil
 }
 
-func (s *conferenceServiceServer) handleList(w http.ResponseWriter, r *http.Request) {
+func (s *conferenceServiceServer) handleList(w http.ResponseWriter, r *http.Request) error {
 	s.logger.For(r.Context()).Info("ConferenceService.List")
 
 	var request ListConferenceRequest
 	if err := otohttp.Decode(r, &request); err != nil {
-		s.server.OnErr(w, r, err)
-		return
+		return errs.Wrap(errs.InvalidArgument, err, "malformed request")
+	}
+	if err := request.Validate(); err != nil {
+		return err
 	}
 	response, err := s.conferenceService.List(r.Context(), request)
 	if err != nil {
+		return err
+	}
+	if err := otohttp.Encode(w, r, http.StatusOK, response); err != nil {
+		// the response may be partially written, we can only report it.
+		s.server.OnErr(w, r, err)
+	}
+	return nil
+}
+
+type webhookServiceServer struct {
+	server         *otohttp.Server
+	tracer         opentracing.Tracer
+	metricsFactory metrics.Factory
+	logger         log.Factory
+	authorizer     *auth.Authorizer
+	webhookService WebhookService
+}
+
+// Register adds the WebhookService to the otohttp.Server.
+func RegisterWebhookService(metricsFactory metrics.Factory, tracer opentracing.Tracer, logger log.Factory, authorizer *auth.Authorizer, server *otohttp.Server, webhookService WebhookService) {
+	handler := &webhookServiceServer{
+		server:         server,
+		tracer:         tracer,
+		logger:         logger,
+		metricsFactory: metricsFactory,
+		authorizer:     authorizer,
+		webhookService: webhookService,
+	}
+	server.Register("WebhookService", "Create",
+		handler.observe(tracing.NewMethodMetrics(metricsFactory, "WebhookService", "Create"), handler.handleCreate))
+	server.Register("WebhookService", "Delete",
+		handler.observe(tracing.NewMethodMetrics(metricsFactory, "WebhookService", "Delete"), handler.handleDelete))
+	server.Register("WebhookService", "GetDelivery",
+		handler.observe(tracing.NewMethodMetrics(metricsFactory, "WebhookService", "GetDelivery"), handler.handleGetDelivery))
+	server.Register("WebhookService", "List",
+		handler.observe(tracing.NewMethodMetrics(metricsFactory, "WebhookService", "List"), handler.handleList))
+	server.Register("WebhookService", "ListDeliveries",
+		handler.observe(tracing.NewMethodMetrics(metricsFactory, "WebhookService", "ListDeliveries"), handler.handleListDeliveries))
+	server.Register("WebhookService", "Redeliver",
+		handler.observe(tracing.NewMethodMetrics(metricsFactory, "WebhookService", "Redeliver"), handler.handleRedeliver))
+}
+
+// observe turns handle into an http.HandlerFunc which answers with the error handle
+// returns, if any, and records the call in m.
+func (s *webhookServiceServer) observe(m *tracing.MethodMetrics, handle func(http.ResponseWriter, *http.Request) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		err := handle(w, r)
+		if err != nil {
+			s.writeError(w, r, err)
+		}
+		m.Observe(start, err)
+	}
+}
+
+// writeError answers with the status and body matching the kind of err, errors that
+// are our fault are logged as their details are not sent.
+func (s *webhookServiceServer) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	status, response := errs.ResponseFor(err)
+	if status >= http.StatusInternalServerError {
+		s.logger.For(r.Context()).Error("WebhookService failed", zap.Error(err))
+	}
+	if err := otohttp.Encode(w, r, status, response); err != nil {
+		s.server.OnErr(w, r, err)
+	}
+}
+
+func (s *webhookServiceServer) handleCreate(w http.ResponseWriter, r *http.Request) error {
+	s.logger.For(r.Context()).Info("WebhookService.Create")
+
+	var request CreateWebhookRequest
+	if err := otohttp.Decode(r, &request); err != nil {
+		return errs.Wrap(errs.InvalidArgument, err, "malformed request")
+	}
+	if err := s.authorizer.Authorize(r.Context(), auth.Permission{
+		Roles:        []auth.Role{"organiser"},
+		ConferenceID: uint32(request.ConferenceID),
+	}); err != nil {
+		return err
+	}
+	if err := request.Validate(); err != nil {
+		return err
+	}
+	response, err := s.webhookService.Create(r.Context(), request)
+	if err != nil {
+		return err
+	}
+	if err := otohttp.Encode(w, r, http.StatusOK, response); err != nil {
+		// the response may be partially written, we can only report it.
 		s.server.OnErr(w, r, err)
-		return
+	}
+	return nil
+}
+
+func (s *webhookServiceServer) handleDelete(w http.ResponseWriter, r *http.Request) error {
+	s.logger.For(r.Context()).Info("WebhookService.Delete")
+
+	var request DeleteWebhookRequest
+	if err := otohttp.Decode(r, &request); err != nil {
+		return errs.Wrap(errs.InvalidArgument, err, "malformed request")
+	}
+	if err := s.authorizer.Authorize(r.Context(), auth.Permission{
+		Roles:        []auth.Role{"organiser"},
+		ConferenceID: uint32(request.ConferenceID),
+	}); err != nil {
+		return err
+	}
+	if err := request.Validate(); err != nil {
+		return err
+	}
+	response, err := s.webhookService.Delete(r.Context(), request)
+	if err != nil {
+		return err
 	}
 	if err := otohttp.Encode(w, r, http.StatusOK, response); err != nil {
+		// the response may be partially written, we can only report it.
 		s.server.OnErr(w, r, err)
-		return
 	}
+	return nil
+}
+
+func (s *webhookServiceServer) handleGetDelivery(w http.ResponseWriter, r *http.Request) error {
+	s.logger.For(r.Context()).Info("WebhookService.GetDelivery")
+
+	var request GetWebhookDeliveryRequest
+	if err := otohttp.Decode(r, &request); err != nil {
+		return errs.Wrap(errs.InvalidArgument, err, "malformed request")
+	}
+	if err := s.authorizer.Authorize(r.Context(), auth.Permission{
+		Roles:        []auth.Role{"organiser"},
+		ConferenceID: uint32(request.ConferenceID),
+	}); err != nil {
+		return err
+	}
+	if err := request.Validate(); err != nil {
+		return err
+	}
+	response, err := s.webhookService.GetDelivery(r.Context(), request)
+	if err != nil {
+		return err
+	}
+	if err := otohttp.Encode(w, r, http.StatusOK, response); err != nil {
+		// the response may be partially written, we can only report it.
+		s.server.OnErr(w, r, err)
+	}
+	return nil
+}
+
+func (s *webhookServiceServer) handleList(w http.ResponseWriter, r *http.Request) error {
+	s.logger.For(r.Context()).Info("WebhookService.List")
+
+	var request ListWebhooksRequest
+	if err := otohttp.Decode(r, &request); err != nil {
+		return errs.Wrap(errs.InvalidArgument, err, "malformed request")
+	}
+	if err := s.authorizer.Authorize(r.Context(), auth.Permission{
+		Roles:        []auth.Role{"organiser"},
+		ConferenceID: uint32(request.ConferenceID),
+	}); err != nil {
+		return err
+	}
+	if err := request.Validate(); err != nil {
+		return err
+	}
+	response, err := s.webhookService.List(r.Context(), request)
+	if err != nil {
+		return err
+	}
+	if err := otohttp.Encode(w, r, http.StatusOK, response); err != nil {
+		// the response may be partially written, we can only report it.
+		s.server.OnErr(w, r, err)
+	}
+	return nil
+}
+
+func (s *webhookServiceServer) handleListDeliveries(w http.ResponseWriter, r *http.Request) error {
+	s.logger.For(r.Context()).Info("WebhookService.ListDeliveries")
+
+	var request ListWebhookDeliveriesRequest
+	if err := otohttp.Decode(r, &request); err != nil {
+		return errs.Wrap(errs.InvalidArgument, err, "malformed request")
+	}
+	if err := s.authorizer.Authorize(r.Context(), auth.Permission{
+		Roles:        []auth.Role{"organiser"},
+		ConferenceID: uint32(request.ConferenceID),
+	}); err != nil {
+		return err
+	}
+	if err := request.Validate(); err != nil {
+		return err
+	}
+	response, err := s.webhookService.ListDeliveries(r.Context(), request)
+	if err != nil {
+		return err
+	}
+	if err := otohttp.Encode(w, r, http.StatusOK, response); err != nil {
+		// the response may be partially written, we can only report it.
+		s.server.OnErr(w, r, err)
+	}
+	return nil
+}
+
+func (s *webhookServiceServer) handleRedeliver(w http.ResponseWriter, r *http.Request) error {
+	s.logger.For(r.Context()).Info("WebhookService.Redeliver")
+
+	var request RedeliverWebhookRequest
+	if err := otohttp.Decode(r, &request); err != nil {
+		return errs.Wrap(errs.InvalidArgument, err, "malformed request")
+	}
+	if err := s.authorizer.Authorize(r.Context(), auth.Permission{
+		Roles:        []auth.Role{"organiser"},
+		ConferenceID: uint32(request.ConferenceID),
+	}); err != nil {
+		return err
+	}
+	if err := request.Validate(); err != nil {
+		return err
+	}
+	response, err := s.webhookService.Redeliver(r.Context(), request)
+	if err != nil {
+		return err
+	}
+	if err := otohttp.Encode(w, r, http.StatusOK, response); err != nil {
+		// the response may be partially written, we can only report it.
+		s.server.OnErr(w, r, err)
+	}
+	return nil
+}
+
+// EventSalesRequest is the request object for AnalyticsService.EventSales.
+type EventSalesRequest struct {
+	ConferenceID uint32 `json:"conferenceID"`
+	EventID      uint32 `json:"eventID"`
+}
+
+// Validate returns an InvalidArgument error listing the fields of the EventSalesRequest
+// that break the rules annotated in def, or nil if there are none.
+func (o *EventSalesRequest) Validate() error {
+	if violations := o.violations(""); len(violations) != 0 {
+		return errs.Invalid(violations...)
+	}
+	return nil
+}
+
+func (o *EventSalesRequest) violations(path validation.Path) []errs.FieldViolation {
+	if o == nil {
+		return nil
+	}
+	var violations []errs.FieldViolation
+	if o.ConferenceID == 0 {
+		violations = append(violations, path.Field("conferenceID").Violation("is required"))
+	}
+	if o.EventID == 0 {
+		violations = append(violations, path.Field("eventID").Violation("is required"))
+	}
+	return violations
+}
+
+// DailySales are the sales made on a day in the time zone of the event.
+type DailySales struct {
+	// Date is formatted as 2006-01-02.
+	Date  string `json:"date"`
+	Sold  int    `json:"sold"`
+	Gross int64  `json:"gross"`
+	Net   int64  `json:"net"`
+}
+
+// Validate returns an InvalidArgument error listing the fields of the DailySales
+// that break the rules annotated in def, or nil if there are none.
+func (o *DailySales) Validate() error {
+	if violations := o.violations(""); len(violations) != 0 {
+		return errs.Invalid(violations...)
+	}
+	return nil
+}
+
+func (o *DailySales) violations(path validation.Path) []errs.FieldViolation {
+	if o == nil {
+		return nil
+	}
+	var violations []errs.FieldViolation
+	return violations
+}
+
+// SlotSales are the sales of a slot, or of every slot of the event in their
+// totals.
+type SlotSales struct {
+	// SlotID and Name are empty in the totals of the event.
+	SlotID uint64 `json:"slotID"`
+	Name   string `json:"name"`
+	// Sold claims were paid for and Held ones were claimed but not paid for yet,
+	// both take capacity.
+	Capacity  int `json:"capacity"`
+	Sold      int `json:"sold"`
+	Held      int `json:"held"`
+	Remaining int `json:"remaining"`
+	// Gross is the cost of the claims sold and Net what is left of it after Discounts,
+	// in cents.
+	Gross     int64 `json:"gross"`
+	Discounts int64 `json:"discounts"`
+	Net       int64 `json:"net"`
+	// Velocity is how many claims were sold a day over the last week.
+	Velocity float64 `json:"velocity"`
+	// SellOut is when what remains is projected to be sold at Velocity, 0 if nothing
+	// remains or nothing sold lately; SellsOut is true if that is before SalesEnd.
+	SellOut  uint64 `json:"sellOut"`
+	SellsOut bool   `json:"sellsOut"`
+	// SalesEnd is when the slot stops being sold, 0 if unknown.
+	SalesEnd uint64 `json:"salesEnd"`
+	// Daily are the sales of every day from the first sale until today.
+	Daily []DailySales `json:"daily"`
+}
+
+// Validate returns an InvalidArgument error listing the fields of the SlotSales
+// that break the rules annotated in def, or nil if there are none.
+func (o *SlotSales) Validate() error {
+	if violations := o.violations(""); len(violations) != 0 {
+		return errs.Invalid(violations...)
+	}
+	return nil
+}
+
+func (o *SlotSales) violations(path validation.Path) []errs.FieldViolation {
+	if o == nil {
+		return nil
+	}
+	var violations []errs.FieldViolation
+	for i := range o.Daily {
+		violations = append(violations, o.Daily[i].violations(path.Field("daily").Index(i))...)
+	}
+	return violations
+}
+
+// PromoUsage is how much a discount was used for the event, by its detail.
+type PromoUsage struct {
+	Code     string `json:"code"`
+	Payments int    `json:"payments"`
+	Claims   int    `json:"claims"`
+	// Discount is what was taken off the claims of the event, in cents.
+	Discount int64 `json:"discount"`
+}
+
+// Validate returns an InvalidArgument error listing the fields of the PromoUsage
+// that break the rules annotated in def, or nil if there are none.
+func (o *PromoUsage) Validate() error {
+	if violations := o.violations(""); len(violations) != 0 {
+		return errs.Invalid(violations...)
+	}
+	return nil
+}
+
+func (o *PromoUsage) violations(path validation.Path) []errs.FieldViolation {
+	if o == nil {
+		return nil
+	}
+	var violations []errs.FieldViolation
+	return violations
+}
+
+// EventSalesResponse is the response object for AnalyticsService.EventSales.
+type EventSalesResponse struct {
+	AsOf uint64 `json:"asOf"`
+	// TimeZone is the one days are counted in.
+	TimeZone string `json:"timeZone"`
+	// Slots are ordered by ID, Total sums them.
+	Slots []SlotSales `json:"slots"`
+	Total SlotSales   `json:"total"`
+	// Promos are ordered by most used.
+	Promos []PromoUsage `json:"promos"`
+	// Error is string explaining what went wrong. Empty if everything was fine.
+	Error string `json:"error,omitempty"`
+}
+
+// Validate returns an InvalidArgument error listing the fields of the EventSalesResponse
+// that break the rules annotated in def, or nil if there are none.
+func (o *EventSalesResponse) Validate() error {
+	if violations := o.violations(""); len(violations) != 0 {
+		return errs.Invalid(violations...)
+	}
+	return nil
+}
+
+func (o *EventSalesResponse) violations(path validation.Path) []errs.FieldViolation {
+	if o == nil {
+		return nil
+	}
+	var violations []errs.FieldViolation
+	for i := range o.Slots {
+		violations = append(violations, o.Slots[i].violations(path.Field("slots").Index(i))...)
+	}
+	violations = append(violations, o.Total.violations(path.Field("total"))...)
+	for i := range o.Promos {
+		violations = append(violations, o.Promos[i].violations(path.Field("promos").Index(i))...)
+	}
+	return violations
+}
+
+// AttendeeFeedRequest is the request object for CalendarService.AttendeeFeed.
+type AttendeeFeedRequest struct {
+}
+
+// Validate returns an InvalidArgument error listing the fields of the AttendeeFeedRequest
+// that break the rules annotated in def, or nil if there are none.
+func (o *AttendeeFeedRequest) Validate() error {
+	if violations := o.violations(""); len(violations) != 0 {
+		return errs.Invalid(violations...)
+	}
+	return nil
+}
+
+func (o *AttendeeFeedRequest) violations(path validation.Path) []errs.FieldViolation {
+	if o == nil {
+		return nil
+	}
+	var violations []errs.FieldViolation
+	return violations
+}
+
+// AttendeeFeedResponse is the response object for CalendarService.AttendeeFeed.
+type AttendeeFeedResponse struct {
+	// URL is the iCalendar feed, anyone holding it can read the agenda.
+	URL string `json:"url"`
+	// Error is string explaining what went wrong. Empty if everything was fine.
+	Error string `json:"error,omitempty"`
+}
+
+// Validate returns an InvalidArgument error listing the fields of the AttendeeFeedResponse
+// that break the rules annotated in def, or nil if there are none.
+func (o *AttendeeFeedResponse) Validate() error {
+	if violations := o.violations(""); len(violations) != 0 {
+		return errs.Invalid(violations...)
+	}
+	return nil
+}
+
+func (o *AttendeeFeedResponse) violations(path validation.Path) []errs.FieldViolation {
+	if o == nil {
+		return nil
+	}
+	var violations []errs.FieldViolation
+	return violations
 }
 
 // EventSlot holds information for any sellable/giftable slot we have in the event
@@ -165,15 +846,167 @@ type EventSlot struct {
 	AvailableToPublic bool `json:"availableToPublic"`
 }
 
+// Validate returns an InvalidArgument error listing the fields of the EventSlot
+// that break the rules annotated in def, or nil if there are none.
+func (o *EventSlot) Validate() error {
+	if violations := o.violations(""); len(violations) != 0 {
+		return errs.Invalid(violations...)
+	}
+	return nil
+}
+
+func (o *EventSlot) violations(path validation.Path) []errs.FieldViolation {
+	if o == nil {
+		return nil
+	}
+	var violations []errs.FieldViolation
+	if o.Name == "" {
+		violations = append(violations, path.Field("name").Violation("is required"))
+	}
+	if o.Cost < 0 {
+		violations = append(violations, path.Field("cost").Violation("must be at least 0"))
+	}
+	if o.Capacity < 0 {
+		violations = append(violations, path.Field("capacity").Violation("must be at least 0"))
+	}
+	if o.StartDate != 0 && o.EndDate != 0 && o.StartDate >= o.EndDate {
+		violations = append(violations, path.Field("startDate").Violation("must be before endDate"))
+	}
+	if o.PurchaseableFrom != 0 && o.PurchaseableUntil != 0 && o.PurchaseableFrom >= o.PurchaseableUntil {
+		violations = append(violations, path.Field("purchaseableFrom").Violation("must be before purchaseableUntil"))
+	}
+	return violations
+}
+
+// SponsorContact is a person to contact at a sponsor for a given matter.
+type SponsorContact struct {
+	ID uint32 `json:"id"`
+	// Role is what this person is the contact for (ie marketing, recruiting or
+	// logistics)
+	Role  string `json:"role"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	Phone string `json:"phone"`
+}
+
+// Validate returns an InvalidArgument error listing the fields of the SponsorContact
+// that break the rules annotated in def, or nil if there are none.
+func (o *SponsorContact) Validate() error {
+	if violations := o.violations(""); len(violations) != 0 {
+		return errs.Invalid(violations...)
+	}
+	return nil
+}
+
+func (o *SponsorContact) violations(path validation.Path) []errs.FieldViolation {
+	if o == nil {
+		return nil
+	}
+	var violations []errs.FieldViolation
+	if o.Email != "" && !validation.Matches("email", o.Email) {
+		violations = append(violations, path.Field("email").Violation("must be a valid email"))
+	}
+	return violations
+}
+
+// Sponsor is a company that pays the conference a fee in consideration for
+// marketing based on sponsorship level.
+type Sponsor struct {
+	ID   uint32 `json:"id"`
+	Name string `json:"name"`
+	// Level is one of the SponsorshipLevels of the event.
+	Level   string `json:"level"`
+	Website string `json:"website"`
+	// Logo is the URL of the logo to display.
+	Logo string `json:"logo"`
+	// Profile is the public profile for display on the website.
+	Profile string `json:"profile"`
+	// Contacts are the people we deal with at the company, these are never public.
+	Contacts []SponsorContact `json:"contacts"`
+}
+
+// Validate returns an InvalidArgument error listing the fields of the Sponsor
+// that break the rules annotated in def, or nil if there are none.
+func (o *Sponsor) Validate() error {
+	if violations := o.violations(""); len(violations) != 0 {
+		return errs.Invalid(violations...)
+	}
+	return nil
+}
+
+func (o *Sponsor) violations(path validation.Path) []errs.FieldViolation {
+	if o == nil {
+		return nil
+	}
+	var violations []errs.FieldViolation
+	if o.Name == "" {
+		violations = append(violations, path.Field("name").Violation("is required"))
+	}
+	for i := range o.Contacts {
+		violations = append(violations, o.Contacts[i].violations(path.Field("contacts").Index(i))...)
+	}
+	return violations
+}
+
 // Event is an instance like GopherCon 2020
 type Event struct {
-	ID        uint32      `json:"id"`
-	Name      string      `json:"name"`
-	Slug      string      `json:"slug"`
-	StartDate uint64      `json:"startDate"`
-	EndDate   uint64      `json:"endDate"`
-	Location  string      `json:"location"`
-	Slots     []EventSlot `json:"slots"`
+	ID        uint32 `json:"id"`
+	Name      string `json:"name"`
+	Slug      string `json:"slug"`
+	StartDate uint64 `json:"startDate"`
+	EndDate   uint64 `json:"endDate"`
+	Location  string `json:"location"`
+	// TimeZone is the IANA name of the zone where the event happens (ie
+	// America/Denver), dates are still Unix timestamps, this is used to present them.
+	TimeZone string `json:"timeZone"`
+	// Live indicates the event is published and ready for public sales.
+	Live  bool        `json:"live"`
+	Slots []EventSlot `json:"slots"`
+	// SponsorshipLevels are the names of the levels sold for this event, from the
+	// highest.
+	SponsorshipLevels []string  `json:"sponsorshipLevels"`
+	Sponsors          []Sponsor `json:"sponsors"`
+}
+
+// Validate returns an InvalidArgument error listing the fields of the Event
+// that break the rules annotated in def, or nil if there are none.
+func (o *Event) Validate() error {
+	if violations := o.violations(""); len(violations) != 0 {
+		return errs.Invalid(violations...)
+	}
+	return nil
+}
+
+func (o *Event) violations(path validation.Path) []errs.FieldViolation {
+	if o == nil {
+		return nil
+	}
+	var violations []errs.FieldViolation
+	if o.Name == "" {
+		violations = append(violations, path.Field("name").Violation("is required"))
+	}
+	if validation.Length(o.Name) > 100 {
+		violations = append(violations, path.Field("name").Violation("must be at most 100 characters long"))
+	}
+	if o.Slug == "" {
+		violations = append(violations, path.Field("slug").Violation("is required"))
+	}
+	if validation.Length(o.Slug) > 50 {
+		violations = append(violations, path.Field("slug").Violation("must be at most 50 characters long"))
+	}
+	if o.Slug != "" && !validation.Matches("slug", o.Slug) {
+		violations = append(violations, path.Field("slug").Violation("must be a valid slug"))
+	}
+	if o.StartDate != 0 && o.EndDate != 0 && o.StartDate >= o.EndDate {
+		violations = append(violations, path.Field("startDate").Violation("must be before endDate"))
+	}
+	for i := range o.Slots {
+		violations = append(violations, o.Slots[i].violations(path.Field("slots").Index(i))...)
+	}
+	for i := range o.Sponsors {
+		violations = append(violations, o.Sponsors[i].violations(path.Field("sponsors").Index(i))...)
+	}
+	return violations
 }
 
 // Conference is a brand like GopherCon
@@ -184,11 +1017,64 @@ type Conference struct {
 	Events []Event `json:"events"`
 }
 
+// Validate returns an InvalidArgument error listing the fields of the Conference
+// that break the rules annotated in def, or nil if there are none.
+func (o *Conference) Validate() error {
+	if violations := o.violations(""); len(violations) != 0 {
+		return errs.Invalid(violations...)
+	}
+	return nil
+}
+
+func (o *Conference) violations(path validation.Path) []errs.FieldViolation {
+	if o == nil {
+		return nil
+	}
+	var violations []errs.FieldViolation
+	if o.Name == "" {
+		violations = append(violations, path.Field("name").Violation("is required"))
+	}
+	if validation.Length(o.Name) > 100 {
+		violations = append(violations, path.Field("name").Violation("must be at most 100 characters long"))
+	}
+	if o.Slug == "" {
+		violations = append(violations, path.Field("slug").Violation("is required"))
+	}
+	if validation.Length(o.Slug) > 50 {
+		violations = append(violations, path.Field("slug").Violation("must be at most 50 characters long"))
+	}
+	if o.Slug != "" && !validation.Matches("slug", o.Slug) {
+		violations = append(violations, path.Field("slug").Violation("must be a valid slug"))
+	}
+	for i := range o.Events {
+		violations = append(violations, o.Events[i].violations(path.Field("events").Index(i))...)
+	}
+	return violations
+}
+
 // CreateConferenceRequest is the request object for ConferenceService.Create.
 type CreateConferenceRequest struct {
 	Conference Conference `json:"conference"`
 }
 
+// Validate returns an InvalidArgument error listing the fields of the CreateConferenceRequest
+// that break the rules annotated in def, or nil if there are none.
+func (o *CreateConferenceRequest) Validate() error {
+	if violations := o.violations(""); len(violations) != 0 {
+		return errs.Invalid(violations...)
+	}
+	return nil
+}
+
+func (o *CreateConferenceRequest) violations(path validation.Path) []errs.FieldViolation {
+	if o == nil {
+		return nil
+	}
+	var violations []errs.FieldViolation
+	violations = append(violations, o.Conference.violations(path.Field("conference"))...)
+	return violations
+}
+
 // CreateConferenceResponse is the response object for ConferenceService.Create.
 type CreateConferenceResponse struct {
 	Conference Conference `json:"conference"`
@@ -196,22 +1082,91 @@ type CreateConferenceResponse struct {
 	Error string `json:"error,omitempty"`
 }
 
+// Validate returns an InvalidArgument error listing the fields of the CreateConferenceResponse
+// that break the rules annotated in def, or nil if there are none.
+func (o *CreateConferenceResponse) Validate() error {
+	if violations := o.violations(""); len(violations) != 0 {
+		return errs.Invalid(violations...)
+	}
+	return nil
+}
+
+func (o *CreateConferenceResponse) violations(path validation.Path) []errs.FieldViolation {
+	if o == nil {
+		return nil
+	}
+	var violations []errs.FieldViolation
+	violations = append(violations, o.Conference.violations(path.Field("conference"))...)
+	return violations
+}
+
 // DeleteConferenceRequest is the request object for ConferenceService.Delete.
 type DeleteConferenceRequest struct {
 	ID uint32 `json:"id"`
 }
 
+// Validate returns an InvalidArgument error listing the fields of the DeleteConferenceRequest
+// that break the rules annotated in def, or nil if there are none.
+func (o *DeleteConferenceRequest) Validate() error {
+	if violations := o.violations(""); len(violations) != 0 {
+		return errs.Invalid(violations...)
+	}
+	return nil
+}
+
+func (o *DeleteConferenceRequest) violations(path validation.Path) []errs.FieldViolation {
+	if o == nil {
+		return nil
+	}
+	var violations []errs.FieldViolation
+	return violations
+}
+
 // DeleteConferenceResponse is the response object for ConferenceService.Delete.
 type DeleteConferenceResponse struct {
 	// Error is string explaining what went wrong. Empty if everything was fine.
 	Error string `json:"error,omitempty"`
 }
 
+// Validate returns an InvalidArgument error listing the fields of the DeleteConferenceResponse
+// that break the rules annotated in def, or nil if there are none.
+func (o *DeleteConferenceResponse) Validate() error {
+	if violations := o.violations(""); len(violations) != 0 {
+		return errs.Invalid(violations...)
+	}
+	return nil
+}
+
+func (o *DeleteConferenceResponse) violations(path validation.Path) []errs.FieldViolation {
+	if o == nil {
+		return nil
+	}
+	var violations []errs.FieldViolation
+	return violations
+}
+
 // GetConferenceRequest is the request object for ConferenceService.Get.
 type GetConferenceRequest struct {
 	ID uint32 `json:"id"`
 }
 
+// Validate returns an InvalidArgument error listing the fields of the GetConferenceRequest
+// that break the rules annotated in def, or nil if there are none.
+func (o *GetConferenceRequest) Validate() error {
+	if violations := o.violations(""); len(violations) != 0 {
+		return errs.Invalid(violations...)
+	}
+	return nil
+}
+
+func (o *GetConferenceRequest) violations(path validation.Path) []errs.FieldViolation {
+	if o == nil {
+		return nil
+	}
+	var violations []errs.FieldViolation
+	return violations
+}
+
 // GetConferenceResponse is the response object containing a single Conference
 type GetConferenceResponse struct {
 	// Conference represents an event like GopherCon 2020
@@ -220,16 +1175,74 @@ type GetConferenceResponse struct {
 	Error string `json:"error,omitempty"`
 }
 
+// Validate returns an InvalidArgument error listing the fields of the GetConferenceResponse
+// that break the rules annotated in def, or nil if there are none.
+func (o *GetConferenceResponse) Validate() error {
+	if violations := o.violations(""); len(violations) != 0 {
+		return errs.Invalid(violations...)
+	}
+	return nil
+}
+
+func (o *GetConferenceResponse) violations(path validation.Path) []errs.FieldViolation {
+	if o == nil {
+		return nil
+	}
+	var violations []errs.FieldViolation
+	violations = append(violations, o.Conference.violations(path.Field("conference"))...)
+	return violations
+}
+
 // GetConferenceBySlugRequest is the request object for
 // ConferenceService.GetBySlug.
 type GetConferenceBySlugRequest struct {
 	Slug string `json:"slug"`
 }
 
+// Validate returns an InvalidArgument error listing the fields of the GetConferenceBySlugRequest
+// that break the rules annotated in def, or nil if there are none.
+func (o *GetConferenceBySlugRequest) Validate() error {
+	if violations := o.violations(""); len(violations) != 0 {
+		return errs.Invalid(violations...)
+	}
+	return nil
+}
+
+func (o *GetConferenceBySlugRequest) violations(path validation.Path) []errs.FieldViolation {
+	if o == nil {
+		return nil
+	}
+	var violations []errs.FieldViolation
+	if o.Slug == "" {
+		violations = append(violations, path.Field("slug").Violation("is required"))
+	}
+	if o.Slug != "" && !validation.Matches("slug", o.Slug) {
+		violations = append(violations, path.Field("slug").Violation("must be a valid slug"))
+	}
+	return violations
+}
+
 // ListConferenceRequest is the request object for ConferenceService.List.
 type ListConferenceRequest struct {
 }
 
+// Validate returns an InvalidArgument error listing the fields of the ListConferenceRequest
+// that break the rules annotated in def, or nil if there are none.
+func (o *ListConferenceRequest) Validate() error {
+	if violations := o.violations(""); len(violations) != 0 {
+		return errs.Invalid(violations...)
+	}
+	return nil
+}
+
+func (o *ListConferenceRequest) violations(path validation.Path) []errs.FieldViolation {
+	if o == nil {
+		return nil
+	}
+	var violations []errs.FieldViolation
+	return violations
+}
+
 // ListConferenceResponse is the response object containing a list of Conferences
 type ListConferenceResponse struct {
 	// Greeting is a nice message welcoming somebody.
@@ -237,3 +1250,475 @@ type ListConferenceResponse struct {
 	// Error is string explaining what went wrong. Empty if everything was fine.
 	Error string `json:"error,omitempty"`
 }
+
+// Validate returns an InvalidArgument error listing the fields of the ListConferenceResponse
+// that break the rules annotated in def, or nil if there are none.
+func (o *ListConferenceResponse) Validate() error {
+	if violations := o.violations(""); len(violations) != 0 {
+		return errs.Invalid(violations...)
+	}
+	return nil
+}
+
+func (o *ListConferenceResponse) violations(path validation.Path) []errs.FieldViolation {
+	if o == nil {
+		return nil
+	}
+	var violations []errs.FieldViolation
+	for i := range o.Conferences {
+		violations = append(violations, o.Conferences[i].violations(path.Field("conferences").Index(i))...)
+	}
+	return violations
+}
+
+// Webhook posts the events of a conference to an URL, each delivery is signed with
+// its secret in the X-Showrunner-Signature header.
+type Webhook struct {
+	ID           uint64 `json:"id"`
+	ConferenceID uint32 `json:"conferenceID"`
+	URL          string `json:"url"`
+	// EventTypes are the types of the events posted (ie ticketing.ClaimCreated),
+	// all of them if empty.
+	EventTypes []string `json:"eventTypes"`
+	// Secret signs the deliveries, one is generated if empty; it is only returned when
+	// the webhook is created.
+	Secret    string `json:"secret"`
+	CreatedAt uint64 `json:"createdAt"`
+}
+
+// Validate returns an InvalidArgument error listing the fields of the Webhook
+// that break the rules annotated in def, or nil if there are none.
+func (o *Webhook) Validate() error {
+	if violations := o.violations(""); len(violations) != 0 {
+		return errs.Invalid(violations...)
+	}
+	return nil
+}
+
+func (o *Webhook) violations(path validation.Path) []errs.FieldViolation {
+	if o == nil {
+		return nil
+	}
+	var violations []errs.FieldViolation
+	if o.URL == "" {
+		violations = append(violations, path.Field("url").Violation("is required"))
+	}
+	if validation.Length(o.URL) > 2000 {
+		violations = append(violations, path.Field("url").Violation("must be at most 2000 characters long"))
+	}
+	if o.URL != "" && !validation.Matches("https url", o.URL) {
+		violations = append(violations, path.Field("url").Violation("must be a valid https url"))
+	}
+	if validation.Length(o.Secret) > 200 {
+		violations = append(violations, path.Field("secret").Violation("must be at most 200 characters long"))
+	}
+	return violations
+}
+
+// CreateWebhookRequest is the request object for WebhookService.Create.
+type CreateWebhookRequest struct {
+	ConferenceID uint32  `json:"conferenceID"`
+	Webhook      Webhook `json:"webhook"`
+}
+
+// Validate returns an InvalidArgument error listing the fields of the CreateWebhookRequest
+// that break the rules annotated in def, or nil if there are none.
+func (o *CreateWebhookRequest) Validate() error {
+	if violations := o.violations(""); len(violations) != 0 {
+		return errs.Invalid(violations...)
+	}
+	return nil
+}
+
+func (o *CreateWebhookRequest) violations(path validation.Path) []errs.FieldViolation {
+	if o == nil {
+		return nil
+	}
+	var violations []errs.FieldViolation
+	if o.ConferenceID == 0 {
+		violations = append(violations, path.Field("conferenceID").Violation("is required"))
+	}
+	violations = append(violations, o.Webhook.violations(path.Field("webhook"))...)
+	return violations
+}
+
+// CreateWebhookResponse is the response object for WebhookService.Create.
+type CreateWebhookResponse struct {
+	Webhook Webhook `json:"webhook"`
+	// Error is string explaining what went wrong. Empty if everything was fine.
+	Error string `json:"error,omitempty"`
+}
+
+// Validate returns an InvalidArgument error listing the fields of the CreateWebhookResponse
+// that break the rules annotated in def, or nil if there are none.
+func (o *CreateWebhookResponse) Validate() error {
+	if violations := o.violations(""); len(violations) != 0 {
+		return errs.Invalid(violations...)
+	}
+	return nil
+}
+
+func (o *CreateWebhookResponse) violations(path validation.Path) []errs.FieldViolation {
+	if o == nil {
+		return nil
+	}
+	var violations []errs.FieldViolation
+	violations = append(violations, o.Webhook.violations(path.Field("webhook"))...)
+	return violations
+}
+
+// DeleteWebhookRequest is the request object for WebhookService.Delete.
+type DeleteWebhookRequest struct {
+	ConferenceID uint32 `json:"conferenceID"`
+	ID           uint64 `json:"id"`
+}
+
+// Validate returns an InvalidArgument error listing the fields of the DeleteWebhookRequest
+// that break the rules annotated in def, or nil if there are none.
+func (o *DeleteWebhookRequest) Validate() error {
+	if violations := o.violations(""); len(violations) != 0 {
+		return errs.Invalid(violations...)
+	}
+	return nil
+}
+
+func (o *DeleteWebhookRequest) violations(path validation.Path) []errs.FieldViolation {
+	if o == nil {
+		return nil
+	}
+	var violations []errs.FieldViolation
+	if o.ConferenceID == 0 {
+		violations = append(violations, path.Field("conferenceID").Violation("is required"))
+	}
+	if o.ID == 0 {
+		violations = append(violations, path.Field("id").Violation("is required"))
+	}
+	return violations
+}
+
+// DeleteWebhookResponse is the response object for WebhookService.Delete.
+type DeleteWebhookResponse struct {
+	// Error is string explaining what went wrong. Empty if everything was fine.
+	Error string `json:"error,omitempty"`
+}
+
+// Validate returns an InvalidArgument error listing the fields of the DeleteWebhookResponse
+// that break the rules annotated in def, or nil if there are none.
+func (o *DeleteWebhookResponse) Validate() error {
+	if violations := o.violations(""); len(violations) != 0 {
+		return errs.Invalid(violations...)
+	}
+	return nil
+}
+
+func (o *DeleteWebhookResponse) violations(path validation.Path) []errs.FieldViolation {
+	if o == nil {
+		return nil
+	}
+	var violations []errs.FieldViolation
+	return violations
+}
+
+// GetWebhookDeliveryRequest is the request object for WebhookService.GetDelivery.
+type GetWebhookDeliveryRequest struct {
+	ConferenceID uint32 `json:"conferenceID"`
+	ID           uint64 `json:"id"`
+}
+
+// Validate returns an InvalidArgument error listing the fields of the GetWebhookDeliveryRequest
+// that break the rules annotated in def, or nil if there are none.
+func (o *GetWebhookDeliveryRequest) Validate() error {
+	if violations := o.violations(""); len(violations) != 0 {
+		return errs.Invalid(violations...)
+	}
+	return nil
+}
+
+func (o *GetWebhookDeliveryRequest) violations(path validation.Path) []errs.FieldViolation {
+	if o == nil {
+		return nil
+	}
+	var violations []errs.FieldViolation
+	if o.ConferenceID == 0 {
+		violations = append(violations, path.Field("conferenceID").Violation("is required"))
+	}
+	if o.ID == 0 {
+		violations = append(violations, path.Field("id").Violation("is required"))
+	}
+	return violations
+}
+
+// WebhookDelivery is an event to post to a webhook.
+type WebhookDelivery struct {
+	ID        uint64 `json:"id"`
+	WebhookID uint64 `json:"webhookID"`
+	// EventID identifies the event, it is the same in every delivery of the event.
+	EventID   uint64 `json:"eventID"`
+	EventType string `json:"eventType"`
+	// Payload is the JSON posted.
+	Payload string `json:"payload"`
+	// Status is pending until delivered, or dead once every attempt failed; dead
+	// deliveries are only attempted again when redelivered.
+	Status string `json:"status"`
+	// Attempts counts the failed attempts.
+	Attempts      int    `json:"attempts"`
+	NextAttemptAt uint64 `json:"nextAttemptAt"`
+	CreatedAt     uint64 `json:"createdAt"`
+}
+
+// Validate returns an InvalidArgument error listing the fields of the WebhookDelivery
+// that break the rules annotated in def, or nil if there are none.
+func (o *WebhookDelivery) Validate() error {
+	if violations := o.violations(""); len(violations) != 0 {
+		return errs.Invalid(violations...)
+	}
+	return nil
+}
+
+func (o *WebhookDelivery) violations(path validation.Path) []errs.FieldViolation {
+	if o == nil {
+		return nil
+	}
+	var violations []errs.FieldViolation
+	return violations
+}
+
+// WebhookAttempt is the log of one attempt of a delivery.
+type WebhookAttempt struct {
+	ID          uint64 `json:"id"`
+	DeliveryID  uint64 `json:"deliveryID"`
+	AttemptedAt uint64 `json:"attemptedAt"`
+	// RequestHeaders and ResponseHeaders hold one header per line.
+	RequestHeaders string `json:"requestHeaders"`
+	RequestBody    string `json:"requestBody"`
+	// ResponseStatus is 0 if no response was received, see Error.
+	ResponseStatus  int    `json:"responseStatus"`
+	ResponseHeaders string `json:"responseHeaders"`
+	// ResponseBody is truncated to 64KiB.
+	ResponseBody string `json:"responseBody"`
+	Error        string `json:"error"`
+	DurationMS   int    `json:"durationMS"`
+}
+
+// Validate returns an InvalidArgument error listing the fields of the WebhookAttempt
+// that break the rules annotated in def, or nil if there are none.
+func (o *WebhookAttempt) Validate() error {
+	if violations := o.violations(""); len(violations) != 0 {
+		return errs.Invalid(violations...)
+	}
+	return nil
+}
+
+func (o *WebhookAttempt) violations(path validation.Path) []errs.FieldViolation {
+	if o == nil {
+		return nil
+	}
+	var violations []errs.FieldViolation
+	return violations
+}
+
+// GetWebhookDeliveryResponse is the response object for
+// WebhookService.GetDelivery.
+type GetWebhookDeliveryResponse struct {
+	Delivery WebhookDelivery `json:"delivery"`
+	// Attempts are in the order they were made.
+	Attempts []WebhookAttempt `json:"attempts"`
+	// Error is string explaining what went wrong. Empty if everything was fine.
+	Error string `json:"error,omitempty"`
+}
+
+// Validate returns an InvalidArgument error listing the fields of the GetWebhookDeliveryResponse
+// that break the rules annotated in def, or nil if there are none.
+func (o *GetWebhookDeliveryResponse) Validate() error {
+	if violations := o.violations(""); len(violations) != 0 {
+		return errs.Invalid(violations...)
+	}
+	return nil
+}
+
+func (o *GetWebhookDeliveryResponse) violations(path validation.Path) []errs.FieldViolation {
+	if o == nil {
+		return nil
+	}
+	var violations []errs.FieldViolation
+	violations = append(violations, o.Delivery.violations(path.Field("delivery"))...)
+	for i := range o.Attempts {
+		violations = append(violations, o.Attempts[i].violations(path.Field("attempts").Index(i))...)
+	}
+	return violations
+}
+
+// ListWebhookDeliveriesRequest is the request object for
+// WebhookService.ListDeliveries.
+type ListWebhookDeliveriesRequest struct {
+	ConferenceID uint32 `json:"conferenceID"`
+	WebhookID    uint64 `json:"webhookID"`
+	// Status only returns the deliveries with this status if set.
+	Status string `json:"status"`
+	// Limit defaults to 50.
+	Limit int `json:"limit"`
+}
+
+// Validate returns an InvalidArgument error listing the fields of the ListWebhookDeliveriesRequest
+// that break the rules annotated in def, or nil if there are none.
+func (o *ListWebhookDeliveriesRequest) Validate() error {
+	if violations := o.violations(""); len(violations) != 0 {
+		return errs.Invalid(violations...)
+	}
+	return nil
+}
+
+func (o *ListWebhookDeliveriesRequest) violations(path validation.Path) []errs.FieldViolation {
+	if o == nil {
+		return nil
+	}
+	var violations []errs.FieldViolation
+	if o.ConferenceID == 0 {
+		violations = append(violations, path.Field("conferenceID").Violation("is required"))
+	}
+	if o.WebhookID == 0 {
+		violations = append(violations, path.Field("webhookID").Violation("is required"))
+	}
+	if o.Limit < 0 {
+		violations = append(violations, path.Field("limit").Violation("must be at least 0"))
+	}
+	if o.Limit > 500 {
+		violations = append(violations, path.Field("limit").Violation("must be at most 500"))
+	}
+	return violations
+}
+
+// ListWebhookDeliveriesResponse is the response object for
+// WebhookService.ListDeliveries.
+type ListWebhookDeliveriesResponse struct {
+	// Deliveries are the latest first.
+	Deliveries []WebhookDelivery `json:"deliveries"`
+	// Error is string explaining what went wrong. Empty if everything was fine.
+	Error string `json:"error,omitempty"`
+}
+
+// Validate returns an InvalidArgument error listing the fields of the ListWebhookDeliveriesResponse
+// that break the rules annotated in def, or nil if there are none.
+func (o *ListWebhookDeliveriesResponse) Validate() error {
+	if violations := o.violations(""); len(violations) != 0 {
+		return errs.Invalid(violations...)
+	}
+	return nil
+}
+
+func (o *ListWebhookDeliveriesResponse) violations(path validation.Path) []errs.FieldViolation {
+	if o == nil {
+		return nil
+	}
+	var violations []errs.FieldViolation
+	for i := range o.Deliveries {
+		violations = append(violations, o.Deliveries[i].violations(path.Field("deliveries").Index(i))...)
+	}
+	return violations
+}
+
+// ListWebhooksRequest is the request object for WebhookService.List.
+type ListWebhooksRequest struct {
+	ConferenceID uint32 `json:"conferenceID"`
+}
+
+// Validate returns an InvalidArgument error listing the fields of the ListWebhooksRequest
+// that break the rules annotated in def, or nil if there are none.
+func (o *ListWebhooksRequest) Validate() error {
+	if violations := o.violations(""); len(violations) != 0 {
+		return errs.Invalid(violations...)
+	}
+	return nil
+}
+
+func (o *ListWebhooksRequest) violations(path validation.Path) []errs.FieldViolation {
+	if o == nil {
+		return nil
+	}
+	var violations []errs.FieldViolation
+	if o.ConferenceID == 0 {
+		violations = append(violations, path.Field("conferenceID").Violation("is required"))
+	}
+	return violations
+}
+
+// ListWebhooksResponse is the response object for WebhookService.List.
+type ListWebhooksResponse struct {
+	Webhooks []Webhook `json:"webhooks"`
+	// Error is string explaining what went wrong. Empty if everything was fine.
+	Error string `json:"error,omitempty"`
+}
+
+// Validate returns an InvalidArgument error listing the fields of the ListWebhooksResponse
+// that break the rules annotated in def, or nil if there are none.
+func (o *ListWebhooksResponse) Validate() error {
+	if violations := o.violations(""); len(violations) != 0 {
+		return errs.Invalid(violations...)
+	}
+	return nil
+}
+
+func (o *ListWebhooksResponse) violations(path validation.Path) []errs.FieldViolation {
+	if o == nil {
+		return nil
+	}
+	var violations []errs.FieldViolation
+	for i := range o.Webhooks {
+		violations = append(violations, o.Webhooks[i].violations(path.Field("webhooks").Index(i))...)
+	}
+	return violations
+}
+
+// RedeliverWebhookRequest is the request object for WebhookService.Redeliver.
+type RedeliverWebhookRequest struct {
+	ConferenceID uint32 `json:"conferenceID"`
+	ID           uint64 `json:"id"`
+}
+
+// Validate returns an InvalidArgument error listing the fields of the RedeliverWebhookRequest
+// that break the rules annotated in def, or nil if there are none.
+func (o *RedeliverWebhookRequest) Validate() error {
+	if violations := o.violations(""); len(violations) != 0 {
+		return errs.Invalid(violations...)
+	}
+	return nil
+}
+
+func (o *RedeliverWebhookRequest) violations(path validation.Path) []errs.FieldViolation {
+	if o == nil {
+		return nil
+	}
+	var violations []errs.FieldViolation
+	if o.ConferenceID == 0 {
+		violations = append(violations, path.Field("conferenceID").Violation("is required"))
+	}
+	if o.ID == 0 {
+		violations = append(violations, path.Field("id").Violation("is required"))
+	}
+	return violations
+}
+
+// RedeliverWebhookResponse is the response object for WebhookService.Redeliver.
+type RedeliverWebhookResponse struct {
+	Delivery WebhookDelivery `json:"delivery"`
+	// Error is string explaining what went wrong. Empty if everything was fine.
+	Error string `json:"error,omitempty"`
+}
+
+// Validate returns an InvalidArgument error listing the fields of the RedeliverWebhookResponse
+// that break the rules annotated in def, or nil if there are none.
+func (o *RedeliverWebhookResponse) Validate() error {
+	if violations := o.violations(""); len(violations) != 0 {
+		return errs.Invalid(violations...)
+	}
+	return nil
+}
+
+func (o *RedeliverWebhookResponse) violations(path validation.Path) []errs.FieldViolation {
+	if o == nil {
+		return nil
+	}
+	var violations []errs.FieldViolation
+	violations = append(violations, o.Delivery.violations(path.Field("delivery"))...)
+	return violations
+}
@@ -0,0 +1,7 @@
+// Package store persists the def types annotated to be stored in a table, its
+// repositories (store.gen.go) and schema (schema.gen.sql) are generated by generate.sh
+// from templates/storage.go.plush and templates/schema.sql.plush, do not edit them.
+//
+// The schema is not applied as is, changes to it need a migration in the migrations
+// package.
+package store
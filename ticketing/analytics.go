@@ -0,0 +1,321 @@
+package ticketing
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// VelocityDays is how many days before now sales velocity is measured over.
+const VelocityDays = 7
+
+// SlotSales are the sales of a slot, or of every slot of an event for SalesReport.Total.
+type SlotSales struct {
+	// Slot is zero in the totals of an event.
+	Slot EventSlot
+	// Sold claims were paid for and Held ones were claimed but not paid for yet, they
+	// take capacity all the same; Remaining is what is left of the capacity, never
+	// negative.
+	Capacity  int
+	Sold      int
+	Held      int
+	Remaining int
+	// Gross is the cost of the claims sold and Net what is left of it after discounts,
+	// each payment's discounts are spread over its claims by their cost.
+	Gross     int64
+	Discounts int64
+	Net       int64
+	// Velocity is how many claims were sold a day over the VelocityDays before now.
+	Velocity float64
+	// SellOut is when what remains is projected to be sold at Velocity, zero if nothing
+	// remains or nothing sold lately; SellsOut is true if that is before sales end.
+	SellOut  time.Time
+	SellsOut bool
+	// SalesEnd is when the slot stops being sold, zero if unknown.
+	SalesEnd time.Time
+}
+
+// DailySales are the sales made on a day, in the time zone of the event.
+type DailySales struct {
+	Day   time.Time
+	Sold  int
+	Gross int64
+	Net   int64
+}
+
+// PromoUsage is how much a discount, by its detail, was used for the event.
+type PromoUsage struct {
+	Code     string
+	Payments int
+	// Claims are the claims of the event paid for with the discount.
+	Claims int
+	// Discount is the share of the discount given for the claims of the event.
+	Discount int64
+}
+
+// SalesReport are the ticket sales of an event at AsOf.
+type SalesReport struct {
+	EventID uint32
+	AsOf    time.Time
+	// Location is the time zone of the event days are counted in, UTC if it has none.
+	Location *time.Location
+	// Slots are ordered by ID, Total sums them.
+	Slots []SlotSales
+	Total SlotSales
+	// Daily are the sales of every day from the first sale until AsOf, per slot in
+	// SlotDaily by slot ID.
+	Daily     []DailySales
+	SlotDaily map[uint64][]DailySales
+	// Promos are ordered by most used.
+	Promos []PromoUsage
+}
+
+// sale is a claim sold, with its share of the discounts of its payment.
+type sale struct {
+	slotID   uint64
+	at       time.Time
+	gross    int64
+	discount int64
+}
+
+// SalesStore reads the slots, claims and payments of an event EventSales reports on.
+type SalesStore interface {
+	SlotStore
+	ExportStore
+}
+
+// EventSales aggregates the claims and payments of the event at now into a SalesReport.
+func EventSales(ctx context.Context, store SalesStore, eventID uint32, now time.Time) (*SalesReport, error) {
+	slots, err := store.ListEventSlotsForEvent(ctx, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("reading the slots of event %d: %w", eventID, err)
+	}
+	claims, err := store.ListClaimsForEvent(ctx, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("reading the claims of event %d: %w", eventID, err)
+	}
+	payments, err := store.ListClaimPaymentsForEvent(ctx, eventID, Page{})
+	if err != nil {
+		return nil, fmt.Errorf("reading the payments of event %d: %w", eventID, err)
+	}
+
+	report := &SalesReport{EventID: eventID, AsOf: now, Location: time.UTC, SlotDaily: map[uint64][]DailySales{}}
+	for _, slot := range slots {
+		if slot.Event != nil && slot.Event.TimeZone != "" {
+			if loc, err := time.LoadLocation(slot.Event.TimeZone); err == nil {
+				report.Location = loc
+			}
+			break
+		}
+	}
+
+	// a claim is sold by the first payment for it, later ones do not count it again.
+	var sales []sale
+	sold := map[uint64]bool{}
+	promos := map[string]*PromoUsage{}
+	for _, payment := range payments {
+		shares := discountShares(&payment)
+		var ours []*SlotClaim
+		var due int64
+		for i, c := range payment.ClaimsPayed {
+			if eventOf(c.EventSlot) != eventID || sold[c.ID] {
+				continue
+			}
+			sold[c.ID] = true
+			ours = append(ours, c)
+			due += c.EventSlot.Cost
+			sales = append(sales, sale{
+				slotID:   c.EventSlot.ID,
+				at:       time.Unix(int64(payment.CreatedAt), 0),
+				gross:    c.EventSlot.Cost,
+				discount: shares[i],
+			})
+		}
+		if len(ours) == 0 {
+			continue
+		}
+		total := payment.TotalDue()
+		for _, fi := range payment.Payment {
+			discount, ok := fi.(*PaymentMethodConferenceDiscount)
+			if !ok {
+				continue
+			}
+			usage, ok := promos[discount.Detail]
+			if !ok {
+				usage = &PromoUsage{Code: discount.Detail}
+				promos[discount.Detail] = usage
+			}
+			usage.Payments++
+			usage.Claims += len(ours)
+			if total > 0 {
+				usage.Discount += share(discount.Amount, due, total)
+			}
+		}
+	}
+
+	bySlot := map[uint64]*SlotSales{}
+	for _, slot := range slots {
+		report.Slots = append(report.Slots, SlotSales{Slot: slot, Capacity: slot.Capacity, SalesEnd: salesEnd(slot)})
+	}
+	for i := range report.Slots {
+		bySlot[report.Slots[i].Slot.ID] = &report.Slots[i]
+	}
+	for _, c := range claims {
+		if s, ok := bySlot[c.EventSlot.ID]; ok && !sold[c.ID] {
+			s.Held++
+		}
+	}
+	since := now.AddDate(0, 0, -VelocityDays)
+	recent := map[uint64]int{}
+	for _, sale := range sales {
+		s, ok := bySlot[sale.slotID]
+		if !ok {
+			continue
+		}
+		s.Sold++
+		s.Gross += sale.gross
+		s.Discounts += sale.discount
+		if sale.at.After(since) && !sale.at.After(now) {
+			recent[sale.slotID]++
+		}
+	}
+
+	recentTotal := 0
+	for i := range report.Slots {
+		s := &report.Slots[i]
+		if s.Remaining = s.Capacity - s.Sold - s.Held; s.Remaining < 0 {
+			s.Remaining = 0
+		}
+		s.project(recent[s.Slot.ID], now)
+		report.Total.Capacity += s.Capacity
+		report.Total.Sold += s.Sold
+		report.Total.Held += s.Held
+		report.Total.Remaining += s.Remaining
+		report.Total.Gross += s.Gross
+		report.Total.Discounts += s.Discounts
+		recentTotal += recent[s.Slot.ID]
+		if s.SalesEnd.After(report.Total.SalesEnd) {
+			report.Total.SalesEnd = s.SalesEnd
+		}
+	}
+	report.Total.project(recentTotal, now)
+
+	report.Daily = daily(sales, nil, report.Location, now)
+	for _, s := range report.Slots {
+		id := s.Slot.ID
+		report.SlotDaily[id] = daily(sales, func(sale sale) bool { return sale.slotID == id }, report.Location, now)
+	}
+	for _, usage := range promos {
+		report.Promos = append(report.Promos, *usage)
+	}
+	sort.Slice(report.Promos, func(i, j int) bool {
+		if report.Promos[i].Payments != report.Promos[j].Payments {
+			return report.Promos[i].Payments > report.Promos[j].Payments
+		}
+		return report.Promos[i].Code < report.Promos[j].Code
+	})
+	return report, nil
+}
+
+// project fills the net revenue and the sell-out projection of the sales, with recent
+// claims sold over the VelocityDays before now.
+func (s *SlotSales) project(recent int, now time.Time) {
+	s.Net = s.Gross - s.Discounts
+	s.Velocity = float64(recent) / VelocityDays
+	if s.Remaining == 0 || s.Velocity == 0 {
+		return
+	}
+	days := math.Ceil(float64(s.Remaining) / s.Velocity)
+	s.SellOut = now.Add(time.Duration(days) * 24 * time.Hour)
+	s.SellsOut = s.SalesEnd.IsZero() || !s.SellOut.After(s.SalesEnd)
+}
+
+// salesEnd returns when the slot stops being sold, when it is no longer purchaseable or
+// else when its event starts, zero if neither is known.
+func salesEnd(slot EventSlot) time.Time {
+	switch {
+	case slot.PurchaseableUntil != 0:
+		return time.Unix(int64(slot.PurchaseableUntil), 0)
+	case slot.Event != nil && slot.Event.StartDate != 0:
+		return time.Unix(int64(slot.Event.StartDate), 0)
+	}
+	return time.Time{}
+}
+
+// discountShares returns the share of the discounts of the payment of each of its claims,
+// in proportion to their cost; discounts beyond what is due are not shared.
+func discountShares(payment *ClaimPayment) []int64 {
+	shares := make([]int64, len(payment.ClaimsPayed))
+	total := payment.TotalDue()
+	var discounts int64
+	for _, fi := range payment.Payment {
+		if fi.Type() == ATDiscount {
+			discounts += fi.Total()
+		}
+	}
+	if discounts > total {
+		discounts = total
+	}
+	if total <= 0 || discounts <= 0 {
+		return shares
+	}
+	// the last claim with a cost gets what rounding left.
+	left, last := discounts, -1
+	for i, c := range payment.ClaimsPayed {
+		shares[i] = share(discounts, c.EventSlot.Cost, total)
+		left -= shares[i]
+		if c.EventSlot.Cost > 0 {
+			last = i
+		}
+	}
+	if last >= 0 {
+		shares[last] += left
+	}
+	return shares
+}
+
+// share returns amount * part / total, rounded down.
+func share(amount, part, total int64) int64 {
+	return int64(float64(amount) * float64(part) / float64(total))
+}
+
+// daily returns the sales kept by keep, all of them if nil, per day in loc from the
+// first sale until now, with the days without sales.
+func daily(sales []sale, keep func(sale) bool, loc *time.Location, now time.Time) []DailySales {
+	day := func(t time.Time) time.Time {
+		t = t.In(loc)
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	}
+	days := map[time.Time]*DailySales{}
+	var first time.Time
+	for _, sale := range sales {
+		if (keep != nil && !keep(sale)) || sale.at.Unix() <= 0 || sale.at.After(now) {
+			continue
+		}
+		d := day(sale.at)
+		if first.IsZero() || d.Before(first) {
+			first = d
+		}
+		if days[d] == nil {
+			days[d] = &DailySales{Day: d}
+		}
+		days[d].Sold++
+		days[d].Gross += sale.gross
+		days[d].Net += sale.gross - sale.discount
+	}
+	series := []DailySales{}
+	if first.IsZero() {
+		return series
+	}
+	// days are added by date rather than by 24 hours, which DST changes would break.
+	for d := first; !d.After(now); d = time.Date(d.Year(), d.Month(), d.Day()+1, 0, 0, 0, 0, loc) {
+		if sales, ok := days[d]; ok {
+			series = append(series, *sales)
+		} else {
+			series = append(series, DailySales{Day: d})
+		}
+	}
+	return series
+}
@@ -0,0 +1,74 @@
+package ticketing
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDiscountShares(t *testing.T) {
+	claim := func(cost int64) *SlotClaim { return &SlotClaim{EventSlot: &EventSlot{Cost: cost}} }
+	for _, tc := range []struct {
+		name    string
+		payment ClaimPayment
+		want    []int64
+	}{
+		{
+			name: "by cost",
+			payment: ClaimPayment{
+				ClaimsPayed: []*SlotClaim{claim(20000), claim(50000)},
+				Payment:     []FinancialInstrument{&PaymentMethodConferenceDiscount{Amount: 14000}},
+			},
+			want: []int64{4000, 10000},
+		},
+		{
+			name: "rounding left to the last",
+			payment: ClaimPayment{
+				ClaimsPayed: []*SlotClaim{claim(100), claim(100), claim(100), claim(0)},
+				Payment:     []FinancialInstrument{&PaymentMethodConferenceDiscount{Amount: 100}},
+			},
+			want: []int64{33, 33, 34, 0},
+		},
+		{
+			name: "beyond what is due",
+			payment: ClaimPayment{
+				ClaimsPayed: []*SlotClaim{claim(100)},
+				Payment:     []FinancialInstrument{&PaymentMethodConferenceDiscount{Amount: 500}},
+			},
+			want: []int64{100},
+		},
+		{
+			name: "no discount",
+			payment: ClaimPayment{
+				ClaimsPayed: []*SlotClaim{claim(100)},
+				Payment:     []FinancialInstrument{&PaymentMethodMoney{Amount: 100}},
+			},
+			want: []int64{0},
+		},
+	} {
+		if got := discountShares(&tc.payment); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: discountShares() = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestDailyFillsDays(t *testing.T) {
+	loc := time.FixedZone("UTC-6", -6*60*60)
+	now := time.Date(2021, 3, 4, 12, 0, 0, 0, loc)
+	sales := []sale{
+		// late on the 1st in UTC, still the 1st in the event's zone.
+		{slotID: 1, at: time.Date(2021, 3, 2, 3, 0, 0, 0, time.UTC), gross: 100, discount: 10},
+		{slotID: 2, at: time.Date(2021, 3, 3, 12, 0, 0, 0, loc), gross: 200},
+		{slotID: 1, at: now.Add(time.Hour), gross: 100},
+	}
+	got := daily(sales, nil, loc, now)
+	want := []DailySales{
+		{Day: time.Date(2021, 3, 1, 0, 0, 0, 0, loc), Sold: 1, Gross: 100, Net: 90},
+		{Day: time.Date(2021, 3, 2, 0, 0, 0, 0, loc)},
+		{Day: time.Date(2021, 3, 3, 0, 0, 0, 0, loc), Sold: 1, Gross: 200, Net: 200},
+		{Day: time.Date(2021, 3, 4, 0, 0, 0, 0, loc)},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("daily() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,1059 @@
+package ticketing
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gopheracademy/manager/database"
+	"github.com/gopheracademy/manager/def"
+	"github.com/gopheracademy/manager/errs"
+	"github.com/gopheracademy/manager/migrations"
+	"github.com/gopheracademy/manager/store"
+)
+
+// newStore returns an empty store and an event its slots can belong to.
+type newStore func(t *testing.T) (PurchaseStore, *def.Event)
+
+func TestMemoryStorage(t *testing.T) {
+	testPurchaseStore(t, func(t *testing.T) (PurchaseStore, *def.Event) {
+		return NewMemoryStorage(), &def.Event{ID: 1, Name: "GopherCon 2021"}
+	})
+}
+
+// TestSQLStorage needs an empty postgres database, ie
+//
+//	createdb showrunner_test
+//	SHOWRUNNER_TEST_DATABASE_URL=postgres://localhost/showrunner_test go test ./ticketing
+func TestSQLStorage(t *testing.T) {
+	url := os.Getenv("SHOWRUNNER_TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("SHOWRUNNER_TEST_DATABASE_URL is not set")
+	}
+	testPurchaseStore(t, func(t *testing.T) (PurchaseStore, *def.Event) {
+		db, err := database.Open(url, log.New(os.Stderr, "", log.LstdFlags))
+		if err != nil {
+			t.Fatal(err)
+		}
+		m := migrations.New(db)
+		if _, err := m.Up(); err != nil {
+			t.Fatalf("migrating: %v", err)
+		}
+		t.Cleanup(func() {
+			if _, err := m.Down(len(migrations.All)); err != nil {
+				t.Errorf("cleaning up: %v", err)
+			}
+			db.Close()
+		})
+		ctx := context.Background()
+		conference, err := store.NewConferenceRepository(db).Create(ctx, &store.Conference{Name: "GopherCon", Slug: "gophercon"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		event, err := store.NewEventRepository(db).Create(ctx, &store.Event{Name: "GopherCon 2021", Slug: "2021", ConferenceID: conference.ID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return NewSQLStorageFromConnection(db), &def.Event{ID: event.ID, Name: event.Name}
+	})
+}
+
+// testPurchaseStore checks the behaviour every PurchaseStore must have, each test gets a
+// store of its own.
+func testPurchaseStore(t *testing.T, newStore newStore) {
+	for _, tc := range []struct {
+		name string
+		test func(t *testing.T, s PurchaseStore, event *def.Event)
+	}{
+		{"EventSlots", testEventSlots},
+		{"SlotsOfNoEvent", testSlotsOfNoEvent},
+		{"Attendees", testAttendees},
+		{"ClaimSlots", testClaimSlots},
+		{"AtomicOperation", testAtomicOperation},
+		{"TransferClaims", testTransferClaims},
+		{"PayClaims", testPayClaims},
+		{"ListClaimsForSlot", testListClaimsForSlot},
+		{"Ledger", testLedger},
+		{"ConcurrentRefunds", testConcurrentRefunds},
+		{"ConcurrentCovers", testConcurrentCovers},
+		{"Receivables", testReceivables},
+		{"RecordedMoney", testRecordedMoney},
+		{"EventSales", testEventSales},
+		{"PagedListings", testPagedListings},
+		{"CancelledContext", testCancelledContext},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			s, event := newStore(t)
+			tc.test(t, s, event)
+		})
+	}
+}
+
+func createSlot(t *testing.T, s PurchaseStore, event *def.Event, name string, cost int64) *EventSlot {
+	t.Helper()
+	ctx := context.Background()
+	slot, err := s.CreateEventSlot(ctx, &EventSlot{Event: event, Name: name, Cost: cost, Capacity: 10})
+	if err != nil {
+		t.Fatalf("CreateEventSlot(%s) = %v", name, err)
+	}
+	if slot.ID == 0 {
+		t.Fatalf("CreateEventSlot(%s) returned no ID", name)
+	}
+	return slot
+}
+
+func createAttendee(t *testing.T, s PurchaseStore, email string) *Attendee {
+	t.Helper()
+	ctx := context.Background()
+	attendee, err := s.CreateAttendee(ctx, &Attendee{Email: email})
+	if err != nil {
+		t.Fatalf("CreateAttendee(%s) = %v", email, err)
+	}
+	if attendee.ID == 0 {
+		t.Fatalf("CreateAttendee(%s) returned no ID", email)
+	}
+	return attendee
+}
+
+// readAttendee reads the attendee, failing if it is not found.
+func readAttendee(t *testing.T, s PurchaseStore, id uint64) *Attendee {
+	t.Helper()
+	ctx := context.Background()
+	attendee, err := s.ReadAttendeeByID(ctx, id)
+	if err != nil {
+		t.Fatalf("ReadAttendeeByID(%d) = %v", id, err)
+	}
+	if attendee == nil {
+		t.Fatalf("attendee %d not found", id)
+	}
+	return attendee
+}
+
+// claimIDSet returns the IDs of the claims, to compare them regardless of order.
+func claimIDSet(claims []SlotClaim) map[uint64]bool {
+	ids := map[uint64]bool{}
+	for _, c := range claims {
+		ids[c.ID] = true
+	}
+	return ids
+}
+
+func testEventSlots(t *testing.T, s PurchaseStore, event *def.Event) {
+	ctx := context.Background()
+	workshop := createSlot(t, s, event, "workshop", 20000)
+	conference := createSlot(t, s, event, "conference", 50000)
+	if workshop.ID == conference.ID {
+		t.Fatalf("both slots got ID %d", workshop.ID)
+	}
+
+	conference.DependsOn = workshop
+	conference.Description = "two days of talks"
+	if err := s.UpdateEventSlot(ctx, conference); err != nil {
+		t.Fatalf("UpdateEventSlot() = %v", err)
+	}
+	read, err := s.ReadEventSlotByID(ctx, conference.ID)
+	if err != nil {
+		t.Fatalf("ReadEventSlotByID() = %v", err)
+	}
+	if read == nil {
+		t.Fatal("the slot was not found")
+	}
+	if read.Name != "conference" || read.Cost != 50000 || read.Description != "two days of talks" {
+		t.Errorf("read %+v, want what was saved", read)
+	}
+	if read.Event == nil || read.Event.ID != event.ID {
+		t.Errorf("read the slot of event %+v, want %d", read.Event, event.ID)
+	}
+	if read.DependsOn == nil || read.DependsOn.ID != workshop.ID || read.DependsOn.Name != "workshop" {
+		t.Errorf("read a slot depending on %+v, want the workshop", read.DependsOn)
+	}
+
+	if missing, err := s.ReadEventSlotByID(ctx, conference.ID+100); err != nil || missing != nil {
+		t.Errorf("ReadEventSlotByID(missing) = %v, %v; want nil, nil", missing, err)
+	}
+	if err := s.UpdateEventSlot(ctx, &EventSlot{ID: conference.ID + 100, Event: event}); err == nil {
+		t.Error("UpdateEventSlot(missing) succeeded")
+	}
+}
+
+func testSlotsOfNoEvent(t *testing.T, s PurchaseStore, event *def.Event) {
+	ctx := context.Background()
+	meetup := createSlot(t, s, nil, "meetup", 1000)
+	conference := createSlot(t, s, event, "conference", 50000)
+	if read, err := s.ReadEventSlotByID(ctx, meetup.ID); err != nil || read == nil || read.Event != nil {
+		t.Fatalf("ReadEventSlotByID() = %+v, %v; want the slot without an event", read, err)
+	}
+	attendee := createAttendee(t, s, "gopher@example.com")
+	claims, err := ClaimSlots(ctx, s, attendee, *meetup, *conference)
+	if err != nil {
+		t.Fatalf("ClaimSlots() = %v", err)
+	}
+	payment, err := PayClaims(ctx, s, attendee, claims, []FinancialInstrument{
+		&PaymentMethodMoney{PaymentRef: "ch_1", Amount: 51000},
+	})
+	if err != nil {
+		t.Fatalf("PayClaims() = %v", err)
+	}
+
+	// claims of slots of no event are read along with the others.
+	read, err := s.ReadAttendeeByEmail(ctx, "gopher@example.com")
+	if err != nil || read == nil || len(read.Claims) != 2 {
+		t.Fatalf("ReadAttendeeByEmail() = %+v, %v; want both claims", read, err)
+	}
+	events := map[uint64]*def.Event{}
+	for _, c := range read.Claims {
+		events[c.EventSlot.ID] = c.EventSlot.Event
+	}
+	if events[meetup.ID] != nil || events[conference.ID] == nil || events[conference.ID].ID != event.ID {
+		t.Errorf("read claims of slots of events %+v, want none for the meetup", events)
+	}
+	if paid, err := s.ReadClaimPaymentByID(ctx, payment.ID); err != nil || paid == nil || len(paid.ClaimsPayed) != 2 {
+		t.Errorf("ReadClaimPaymentByID() = %+v, %v; want the payment of both claims", paid, err)
+	}
+}
+
+func testAttendees(t *testing.T, s PurchaseStore, event *def.Event) {
+	ctx := context.Background()
+	if _, err := s.ReadAttendeeByEmail(ctx, ""); err == nil {
+		t.Error("ReadAttendeeByEmail(\"\") succeeded")
+	}
+	if _, err := s.ReadAttendeeByID(ctx, 0); err == nil {
+		t.Error("ReadAttendeeByID(0) succeeded")
+	}
+	if missing, err := s.ReadAttendeeByEmail(ctx, "nobody@example.com"); err != nil || missing != nil {
+		t.Errorf("ReadAttendeeByEmail(missing) = %v, %v; want nil, nil", missing, err)
+	}
+
+	created := createAttendee(t, s, "gopher@example.com")
+	byEmail, err := s.ReadAttendeeByEmail(ctx, "gopher@example.com")
+	if err != nil || byEmail == nil || byEmail.ID != created.ID {
+		t.Fatalf("ReadAttendeeByEmail() = %+v, %v; want attendee %d", byEmail, err, created.ID)
+	}
+	if len(byEmail.Claims) != 0 {
+		t.Errorf("a new attendee has claims %+v", byEmail.Claims)
+	}
+
+	// the email is theirs whatever its case, ie a checkout typed in another case.
+	again, err := s.CreateAttendee(ctx, &Attendee{Email: "Gopher@Example.com"})
+	if err != nil || again == nil || again.ID != created.ID {
+		t.Fatalf("CreateAttendee() in another case = %+v, %v; want attendee %d", again, err, created.ID)
+	}
+	if byEmail, err := s.ReadAttendeeByEmail(ctx, "GOPHER@example.com"); err != nil || byEmail == nil || byEmail.ID != created.ID {
+		t.Errorf("ReadAttendeeByEmail() in another case = %+v, %v; want attendee %d", byEmail, err, created.ID)
+	}
+	mixed := createAttendee(t, s, " Jane@Example.com")
+	if mixed.Email != "jane@example.com" {
+		t.Errorf("created attendee with email %q, want it normalized", mixed.Email)
+	}
+	mixed.Email = "JANE@example.com "
+	if updated, err := s.UpdateAttendee(ctx, mixed); err != nil || updated == nil || updated.Email != "jane@example.com" {
+		t.Errorf("UpdateAttendee() = %+v, %v; want the email normalized", updated, err)
+	}
+	if read := readAttendee(t, s, mixed.ID); read.Email != "jane@example.com" {
+		t.Errorf("read attendee with email %q, want it normalized", read.Email)
+	}
+
+	created.CoCAccepted = true
+	updated, err := s.UpdateAttendee(ctx, created)
+	if err != nil || updated == nil {
+		t.Fatalf("UpdateAttendee() = %+v, %v", updated, err)
+	}
+	if read := readAttendee(t, s, created.ID); !read.CoCAccepted {
+		t.Error("the code of conduct acceptance was not saved")
+	}
+	if missing, err := s.UpdateAttendee(ctx, &Attendee{ID: created.ID + 100, Email: "nobody@example.com"}); err != nil || missing != nil {
+		t.Errorf("UpdateAttendee(missing) = %+v, %v; want nil, nil", missing, err)
+	}
+}
+
+func testClaimSlots(t *testing.T, s PurchaseStore, event *def.Event) {
+	ctx := context.Background()
+	workshop := createSlot(t, s, event, "workshop", 20000)
+	conference := createSlot(t, s, event, "conference", 50000)
+	attendee := createAttendee(t, s, "gopher@example.com")
+
+	claims, err := ClaimSlots(ctx, s, attendee, *workshop, *conference)
+	if err != nil {
+		t.Fatalf("ClaimSlots() = %v", err)
+	}
+	if len(claims) != 2 {
+		t.Fatalf("got %d claims, want 2", len(claims))
+	}
+	if claims[0].ID == 0 || claims[0].ID == claims[1].ID {
+		t.Errorf("claims got IDs %d and %d", claims[0].ID, claims[1].ID)
+	}
+	if claims[0].TicketID == "" || claims[0].TicketID == claims[1].TicketID {
+		t.Errorf("claims got tickets %q and %q", claims[0].TicketID, claims[1].TicketID)
+	}
+
+	read := readAttendee(t, s, attendee.ID)
+	if len(read.Claims) != 2 {
+		t.Fatalf("read %d claims, want 2", len(read.Claims))
+	}
+	want := map[uint64]SlotClaim{claims[0].ID: claims[0], claims[1].ID: claims[1]}
+	for _, c := range read.Claims {
+		w, ok := want[c.ID]
+		if !ok {
+			t.Errorf("read claim %d, which was not made", c.ID)
+			continue
+		}
+		if c.TicketID != w.TicketID || c.EventSlot == nil || c.EventSlot.ID != w.EventSlot.ID ||
+			c.EventSlot.Name != w.EventSlot.Name || c.EventSlot.Event == nil || c.EventSlot.Event.ID != event.ID {
+			t.Errorf("read claim %+v, want %+v", c, w)
+		}
+	}
+
+	// claiming more keeps the claims made before.
+	more, err := ClaimSlots(ctx, s, read, *workshop)
+	if err != nil {
+		t.Fatalf("ClaimSlots() again = %v", err)
+	}
+	if got := readAttendee(t, s, attendee.ID).Claims; len(got) != 3 || !claimIDSet(got)[more[0].ID] {
+		t.Errorf("read claims %+v after claiming again, want 3", got)
+	}
+}
+
+func testAtomicOperation(t *testing.T, s PurchaseStore, event *def.Event) {
+	ctx := context.Background()
+	_, cancel, atomic, err := s.AtomicOperation(ctx)
+	if err != nil {
+		t.Fatalf("AtomicOperation() = %v", err)
+	}
+	cancelled := createSlot(t, atomic, event, "cancelled", 100)
+	if err := cancel(); err != nil {
+		t.Fatalf("cancel() = %v", err)
+	}
+	if slot, err := s.ReadEventSlotByID(ctx, cancelled.ID); err != nil || slot != nil {
+		t.Errorf("read %+v, %v after cancelling, want nothing", slot, err)
+	}
+
+	commit, _, atomic, err := s.AtomicOperation(ctx)
+	if err != nil {
+		t.Fatalf("AtomicOperation() = %v", err)
+	}
+	committed := createSlot(t, atomic, event, "committed", 100)
+	attendee := createAttendee(t, atomic, "gopher@example.com")
+	if err := commit(); err != nil {
+		t.Fatalf("commit() = %v", err)
+	}
+	if slot, err := s.ReadEventSlotByID(ctx, committed.ID); err != nil || slot == nil {
+		t.Errorf("read %+v, %v after committing, want the slot", slot, err)
+	}
+	readAttendee(t, s, attendee.ID)
+}
+
+func testTransferClaims(t *testing.T, s PurchaseStore, event *def.Event) {
+	ctx := context.Background()
+	workshop := createSlot(t, s, event, "workshop", 20000)
+	conference := createSlot(t, s, event, "conference", 50000)
+	buyer := createAttendee(t, s, "buyer@example.com")
+	colleague := createAttendee(t, s, "colleague@example.com")
+	claims, err := ClaimSlots(ctx, s, buyer, *workshop, *conference)
+	if err != nil {
+		t.Fatalf("ClaimSlots() = %v", err)
+	}
+
+	buyer = readAttendee(t, s, buyer.ID)
+	if _, _, err := TransferClaims(ctx, s, colleague, buyer, claims[:1]); err == nil {
+		t.Error("transferring claims the source does not own succeeded")
+	}
+	if _, _, err := TransferClaims(ctx, s, buyer, colleague, claims[:1]); err != nil {
+		t.Fatalf("TransferClaims() = %v", err)
+	}
+
+	if got := readAttendee(t, s, buyer.ID).Claims; len(got) != 1 || got[0].ID != claims[1].ID {
+		t.Errorf("the buyer kept %+v, want claim %d", got, claims[1].ID)
+	}
+	if got := readAttendee(t, s, colleague.ID).Claims; len(got) != 1 || got[0].ID != claims[0].ID {
+		t.Errorf("the colleague got %+v, want claim %d", got, claims[0].ID)
+	}
+}
+
+func testPayClaims(t *testing.T, s PurchaseStore, event *def.Event) {
+	ctx := context.Background()
+	conference := createSlot(t, s, event, "conference", 50000)
+	attendee := createAttendee(t, s, "gopher@example.com")
+	claims, err := ClaimSlots(ctx, s, attendee, *conference)
+	if err != nil {
+		t.Fatalf("ClaimSlots() = %v", err)
+	}
+
+	payment, err := PayClaims(ctx, s, attendee, claims, []FinancialInstrument{
+		&PaymentMethodConferenceDiscount{Detail: "speaker", Amount: 10000},
+		&PaymentMethodCreditNote{Detail: "invoice", Amount: 40000},
+	})
+	if err != nil {
+		t.Fatalf("PayClaims() = %v", err)
+	}
+	if payment.ID == 0 {
+		t.Fatal("the payment got no ID")
+	}
+	for _, fi := range payment.Payment {
+		if id := instrumentID(fi); id == 0 {
+			t.Errorf("the %T got no ID", fi)
+		}
+	}
+	if payment.Fulfilled() {
+		t.Error("a payment on credit is fulfilled")
+	}
+
+	err = CoverCredit(ctx, s, payment, []FinancialInstrument{&PaymentMethodCreditNote{Amount: 40000}})
+	var invalid *ErrInvalidCurrency
+	if !errors.As(err, &invalid) {
+		t.Errorf("covering credit with credit = %v, want ErrInvalidCurrency", err)
+	}
+
+	// CoverCredit appends the instruments before checking them.
+	payment.Payment = payment.Payment[:2]
+	if err := CoverCredit(ctx, s, payment, []FinancialInstrument{&PaymentMethodMoney{PaymentRef: "ch_1", Amount: 40000}}); err != nil {
+		t.Fatalf("CoverCredit() = %v", err)
+	}
+	if !payment.Fulfilled() {
+		t.Error("the payment is not fulfilled once the credit is covered")
+	}
+
+	read, err := s.ReadClaimPaymentByID(ctx, payment.ID)
+	if err != nil || read == nil {
+		t.Fatalf("ReadClaimPaymentByID() = %+v, %v", read, err)
+	}
+	if read.AttendeeID != attendee.ID {
+		t.Errorf("read a payment by attendee %d, want %d", read.AttendeeID, attendee.ID)
+	}
+	if len(read.ClaimsPayed) != 1 || read.ClaimsPayed[0].ID != claims[0].ID ||
+		read.ClaimsPayed[0].EventSlot == nil || read.ClaimsPayed[0].EventSlot.Cost != 50000 {
+		t.Errorf("read claims payed %+v, want claim %d with its slot", read.ClaimsPayed, claims[0].ID)
+	}
+	var kinds []AssetType
+	for _, fi := range read.Payment {
+		kinds = append(kinds, fi.Type())
+	}
+	if want := []AssetType{ATCash, ATDiscount, ATReceivable}; !reflect.DeepEqual(kinds, want) {
+		t.Errorf("read instruments %v, want %v", kinds, want)
+	}
+	if !read.Fulfilled() {
+		t.Error("the payment read back is not fulfilled")
+	}
+	if missing, err := s.ReadClaimPaymentByID(ctx, payment.ID+100); err != nil || missing != nil {
+		t.Errorf("ReadClaimPaymentByID(missing) = %+v, %v; want nil, nil", missing, err)
+	}
+
+	other := createAttendee(t, s, "other@example.com")
+	for _, tc := range []struct {
+		attendee *Attendee
+		want     int
+	}{{attendee, 1}, {other, 0}} {
+		payments, err := s.ListClaimPaymentsForAttendee(ctx, tc.attendee.ID)
+		if err != nil {
+			t.Fatalf("ListClaimPaymentsForAttendee(%d) = %v", tc.attendee.ID, err)
+		}
+		if len(payments) != tc.want {
+			t.Errorf("%s made %d payments, want %d", tc.attendee.Email, len(payments), tc.want)
+		}
+	}
+}
+
+func testListClaimsForSlot(t *testing.T, s PurchaseStore, event *def.Event) {
+	ctx := context.Background()
+	workshop := createSlot(t, s, event, "workshop", 20000)
+	conference := createSlot(t, s, event, "conference", 50000)
+	for _, email := range []string{"a@example.com", "b@example.com"} {
+		if _, err := ClaimSlots(ctx, s, createAttendee(t, s, email), *conference); err != nil {
+			t.Fatalf("ClaimSlots() = %v", err)
+		}
+	}
+
+	claims, err := s.ListClaimsForSlot(ctx, conference.ID)
+	if err != nil {
+		t.Fatalf("ListClaimsForSlot() = %v", err)
+	}
+	if len(claims) != 2 || claims[0].ID >= claims[1].ID {
+		t.Fatalf("listed claims %+v, want 2 in order", claims)
+	}
+	for _, c := range claims {
+		if c.EventSlot == nil || c.EventSlot.ID != conference.ID || c.EventSlot.Event == nil {
+			t.Errorf("listed claim %+v, want it with the conference slot", c)
+		}
+	}
+	if claims, err := s.ListClaimsForSlot(ctx, workshop.ID); err != nil || len(claims) != 0 {
+		t.Errorf("ListClaimsForSlot(unclaimed) = %+v, %v; want none", claims, err)
+	}
+}
+
+func testLedger(t *testing.T, s PurchaseStore, event *def.Event) {
+	ctx := context.Background()
+	conference := createSlot(t, s, event, "conference", 50000)
+	workshop := createSlot(t, s, event, "workshop", 20000)
+	attendee := createAttendee(t, s, "gopher@example.com")
+	claims, err := ClaimSlots(ctx, s, attendee, *conference, *workshop)
+	if err != nil {
+		t.Fatalf("ClaimSlots() = %v", err)
+	}
+	onCredit, err := PayClaims(ctx, s, attendee, claims[:1], []FinancialInstrument{
+		&PaymentMethodConferenceDiscount{Detail: "speaker", Amount: 10000},
+		&PaymentMethodCreditNote{Detail: "invoice", Amount: 40000},
+	})
+	if err != nil {
+		t.Fatalf("PayClaims(on credit) = %v", err)
+	}
+	paid, err := PayClaims(ctx, s, attendee, claims[1:], []FinancialInstrument{
+		&PaymentMethodMoney{PaymentRef: "ch_1", Amount: 20000},
+	})
+	if err != nil {
+		t.Fatalf("PayClaims(paid) = %v", err)
+	}
+
+	report, err := EventRevenue(ctx, s, event.ID)
+	if err != nil {
+		t.Fatalf("EventRevenue() = %v", err)
+	}
+	if report.Sold != 70000 || report.Outstanding != 40000 || !report.Reconciled() {
+		t.Errorf("revenue before settling = %+v, want 70000 sold and 40000 outstanding, reconciled", report)
+	}
+
+	if err := CoverCredit(ctx, s, onCredit, []FinancialInstrument{&PaymentMethodMoney{PaymentRef: "ch_2", Amount: 40000}}); err != nil {
+		t.Fatalf("CoverCredit() = %v", err)
+	}
+	if err := RefundPayment(ctx, s, paid, 5000, "cancelled workshop"); err != nil {
+		t.Fatalf("RefundPayment() = %v", err)
+	}
+	if recorded, err := RecordFee(ctx, s, paid, 300, "stripe"); err != nil || !recorded {
+		t.Fatalf("RecordFee() = %v, %v", recorded, err)
+	}
+	if recorded, err := RecordFee(ctx, s, paid, 300, "stripe"); err != nil || recorded {
+		t.Errorf("RecordFee() of the same memo again = %v, %v; want nothing recorded", recorded, err)
+	}
+	if err := RefundPayment(ctx, s, paid, 15000, "more than is left"); err == nil {
+		t.Error("refunded more than was left of the payment")
+	}
+
+	entries, err := s.ListJournalEntriesForPayment(ctx, paid.ID)
+	if err != nil {
+		t.Fatalf("ListJournalEntriesForPayment() = %v", err)
+	}
+	var kinds []EntryKind
+	for _, e := range entries {
+		kinds = append(kinds, e.Kind)
+		if e.ID == 0 || e.EventID != event.ID || e.PaymentID != paid.ID {
+			t.Errorf("listed entry %+v, want it with an ID, event %d and payment %d", e, event.ID, paid.ID)
+		}
+		if err := e.Validate(); err != nil {
+			t.Errorf("listed entry %+v: %v", e, err)
+		}
+	}
+	if want := []EntryKind{EntryOrder, EntryRefund, EntryFee}; !reflect.DeepEqual(kinds, want) {
+		t.Errorf("listed entries %v, want %v", kinds, want)
+	}
+
+	trial, err := TrialBalance(ctx, s)
+	if err != nil {
+		t.Fatalf("TrialBalance() = %v", err)
+	}
+	if !trial.Balanced() || trial.Debits != 115300 {
+		t.Errorf("trial balance has debits %d and credits %d, want 115300 each", trial.Debits, trial.Credits)
+	}
+	balances := map[Account]int64{}
+	for _, a := range trial.Accounts {
+		balances[a.Account] = a.Balance()
+	}
+	want := map[Account]int64{
+		AccountCash:            54700,
+		AccountReceivables:     0,
+		AccountDiscounts:       10000,
+		AccountRefunds:         5000,
+		AccountFees:            300,
+		AccountDeferredRevenue: 70000,
+	}
+	if !reflect.DeepEqual(balances, want) {
+		t.Errorf("balances = %v, want %v", balances, want)
+	}
+
+	report, err = EventRevenue(ctx, s, event.ID)
+	if err != nil {
+		t.Fatalf("EventRevenue() = %v", err)
+	}
+	if report.Net() != 55000 || report.Collected != 54700 || report.Billed != 70000 || !report.Reconciled() {
+		t.Errorf("revenue = %+v, want 55000 net, 54700 collected and 70000 billed, reconciled", report)
+	}
+
+	workshop.Cost = 25000
+	if err := s.UpdateEventSlot(ctx, workshop); err != nil {
+		t.Fatalf("UpdateEventSlot() = %v", err)
+	}
+	if report, err := EventRevenue(ctx, s, event.ID); err != nil || report.Reconciled() {
+		t.Errorf("revenue once the cost of a paid slot changed = %+v, %v; want it not reconciled", report, err)
+	}
+}
+
+func testConcurrentRefunds(t *testing.T, s PurchaseStore, event *def.Event) {
+	ctx := context.Background()
+	conference := createSlot(t, s, event, "conference", 50000)
+	attendee := createAttendee(t, s, "gopher@example.com")
+	claims, err := ClaimSlots(ctx, s, attendee, *conference)
+	if err != nil {
+		t.Fatalf("ClaimSlots() = %v", err)
+	}
+	paid, err := PayClaims(ctx, s, attendee, claims, []FinancialInstrument{
+		&PaymentMethodMoney{PaymentRef: "ch_1", Amount: 50000},
+	})
+	if err != nil {
+		t.Fatalf("PayClaims() = %v", err)
+	}
+
+	// each refund takes 20000 of the 50000 paid, so at most 2 of them fit.
+	const refunds = 5
+	results := make(chan error, refunds)
+	for i := 0; i < refunds; i++ {
+		go func() { results <- RefundPayment(ctx, s, paid, 20000, "cancelled") }()
+	}
+	refunded := 0
+	for i := 0; i < refunds; i++ {
+		if err := <-results; err == nil {
+			refunded++
+		}
+	}
+	if refunded == 0 || refunded > 2 {
+		t.Errorf("%d concurrent refunds of 20000 succeeded, want 1 or 2", refunded)
+	}
+	entries, err := s.ListJournalEntriesForPayment(ctx, paid.ID)
+	if err != nil {
+		t.Fatalf("ListJournalEntriesForPayment() = %v", err)
+	}
+	var left int64
+	for _, e := range entries {
+		for _, l := range e.Lines {
+			if l.Account == AccountCash {
+				left += l.Debit - l.Credit
+			}
+		}
+	}
+	if left != 50000-int64(refunded)*20000 {
+		t.Errorf("%d left of the payment after %d refunds, want %d", left, refunded, 50000-int64(refunded)*20000)
+	}
+	if err := RefundPayment(ctx, s, &ClaimPayment{ID: paid.ID + 100}, 1, "unknown"); err == nil {
+		t.Error("refunded a payment that does not exist")
+	}
+}
+
+func testConcurrentCovers(t *testing.T, s PurchaseStore, event *def.Event) {
+	ctx := context.Background()
+	conference := createSlot(t, s, event, "conference", 40000)
+	sponsor := createAttendee(t, s, "sponsor@example.com")
+	claims, err := ClaimSlots(ctx, s, sponsor, *conference)
+	if err != nil {
+		t.Fatalf("ClaimSlots() = %v", err)
+	}
+	onCredit, err := PayClaims(ctx, s, sponsor, claims, []FinancialInstrument{
+		&PaymentMethodCreditNote{Detail: "invoice", Amount: 40000},
+	})
+	if err != nil {
+		t.Fatalf("PayClaims() = %v", err)
+	}
+	if err := s.SuspendClaims(ctx, []uint64{claims[0].ID}, true); err != nil {
+		t.Fatalf("SuspendClaims() = %v", err)
+	}
+
+	// each cover pays half of the credit from a copy of the payment read before the other
+	// added its half, together they cover it.
+	results := make(chan error, 2)
+	for _, ref := range []string{"ch_1", "ch_2"} {
+		stale := *onCredit
+		stale.Payment = append([]FinancialInstrument(nil), onCredit.Payment...)
+		ref := ref
+		go func() {
+			results <- CoverCredit(ctx, s, &stale, []FinancialInstrument{&PaymentMethodMoney{PaymentRef: ref, Amount: 20000}})
+		}()
+	}
+	for i := 0; i < 2; i++ {
+		if err := <-results; err != nil {
+			t.Fatalf("CoverCredit() = %v", err)
+		}
+	}
+	payment, err := s.ReadClaimPaymentByID(ctx, onCredit.ID)
+	if err != nil || payment == nil || len(payment.Payment) != 3 {
+		t.Fatalf("ReadClaimPaymentByID() = %+v, %v; want the credit note and both covers", payment, err)
+	}
+	if c := readAttendee(t, s, sponsor.ID).Claims[0]; c.Suspended {
+		t.Errorf("claim %d is still suspended once both covers paid the credit", c.ID)
+	}
+	entries, err := s.ListJournalEntriesForPayment(ctx, onCredit.ID)
+	if err != nil {
+		t.Fatalf("ListJournalEntriesForPayment() = %v", err)
+	}
+	var owed int64
+	for _, e := range entries {
+		for _, l := range e.Lines {
+			if l.Account == AccountReceivables {
+				owed += l.Debit - l.Credit
+			}
+		}
+	}
+	if owed != 0 {
+		t.Errorf("%d is owed on the payment once covered, want 0", owed)
+	}
+}
+
+func testReceivables(t *testing.T, s PurchaseStore, event *def.Event) {
+	ctx := context.Background()
+	conference := createSlot(t, s, event, "conference", 50000)
+	sponsor := createAttendee(t, s, "sponsor@example.com")
+	claims, err := ClaimSlots(ctx, s, sponsor, *conference, *conference)
+	if err != nil {
+		t.Fatalf("ClaimSlots() = %v", err)
+	}
+	onCredit, err := PayClaims(ctx, s, sponsor, claims[:1], []FinancialInstrument{
+		&PaymentMethodConferenceDiscount{Detail: "sponsor", Amount: 10000},
+		&PaymentMethodCreditNote{Detail: "invoice", Amount: 40000},
+	})
+	if err != nil {
+		t.Fatalf("PayClaims(on credit) = %v", err)
+	}
+	if _, err := PayClaims(ctx, s, sponsor, claims[1:], []FinancialInstrument{
+		&PaymentMethodMoney{PaymentRef: "ch_1", Amount: 50000},
+	}); err != nil {
+		t.Fatalf("PayClaims(paid) = %v", err)
+	}
+
+	payments, err := s.ListClaimPaymentsOnCredit(ctx)
+	if err != nil {
+		t.Fatalf("ListClaimPaymentsOnCredit() = %v", err)
+	}
+	if len(payments) != 1 || payments[0].ID != onCredit.ID || payments[0].CreatedAt == 0 {
+		t.Fatalf("listed payments on credit %+v, want payment %d with its date", payments, onCredit.ID)
+	}
+
+	now := time.Unix(int64(payments[0].CreatedAt), 0).AddDate(0, 0, 45)
+	report, err := AgeReceivables(ctx, s, now)
+	if err != nil {
+		t.Fatalf("AgeReceivables() = %v", err)
+	}
+	if len(report.Receivables) != 1 {
+		t.Fatalf("aged %d receivables, want 1", len(report.Receivables))
+	}
+	r := report.Receivables[0]
+	if r.Payment.ID != onCredit.ID || r.EventID != event.ID || r.AgeDays != 45 || r.Outstanding != 30000 {
+		t.Errorf("aged %+v, want payment %d of event %d, 45 days old with 30000 outstanding", r, onCredit.ID, event.ID)
+	}
+	if want := []int64{0, 30000, 0, 0}; !reflect.DeepEqual(report.Totals, want) || report.Total != 30000 {
+		t.Errorf("aged totals %v and %d, want %v and 30000", report.Totals, report.Total, want)
+	}
+
+	if err := s.SuspendClaims(ctx, []uint64{claims[0].ID}, true); err != nil {
+		t.Fatalf("SuspendClaims() = %v", err)
+	}
+	suspended := map[uint64]bool{}
+	for _, c := range readAttendee(t, s, sponsor.ID).Claims {
+		suspended[c.ID] = !c.Redeemable()
+	}
+	if want := map[uint64]bool{claims[0].ID: true, claims[1].ID: false}; !reflect.DeepEqual(suspended, want) {
+		t.Errorf("suspended claims %v, want %v", suspended, want)
+	}
+
+	if err := CoverCredit(ctx, s, onCredit, []FinancialInstrument{&PaymentMethodMoney{PaymentRef: "ch_2", Amount: 30000}}); err != nil {
+		t.Fatalf("CoverCredit() = %v", err)
+	}
+	for _, c := range readAttendee(t, s, sponsor.ID).Claims {
+		if c.Suspended {
+			t.Errorf("claim %d is still suspended once the credit is covered", c.ID)
+		}
+	}
+	if report, err := AgeReceivables(ctx, s, now); err != nil || len(report.Receivables) != 0 {
+		t.Errorf("AgeReceivables() once covered = %+v, %v; want none", report, err)
+	}
+}
+
+func testRecordedMoney(t *testing.T, s PurchaseStore, event *def.Event) {
+	ctx := context.Background()
+	conference := createSlot(t, s, event, "conference", 50000)
+	attendee := createAttendee(t, s, "attendee@example.com")
+	claims, err := ClaimSlots(ctx, s, attendee, *conference)
+	if err != nil {
+		t.Fatalf("ClaimSlots() = %v", err)
+	}
+	payment, err := PayClaims(ctx, s, attendee, claims, []FinancialInstrument{
+		&PaymentMethodConferenceDiscount{Detail: "early bird", Amount: 10000},
+		&PaymentMethodMoney{PaymentRef: "ch_1", Amount: 40000},
+	})
+	if err != nil {
+		t.Fatalf("PayClaims() = %v", err)
+	}
+
+	recorded, err := s.ListRecordedMoney(ctx, nil, 0, 0)
+	if err != nil {
+		t.Fatalf("ListRecordedMoney() = %v", err)
+	}
+	if len(recorded) != 1 {
+		t.Fatalf("listed %d recorded money, want 1", len(recorded))
+	}
+	// money of payments made out of the range is listed only by its reference.
+	before := payment.CreatedAt - 2*86400
+	for _, refs := range [][]string{nil, {"ch_2"}} {
+		if out, err := s.ListRecordedMoney(ctx, refs, before, before+86400); err != nil || len(out) != 0 {
+			t.Errorf("ListRecordedMoney(%v) out of the range = %+v, %v; want none", refs, out, err)
+		}
+	}
+	for _, refs := range [][]string{{"ch_1"}, {"ch_2", "ch_1"}} {
+		if byRef, err := s.ListRecordedMoney(ctx, refs, before, before+86400); err != nil || len(byRef) != 1 {
+			t.Errorf("ListRecordedMoney(%v) = %+v, %v; want ch_1", refs, byRef, err)
+		}
+	}
+	if inRange, err := s.ListRecordedMoney(ctx, nil, payment.CreatedAt, payment.CreatedAt); err != nil || len(inRange) != 1 {
+		t.Errorf("ListRecordedMoney() in the range = %+v, %v; want ch_1", inRange, err)
+	}
+	m := recorded[0]
+	if m.ID == 0 || m.PaymentRef != "ch_1" || m.Amount != 40000 || m.ClaimPaymentID != payment.ID || m.PaidAt != payment.CreatedAt {
+		t.Errorf("listed %+v, want ch_1 of 40000 paid by payment %d at %d", m, payment.ID, payment.CreatedAt)
+	}
+}
+
+func testEventSales(t *testing.T, s PurchaseStore, event *def.Event) {
+	ctx := context.Background()
+	workshop := createSlot(t, s, event, "workshop", 20000)
+	conference := createSlot(t, s, event, "conference", 50000)
+	buyer := createAttendee(t, s, "buyer@example.com")
+	claims, err := ClaimSlots(ctx, s, buyer, *workshop, *conference)
+	if err != nil {
+		t.Fatalf("ClaimSlots(buyer) = %v", err)
+	}
+	if _, err := PayClaims(ctx, s, buyer, claims, []FinancialInstrument{
+		&PaymentMethodConferenceDiscount{Detail: "EARLY", Amount: 14000},
+		&PaymentMethodMoney{PaymentRef: "ch_1", Amount: 56000},
+	}); err != nil {
+		t.Fatalf("PayClaims() = %v", err)
+	}
+	if _, err := ClaimSlots(ctx, s, createAttendee(t, s, "holder@example.com"), *conference); err != nil {
+		t.Fatalf("ClaimSlots(holder) = %v", err)
+	}
+
+	slots, err := s.ListEventSlotsForEvent(ctx, event.ID)
+	if err != nil {
+		t.Fatalf("ListEventSlotsForEvent() = %v", err)
+	}
+	if len(slots) != 2 || slots[0].ID != workshop.ID || slots[1].ID != conference.ID || slots[0].Event == nil {
+		t.Fatalf("listed slots %+v, want the workshop and conference with their event", slots)
+	}
+	eventClaims, err := s.ListClaimsForEvent(ctx, event.ID)
+	if err != nil {
+		t.Fatalf("ListClaimsForEvent() = %v", err)
+	}
+	if len(eventClaims) != 3 || eventClaims[0].ID != claims[0].ID || eventClaims[2].EventSlot.ID != conference.ID {
+		t.Fatalf("listed claims %+v, want the 3 claims in order with their slot", eventClaims)
+	}
+
+	now := time.Now()
+	report, err := EventSales(ctx, s, event.ID, now)
+	if err != nil {
+		t.Fatalf("EventSales() = %v", err)
+	}
+	for _, tc := range []struct {
+		name                  string
+		got                   SlotSales
+		sold, held, remaining int
+		gross, discounts, net int64
+	}{
+		{"workshop", report.Slots[0], 1, 0, 9, 20000, 4000, 16000},
+		{"conference", report.Slots[1], 1, 1, 8, 50000, 10000, 40000},
+		{"total", report.Total, 2, 1, 17, 70000, 14000, 56000},
+	} {
+		g := tc.got
+		if g.Sold != tc.sold || g.Held != tc.held || g.Remaining != tc.remaining ||
+			g.Gross != tc.gross || g.Discounts != tc.discounts || g.Net != tc.net {
+			t.Errorf("%s: sold %d, held %d, remaining %d, gross %d, discounts %d, net %d; want %d, %d, %d, %d, %d, %d",
+				tc.name, g.Sold, g.Held, g.Remaining, g.Gross, g.Discounts, g.Net,
+				tc.sold, tc.held, tc.remaining, tc.gross, tc.discounts, tc.net)
+		}
+	}
+	// 2 sold in the last 7 days leave 17 to sell in 60 days.
+	if want := now.Add(60 * 24 * time.Hour); !report.Total.SellOut.Equal(want) || !report.Total.SellsOut {
+		t.Errorf("total sells out at %v (%v), want %v", report.Total.SellOut, report.Total.SellsOut, want)
+	}
+	if len(report.Daily) != 1 || report.Daily[0].Sold != 2 || report.Daily[0].Net != 56000 {
+		t.Errorf("daily sales %+v, want 2 sold for 56000 today", report.Daily)
+	}
+	if want := []PromoUsage{{Code: "EARLY", Payments: 1, Claims: 2, Discount: 14000}}; !reflect.DeepEqual(report.Promos, want) {
+		t.Errorf("promos %+v, want %+v", report.Promos, want)
+	}
+}
+
+func testPagedListings(t *testing.T, s PurchaseStore, event *def.Event) {
+	ctx := context.Background()
+	slot := createSlot(t, s, event, "conference", 50000)
+	createAttendee(t, s, "without@example.com")
+	var holders []*Attendee
+	for _, email := range []string{"first@example.com", "second@example.com", "third@example.com"} {
+		holder := createAttendee(t, s, email)
+		claims, err := ClaimSlots(ctx, s, holder, *slot)
+		if err != nil {
+			t.Fatalf("ClaimSlots(%s) = %v", email, err)
+		}
+		if _, err := PayClaims(ctx, s, holder, claims, []FinancialInstrument{
+			&PaymentMethodMoney{PaymentRef: "ch_" + email, Amount: 50000},
+		}); err != nil {
+			t.Fatalf("PayClaims(%s) = %v", email, err)
+		}
+		holders = append(holders, holder)
+	}
+
+	first, err := s.ListAttendeesForEvent(ctx, event.ID, Page{Limit: 2})
+	if err != nil {
+		t.Fatalf("ListAttendeesForEvent() = %v", err)
+	}
+	if len(first) != 2 || first[0].ID != holders[0].ID || first[1].ID != holders[1].ID {
+		t.Fatalf("listed attendees %+v, want the first 2 holders", first)
+	}
+	if len(first[0].Claims) != 1 || first[0].Claims[0].EventSlot == nil || first[0].Claims[0].EventSlot.Name != "conference" {
+		t.Errorf("listed claims %+v, want the conference claim with its slot", first[0].Claims)
+	}
+	rest, err := s.ListAttendeesForEvent(ctx, event.ID, Page{AfterID: first[1].ID, Limit: 2})
+	if err != nil {
+		t.Fatalf("ListAttendeesForEvent() = %v", err)
+	}
+	if len(rest) != 1 || rest[0].ID != holders[2].ID {
+		t.Errorf("listed attendees %+v after %d, want the last holder", rest, first[1].ID)
+	}
+
+	payments, err := s.ListClaimPaymentsForEvent(ctx, event.ID, Page{Limit: 2})
+	if err != nil {
+		t.Fatalf("ListClaimPaymentsForEvent() = %v", err)
+	}
+	if len(payments) != 2 || payments[0].AttendeeID != holders[0].ID || len(payments[0].Payment) != 1 {
+		t.Fatalf("listed payments %+v, want the first 2 with their instruments", payments)
+	}
+	restPayments, err := s.ListClaimPaymentsForEvent(ctx, event.ID, Page{AfterID: payments[1].ID})
+	if err != nil {
+		t.Fatalf("ListClaimPaymentsForEvent() = %v", err)
+	}
+	if len(restPayments) != 1 || restPayments[0].AttendeeID != holders[2].ID {
+		t.Errorf("listed payments %+v after %d, want the last one", restPayments, payments[1].ID)
+	}
+}
+
+func testCancelledContext(t *testing.T, s PurchaseStore, event *def.Event) {
+	slot := createSlot(t, s, event, "conference", 50000)
+	attendee := createAttendee(t, s, "gopher@example.com")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := s.ReadAttendeeByID(ctx, attendee.ID); err == nil {
+		t.Error("read with a cancelled context")
+	}
+	if _, _, _, err := s.AtomicOperation(ctx); err == nil {
+		t.Error("began an atomic operation with a cancelled context")
+	}
+	if _, err := ClaimSlots(ctx, s, attendee, *slot); err == nil {
+		t.Error("claimed a slot with a cancelled context")
+	}
+	if got := readAttendee(t, s, attendee.ID).Claims; len(got) != 0 {
+		t.Errorf("got claims %+v made with a cancelled context", got)
+	}
+}
+
+func instrumentID(fi FinancialInstrument) uint64 {
+	switch p := fi.(type) {
+	case *PaymentMethodMoney:
+		return p.ID
+	case *PaymentMethodConferenceDiscount:
+		return p.ID
+	case *PaymentMethodCreditNote:
+		return p.ID
+	}
+	return 0
+}
+
+func TestMemoryStorageEvents(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStorage()
+	event := &def.Event{ID: 1}
+	slot := createSlot(t, s, event, "conference", 50000)
+	attendee := createAttendee(t, s, "gopher@example.com")
+	if _, err := ClaimSlots(ctx, s, attendee, *slot); err != nil {
+		t.Fatalf("ClaimSlots() = %v", err)
+	}
+	events := s.Events()
+	if len(events) != 1 || events[0].Type != EventClaimCreated || events[0].ID == 0 {
+		t.Fatalf("got events %+v, want a claim created", events)
+	}
+	var created ClaimCreated
+	if err := events[0].Decode(&created); err != nil {
+		t.Fatal(err)
+	}
+	if created.AttendeeID != attendee.ID || created.SlotID != slot.ID || created.EventID != event.ID {
+		t.Errorf("got %+v, want the claim of attendee %d on slot %d", created, attendee.ID, slot.ID)
+	}
+
+	paid, err := PayClaims(ctx, s, attendee, attendee.Claims, []FinancialInstrument{
+		&PaymentMethodMoney{PaymentRef: "ch_1", Amount: 50000},
+	})
+	if err != nil {
+		t.Fatalf("PayClaims() = %v", err)
+	}
+	if recorded, err := RecordFee(ctx, s, paid, 300, "stripe"); err != nil || !recorded {
+		t.Fatalf("RecordFee() = %v, %v", recorded, err)
+	}
+	if err := RefundPayment(ctx, s, paid, 5000, "cancelled workshop"); err != nil {
+		t.Fatalf("RefundPayment() = %v", err)
+	}
+	events = s.Events()
+	if len(events) != 3 || events[1].Type != EventPaymentRecorded || events[2].Type != EventPaymentRefunded {
+		t.Fatalf("got events %+v, want the payment and its refund, not the fee", events)
+	}
+	var refunded PaymentRefunded
+	if err := events[2].Decode(&refunded); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := s.ListJournalEntriesForPayment(ctx, paid.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	refund := entries[len(entries)-1]
+	if refunded.RefundID != refund.ID || refunded.PaymentID != paid.ID || refunded.EventID != event.ID ||
+		refunded.Amount != 5000 || refunded.Memo != "cancelled workshop" {
+		t.Errorf("got %+v, want refund %d of payment %d", refunded, refund.ID, paid.ID)
+	}
+}
+
+func TestMemoryStorageConflicts(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStorage()
+	event := &def.Event{ID: 1}
+	commit, _, atomic, err := s.AtomicOperation(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	createSlot(t, atomic, event, "workshop", 20000)
+	// written outside of the atomic operation while it is ongoing.
+	createSlot(t, s, event, "conference", 50000)
+	if err := commit(); !errs.Is(err, errs.Conflict) {
+		t.Errorf("commit() = %v, want a conflict", err)
+	}
+	if err := commit(); err == nil {
+		t.Error("committing twice succeeded")
+	}
+
+	// nested operations commit into the one they were begun from.
+	commit, _, outer, err := s.AtomicOperation(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	innerCommit, _, inner, err := outer.AtomicOperation(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slot := createSlot(t, inner, event, "nested", 100)
+	if err := innerCommit(); err != nil {
+		t.Fatalf("committing the inner operation = %v", err)
+	}
+	if read, _ := s.ReadEventSlotByID(ctx, slot.ID); read != nil {
+		t.Error("the inner operation was visible before the outer one committed")
+	}
+	if err := commit(); err != nil {
+		t.Fatalf("committing the outer operation = %v", err)
+	}
+	if read, _ := s.ReadEventSlotByID(ctx, slot.ID); read == nil {
+		t.Error("the nested slot was not committed")
+	}
+}
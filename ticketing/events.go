@@ -0,0 +1,134 @@
+package ticketing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gopheracademy/manager/outbox"
+)
+
+// The domain events written to the outbox by ticketing operations, the payload of each
+// is the type of the same name.
+const (
+	EventClaimCreated      = "ticketing.ClaimCreated"
+	EventPaymentRecorded   = "ticketing.PaymentRecorded"
+	EventClaimsTransferred = "ticketing.ClaimsTransferred"
+	EventPaymentRefunded   = "ticketing.PaymentRefunded"
+)
+
+// ClaimCreated is emitted by ClaimSlots for each slot claimed.
+type ClaimCreated struct {
+	// EventID is the conference event of the slot, as in every ticketing payload; it is
+	// 0 if the slot had no event loaded.
+	EventID       uint32 `json:"eventID,omitempty"`
+	ClaimID       uint64 `json:"claimID"`
+	TicketID      string `json:"ticketID"`
+	SlotID        uint64 `json:"slotID"`
+	AttendeeID    uint64 `json:"attendeeID"`
+	AttendeeEmail string `json:"attendeeEmail"`
+}
+
+// PaymentRecorded is emitted by PayClaims and CoverCredit when payments are added.
+type PaymentRecorded struct {
+	EventID   uint32 `json:"eventID,omitempty"`
+	PaymentID uint64 `json:"paymentID"`
+	// AttendeeID is who paid, it is not known when covering credit.
+	AttendeeID uint64   `json:"attendeeID,omitempty"`
+	ClaimIDs   []uint64 `json:"claimIDs"`
+	// SlotIDs are the slots of the claims, in the same order.
+	SlotIDs   []uint64 `json:"slotIDs"`
+	TotalDue  int64    `json:"totalDue"`
+	Fulfilled bool     `json:"fulfilled"`
+}
+
+// ClaimsTransferred is emitted by TransferClaims.
+type ClaimsTransferred struct {
+	EventID     uint32   `json:"eventID,omitempty"`
+	SourceID    uint64   `json:"sourceID"`
+	SourceEmail string   `json:"sourceEmail"`
+	TargetID    uint64   `json:"targetID"`
+	TargetEmail string   `json:"targetEmail"`
+	ClaimIDs    []uint64 `json:"claimIDs"`
+	// SlotIDs are the slots of the claims, in the same order.
+	SlotIDs []uint64 `json:"slotIDs"`
+}
+
+// PaymentRefunded is emitted by RefundPayment.
+type PaymentRefunded struct {
+	EventID   uint32 `json:"eventID,omitempty"`
+	PaymentID uint64 `json:"paymentID"`
+	// RefundID is the journal entry of the refund.
+	RefundID uint64 `json:"refundID"`
+	Amount   int64  `json:"amount"`
+	Memo     string `json:"memo,omitempty"`
+}
+
+// emit appends an event of the type per payload to the outbox of the atomic operation.
+func emit(ctx context.Context, atomic PurchaseStore, eventType string, payloads ...interface{}) error {
+	events := make([]outbox.Event, 0, len(payloads))
+	for _, payload := range payloads {
+		e, err := outbox.NewEvent(eventType, payload)
+		if err != nil {
+			return err
+		}
+		events = append(events, e)
+	}
+	if err := atomic.AppendEvents(ctx, events...); err != nil {
+		return fmt.Errorf("emitting %s: %w", eventType, err)
+	}
+	return nil
+}
+
+func paymentRecorded(payment *ClaimPayment, attendeeID uint64) PaymentRecorded {
+	claimIDs := make([]uint64, 0, len(payment.ClaimsPayed))
+	slotIDs := make([]uint64, 0, len(payment.ClaimsPayed))
+	var eventID uint32
+	for _, c := range payment.ClaimsPayed {
+		claimIDs = append(claimIDs, c.ID)
+		slotIDs = append(slotIDs, slotOf(c))
+		if eventID == 0 {
+			eventID = eventOf(c.EventSlot)
+		}
+	}
+	return PaymentRecorded{
+		EventID:    eventID,
+		PaymentID:  payment.ID,
+		AttendeeID: attendeeID,
+		ClaimIDs:   claimIDs,
+		SlotIDs:    slotIDs,
+		TotalDue:   payment.TotalDue(),
+		Fulfilled:  payment.Fulfilled(),
+	}
+}
+
+func claimIDs(claims []SlotClaim) []uint64 {
+	ids := make([]uint64, 0, len(claims))
+	for _, c := range claims {
+		ids = append(ids, c.ID)
+	}
+	return ids
+}
+
+func slotIDs(claims []SlotClaim) []uint64 {
+	ids := make([]uint64, 0, len(claims))
+	for i := range claims {
+		ids = append(ids, slotOf(&claims[i]))
+	}
+	return ids
+}
+
+// slotOf returns the ID of the slot of the claim, 0 if it is not known.
+func slotOf(c *SlotClaim) uint64 {
+	if c.EventSlot == nil {
+		return 0
+	}
+	return c.EventSlot.ID
+}
+
+// eventOf returns the ID of the conference event of slot, 0 if it is not known.
+func eventOf(slot *EventSlot) uint32 {
+	if slot == nil || slot.Event == nil {
+		return 0
+	}
+	return slot.Event.ID
+}
@@ -0,0 +1,511 @@
+package ticketing
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Account is an account of the ledger money movements are posted to.
+type Account string
+
+const (
+	// AccountCash is the money received, less what was refunded and paid in fees.
+	AccountCash Account = "cash"
+	// AccountReceivables is what attendees owe, the credit extended to them until it is
+	// settled.
+	AccountReceivables Account = "receivables"
+	// AccountDiscounts is the part of the price of the claims given away as discounts.
+	AccountDiscounts Account = "discounts_given"
+	// AccountDeferredRevenue is the price of the claims sold, revenue for events that
+	// have not happened yet.
+	AccountDeferredRevenue Account = "deferred_revenue"
+	// AccountRefunds is the money given back to attendees.
+	AccountRefunds Account = "refunds"
+	// AccountFees is what payment processors charged to move the money.
+	AccountFees Account = "fees"
+)
+
+// Accounts are every account of the ledger, in the order reports list them.
+var Accounts = []Account{
+	AccountCash,
+	AccountReceivables,
+	AccountDiscounts,
+	AccountRefunds,
+	AccountFees,
+	AccountDeferredRevenue,
+}
+
+// CreditNormal returns true for accounts whose balance is their credits less their
+// debits, deferred revenue, the others are debit accounts.
+func (a Account) CreditNormal() bool {
+	return a == AccountDeferredRevenue
+}
+
+// EntryKind is the money movement a journal entry records.
+type EntryKind string
+
+const (
+	// EntryOrder is posted by PayClaims, claims sold against the instruments paying them.
+	EntryOrder EntryKind = "order"
+	// EntrySettlement is posted by CoverCredit, receivables settled with money or
+	// discounts.
+	EntrySettlement EntryKind = "settlement"
+	// EntryRefund is posted by RefundPayment.
+	EntryRefund EntryKind = "refund"
+	// EntryFee is posted by RecordFee.
+	EntryFee EntryKind = "fee"
+)
+
+// JournalLine is a debit or a credit to an account, in cents; only one of them is set.
+type JournalLine struct {
+	Account Account `gaum:"field_name:account"`
+	Debit   int64   `gaum:"field_name:debit"`
+	Credit  int64   `gaum:"field_name:credit"`
+}
+
+// JournalEntry is a balanced set of lines recording one money movement, entries are only
+// ever appended to the journal, a movement is undone by posting its opposite.
+type JournalEntry struct {
+	ID   uint64    `gaum:"field_name:id"`
+	Kind EntryKind `gaum:"field_name:kind"`
+	// EventID is the conference event the movement belongs to, that of the claims of the
+	// payment; orders and settlements of claims of several events are posted an entry per
+	// event, refunds and fees to the first. 0 if they had no event loaded.
+	EventID   uint32 `gaum:"field_name:event_id"`
+	PaymentID uint64 `gaum:"field_name:claim_payment_id"`
+	Memo      string `gaum:"field_name:memo"`
+	PostedAt  uint64 `gaum:"field_name:posted_at"` // PostedAt is Unix timestamp, seconds since Epoch (1/1/1970 UTC)
+	Lines     []JournalLine
+}
+
+// Validate returns an error unless the entry has lines, each either a positive debit or
+// a positive credit to a known account, and its debits equal its credits.
+func (e *JournalEntry) Validate() error {
+	if len(e.Lines) < 2 {
+		return fmt.Errorf("journal entry has %d lines, it needs at least a debit and a credit", len(e.Lines))
+	}
+	var debits, credits int64
+	for _, l := range e.Lines {
+		if !knownAccount(l.Account) {
+			return fmt.Errorf("journal entry posts to unknown account %q", l.Account)
+		}
+		if l.Debit < 0 || l.Credit < 0 || (l.Debit == 0) == (l.Credit == 0) {
+			return fmt.Errorf("journal line of %s must either debit or credit a positive amount", l.Account)
+		}
+		debits += l.Debit
+		credits += l.Credit
+	}
+	if debits != credits {
+		return fmt.Errorf("journal entry is not balanced, debits %d and credits %d", debits, credits)
+	}
+	return nil
+}
+
+func knownAccount(a Account) bool {
+	for _, known := range Accounts {
+		if a == known {
+			return true
+		}
+	}
+	return false
+}
+
+// entryBuilder accumulates the lines of an entry, skipping those of 0 and posting
+// negative amounts to the other side.
+type entryBuilder struct {
+	entry JournalEntry
+}
+
+func newEntry(kind EntryKind, payment *ClaimPayment, memo string) *entryBuilder {
+	e := JournalEntry{
+		Kind:      kind,
+		PaymentID: payment.ID,
+		Memo:      memo,
+		PostedAt:  uint64(time.Now().Unix()),
+	}
+	for _, c := range payment.ClaimsPayed {
+		if e.EventID = eventOf(c.EventSlot); e.EventID != 0 {
+			break
+		}
+	}
+	return &entryBuilder{entry: e}
+}
+
+func (b *entryBuilder) debit(account Account, amount int64) *entryBuilder {
+	switch {
+	case amount > 0:
+		b.entry.Lines = append(b.entry.Lines, JournalLine{Account: account, Debit: amount})
+	case amount < 0:
+		b.entry.Lines = append(b.entry.Lines, JournalLine{Account: account, Credit: -amount})
+	}
+	return b
+}
+
+func (b *entryBuilder) credit(account Account, amount int64) *entryBuilder {
+	return b.debit(account, -amount)
+}
+
+// instrumentAccount returns the account the instrument is a debit to.
+func instrumentAccount(fi FinancialInstrument) Account {
+	switch fi.Type() {
+	case ATCash:
+		return AccountCash
+	case ATDiscount:
+		return AccountDiscounts
+	}
+	return AccountReceivables
+}
+
+// eventShare is the part of a payment for the claims of one of its events.
+type eventShare struct {
+	EventID uint32
+	// Due is the price of the claims of the event.
+	Due int64
+	// Paid is what the money and discounts of the payment paid of Due, by account.
+	Paid map[Account]int64
+}
+
+// owed returns what is left to pay of Due, negative if it was overpaid.
+func (s *eventShare) owed() int64 {
+	owed := s.Due
+	for _, amount := range s.Paid {
+		owed -= amount
+	}
+	return owed
+}
+
+// sharesByEvent splits a payment by the events of its claims, in the order they come;
+// each instrument but credit notes pays for the events in proportion to the price of
+// their claims, the last event with a price gets what rounding left.
+func sharesByEvent(claims []*SlotClaim, instruments []FinancialInstrument) []eventShare {
+	var shares []eventShare
+	index := map[uint32]int{}
+	var total int64
+	for _, c := range claims {
+		id := eventOf(c.EventSlot)
+		i, ok := index[id]
+		if !ok {
+			i = len(shares)
+			index[id] = i
+			shares = append(shares, eventShare{EventID: id, Paid: map[Account]int64{}})
+		}
+		shares[i].Due += c.EventSlot.Cost
+		total += c.EventSlot.Cost
+	}
+	if len(shares) == 0 {
+		shares = append(shares, eventShare{Paid: map[Account]int64{}})
+	}
+	last := 0
+	for i := range shares {
+		if shares[i].Due > 0 {
+			last = i
+		}
+	}
+	for _, fi := range instruments {
+		if fi.Type() == ATReceivable {
+			continue
+		}
+		account, left := instrumentAccount(fi), fi.Total()
+		if total > 0 {
+			for i := range shares {
+				paid := share(fi.Total(), shares[i].Due, total)
+				shares[i].Paid[account] += paid
+				left -= paid
+			}
+		}
+		shares[last].Paid[account] += left
+	}
+	return shares
+}
+
+// orderEntries record the claims of the payment as sold for their price, against the
+// money and discounts paying them, with an entry per event; the rest of the price is
+// owed, which is what its credit notes are for, or is owed back if it was overpaid.
+func orderEntries(payment *ClaimPayment) []*JournalEntry {
+	var entries []*JournalEntry
+	for _, s := range sharesByEvent(payment.ClaimsPayed, payment.Payment) {
+		b := newEntry(EntryOrder, payment, payment.Invoice)
+		b.entry.EventID = s.EventID
+		for _, a := range Accounts {
+			b.debit(a, s.Paid[a])
+		}
+		b.debit(AccountReceivables, s.owed())
+		b.credit(AccountDeferredRevenue, s.Due)
+		entries = append(entries, &b.entry)
+	}
+	return entries
+}
+
+// settlementEntries record the money and discounts added to the payment as settling
+// what is owed on it, with an entry per event split like those of the order.
+func settlementEntries(payment *ClaimPayment, instruments []FinancialInstrument) []*JournalEntry {
+	var entries []*JournalEntry
+	for _, s := range sharesByEvent(payment.ClaimsPayed, instruments) {
+		b := newEntry(EntrySettlement, payment, payment.Invoice)
+		b.entry.EventID = s.EventID
+		var settled int64
+		for _, a := range Accounts {
+			b.debit(a, s.Paid[a])
+			settled += s.Paid[a]
+		}
+		b.credit(AccountReceivables, settled)
+		entries = append(entries, &b.entry)
+	}
+	return entries
+}
+
+// post validates the entries and posts them within the atomic operation.
+func post(ctx context.Context, atomic JournalStore, entries ...*JournalEntry) error {
+	for _, entry := range entries {
+		if len(entry.Lines) == 0 {
+			// nothing moved, ie claims given for free.
+			continue
+		}
+		if err := entry.Validate(); err != nil {
+			return fmt.Errorf("posting %s: %w", entry.Kind, err)
+		}
+		posted, err := atomic.PostJournalEntry(ctx, entry)
+		if err != nil {
+			return fmt.Errorf("posting %s: %w", entry.Kind, err)
+		}
+		entry.ID = posted.ID
+	}
+	return nil
+}
+
+// RefundPayment gives amount of the money received for the payment back, it fails if
+// that is more than what is left of it after previous refunds. The refund is emitted
+// for the payer to be sent a receipt.
+func RefundPayment(ctx context.Context, store PurchaseStore,
+	payment *ClaimPayment, amount int64, memo string) error {
+	b := newEntry(EntryRefund, payment, memo)
+	b.debit(AccountRefunds, amount).credit(AccountCash, amount)
+	_, err := postForPayment(ctx, store, payment, amount, &b.entry, false)
+	return err
+}
+
+// RecordFee records amount charged by the payment processor for moving the money of the
+// payment, it fails if that is more than what is left of it. It records nothing and
+// returns false if a fee with the memo was recorded for the payment already, ie by a
+// reconciliation run at once.
+func RecordFee(ctx context.Context, store PurchaseStore,
+	payment *ClaimPayment, amount int64, memo string) (bool, error) {
+	b := newEntry(EntryFee, payment, memo)
+	b.debit(AccountFees, amount).credit(AccountCash, amount)
+	return postForPayment(ctx, store, payment, amount, &b.entry, true)
+}
+
+// postForPayment posts an entry taking amount out of the money left of the payment,
+// within an atomic operation holding the lock of the payment so concurrent ones wait
+// for it and can not take more than there is. With once, it posts nothing and returns
+// false if an entry of the kind and memo was posted for the payment already.
+func postForPayment(ctx context.Context, store PurchaseStore,
+	payment *ClaimPayment, amount int64, entry *JournalEntry, once bool) (bool, error) {
+	if amount <= 0 {
+		return false, fmt.Errorf("the amount must be positive, got %d", amount)
+	}
+	succed, fail, atomic, err := store.AtomicOperation(ctx)
+	if err != nil {
+		return false, fmt.Errorf("beginning atomic operation: %w", err)
+	}
+	found, err := atomic.LockClaimPayment(ctx, payment.ID)
+	if err == nil && !found {
+		err = fmt.Errorf("payment %d does not exist", payment.ID)
+	}
+	if err != nil {
+		if atomicErr := fail(); atomicErr != nil {
+			err = fmt.Errorf("%w (also cancelling atomic operation: %v)", err, atomicErr)
+		}
+		return false, fmt.Errorf("locking the payment: %w", err)
+	}
+	entries, err := atomic.ListJournalEntriesForPayment(ctx, payment.ID)
+	if err != nil {
+		if atomicErr := fail(); atomicErr != nil {
+			err = fmt.Errorf("%w (also cancelling atomic operation: %v)", err, atomicErr)
+		}
+		return false, fmt.Errorf("reading the journal of the payment: %w", err)
+	}
+	for _, e := range entries {
+		if once && e.Kind == entry.Kind && e.Memo == entry.Memo {
+			if atomicErr := fail(); atomicErr != nil {
+				return false, fmt.Errorf("cancelling atomic operation: %w", atomicErr)
+			}
+			return false, nil
+		}
+	}
+	var left int64
+	for _, e := range entries {
+		for _, l := range e.Lines {
+			if l.Account == AccountCash {
+				left += l.Debit - l.Credit
+			}
+		}
+	}
+	if amount > left {
+		if atomicErr := fail(); atomicErr != nil {
+			return false, fmt.Errorf("cancelling atomic operation: %w", atomicErr)
+		}
+		return false, fmt.Errorf("payment %d has %d left, less than %d", payment.ID, left, amount)
+	}
+	err = post(ctx, atomic, entry)
+	if err == nil && entry.Kind == EntryRefund {
+		err = emit(ctx, atomic, EventPaymentRefunded, PaymentRefunded{
+			EventID:   entry.EventID,
+			PaymentID: payment.ID,
+			RefundID:  entry.ID,
+			Amount:    amount,
+			Memo:      entry.Memo,
+		})
+	}
+	if err != nil {
+		if atomicErr := fail(); atomicErr != nil {
+			err = fmt.Errorf("%w (also cancelling atomic operation: %v)", err, atomicErr)
+		}
+		return false, err
+	}
+	if err := succed(); err != nil {
+		return false, fmt.Errorf("confirming atomic operation: %w", err)
+	}
+	return true, nil
+}
+
+// AccountBalance are the debits and credits posted to an account.
+type AccountBalance struct {
+	Account Account `gaum:"field_name:account"`
+	Debit   int64   `gaum:"field_name:debit"`
+	Credit  int64   `gaum:"field_name:credit"`
+}
+
+// Balance returns the balance of the account on its normal side, negative if it is on
+// the other.
+func (a AccountBalance) Balance() int64 {
+	if a.Account.CreditNormal() {
+		return a.Credit - a.Debit
+	}
+	return a.Debit - a.Credit
+}
+
+// TrialBalanceReport are the balances of every account, the journal is consistent when
+// the debits equal the credits.
+type TrialBalanceReport struct {
+	Accounts []AccountBalance
+	Debits   int64
+	Credits  int64
+}
+
+// Balanced returns true if the debits equal the credits.
+func (t *TrialBalanceReport) Balanced() bool {
+	return t.Debits == t.Credits
+}
+
+// TrialBalance returns the balances of every account over the whole journal.
+func TrialBalance(ctx context.Context, store JournalStore) (*TrialBalanceReport, error) {
+	sums, err := store.SumJournal(ctx, 0)
+	if err != nil {
+		return nil, fmt.Errorf("summing the journal: %w", err)
+	}
+	report := &TrialBalanceReport{Accounts: balances(sums)}
+	for _, a := range report.Accounts {
+		report.Debits += a.Debit
+		report.Credits += a.Credit
+	}
+	return report, nil
+}
+
+// balances returns the sums of every account, in the order of Accounts and 0 for those
+// without lines.
+func balances(sums []AccountBalance) []AccountBalance {
+	byAccount := map[Account]AccountBalance{}
+	for _, s := range sums {
+		byAccount[s.Account] = s
+	}
+	all := make([]AccountBalance, len(Accounts))
+	for i, a := range Accounts {
+		all[i] = byAccount[a]
+		all[i].Account = a
+	}
+	return all
+}
+
+// RevenueReport is what an event sold and collected according to the journal, next to
+// what its payments add up to.
+type RevenueReport struct {
+	EventID uint32
+	// Sold is the price of the claims sold, the balance of deferred revenue.
+	Sold      int64
+	Discounts int64
+	Refunds   int64
+	Fees      int64
+	// Collected is the money received less refunds and fees, the balance of cash.
+	Collected int64
+	// Outstanding is what attendees owe, the balance of receivables.
+	Outstanding int64
+
+	// Billed is the price of the event's claims paid for, at the current price of their
+	// slots.
+	Billed int64
+	// Owed is the part of Billed the payments' money and discounts do not cover.
+	Owed int64
+}
+
+// Net returns the revenue once discounts and refunds are taken out.
+func (r *RevenueReport) Net() int64 {
+	return r.Sold - r.Discounts - r.Refunds
+}
+
+// Reconciled returns true if the journal agrees with the payments, what was sold is
+// what they bill and what is outstanding is what they owe; they disagree if, for
+// instance, the cost of a slot was changed after it was paid for.
+func (r *RevenueReport) Reconciled() bool {
+	return r.Sold == r.Billed && r.Outstanding == r.Owed
+}
+
+// RevenueStore reads the journal and payments of an event EventRevenue reports on.
+type RevenueStore interface {
+	JournalStore
+	ExportStore
+}
+
+// EventRevenue returns the revenue report of the event.
+func EventRevenue(ctx context.Context, store RevenueStore, eventID uint32) (*RevenueReport, error) {
+	if eventID == 0 {
+		return nil, fmt.Errorf("event id is not valid")
+	}
+	sums, err := store.SumJournal(ctx, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("summing the journal of the event: %w", err)
+	}
+	report := &RevenueReport{EventID: eventID}
+	for _, a := range balances(sums) {
+		switch a.Account {
+		case AccountDeferredRevenue:
+			report.Sold = a.Balance()
+		case AccountDiscounts:
+			report.Discounts = a.Balance()
+		case AccountRefunds:
+			report.Refunds = a.Balance()
+		case AccountFees:
+			report.Fees = a.Balance()
+		case AccountCash:
+			report.Collected = a.Balance()
+		case AccountReceivables:
+			report.Outstanding = a.Balance()
+		}
+	}
+	payments, err := store.ListClaimPaymentsForEvent(ctx, eventID, Page{})
+	if err != nil {
+		return nil, fmt.Errorf("reading the payments of the event: %w", err)
+	}
+	// payments for claims of several events only bill the event for its claims, split
+	// like the journal entries of their order.
+	for i := range payments {
+		for _, s := range sharesByEvent(payments[i].ClaimsPayed, payments[i].Payment) {
+			if s.EventID == eventID {
+				report.Billed += s.Due
+				report.Owed += s.owed()
+			}
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,153 @@
+package ticketing
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/gopheracademy/manager/def"
+)
+
+func TestJournalEntryValidate(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		lines []JournalLine
+		valid bool
+	}{
+		{"balanced", []JournalLine{{Account: AccountCash, Debit: 100}, {Account: AccountDeferredRevenue, Credit: 100}}, true},
+		{"unbalanced", []JournalLine{{Account: AccountCash, Debit: 100}, {Account: AccountDeferredRevenue, Credit: 90}}, false},
+		{"one line", []JournalLine{{Account: AccountCash, Debit: 0}}, false},
+		{"both sides", []JournalLine{{Account: AccountCash, Debit: 100, Credit: 100}, {Account: AccountFees, Debit: 0}}, false},
+		{"negative", []JournalLine{{Account: AccountCash, Debit: -100}, {Account: AccountFees, Credit: -100}}, false},
+		{"unknown account", []JournalLine{{Account: "petty_cash", Debit: 100}, {Account: AccountCash, Credit: 100}}, false},
+	} {
+		e := &JournalEntry{Kind: EntryOrder, Lines: tc.lines}
+		if err := e.Validate(); (err == nil) != tc.valid {
+			t.Errorf("%s: Validate() = %v, want valid %v", tc.name, err, tc.valid)
+		}
+	}
+}
+
+func TestOrderEntry(t *testing.T) {
+	slot := &EventSlot{Event: &def.Event{ID: 7}, Cost: 50000}
+	for _, tc := range []struct {
+		name        string
+		instruments []FinancialInstrument
+		want        []JournalLine
+	}{
+		{
+			name: "on credit",
+			instruments: []FinancialInstrument{
+				&PaymentMethodConferenceDiscount{Amount: 10000},
+				&PaymentMethodCreditNote{Amount: 40000},
+			},
+			want: []JournalLine{
+				{Account: AccountDiscounts, Debit: 10000},
+				{Account: AccountReceivables, Debit: 40000},
+				{Account: AccountDeferredRevenue, Credit: 50000},
+			},
+		},
+		{
+			name:        "paid",
+			instruments: []FinancialInstrument{&PaymentMethodMoney{Amount: 50000}},
+			want: []JournalLine{
+				{Account: AccountCash, Debit: 50000},
+				{Account: AccountDeferredRevenue, Credit: 50000},
+			},
+		},
+		{
+			name:        "overpaid",
+			instruments: []FinancialInstrument{&PaymentMethodMoney{Amount: 60000}},
+			want: []JournalLine{
+				{Account: AccountCash, Debit: 60000},
+				{Account: AccountReceivables, Credit: 10000},
+				{Account: AccountDeferredRevenue, Credit: 50000},
+			},
+		},
+	} {
+		entries := orderEntries(&ClaimPayment{
+			ID:          3,
+			ClaimsPayed: []*SlotClaim{{ID: 1, EventSlot: slot}},
+			Payment:     tc.instruments,
+		})
+		if len(entries) != 1 {
+			t.Fatalf("%s: posted %d entries, want 1", tc.name, len(entries))
+		}
+		entry := entries[0]
+		if !reflect.DeepEqual(entry.Lines, tc.want) {
+			t.Errorf("%s: posted %+v, want %+v", tc.name, entry.Lines, tc.want)
+		}
+		if entry.EventID != 7 || entry.PaymentID != 3 {
+			t.Errorf("%s: posted for event %d and payment %d, want 7 and 3", tc.name, entry.EventID, entry.PaymentID)
+		}
+		if err := entry.Validate(); err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+		}
+	}
+}
+
+func TestEventRevenueOfPaymentForTwoEvents(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStorage()
+	conference, workshop := &def.Event{ID: 1}, &def.Event{ID: 2}
+	ticket, err := s.CreateEventSlot(ctx, &EventSlot{Event: conference, Name: "ticket", Cost: 30000, Capacity: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	seat, err := s.CreateEventSlot(ctx, &EventSlot{Event: workshop, Name: "seat", Cost: 10000, Capacity: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	attendee, err := s.CreateAttendee(ctx, &Attendee{Email: "ada@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := ClaimSlots(ctx, s, attendee, *ticket, *seat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payment, err := PayClaims(ctx, s, attendee, claims, []FinancialInstrument{
+		&PaymentMethodMoney{PaymentRef: "ch_1", Amount: 20000},
+		&PaymentMethodConferenceDiscount{Detail: "early", Amount: 4000},
+		&PaymentMethodCreditNote{Detail: "invoice", Amount: 16000},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// each event is billed and sold the price of its claims, the instruments pay for
+	// them in proportion.
+	check := func(when string, want map[uint32]RevenueReport) {
+		t.Helper()
+		for eventID, w := range want {
+			r, err := EventRevenue(ctx, s, eventID)
+			if err != nil {
+				t.Fatalf("EventRevenue(%d) = %v", eventID, err)
+			}
+			if r.Sold != w.Sold || r.Billed != w.Sold || r.Discounts != w.Discounts ||
+				r.Collected != w.Collected || r.Outstanding != w.Outstanding || r.Owed != w.Outstanding {
+				t.Errorf("%s, event %d revenue is %+v, want %+v", when, eventID, *r, w)
+			}
+			if !r.Reconciled() {
+				t.Errorf("%s, event %d is not reconciled: %+v", when, eventID, *r)
+			}
+		}
+	}
+	check("once ordered", map[uint32]RevenueReport{
+		1: {Sold: 30000, Discounts: 3000, Collected: 15000, Outstanding: 12000},
+		2: {Sold: 10000, Discounts: 1000, Collected: 5000, Outstanding: 4000},
+	})
+	if err := CoverCredit(ctx, s, payment, []FinancialInstrument{
+		&PaymentMethodMoney{PaymentRef: "ch_2", Amount: 16000},
+	}); err != nil {
+		t.Fatalf("CoverCredit() = %v", err)
+	}
+	check("once settled", map[uint32]RevenueReport{
+		1: {Sold: 30000, Discounts: 3000, Collected: 27000},
+		2: {Sold: 10000, Discounts: 1000, Collected: 9000},
+	})
+	trial, err := TrialBalance(ctx, s)
+	if err != nil || !trial.Balanced() {
+		t.Errorf("TrialBalance() = %+v, %v; want it balanced", trial, err)
+	}
+}
@@ -0,0 +1,959 @@
+package ticketing
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/gopheracademy/manager/errs"
+	"github.com/gopheracademy/manager/outbox"
+)
+
+// MemoryStorage keeps ticketing models in memory, it behaves like SQLStorage so it can
+// stand in for it in tests and demos.
+//
+// An AtomicOperation works on a copy of the data, taken when it begins, which replaces
+// the data of its parent when committed; committing fails with errs.Conflict if the
+// parent was written to in the meantime, as a serializable transaction would.
+type MemoryStorage struct {
+	lock *sync.Mutex
+	data *memoryData
+	// parent is the store the atomic operation was begun from, nil for the root.
+	parent *MemoryStorage
+	// base is the version of parent the data was copied from.
+	base uint64
+	// done is set once the atomic operation was committed or cancelled.
+	done bool
+}
+
+var _ PurchaseStore = &MemoryStorage{}
+
+// memoryData is everything stored, values are copied in and out so callers never share
+// them with the store.
+type memoryData struct {
+	version uint64
+	// ids are the last ID given per kind of model, like the sequences of SQL tables.
+	ids       map[string]uint64
+	attendees map[uint64]Attendee
+	slots     map[uint64]EventSlot
+	claims    map[uint64]SlotClaim
+	owners    map[uint64]uint64 // claim ID to attendee ID.
+	payments  map[uint64]ClaimPayment
+	events    []outbox.Event
+	journal   []JournalEntry
+}
+
+// NewMemoryStorage returns an empty MemoryStorage.
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{
+		lock: &sync.Mutex{},
+		data: &memoryData{
+			ids:       map[string]uint64{},
+			attendees: map[uint64]Attendee{},
+			slots:     map[uint64]EventSlot{},
+			claims:    map[uint64]SlotClaim{},
+			owners:    map[uint64]uint64{},
+			payments:  map[uint64]ClaimPayment{},
+		},
+	}
+}
+
+func (d *memoryData) clone() *memoryData {
+	c := &memoryData{
+		version:   d.version,
+		ids:       make(map[string]uint64, len(d.ids)),
+		attendees: make(map[uint64]Attendee, len(d.attendees)),
+		slots:     make(map[uint64]EventSlot, len(d.slots)),
+		claims:    make(map[uint64]SlotClaim, len(d.claims)),
+		owners:    make(map[uint64]uint64, len(d.owners)),
+		payments:  make(map[uint64]ClaimPayment, len(d.payments)),
+		events:    append([]outbox.Event{}, d.events...),
+		journal:   append([]JournalEntry{}, d.journal...),
+	}
+	for k, v := range d.ids {
+		c.ids[k] = v
+	}
+	for k, v := range d.attendees {
+		c.attendees[k] = v
+	}
+	for k, v := range d.slots {
+		c.slots[k] = v
+	}
+	for k, v := range d.claims {
+		c.claims[k] = v
+	}
+	for k, v := range d.owners {
+		c.owners[k] = v
+	}
+	for k, v := range d.payments {
+		c.payments[k] = v
+	}
+	return c
+}
+
+// nextID returns the next ID of the kind of model, IDs start at 1 like BIGSERIAL.
+func (d *memoryData) nextID(kind string) uint64 {
+	d.ids[kind]++
+	return d.ids[kind]
+}
+
+// write runs f holding the lock and records that the data changed.
+func (s *MemoryStorage) write(ctx context.Context, f func(d *memoryData) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.done {
+		return fmt.Errorf("the atomic operation is over")
+	}
+	if err := f(s.data); err != nil {
+		return err
+	}
+	s.data.version++
+	return nil
+}
+
+// read runs f holding the lock.
+func (s *MemoryStorage) read(ctx context.Context, f func(d *memoryData) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return f(s.data)
+}
+
+// AtomicOperation returns a store working on a copy of the data of this one, commit
+// replaces the data of this one with it and cancel discards it.
+func (s *MemoryStorage) AtomicOperation(ctx context.Context) (func() error, func() error, PurchaseStore, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, nil, fmt.Errorf("beginning atomic operation: %w", err)
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.done {
+		return nil, nil, nil, fmt.Errorf("beginning atomic operation: the atomic operation is over")
+	}
+	atomic := &MemoryStorage{
+		lock:   &sync.Mutex{},
+		data:   s.data.clone(),
+		parent: s,
+		base:   s.data.version,
+	}
+	return atomic.commit, atomic.cancel, atomic, nil
+}
+
+func (s *MemoryStorage) commit() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.done {
+		return fmt.Errorf("the atomic operation is over")
+	}
+	s.done = true
+	s.parent.lock.Lock()
+	defer s.parent.lock.Unlock()
+	if s.parent.data.version != s.base {
+		return errs.New(errs.Conflict, "the data changed during the atomic operation")
+	}
+	s.parent.data = s.data
+	s.parent.data.version++
+	return nil
+}
+
+func (s *MemoryStorage) cancel() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.done {
+		return fmt.Errorf("the atomic operation is over")
+	}
+	s.done = true
+	return nil
+}
+
+// Events returns the events appended to the outbox, in order.
+func (s *MemoryStorage) Events() []outbox.Event {
+	var events []outbox.Event
+	s.read(context.Background(), func(d *memoryData) error {
+		events = append(events, d.events...)
+		return nil
+	})
+	return events
+}
+
+// AppendEvents implements PurchaseStore
+func (s *MemoryStorage) AppendEvents(ctx context.Context, events ...outbox.Event) error {
+	return s.write(ctx, func(d *memoryData) error {
+		for _, e := range events {
+			e.ID = d.nextID("outbox")
+			d.events = append(d.events, e)
+		}
+		return nil
+	})
+}
+
+// CreateAttendee implements PurchaseStore
+func (s *MemoryStorage) CreateAttendee(ctx context.Context, a *Attendee) (*Attendee, error) {
+	var created Attendee
+	err := s.write(ctx, func(d *memoryData) error {
+		for _, c := range a.Claims {
+			if _, ok := d.claims[c.ID]; !ok {
+				return fmt.Errorf("creating new attendee: claim %d does not exist", c.ID)
+			}
+		}
+		email := NormalizeEmail(a.Email)
+		var claims []SlotClaim
+		if existing, ok := d.attendeeByEmail(email); ok {
+			// like the unique index on lower(email), the claims are theirs.
+			created = existing
+			for _, claimID := range sortedIDs(len(d.owners), func(add func(uint64)) {
+				for claimID, owner := range d.owners {
+					if owner == existing.ID {
+						add(claimID)
+					}
+				}
+			}) {
+				claims = append(claims, d.claim(claimID))
+			}
+		} else {
+			created = Attendee{ID: d.nextID("attendee"), Email: email, CoCAccepted: a.CoCAccepted}
+			d.attendees[created.ID] = created
+		}
+		for _, c := range a.Claims {
+			d.owners[c.ID] = created.ID
+		}
+		created.Claims = append(claims, a.Claims...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// ReadAttendeeByEmail implements PurchaseStore
+func (s *MemoryStorage) ReadAttendeeByEmail(ctx context.Context, email string) (*Attendee, error) {
+	if email == "" {
+		return nil, fmt.Errorf("email is empty")
+	}
+	email = NormalizeEmail(email)
+	return s.readAttendee(ctx, func(a Attendee) bool { return a.Email == email })
+}
+
+// attendeeByEmail returns the attendee with the normalized email.
+func (d *memoryData) attendeeByEmail(email string) (Attendee, bool) {
+	for _, a := range d.attendees {
+		if a.Email == email {
+			return a, true
+		}
+	}
+	return Attendee{}, false
+}
+
+// ReadAttendeeByID implements PurchaseStore
+func (s *MemoryStorage) ReadAttendeeByID(ctx context.Context, id uint64) (*Attendee, error) {
+	if id == 0 {
+		return nil, fmt.Errorf("id is not valid")
+	}
+	return s.readAttendee(ctx, func(a Attendee) bool { return a.ID == id })
+}
+
+func (s *MemoryStorage) readAttendee(ctx context.Context, match func(Attendee) bool) (*Attendee, error) {
+	var found *Attendee
+	err := s.read(ctx, func(d *memoryData) error {
+		for _, id := range sortedIDs(len(d.attendees), func(add func(uint64)) {
+			for id := range d.attendees {
+				add(id)
+			}
+		}) {
+			a := d.attendees[id]
+			if !match(a) {
+				continue
+			}
+			for _, claimID := range sortedIDs(len(d.owners), func(add func(uint64)) {
+				for claimID, owner := range d.owners {
+					if owner == a.ID {
+						add(claimID)
+					}
+				}
+			}) {
+				a.Claims = append(a.Claims, d.claim(claimID))
+			}
+			found = &a
+			return nil
+		}
+		return nil
+	})
+	return found, err
+}
+
+// UpdateAttendee implements PurchaseStore
+func (s *MemoryStorage) UpdateAttendee(ctx context.Context, attendee *Attendee) (*Attendee, error) {
+	updated := *attendee
+	updated.Email = NormalizeEmail(attendee.Email)
+	attendee = &updated
+	var found bool
+	err := s.write(ctx, func(d *memoryData) error {
+		existing, ok := d.attendees[attendee.ID]
+		if !ok {
+			return nil
+		}
+		found = true
+		for _, c := range attendee.Claims {
+			if _, ok := d.claims[c.ID]; !ok {
+				return fmt.Errorf("updating attendee claims: claim %d does not exist", c.ID)
+			}
+		}
+		if other, ok := d.attendeeByEmail(attendee.Email); ok && other.ID != attendee.ID {
+			return fmt.Errorf("updating attendee: %s is the email of attendee %d", attendee.Email, other.ID)
+		}
+		existing.Email = attendee.Email
+		existing.CoCAccepted = attendee.CoCAccepted
+		d.attendees[attendee.ID] = existing
+		// claims that were someone else's, ie transferred, become theirs.
+		for _, c := range attendee.Claims {
+			d.owners[c.ID] = attendee.ID
+		}
+		return nil
+	})
+	if err != nil || !found {
+		return nil, err
+	}
+	return attendee, nil
+}
+
+// CreateEventSlot implements PurchaseStore
+func (s *MemoryStorage) CreateEventSlot(ctx context.Context, e *EventSlot) (*EventSlot, error) {
+	var created EventSlot
+	err := s.write(ctx, func(d *memoryData) error {
+		if e.DependsOn != nil {
+			if _, ok := d.slots[e.DependsOn.ID]; !ok {
+				return fmt.Errorf("creating event slot: slot %d it depends on does not exist", e.DependsOn.ID)
+			}
+		}
+		created = *e
+		created.ID = d.nextID("event_slot")
+		d.slots[created.ID] = created
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// ReadEventSlotByID implements PurchaseStore
+func (s *MemoryStorage) ReadEventSlotByID(ctx context.Context, id uint64) (*EventSlot, error) {
+	var found *EventSlot
+	err := s.read(ctx, func(d *memoryData) error {
+		found = d.slot(id)
+		return nil
+	})
+	return found, err
+}
+
+// UpdateEventSlot implements PurchaseStore
+func (s *MemoryStorage) UpdateEventSlot(ctx context.Context, e *EventSlot) error {
+	return s.write(ctx, func(d *memoryData) error {
+		if _, ok := d.slots[e.ID]; !ok {
+			return fmt.Errorf("event slot %d was not updated", e.ID)
+		}
+		d.slots[e.ID] = *e
+		return nil
+	})
+}
+
+// CreateSlotClaim implements PurchaseStore
+func (s *MemoryStorage) CreateSlotClaim(ctx context.Context, slotClaim *SlotClaim) (*SlotClaim, error) {
+	var created SlotClaim
+	err := s.write(ctx, func(d *memoryData) error {
+		if slotClaim.EventSlot == nil {
+			return fmt.Errorf("saving slot claim: it has no slot")
+		}
+		if _, ok := d.slots[slotClaim.EventSlot.ID]; !ok {
+			return fmt.Errorf("saving slot claim: slot %d does not exist", slotClaim.EventSlot.ID)
+		}
+		created = *slotClaim
+		for _, c := range d.claims {
+			if c.TicketID == created.TicketID {
+				created.TicketID = uuid.NewV4().String()
+				break
+			}
+		}
+		created.ID = d.nextID("slot_claim")
+		d.claims[created.ID] = created
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// CreateClaimPayment implements PurchaseStore
+func (s *MemoryStorage) CreateClaimPayment(ctx context.Context, c *ClaimPayment) (*ClaimPayment, error) {
+	var created ClaimPayment
+	err := s.write(ctx, func(d *memoryData) error {
+		if c.AttendeeID != 0 {
+			if _, ok := d.attendees[c.AttendeeID]; !ok {
+				return fmt.Errorf("inserting payment for claims: attendee %d does not exist", c.AttendeeID)
+			}
+		}
+		claims, err := d.claimRefs(nil, c.ClaimsPayed)
+		if err != nil {
+			return fmt.Errorf("inserting payment for claims: %w", err)
+		}
+		payments, err := d.saveInstruments(c.Payment)
+		if err != nil {
+			return fmt.Errorf("inserting payment for claims: %w", err)
+		}
+		created = ClaimPayment{
+			ID:          d.nextID("claim_payment"),
+			AttendeeID:  c.AttendeeID,
+			Invoice:     c.Invoice,
+			ClaimsPayed: claims,
+			Payment:     payments,
+			CreatedAt:   createdAt(c),
+		}
+		d.payments[created.ID] = created
+		created.ClaimsPayed = c.ClaimsPayed
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// UpdateClaimPayment implements PurchaseStore
+func (s *MemoryStorage) UpdateClaimPayment(ctx context.Context, c *ClaimPayment) (*ClaimPayment, error) {
+	var updated ClaimPayment
+	err := s.write(ctx, func(d *memoryData) error {
+		existing, ok := d.payments[c.ID]
+		if !ok {
+			return fmt.Errorf("claim payment was not found")
+		}
+		claims, err := d.claimRefs(existing.ClaimsPayed, c.ClaimsPayed)
+		if err != nil {
+			return fmt.Errorf("updating payment for claims: %w", err)
+		}
+		payments, err := d.saveInstruments(c.Payment)
+		if err != nil {
+			return fmt.Errorf("updating payment for claims: %w", err)
+		}
+		updated = ClaimPayment{
+			ID:          c.ID,
+			AttendeeID:  c.AttendeeID,
+			Invoice:     c.Invoice,
+			ClaimsPayed: claims,
+			Payment:     payments,
+			CreatedAt:   existing.CreatedAt,
+		}
+		d.payments[c.ID] = updated
+		updated.ClaimsPayed = c.ClaimsPayed
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// saveInstruments returns copies of the instruments, with an ID for those without one.
+func (d *memoryData) saveInstruments(instruments []FinancialInstrument) ([]FinancialInstrument, error) {
+	saved := make([]FinancialInstrument, len(instruments))
+	for i, fi := range instruments {
+		switch payment := fi.(type) {
+		case *PaymentMethodMoney:
+			p := *payment
+			if p.ID == 0 {
+				p.ID = d.nextID("payment_method_money")
+			}
+			saved[i] = &p
+		case *PaymentMethodConferenceDiscount:
+			p := *payment
+			if p.ID == 0 {
+				p.ID = d.nextID("payment_method_event_discount")
+			}
+			saved[i] = &p
+		case *PaymentMethodCreditNote:
+			p := *payment
+			if p.ID == 0 {
+				p.ID = d.nextID("payment_method_credit_note")
+			}
+			saved[i] = &p
+		default:
+			return nil, fmt.Errorf("not sure how to process payments of type %T", fi)
+		}
+	}
+	return saved, nil
+}
+
+// ChangeSlotClaimOwner implements PurchaseStore
+func (s *MemoryStorage) ChangeSlotClaimOwner(ctx context.Context, slots []SlotClaim, source *Attendee, target *Attendee) (*Attendee, *Attendee, error) {
+	if source == nil || target == nil {
+		return nil, nil, fmt.Errorf("either source or target is undefined")
+	}
+	if len(slots) == 0 {
+		return nil, nil, fmt.Errorf("no slots to transfer")
+	}
+	if len(slots) > len(source.Claims) {
+		return nil, nil, fmt.Errorf("the passed source lacks those claims")
+	}
+	claimIDsIndex := map[uint64]bool{}
+	for _, slot := range slots {
+		if slot.ID == 0 {
+			return nil, nil, fmt.Errorf("some slot claims lack IDs, perhaps the have not been saved yet")
+		}
+		claimIDsIndex[slot.ID] = true
+	}
+	err := s.write(ctx, func(d *memoryData) error {
+		changed := 0
+		for id := range claimIDsIndex {
+			if d.owners[id] == source.ID {
+				changed++
+			}
+		}
+		if changed != len(slots) {
+			return fmt.Errorf("got %d claims to change but only changed %d", len(slots), changed)
+		}
+		for id := range claimIDsIndex {
+			d.owners[id] = target.ID
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	newClaims := make([]SlotClaim, 0, len(source.Claims)-len(claimIDsIndex))
+	for i := range source.Claims {
+		if claimIDsIndex[source.Claims[i].ID] {
+			target.Claims = append(target.Claims, source.Claims[i])
+			continue
+		}
+		newClaims = append(newClaims, source.Claims[i])
+	}
+	source.Claims = newClaims
+	return source, target, nil
+}
+
+// ReadClaimPaymentByID implements PurchaseStore
+func (s *MemoryStorage) ReadClaimPaymentByID(ctx context.Context, id uint64) (*ClaimPayment, error) {
+	var found *ClaimPayment
+	err := s.read(ctx, func(d *memoryData) error {
+		if _, ok := d.payments[id]; ok {
+			p := d.payment(id)
+			found = &p
+		}
+		return nil
+	})
+	return found, err
+}
+
+// ListClaimPaymentsForAttendee implements PurchaseStore
+func (s *MemoryStorage) ListClaimPaymentsForAttendee(ctx context.Context, attendeeID uint64) ([]ClaimPayment, error) {
+	payments := []ClaimPayment{}
+	err := s.read(ctx, func(d *memoryData) error {
+		for _, id := range sortedIDs(len(d.payments), func(add func(uint64)) {
+			for id, p := range d.payments {
+				if p.AttendeeID == attendeeID {
+					add(id)
+				}
+			}
+		}) {
+			payments = append(payments, d.payment(id))
+		}
+		return nil
+	})
+	return payments, err
+}
+
+// ListClaimsForSlot implements PurchaseStore
+func (s *MemoryStorage) ListClaimsForSlot(ctx context.Context, slotID uint64) ([]SlotClaim, error) {
+	claims := []SlotClaim{}
+	err := s.read(ctx, func(d *memoryData) error {
+		for _, id := range sortedIDs(len(d.claims), func(add func(uint64)) {
+			for id, c := range d.claims {
+				if c.EventSlot != nil && c.EventSlot.ID == slotID {
+					add(id)
+				}
+			}
+		}) {
+			claims = append(claims, d.claim(id))
+		}
+		return nil
+	})
+	return claims, err
+}
+
+// ListEventSlotsForEvent implements PurchaseStore
+func (s *MemoryStorage) ListEventSlotsForEvent(ctx context.Context, eventID uint32) ([]EventSlot, error) {
+	slots := []EventSlot{}
+	err := s.read(ctx, func(d *memoryData) error {
+		for _, id := range sortedIDs(len(d.slots), func(add func(uint64)) {
+			for id, slot := range d.slots {
+				if slot.Event != nil && slot.Event.ID == eventID {
+					add(id)
+				}
+			}
+		}) {
+			slots = append(slots, *d.slot(id))
+		}
+		return nil
+	})
+	return slots, err
+}
+
+// ListClaimsForEvent implements PurchaseStore
+func (s *MemoryStorage) ListClaimsForEvent(ctx context.Context, eventID uint32) ([]SlotClaim, error) {
+	claims := []SlotClaim{}
+	err := s.read(ctx, func(d *memoryData) error {
+		for _, id := range sortedIDs(len(d.claims), func(add func(uint64)) {
+			for id := range d.claims {
+				if c := d.claim(id); eventOf(c.EventSlot) == eventID {
+					add(id)
+				}
+			}
+		}) {
+			claims = append(claims, d.claim(id))
+		}
+		return nil
+	})
+	return claims, err
+}
+
+// slot returns a copy of the stored slot with its event and the slot it depends on, whose
+// own dependency is not loaded as by SQLStorage, nil if it does not exist.
+func (d *memoryData) slot(id uint64) *EventSlot {
+	slot, ok := d.slots[id]
+	if !ok {
+		return nil
+	}
+	if slot.Event != nil {
+		event := *slot.Event
+		slot.Event = &event
+	}
+	if slot.DependsOn != nil {
+		dependency := d.slots[slot.DependsOn.ID]
+		dependency.DependsOn = nil
+		if dependency.Event != nil {
+			event := *dependency.Event
+			dependency.Event = &event
+		}
+		slot.DependsOn = &dependency
+	}
+	return &slot
+}
+
+// claim returns a copy of the stored claim with its slot.
+func (d *memoryData) claim(id uint64) SlotClaim {
+	c := d.claims[id]
+	if c.EventSlot != nil {
+		c.EventSlot = d.slot(c.EventSlot.ID)
+	}
+	return c
+}
+
+// payment returns a copy of the stored payment with its claims, ordered by ID, and its
+// instruments ordered as by SQLStorage.
+func (d *memoryData) payment(id uint64) ClaimPayment {
+	p := d.payments[id]
+	claims := make([]*SlotClaim, len(p.ClaimsPayed))
+	for i, ref := range p.ClaimsPayed {
+		c := d.claim(ref.ID)
+		claims[i] = &c
+	}
+	p.ClaimsPayed = claims
+	p.Payment = append([]FinancialInstrument{}, p.Payment...)
+	sort.SliceStable(p.Payment, func(i, j int) bool {
+		ki, ii := instrumentOrder(p.Payment[i])
+		kj, ij := instrumentOrder(p.Payment[j])
+		if ki != kj {
+			return ki < kj
+		}
+		return ii < ij
+	})
+	for i, fi := range p.Payment {
+		p.Payment[i] = copyInstrument(fi)
+	}
+	return p
+}
+
+// claimRefs returns the references to the existing claims plus those paid for, ordered
+// by ID and failing if any of those is not stored.
+func (d *memoryData) claimRefs(existing []*SlotClaim, paid []*SlotClaim) ([]*SlotClaim, error) {
+	ids := map[uint64]bool{}
+	for _, c := range existing {
+		ids[c.ID] = true
+	}
+	for _, c := range paid {
+		if c == nil || c.ID == 0 {
+			return nil, fmt.Errorf("some slot claims lack IDs, perhaps the have not been saved yet")
+		}
+		if _, ok := d.claims[c.ID]; !ok {
+			return nil, fmt.Errorf("claim %d does not exist", c.ID)
+		}
+		ids[c.ID] = true
+	}
+	refs := make([]*SlotClaim, 0, len(ids))
+	for _, id := range sortedIDs(len(ids), func(add func(uint64)) {
+		for id := range ids {
+			add(id)
+		}
+	}) {
+		refs = append(refs, &SlotClaim{ID: id})
+	}
+	return refs, nil
+}
+
+// instrumentOrder returns the rank of the kind of the instrument and its ID, SQLStorage
+// reads money, then discounts, then credit.
+func instrumentOrder(fi FinancialInstrument) (int, uint64) {
+	switch p := fi.(type) {
+	case *PaymentMethodMoney:
+		return 0, p.ID
+	case *PaymentMethodConferenceDiscount:
+		return 1, p.ID
+	case *PaymentMethodCreditNote:
+		return 2, p.ID
+	}
+	return 3, 0
+}
+
+func copyInstrument(fi FinancialInstrument) FinancialInstrument {
+	switch p := fi.(type) {
+	case *PaymentMethodMoney:
+		c := *p
+		return &c
+	case *PaymentMethodConferenceDiscount:
+		c := *p
+		return &c
+	case *PaymentMethodCreditNote:
+		c := *p
+		return &c
+	}
+	return fi
+}
+
+// sortedIDs returns the IDs passed to add by each in ascending order, so reads are as
+// deterministic as ordered SQL queries.
+func sortedIDs(size int, each func(add func(uint64))) []uint64 {
+	ids := make([]uint64, 0, size)
+	each(func(id uint64) { ids = append(ids, id) })
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// ListClaimPaymentsForEvent implements PurchaseStore
+func (s *MemoryStorage) ListClaimPaymentsForEvent(ctx context.Context, eventID uint32, page Page) ([]ClaimPayment, error) {
+	payments := []ClaimPayment{}
+	err := s.read(ctx, func(d *memoryData) error {
+		for _, id := range paged(sortedIDs(len(d.payments), func(add func(uint64)) {
+			for id, p := range d.payments {
+				for _, ref := range p.ClaimsPayed {
+					if c := d.claim(ref.ID); eventOf(c.EventSlot) == eventID {
+						add(id)
+						break
+					}
+				}
+			}
+		}), page) {
+			payments = append(payments, d.payment(id))
+		}
+		return nil
+	})
+	return payments, err
+}
+
+// ListAttendeesForEvent implements PurchaseStore
+func (s *MemoryStorage) ListAttendeesForEvent(ctx context.Context, eventID uint32, page Page) ([]Attendee, error) {
+	attendees := []Attendee{}
+	err := s.read(ctx, func(d *memoryData) error {
+		claims := map[uint64][]uint64{}
+		for _, claimID := range sortedIDs(len(d.owners), func(add func(uint64)) {
+			for claimID := range d.owners {
+				add(claimID)
+			}
+		}) {
+			if c := d.claim(claimID); eventOf(c.EventSlot) == eventID {
+				claims[d.owners[claimID]] = append(claims[d.owners[claimID]], claimID)
+			}
+		}
+		for _, id := range paged(sortedIDs(len(claims), func(add func(uint64)) {
+			for id := range claims {
+				add(id)
+			}
+		}), page) {
+			a := d.attendees[id]
+			a.Claims = nil
+			for _, claimID := range claims[id] {
+				a.Claims = append(a.Claims, d.claim(claimID))
+			}
+			attendees = append(attendees, a)
+		}
+		return nil
+	})
+	return attendees, err
+}
+
+// paged returns the sorted IDs in the page.
+func paged(ids []uint64, page Page) []uint64 {
+	i := sort.Search(len(ids), func(i int) bool { return ids[i] > page.AfterID })
+	ids = ids[i:]
+	if page.Limit > 0 && len(ids) > page.Limit {
+		ids = ids[:page.Limit]
+	}
+	return ids
+}
+
+// PostJournalEntry implements PurchaseStore
+func (s *MemoryStorage) PostJournalEntry(ctx context.Context, entry *JournalEntry) (*JournalEntry, error) {
+	if err := entry.Validate(); err != nil {
+		return nil, err
+	}
+	var posted JournalEntry
+	err := s.write(ctx, func(d *memoryData) error {
+		if entry.PaymentID != 0 {
+			if _, ok := d.payments[entry.PaymentID]; !ok {
+				return fmt.Errorf("inserting journal entry: payment %d does not exist", entry.PaymentID)
+			}
+		}
+		posted = *entry
+		posted.ID = d.nextID("journal_entry")
+		posted.Lines = append([]JournalLine{}, entry.Lines...)
+		d.journal = append(d.journal, posted)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	posted.Lines = append([]JournalLine{}, posted.Lines...)
+	return &posted, nil
+}
+
+// ListJournalEntriesForPayment implements PurchaseStore
+func (s *MemoryStorage) ListJournalEntriesForPayment(ctx context.Context, paymentID uint64) ([]JournalEntry, error) {
+	entries := []JournalEntry{}
+	err := s.read(ctx, func(d *memoryData) error {
+		for _, e := range d.journal {
+			if e.PaymentID == paymentID {
+				e.Lines = append([]JournalLine{}, e.Lines...)
+				entries = append(entries, e)
+			}
+		}
+		return nil
+	})
+	return entries, err
+}
+
+// LockClaimPayment implements PurchaseStore, atomic operations already fail to commit
+// when another one committed since they began so it only looks the payment up.
+func (s *MemoryStorage) LockClaimPayment(ctx context.Context, id uint64) (bool, error) {
+	found := false
+	err := s.read(ctx, func(d *memoryData) error {
+		_, found = d.payments[id]
+		return nil
+	})
+	return found, err
+}
+
+// SumJournal implements PurchaseStore
+func (s *MemoryStorage) SumJournal(ctx context.Context, eventID uint32) ([]AccountBalance, error) {
+	sums := map[Account]AccountBalance{}
+	err := s.read(ctx, func(d *memoryData) error {
+		for _, e := range d.journal {
+			if eventID != 0 && e.EventID != eventID {
+				continue
+			}
+			for _, l := range e.Lines {
+				sum := sums[l.Account]
+				sum.Account = l.Account
+				sum.Debit += l.Debit
+				sum.Credit += l.Credit
+				sums[l.Account] = sum
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	balances := make([]AccountBalance, 0, len(sums))
+	for _, sum := range sums {
+		balances = append(balances, sum)
+	}
+	sort.Slice(balances, func(i, j int) bool { return balances[i].Account < balances[j].Account })
+	return balances, nil
+}
+
+// ListClaimPaymentsOnCredit implements PurchaseStore
+func (s *MemoryStorage) ListClaimPaymentsOnCredit(ctx context.Context) ([]ClaimPayment, error) {
+	payments := []ClaimPayment{}
+	err := s.read(ctx, func(d *memoryData) error {
+		for _, id := range sortedIDs(len(d.payments), func(add func(uint64)) {
+			for id, p := range d.payments {
+				for _, fi := range p.Payment {
+					if fi.Type() == ATReceivable {
+						add(id)
+						break
+					}
+				}
+			}
+		}) {
+			payments = append(payments, d.payment(id))
+		}
+		return nil
+	})
+	return payments, err
+}
+
+// SuspendClaims implements PurchaseStore
+func (s *MemoryStorage) SuspendClaims(ctx context.Context, claimIDs []uint64, suspended bool) error {
+	if len(claimIDs) == 0 {
+		return nil
+	}
+	return s.write(ctx, func(d *memoryData) error {
+		for _, id := range claimIDs {
+			if c, ok := d.claims[id]; ok {
+				c.Suspended = suspended
+				d.claims[id] = c
+			}
+		}
+		return nil
+	})
+}
+
+// ListRecordedMoney implements PurchaseStore
+func (s *MemoryStorage) ListRecordedMoney(ctx context.Context, refs []string, from, to uint64) ([]RecordedMoney, error) {
+	byRef := make(map[string]bool, len(refs))
+	for _, ref := range refs {
+		byRef[ref] = true
+	}
+	recorded := []RecordedMoney{}
+	err := s.read(ctx, func(d *memoryData) error {
+		for _, id := range sortedIDs(len(d.payments), func(add func(uint64)) {
+			for id := range d.payments {
+				add(id)
+			}
+		}) {
+			p := d.payments[id]
+			for _, fi := range p.Payment {
+				money, ok := fi.(*PaymentMethodMoney)
+				inRange := to == 0 || (p.CreatedAt >= from && p.CreatedAt <= to)
+				if ok && (inRange || byRef[money.PaymentRef]) {
+					recorded = append(recorded, RecordedMoney{
+						PaymentMethodMoney: *money,
+						ClaimPaymentID:     p.ID,
+						PaidAt:             p.CreatedAt,
+					})
+				}
+			}
+		}
+		return nil
+	})
+	sort.Slice(recorded, func(i, j int) bool { return recorded[i].ID < recorded[j].ID })
+	return recorded, err
+}
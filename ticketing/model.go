@@ -1,6 +1,10 @@
 package ticketing
 
-import "github.com/gopheracademy/manager/def"
+import (
+	"strings"
+
+	"github.com/gopheracademy/manager/def"
+)
 
 // EventSlot holds information for any sellable/giftable slot we have in the event for
 // a Talk or any other activity that requires admission.
@@ -30,10 +34,14 @@ type EventSlot struct {
 // ClaimPayment represents a payment for N claims
 type ClaimPayment struct {
 	ID uint64 `gaum:"field_name:id"`
+	// AttendeeID is who paid, the claims may be someone else's once transferred.
+	AttendeeID uint64 `gaum:"field_name:attendee_id"`
 	// ClaimsPayed would be what in a bill one see as detail.
 	ClaimsPayed []*SlotClaim
 	Payment     []FinancialInstrument
 	Invoice     string `gaum:"field_name:invoice"` // let us fill this once we know how to invoice
+	// CreatedAt is when the payment was made, its credit notes age from then.
+	CreatedAt uint64 `gaum:"field_name:created_at"` // CreatedAt is Unix timestamp, seconds since Epoch (1/1/1970 UTC)
 }
 
 // TotalDue returns the total cost to cover by this payment.
@@ -62,6 +70,15 @@ type SlotClaim struct {
 	// Redeemed represents whether this has been used (ie the Attendee enrolled in front desk
 	// or into the online conf system) until this is not true, transfer/refund might be possible.
 	Redeemed bool `gaum:"field_name:redeemed"`
+	// Suspended claims cannot be redeemed until the debt of the payment for them, which
+	// was overdue at the cutoff of their event, is settled.
+	Suspended bool `gaum:"field_name:suspended"`
+}
+
+// Redeemable returns true if the claim can be used, it was neither used already nor
+// suspended.
+func (c *SlotClaim) Redeemable() bool {
+	return !c.Redeemed && !c.Suspended
 }
 
 // Attendee is a person attending one or more Slots of the Conference.
@@ -69,10 +86,16 @@ type Attendee struct {
 	ID    uint64 `gaum:"field_name:id"`
 	Email string `gaum:"field_name:email"`
 	// CoCAccepted, claims cannot be used without this.
-	CoCAccepted bool `gaum:"field_name:co_c_accepted"`
+	CoCAccepted bool `gaum:"field_name:coc_accepted"`
 	Claims      []SlotClaim
 }
 
+// NormalizeEmail returns the email as stores keep it, lowercased and trimmed, so one
+// attendee is found whatever case their email is typed in.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
 // Finance Section
 
 // PaymentMethodMoney represents a payment in cash.
@@ -94,6 +117,16 @@ func (p *PaymentMethodMoney) Type() AssetType {
 
 var _ FinancialInstrument = &PaymentMethodMoney{}
 
+// RecordedMoney is money recorded as paying a payment, ie to reconcile it with the
+// payouts of the provider that took it.
+type RecordedMoney struct {
+	PaymentMethodMoney
+	ClaimPaymentID uint64 `gaum:"field_name:claim_payment_id"`
+	// PaidAt is when the payment was made, the money may have been added later to cover
+	// its credit.
+	PaidAt uint64 `gaum:"field_name:paid_at"` // PaidAt is Unix timestamp, seconds since Epoch (1/1/1970 UTC)
+}
+
 // PaymentMethodConferenceDiscount represents a discount issued by the event.
 type PaymentMethodConferenceDiscount struct {
 	ID uint64 `gaum:"field_name:id"`
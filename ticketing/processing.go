@@ -1,39 +1,125 @@
 package ticketing
 
 import (
+	"context"
 	"fmt"
+	"time"
 
 	uuid "github.com/satori/go.uuid"
+
+	"github.com/gopheracademy/manager/outbox"
 )
 
-// PurchaseStore offers functionality for persistence of ticketing models.
+// PurchaseStore offers functionality for persistence of ticketing models, those reading
+// or writing a part of them accept the narrower store of it.
 type PurchaseStore interface {
 	// AtomicOperation returns a store which will act as one single atomic operation.
 	// It returns a commit and cancel functions and the Store .
-	AtomicOperation() (func() error, func() error, PurchaseStore, error)
-	// CreateSlotClaim saves a slot claim and returns it with the populated ID
-	CreateSlotClaim(*SlotClaim) (*SlotClaim, error)
+	AtomicOperation(ctx context.Context) (func() error, func() error, PurchaseStore, error)
+	// AppendEvents writes domain events to the outbox, within the atomic operation so
+	// they are only delivered if it succeeds.
+	AppendEvents(ctx context.Context, events ...outbox.Event) error
+
+	AttendeeStore
+	SlotStore
+	PaymentStore
+	JournalStore
+	ReceivableStore
+	ExportStore
+}
 
+// AttendeeStore keeps attendees and who holds which claims.
+type AttendeeStore interface {
 	// UpdateAttendee saves the passed attendee attributes on top of the existing one.
-	UpdateAttendee(*Attendee) (*Attendee, error)
+	UpdateAttendee(context.Context, *Attendee) (*Attendee, error)
+	ChangeSlotClaimOwner(context.Context, []SlotClaim, *Attendee, *Attendee) (*Attendee, *Attendee, error)
 
-	CreateClaimPayment(*ClaimPayment) (*ClaimPayment, error)
+	CreateAttendee(ctx context.Context, a *Attendee) (*Attendee, error)
+	ReadAttendeeByEmail(ctx context.Context, email string) (*Attendee, error)
+	ReadAttendeeByID(ctx context.Context, id uint64) (*Attendee, error)
+}
+
+// SlotStore keeps the slots of events and the claims made on them.
+type SlotStore interface {
+	CreateEventSlot(ctx context.Context, e *EventSlot) (*EventSlot, error)
+	ReadEventSlotByID(ctx context.Context, id uint64) (*EventSlot, error)
+	UpdateEventSlot(ctx context.Context, e *EventSlot) error
+	// ListEventSlotsForEvent returns the slots of the event, ordered by ID.
+	ListEventSlotsForEvent(ctx context.Context, eventID uint32) ([]EventSlot, error)
+
+	// CreateSlotClaim saves a slot claim and returns it with the populated ID
+	CreateSlotClaim(context.Context, *SlotClaim) (*SlotClaim, error)
+	// ListClaimsForSlot returns the claims of the slot, with the slot.
+	ListClaimsForSlot(ctx context.Context, slotID uint64) ([]SlotClaim, error)
+	// ListClaimsForEvent returns the claims of the slots of the event with their slot, in
+	// the order they were made.
+	ListClaimsForEvent(ctx context.Context, eventID uint32) ([]SlotClaim, error)
+}
+
+// PaymentStore keeps payments for claims and the instruments they were paid with.
+type PaymentStore interface {
+	CreateClaimPayment(context.Context, *ClaimPayment) (*ClaimPayment, error)
+	UpdateClaimPayment(context.Context, *ClaimPayment) (*ClaimPayment, error)
+	// ReadClaimPaymentByID returns the payment with its claims, their slots, and the
+	// instruments it was paid with, or nil if it does not exist.
+	ReadClaimPaymentByID(ctx context.Context, id uint64) (*ClaimPayment, error)
+	// ListClaimPaymentsForAttendee returns the payments made by the attendee, read like
+	// ReadClaimPaymentByID.
+	ListClaimPaymentsForAttendee(ctx context.Context, attendeeID uint64) ([]ClaimPayment, error)
+	// LockClaimPayment keeps other atomic operations from changing what is left of the
+	// payment until the one it is called in ends, it returns false if there is no such
+	// payment.
+	LockClaimPayment(ctx context.Context, id uint64) (bool, error)
+	// ListRecordedMoney returns the money instruments recorded with one of the refs or for
+	// payments made between from and to included, with the payment each paid, ordered by
+	// ID; a zero to lists every one.
+	ListRecordedMoney(ctx context.Context, refs []string, from, to uint64) ([]RecordedMoney, error)
+}
+
+// JournalStore keeps the ledger.
+type JournalStore interface {
+	// PostJournalEntry appends a validated entry to the journal and returns it with its
+	// ID, within the atomic operation along with the movement it records.
+	PostJournalEntry(ctx context.Context, entry *JournalEntry) (*JournalEntry, error)
+	// ListJournalEntriesForPayment returns the entries of the payment, in the order they
+	// were posted.
+	ListJournalEntriesForPayment(ctx context.Context, paymentID uint64) ([]JournalEntry, error)
+	// SumJournal returns the debits and credits posted to each account with lines, by
+	// entries of the event or of every entry for event 0, ordered by account.
+	SumJournal(ctx context.Context, eventID uint32) ([]AccountBalance, error)
+}
+
+// ReceivableStore reads the payments on credit and suspends the claims of those overdue.
+type ReceivableStore interface {
+	// ListClaimPaymentsOnCredit returns the payments made with credit notes, whether or
+	// not they were covered since, read like ReadClaimPaymentByID.
+	ListClaimPaymentsOnCredit(ctx context.Context) ([]ClaimPayment, error)
+	// SuspendClaims suspends the claims with the IDs, or lifts their suspension.
+	SuspendClaims(ctx context.Context, claimIDs []uint64, suspended bool) error
+}
 
-	UpdateClaimPayment(*ClaimPayment) (*ClaimPayment, error)
-	ChangeSlotClaimOwner([]SlotClaim, *Attendee, *Attendee) (*Attendee, *Attendee, error)
+// ExportStore reads the attendees and payments of an event a page at a time.
+type ExportStore interface {
+	// ListClaimPaymentsForEvent returns a page of the payments for claims of slots of
+	// the event, read like ReadClaimPaymentByID; those for claims of several events are
+	// listed for each.
+	ListClaimPaymentsForEvent(ctx context.Context, eventID uint32, page Page) ([]ClaimPayment, error)
+	// ListAttendeesForEvent returns a page of the attendees holding claims of slots of
+	// the event, with only those claims.
+	ListAttendeesForEvent(ctx context.Context, eventID uint32, page Page) ([]Attendee, error)
+}
 
-	CreateAttendee(a *Attendee) (*Attendee, error)
-	ReadAttendeeByEmail(email string) (*Attendee, error)
-	ReadAttendeeByID(id uint64) (*Attendee, error)
-	CreateEventSlot(e *EventSlot) (*EventSlot, error)
-	ReadEventSlotByID(id uint64) (*EventSlot, error)
-	UpdateEventSlot(e *EventSlot) error
+// Page bounds a listing ordered by ID to the Limit items after AfterID, every item after
+// it if Limit is 0; the next page starts after the ID of the last item of the previous.
+type Page struct {
+	AfterID uint64
+	Limit   int
 }
 
 // ClaimSlots claims N slots for an attendee.
-func ClaimSlots(storer PurchaseStore,
+func ClaimSlots(ctx context.Context, storer PurchaseStore,
 	attendee *Attendee, slots ...EventSlot) ([]SlotClaim, error) {
-	succed, fail, atomic, err := storer.AtomicOperation()
+	succed, fail, atomic, err := storer.AtomicOperation(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("beginning atomic operation: %w", err)
 	}
@@ -44,31 +130,49 @@ func ClaimSlots(storer PurchaseStore,
 			EventSlot: &slot,
 			TicketID:  uuid.NewV4().String(),
 		}
-		sc, err = atomic.CreateSlotClaim(sc)
+		sc, err = atomic.CreateSlotClaim(ctx, sc)
 		if err != nil {
 			if atomicErr := fail(); atomicErr != nil {
 				err = fmt.Errorf("%w (also cancelling atomic operation: %v)", err, atomicErr)
 			}
 			return nil, fmt.Errorf("Claiming a slot: %w", err)
 		}
-		claims[i] = *sc
+		claims = append(claims, *sc)
 	}
 	attendee.Claims = append(attendee.Claims, claims...)
-	_, err = atomic.UpdateAttendee(attendee)
+	_, err = atomic.UpdateAttendee(ctx, attendee)
 	if err != nil {
 		if atomicErr := fail(); atomicErr != nil {
 			err = fmt.Errorf("%w (also cancelling atomic operation: %v)", err, atomicErr)
 		}
 		return nil, fmt.Errorf("Updating claimed slots for attendee: %w", err)
 	}
+	created := make([]interface{}, 0, len(claims))
+	for _, c := range claims {
+		created = append(created, ClaimCreated{
+			EventID:       eventOf(c.EventSlot),
+			ClaimID:       c.ID,
+			TicketID:      c.TicketID,
+			SlotID:        c.EventSlot.ID,
+			AttendeeID:    attendee.ID,
+			AttendeeEmail: attendee.Email,
+		})
+	}
+	if err := emit(ctx, atomic, EventClaimCreated, created...); err != nil {
+		if atomicErr := fail(); atomicErr != nil {
+			err = fmt.Errorf("%w (also cancelling atomic operation: %v)", err, atomicErr)
+		}
+		return nil, err
+	}
 	if err := succed(); err != nil {
 		return nil, fmt.Errorf("confirming atomic operation: %w", err)
 	}
 	return claims, nil
 }
 
-// PayClaims assigns payments and/or credits to a set of claims.
-func PayClaims(store PurchaseStore,
+// PayClaims assigns payments and/or credits to a set of claims, posting the sale of the
+// claims to the journal.
+func PayClaims(ctx context.Context, store PurchaseStore,
 	attendee *Attendee, claims []SlotClaim,
 	payments []FinancialInstrument) (*ClaimPayment, error) {
 	ptrClaims := make([]*SlotClaim, len(claims))
@@ -76,21 +180,35 @@ func PayClaims(store PurchaseStore,
 		ptrClaims[i] = &claims[i]
 	}
 	claimPayment := &ClaimPayment{
+		AttendeeID:  attendee.ID,
 		ClaimsPayed: ptrClaims,
 		Payment:     payments,
+		CreatedAt:   uint64(time.Now().Unix()),
 	}
-	succed, fail, atomic, err := store.AtomicOperation()
+	succed, fail, atomic, err := store.AtomicOperation(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("beginning atomic operation: %w", err)
 	}
 
-	claimPayment, err = atomic.CreateClaimPayment(claimPayment)
+	claimPayment, err = atomic.CreateClaimPayment(ctx, claimPayment)
 	if err != nil {
 		if atomicErr := fail(); atomicErr != nil {
 			err = fmt.Errorf("%w (also cancelling atomic operation: %v)", err, atomicErr)
 		}
 		return nil, fmt.Errorf("paying for claims: %w", err)
 	}
+	if err := post(ctx, atomic, orderEntries(claimPayment)...); err != nil {
+		if atomicErr := fail(); atomicErr != nil {
+			err = fmt.Errorf("%w (also cancelling atomic operation: %v)", err, atomicErr)
+		}
+		return nil, err
+	}
+	if err := emit(ctx, atomic, EventPaymentRecorded, paymentRecorded(claimPayment, attendee.ID)); err != nil {
+		if atomicErr := fail(); atomicErr != nil {
+			err = fmt.Errorf("%w (also cancelling atomic operation: %v)", err, atomicErr)
+		}
+		return nil, err
+	}
 	if err := succed(); err != nil {
 		return nil, fmt.Errorf("confirming atomic operation: %w", err)
 	}
@@ -108,21 +226,44 @@ func (e *ErrInvalidCurrency) Error() string {
 	return fmt.Sprintf("the debt cannot be covered with %s", e.currencyType)
 }
 
-// CoverCredit adds funds to a payment to cover for receivables.
-func CoverCredit(store PurchaseStore,
+// CoverCredit adds funds to a payment to cover for receivables, posting them as settling
+// what is owed on it; once it is covered the claims it pays for are no longer suspended.
+// The payment is locked and read again first, so covers and refunds made at once each
+// add to what the others left; existingPayment is updated once it succeeded.
+func CoverCredit(ctx context.Context, store PurchaseStore,
 	existingPayment *ClaimPayment,
 	payments []FinancialInstrument) error {
 	for i := range payments {
 		if payments[i].Type() == ATReceivable {
 			return &ErrInvalidCurrency{currencyType: payments[i].Type()}
 		}
-		existingPayment.Payment = append(existingPayment.Payment, payments[i])
 	}
-	succed, fail, atomic, err := store.AtomicOperation()
+	succed, fail, atomic, err := store.AtomicOperation(ctx)
 	if err != nil {
 		return fmt.Errorf("beginning atomic operation: %w", err)
 	}
-	_, err = atomic.UpdateClaimPayment(existingPayment)
+	found, err := atomic.LockClaimPayment(ctx, existingPayment.ID)
+	if err == nil && !found {
+		err = fmt.Errorf("payment %d does not exist", existingPayment.ID)
+	}
+	if err != nil {
+		if atomicErr := fail(); atomicErr != nil {
+			err = fmt.Errorf("%w (also cancelling atomic operation: %v)", err, atomicErr)
+		}
+		return fmt.Errorf("locking the payment: %w", err)
+	}
+	payment, err := atomic.ReadClaimPaymentByID(ctx, existingPayment.ID)
+	if err == nil && payment == nil {
+		err = fmt.Errorf("payment %d does not exist", existingPayment.ID)
+	}
+	if err != nil {
+		if atomicErr := fail(); atomicErr != nil {
+			err = fmt.Errorf("%w (also cancelling atomic operation: %v)", err, atomicErr)
+		}
+		return fmt.Errorf("reading the payment: %w", err)
+	}
+	payment.Payment = append(payment.Payment, payments...)
+	_, err = atomic.UpdateClaimPayment(ctx, payment)
 	if err != nil {
 		if atomicErr := fail(); atomicErr != nil {
 			err = fmt.Errorf("%w (also cancelling atomic operation: %v)", err, atomicErr)
@@ -130,16 +271,37 @@ func CoverCredit(store PurchaseStore,
 
 		return fmt.Errorf("saving new payments %w", err)
 	}
+	if err := post(ctx, atomic, settlementEntries(payment, payments)...); err != nil {
+		if atomicErr := fail(); atomicErr != nil {
+			err = fmt.Errorf("%w (also cancelling atomic operation: %v)", err, atomicErr)
+		}
+		return err
+	}
+	if covered, _ := DebtBalanced(payment.Payment...); covered {
+		if err := atomic.SuspendClaims(ctx, paidClaimIDs(payment), false); err != nil {
+			if atomicErr := fail(); atomicErr != nil {
+				err = fmt.Errorf("%w (also cancelling atomic operation: %v)", err, atomicErr)
+			}
+			return fmt.Errorf("lifting the suspension of claims: %w", err)
+		}
+	}
+	if err := emit(ctx, atomic, EventPaymentRecorded, paymentRecorded(payment, 0)); err != nil {
+		if atomicErr := fail(); atomicErr != nil {
+			err = fmt.Errorf("%w (also cancelling atomic operation: %v)", err, atomicErr)
+		}
+		return err
+	}
 
 	if err := succed(); err != nil {
 		return fmt.Errorf("confirming atomic operation: %w", err)
 	}
+	*existingPayment = *payment
 
 	return nil
 }
 
 // TransferClaims transfer claims from one user the the other, assuming they belong to the first.
-func TransferClaims(storer PurchaseStore,
+func TransferClaims(ctx context.Context, storer PurchaseStore,
 	source, target *Attendee, claims []SlotClaim) (*Attendee, *Attendee, error) {
 	var err error
 	sourceClaimsMap := map[uint64]bool{}
@@ -151,16 +313,33 @@ func TransferClaims(storer PurchaseStore,
 			return nil, nil, fmt.Errorf("%d claim for slot %s does not belong to %s", claims[i].ID, claims[i].EventSlot.Name, source.Email)
 		}
 	}
-	succed, fail, atomic, err := storer.AtomicOperation()
+	succed, fail, atomic, err := storer.AtomicOperation(ctx)
 	if err != nil {
 		return nil, nil, fmt.Errorf("beginning atomic operation: %w", err)
 	}
-	if source, target, err = atomic.ChangeSlotClaimOwner(claims, source, target); err != nil {
+	if source, target, err = atomic.ChangeSlotClaimOwner(ctx, claims, source, target); err != nil {
 		if atomicErr := fail(); atomicErr != nil {
 			err = fmt.Errorf("%w (also cancelling atomic operation: %v)", err, atomicErr)
 		}
 		return nil, nil, fmt.Errorf("reowning slot claim: %w", err)
 	}
+	transferred := ClaimsTransferred{
+		SourceID:    source.ID,
+		SourceEmail: source.Email,
+		TargetID:    target.ID,
+		TargetEmail: target.Email,
+		ClaimIDs:    claimIDs(claims),
+		SlotIDs:     slotIDs(claims),
+	}
+	if len(claims) != 0 {
+		transferred.EventID = eventOf(claims[0].EventSlot)
+	}
+	if err := emit(ctx, atomic, EventClaimsTransferred, transferred); err != nil {
+		if atomicErr := fail(); atomicErr != nil {
+			err = fmt.Errorf("%w (also cancelling atomic operation: %v)", err, atomicErr)
+		}
+		return nil, nil, err
+	}
 	if err := succed(); err != nil {
 		return nil, nil, fmt.Errorf("confirming atomic operation: %w", err)
 	}
@@ -0,0 +1,54 @@
+package ticketing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gopheracademy/manager/outbox"
+)
+
+// claimStore keeps the claims created in memory, the methods ClaimSlots does not use
+// are left unimplemented.
+type claimStore struct {
+	PurchaseStore
+	claims []SlotClaim
+}
+
+func (s *claimStore) AtomicOperation(ctx context.Context) (func() error, func() error, PurchaseStore, error) {
+	done := func() error { return nil }
+	return done, done, s, nil
+}
+
+func (s *claimStore) CreateSlotClaim(ctx context.Context, sc *SlotClaim) (*SlotClaim, error) {
+	sc.ID = uint64(len(s.claims) + 1)
+	s.claims = append(s.claims, *sc)
+	return sc, nil
+}
+
+func (s *claimStore) UpdateAttendee(ctx context.Context, a *Attendee) (*Attendee, error) {
+	return a, nil
+}
+
+func (s *claimStore) AppendEvents(ctx context.Context, events ...outbox.Event) error {
+	return nil
+}
+
+func TestClaimSlots(t *testing.T) {
+	s := &claimStore{}
+	attendee := &Attendee{ID: 1, Email: "gopher@example.com"}
+	claims, err := ClaimSlots(context.Background(), s, attendee, EventSlot{ID: 1, Name: "conference"}, EventSlot{ID: 2, Name: "workshop"})
+	if err != nil {
+		t.Fatalf("ClaimSlots() = %v", err)
+	}
+	if len(claims) != 2 || claims[0].EventSlot.ID != 1 || claims[1].EventSlot.ID != 2 {
+		t.Fatalf("ClaimSlots() = %+v, want a claim of slots 1 and 2", claims)
+	}
+	for i, c := range claims {
+		if c.ID != s.claims[i].ID || c.TicketID == "" {
+			t.Errorf("claim %d is %+v, want %+v with a ticket", i, c, s.claims[i])
+		}
+	}
+	if len(attendee.Claims) != 2 {
+		t.Errorf("attendee holds %d claims, want 2", len(attendee.Claims))
+	}
+}
@@ -0,0 +1,217 @@
+package ticketing
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// createdAt returns when the payment was made, now if it was not set.
+func createdAt(payment *ClaimPayment) uint64 {
+	if payment.CreatedAt != 0 {
+		return payment.CreatedAt
+	}
+	return uint64(time.Now().Unix())
+}
+
+// paidClaimIDs returns the IDs of the claims the payment pays for.
+func paidClaimIDs(payment *ClaimPayment) []uint64 {
+	ids := make([]uint64, 0, len(payment.ClaimsPayed))
+	for _, c := range payment.ClaimsPayed {
+		ids = append(ids, c.ID)
+	}
+	return ids
+}
+
+// AgingBucket is a range of ages of outstanding credit, up to MaxDays or unbounded if 0.
+type AgingBucket struct {
+	Name    string
+	MaxDays int
+}
+
+// AgingBuckets are the buckets receivables are aged in, from the youngest.
+var AgingBuckets = []AgingBucket{
+	{Name: "0-30", MaxDays: 30},
+	{Name: "31-60", MaxDays: 60},
+	{Name: "61-90", MaxDays: 90},
+	{Name: "over 90", MaxDays: 0},
+}
+
+// bucketOf returns the index in AgingBuckets of the age.
+func bucketOf(days int) int {
+	for i, b := range AgingBuckets {
+		if b.MaxDays == 0 || days <= b.MaxDays {
+			return i
+		}
+	}
+	return len(AgingBuckets) - 1
+}
+
+// Receivable is a payment whose credit notes are not covered.
+type Receivable struct {
+	Payment ClaimPayment
+	// EventID is the conference event of the claims of the payment, 0 if unknown.
+	EventID uint32
+	// Outstanding is what is left to cover, as DebtBalanced counts it.
+	Outstanding int64
+	// AgeDays is how many whole days passed since the payment was made.
+	AgeDays int
+	// Bucket is the index in AgingBuckets of the age.
+	Bucket int
+}
+
+// AgingReport lists the receivables, oldest first, with their totals per bucket.
+type AgingReport struct {
+	AsOf        time.Time
+	Receivables []Receivable
+	// Totals are the outstanding amounts per bucket, in the order of AgingBuckets.
+	Totals []int64
+	Total  int64
+}
+
+// AgeReceivables returns the payments whose credit notes are not covered at now,
+// bucketed by how long ago they were made.
+func AgeReceivables(ctx context.Context, store ReceivableStore, now time.Time) (*AgingReport, error) {
+	payments, err := store.ListClaimPaymentsOnCredit(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("reading payments on credit: %w", err)
+	}
+	report := &AgingReport{AsOf: now, Totals: make([]int64, len(AgingBuckets))}
+	// payments are listed in the order they were made, oldest first.
+	for i := range payments {
+		covered, outstanding := DebtBalanced(payments[i].Payment...)
+		if covered {
+			continue
+		}
+		r := Receivable{
+			Payment:     payments[i],
+			Outstanding: outstanding,
+			AgeDays:     ageDays(payments[i].CreatedAt, now),
+		}
+		for _, c := range payments[i].ClaimsPayed {
+			if r.EventID = eventOf(c.EventSlot); r.EventID != 0 {
+				break
+			}
+		}
+		r.Bucket = bucketOf(r.AgeDays)
+		report.Receivables = append(report.Receivables, r)
+		report.Totals[r.Bucket] += outstanding
+		report.Total += outstanding
+	}
+	return report, nil
+}
+
+// ageDays returns the whole days from the Unix timestamp to now, 0 if it is unknown or
+// in the future.
+func ageDays(unix uint64, now time.Time) int {
+	if unix == 0 || int64(unix) > now.Unix() {
+		return 0
+	}
+	return int(now.Sub(time.Unix(int64(unix), 0)) / (24 * time.Hour))
+}
+
+// DunningPolicy is how DunReceivables chases outstanding credit.
+type DunningPolicy struct {
+	// ReminderDays are the ages, in days and ascending, at which the payer is reminded.
+	ReminderDays []int
+	// Suspend suspends the claims of payments still owing at the cutoff of their event,
+	// CutoffDays before it starts.
+	Suspend    bool
+	CutoffDays int
+}
+
+// Step returns how many of the reminder ages the age reached, 0 if none.
+func (p DunningPolicy) Step(ageDays int) int {
+	step := 0
+	for i, days := range p.ReminderDays {
+		if ageDays >= days {
+			step = i + 1
+		}
+	}
+	return step
+}
+
+// Cutoff returns when the claims of the receivable are suspended if it is still
+// outstanding, zero if they are not, ie when suspending is off or its event has no start
+// date.
+func (p DunningPolicy) Cutoff(r Receivable) time.Time {
+	if !p.Suspend {
+		return time.Time{}
+	}
+	for _, c := range r.Payment.ClaimsPayed {
+		if c.EventSlot != nil && c.EventSlot.Event != nil && c.EventSlot.Event.StartDate != 0 {
+			start := time.Unix(int64(c.EventSlot.Event.StartDate), 0)
+			return start.AddDate(0, 0, -p.CutoffDays)
+		}
+	}
+	return time.Time{}
+}
+
+// Reminder is due to the payer of a receivable.
+type Reminder struct {
+	Receivable
+	// Step is how many reminder ages were reached, a reminder is due once per step.
+	Step int
+	// Cutoff is when the claims are suspended, zero if they are not; Suspended is true if
+	// it passed.
+	Cutoff    time.Time
+	Suspended bool
+}
+
+// DunningRun is what a run of DunReceivables did.
+type DunningRun struct {
+	Receivables int
+	Reminded    int
+	// Suspended is how many claims were suspended.
+	Suspended int
+}
+
+// DunReceivables chases the receivables at now: remind is called for those old enough
+// for a reminder, on every run while no further step is reached so it must only send one
+// per payment and step, and the claims of those overdue at their cutoff are suspended.
+// It carries on past failures, returning the first along with how many failed.
+func DunReceivables(ctx context.Context, store ReceivableStore, policy DunningPolicy, now time.Time,
+	remind func(context.Context, Reminder) error) (*DunningRun, error) {
+	report, err := AgeReceivables(ctx, store, now)
+	if err != nil {
+		return nil, err
+	}
+	run := &DunningRun{Receivables: len(report.Receivables)}
+	var first error
+	failed := 0
+	fail := func(err error) {
+		if first == nil {
+			first = err
+		}
+		failed++
+	}
+	for _, r := range report.Receivables {
+		reminder := Reminder{Receivable: r, Step: policy.Step(r.AgeDays), Cutoff: policy.Cutoff(r)}
+		if !reminder.Cutoff.IsZero() && !now.Before(reminder.Cutoff) {
+			reminder.Suspended = true
+			var suspend []uint64
+			for _, c := range r.Payment.ClaimsPayed {
+				if !c.Suspended {
+					suspend = append(suspend, c.ID)
+				}
+			}
+			if err := store.SuspendClaims(ctx, suspend, true); err != nil {
+				fail(fmt.Errorf("suspending the claims of payment %d: %w", r.Payment.ID, err))
+				continue
+			}
+			run.Suspended += len(suspend)
+		}
+		if reminder.Step == 0 {
+			continue
+		}
+		if err := remind(ctx, reminder); err != nil {
+			fail(fmt.Errorf("reminding of payment %d: %w", r.Payment.ID, err))
+			continue
+		}
+		run.Reminded++
+	}
+	if first != nil {
+		return run, fmt.Errorf("dunning %d of %d receivables failed, the first: %w", failed, len(report.Receivables), first)
+	}
+	return run, nil
+}
@@ -0,0 +1,74 @@
+package ticketing
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gopheracademy/manager/def"
+)
+
+func TestDunningPolicyStep(t *testing.T) {
+	policy := DunningPolicy{ReminderDays: []int{30, 60, 90}}
+	for age, want := range map[int]int{0: 0, 29: 0, 30: 1, 59: 1, 60: 2, 89: 2, 120: 3} {
+		if got := policy.Step(age); got != want {
+			t.Errorf("Step(%d) = %d, want %d", age, got, want)
+		}
+	}
+}
+
+func TestDunReceivables(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStorage()
+	start := time.Unix(time.Now().AddDate(0, 0, 120).Unix(), 0)
+	event := &def.Event{ID: 1, Name: "GopherCon 2021", StartDate: uint64(start.Unix())}
+	slot := createSlot(t, s, event, "conference", 50000)
+	sponsor := createAttendee(t, s, "sponsor@example.com")
+	claims, err := ClaimSlots(ctx, s, sponsor, *slot)
+	if err != nil {
+		t.Fatalf("ClaimSlots() = %v", err)
+	}
+	payment, err := PayClaims(ctx, s, sponsor, claims, []FinancialInstrument{
+		&PaymentMethodCreditNote{Detail: "invoice", Amount: 50000},
+	})
+	if err != nil {
+		t.Fatalf("PayClaims() = %v", err)
+	}
+	made := time.Unix(int64(payment.CreatedAt), 0)
+	policy := DunningPolicy{ReminderDays: []int{30, 60}, Suspend: true, CutoffDays: 7}
+
+	for _, tc := range []struct {
+		name      string
+		now       time.Time
+		step      int
+		suspended bool
+	}{
+		{"too young", made.AddDate(0, 0, 10), 0, false},
+		{"first reminder", made.AddDate(0, 0, 31), 1, false},
+		{"second reminder", made.AddDate(0, 0, 61), 2, false},
+		{"past the cutoff", start.AddDate(0, 0, -6), 2, true},
+	} {
+		var reminders []Reminder
+		run, err := DunReceivables(ctx, s, policy, tc.now, func(ctx context.Context, r Reminder) error {
+			reminders = append(reminders, r)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("%s: DunReceivables() = %v", tc.name, err)
+		}
+		if run.Receivables != 1 {
+			t.Errorf("%s: dunned %d receivables, want 1", tc.name, run.Receivables)
+		}
+		if tc.step == 0 {
+			if len(reminders) != 0 {
+				t.Errorf("%s: reminded %+v, want none", tc.name, reminders)
+			}
+		} else if len(reminders) != 1 || reminders[0].Step != tc.step || reminders[0].Suspended != tc.suspended ||
+			reminders[0].Outstanding != 50000 || !reminders[0].Cutoff.Equal(start.AddDate(0, 0, -7)) {
+			t.Errorf("%s: reminded %+v, want step %d, suspended %v", tc.name, reminders, tc.step, tc.suspended)
+		}
+		if got := readAttendee(t, s, sponsor.ID).Claims[0].Suspended; got != tc.suspended {
+			t.Errorf("%s: claim suspended %v, want %v", tc.name, got, tc.suspended)
+		}
+	}
+}
@@ -1,47 +1,29 @@
 package ticketing
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"log"
-	"net"
-	"time"
 
 	"github.com/ShiftLeftSecurity/gaum/db/chain"
 	"github.com/ShiftLeftSecurity/gaum/db/connection"
-	"github.com/ShiftLeftSecurity/gaum/db/logging"
-	"github.com/ShiftLeftSecurity/gaum/db/postgres"
+	"github.com/gopheracademy/manager/database"
 	"github.com/gopheracademy/manager/def"
-	"github.com/jackc/pgerrcode"
-	"github.com/jackc/pgx"
+	"github.com/gopheracademy/manager/outbox"
+	"github.com/gopheracademy/manager/tracing"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
 	uuid "github.com/satori/go.uuid"
 )
 
 // NewSQLStorage returns a new Storage connected to the postgres-like db indicated by the connectionString.
 func NewSQLStorage(connectionString string, logger *log.Logger) (*SQLStorage, error) {
-	logLevel := connection.Error
-
-	connector := postgres.Connector{
-		ConnectionString: connectionString,
-	}
-	maxConnLifetime := 1 * time.Minute
-
-	// you could open this without the config info but I put it here so other people looking at it
-	// know where to tweak if necessary.
-	db, err := connector.Open(&connection.Information{
-		Logger:          logging.NewGoLogger(logger),
-		LogLevel:        logLevel,
-		ConnMaxLifetime: &maxConnLifetime,
-		CustomDial: func(network, addr string) (net.Conn, error) {
-			d := &net.Dialer{
-				KeepAlive: time.Minute,
-			}
-			return d.Dial(network, addr)
-		},
-	})
+	db, err := database.Open(connectionString, logger)
 	if err != nil {
-		return nil, fmt.Errorf("initializing db connection: %w", err)
+		return nil, err
 	}
-	return &SQLStorage{conn: db}, nil
+	return &SQLStorage{conn: db, closer: db}, nil
 }
 
 // NewSQLStorageFromConnection returns a new SQLStorage using the passed connection
@@ -52,60 +34,100 @@ func NewSQLStorageFromConnection(conn connection.DB) *SQLStorage {
 // SQLStorage provides a Postgres Flavored storage backend to store ticketing information.
 type SQLStorage struct {
 	conn connection.DB
+	// closer is the connection when it was opened by NewSQLStorage.
+	closer io.Closer
+}
+
+// Close closes the connection opened by NewSQLStorage, those passed to
+// NewSQLStorageFromConnection belong to the caller and are left open.
+func (s *SQLStorage) Close() error {
+	if s.closer == nil {
+		return nil
+	}
+	return s.closer.Close()
 }
 
 var _ PurchaseStore = &SQLStorage{}
 
 // AtomicOperation begins a transaction and returns commit and rollback functions along with a new
 // SQLStorage wrapping the tx
-func (s *SQLStorage) AtomicOperation() (func() error, func() error, PurchaseStore, error) {
-	tx, err := s.conn.BeginTransaction()
+func (s *SQLStorage) AtomicOperation(ctx context.Context) (func() error, func() error, PurchaseStore, error) {
+	tx, err := database.WithContext(ctx, s.conn).BeginTransaction()
 	if err != nil {
 		return nil, nil, nil, fmt.Errorf("beginning transation: %w", err)
 	}
 	return tx.CommitTransaction, tx.RollbackTransaction, &SQLStorage{conn: tx}, nil
 }
 
+// AppendEvents implements PurchaseStore, the events are written in the transaction when
+// called on the store of an AtomicOperation.
+func (s *SQLStorage) AppendEvents(ctx context.Context, events ...outbox.Event) error {
+	span, conn := s.trace(ctx, "AppendEvents")
+	defer span.Finish()
+	return outbox.Append(conn, events...)
+}
+
+// trace starts the span of a storage operation and returns the connection bound to its
+// context, so the operation is cancelled along with it.
+func (s *SQLStorage) trace(ctx context.Context, operation string) (opentracing.Span, connection.DB) {
+	span, ctx := tracing.ChildSpan(ctx, "ticketing."+operation)
+	ext.DBType.Set(span, "sql")
+	return span, database.WithContext(ctx, s.conn)
+}
+
 const (
 	ticketIDUniqueConstraint = "ticket_id_is_unique"
 	tableSlotClaims          = "slot_claim"
 )
 
 // CreateAttendee creates a new attendee in the database and returns it.
-func (s *SQLStorage) CreateAttendee(a *Attendee) (*Attendee, error) {
+func (s *SQLStorage) CreateAttendee(ctx context.Context, a *Attendee) (*Attendee, error) {
+	span, conn := s.trace(ctx, "CreateAttendee")
+	defer span.Finish()
+	email := NormalizeEmail(a.Email)
+	claims := a.Claims
 	results := []Attendee{}
-	err := chain.New(s.conn).Insert(map[string]interface{}{
-		"email":        a.Email,
+	err := chain.New(conn).Insert(map[string]interface{}{
+		"email":        email,
 		"coc_accepted": a.CoCAccepted,
-	}).Table(tableAttendee).Returning("*").
+	}).Table(tableAttendee).
+		OnConflict(func(c *chain.OnConflict) {
+			c.OnColumn("lower(email)").DoNothing()
+		}).Returning("*").
 		Fetch(&results)
 	if err != nil {
 		return nil, fmt.Errorf("creating new attendee: %w", err)
 	}
 	if len(results) == 0 {
-		return nil, fmt.Errorf("attendee was not created")
+		// another attendee has the email, ie created by a checkout made at once, the
+		// claims are theirs.
+		existing, err := selectAttendee(conn, email, 0)
+		if err != nil {
+			return nil, fmt.Errorf("creating new attendee: %w", err)
+		}
+		if existing == nil {
+			return nil, fmt.Errorf("attendee was not created")
+		}
+		claims = append(existing.Claims, claims...)
+		results = append(results, *existing)
 	}
-	newClaims := make([]SlotClaim, len(a.Claims))
-	for i := range a.Claims {
-		c := a.Claims[i]
-		returnedClaims := []SlotClaim{}
-		err := chain.New(s.conn).Insert(map[string]interface{}{
+	newClaims := make([]SlotClaim, len(claims))
+	for i := range claims {
+		c := claims[i]
+		err := chain.New(conn).Insert(map[string]interface{}{
 			"attendee_id":   results[0].ID,
-			"slot_claim_id": c.Redeemed,
+			"slot_claim_id": c.ID,
 		}).Table(tableAttendeeSlotClaims).
 			OnConflict(func(c *chain.OnConflict) {
 				// This claim was someone else's, this might be the result of transfering.
 				c.OnConstraint(slotClaimIDUniqueConstraint).
 					DoUpdate().
 					Set("attendee_id", results[0].ID)
-			}).Returning("*").Fetch(&returnedClaims)
+			}).Exec()
 		if err != nil {
 			return nil, fmt.Errorf("inserting attendee claims: %w", err)
 		}
-		if len(returnedClaims) == 0 {
-			return nil, fmt.Errorf("could not create claim")
-		}
-		newClaims[i] = returnedClaims[0]
+		newClaims[i] = c
 	}
 	newAttendee := results[0]
 	newAttendee.Claims = newClaims
@@ -113,26 +135,30 @@ func (s *SQLStorage) CreateAttendee(a *Attendee) (*Attendee, error) {
 }
 
 // ReadAttendeeByEmail returns an attendee for that email if one exists.
-func (s *SQLStorage) ReadAttendeeByEmail(email string) (*Attendee, error) {
+func (s *SQLStorage) ReadAttendeeByEmail(ctx context.Context, email string) (*Attendee, error) {
 	if email == "" {
 		return nil, fmt.Errorf("email is empty")
 	}
-	return s.readAttendee(email, 0)
+	span, conn := s.trace(ctx, "ReadAttendeeByEmail")
+	defer span.Finish()
+	return selectAttendee(conn, NormalizeEmail(email), 0)
 }
 
 // ReadAttendeeByID returns an attendee for the given ID if one exists.
-func (s *SQLStorage) ReadAttendeeByID(id uint64) (*Attendee, error) {
+func (s *SQLStorage) ReadAttendeeByID(ctx context.Context, id uint64) (*Attendee, error) {
 	if id == 0 {
 		return nil, fmt.Errorf("id is not valid")
 	}
-	return s.readAttendee("", id)
+	span, conn := s.trace(ctx, "ReadAttendeeByID")
+	defer span.Finish()
+	return selectAttendee(conn, "", id)
 }
 
-func (s *SQLStorage) readAttendee(email string, id uint64) (*Attendee, error) {
+func selectAttendee(conn connection.DB, email string, id uint64) (*Attendee, error) {
 	results := []Attendee{}
-	q := chain.New(s.conn).Select("*").From(tableAttendee)
+	q := chain.New(conn).Select("*").From(tableAttendee)
 	if email != "" {
-		q.AndWhere("email = ?", email)
+		q.AndWhere("lower(email) = lower(?)", email)
 	}
 	if id != 0 {
 		q.AndWhere("id = ?", id)
@@ -144,17 +170,23 @@ func (s *SQLStorage) readAttendee(email string, id uint64) (*Attendee, error) {
 	if len(results) == 0 {
 		return nil, nil
 	}
-	claims := []SlotClaim{}
-	ats := chain.TablePrefix(attendeesToSlotTable)
+	rows := []wrapSlotClaim{}
+	ats := chain.TablePrefix(tableAttendeeSlotClaims)
 	tsc := chain.TablePrefix(tableSlotClaims)
-	err = chain.New(s.conn).Select("*").From(tableSlotClaims).
-		Join(attendeesToSlotTable,
+	err = chain.New(conn).Select(claimColumns()...).
+		From(tableSlotClaims).
+		Join(tableAttendeeSlotClaims,
 			chain.CompareExpressions(chain.Eq, tsc("id"), ats("slot_claim_id"))).
 		AndWhere(ats("attendee_id = ?"), results[0].ID).
-		Fetch(&claims)
+		OrderBy(chain.Asc(tsc("id"))).
+		Fetch(&rows)
 	if err != nil {
 		return nil, fmt.Errorf("reading claims for attendee: %w", err)
 	}
+	claims, err := hydrateClaims(conn, rows)
+	if err != nil {
+		return nil, err
+	}
 	newAttendee := results[0]
 	newAttendee.Claims = claims
 	return &newAttendee, nil
@@ -163,10 +195,11 @@ func (s *SQLStorage) readAttendee(email string, id uint64) (*Attendee, error) {
 const eventSlotTable = "event_slot"
 
 // CreateEventSlot saves a slot in the database.
-func (s *SQLStorage) CreateEventSlot(e *EventSlot) (*EventSlot, error) {
+func (s *SQLStorage) CreateEventSlot(ctx context.Context, e *EventSlot) (*EventSlot, error) {
+	span, conn := s.trace(ctx, "CreateEventSlot")
+	defer span.Finish()
 	results := []EventSlot{}
 	insertMap := map[string]interface{}{
-		"event_id":            e.Event.ID,
 		"name":                e.Name,
 		"description":         e.Description,
 		"cost":                e.Cost,
@@ -183,7 +216,7 @@ func (s *SQLStorage) CreateEventSlot(e *EventSlot) (*EventSlot, error) {
 	if e.Event != nil {
 		insertMap["event_id"] = e.Event.ID
 	}
-	err := chain.New(s.conn).Insert(insertMap).
+	err := chain.New(conn).Insert(insertMap).
 		Table(eventSlotTable).Returning("*").
 		Fetch(&results)
 	if err != nil {
@@ -195,52 +228,220 @@ func (s *SQLStorage) CreateEventSlot(e *EventSlot) (*EventSlot, error) {
 	return &results[0], nil
 }
 
+type wrapSlotClaim struct {
+	SlotClaim
+	EventSlotID uint64 `gaum:"field_name:event_slot_id"`
+}
+
 type wrapEventSlot struct {
 	EventSlot
 	DependsOnID uint64 `gaum:"field_name:depends_on_id"`
 	EventID     uint64 `gaum:"field_name:event_id"`
 }
 
+// claimColumns are the columns claims are read with, the ID of their slot included.
+func claimColumns() []string {
+	tsc := chain.TablePrefix(tableSlotClaims)
+	return []string{tsc("id"), tsc("ticket_id"), tsc("redeemed"), tsc("suspended"),
+		"COALESCE(" + tsc("event_slot_id") + ", 0) AS event_slot_id"}
+}
+
+// slotColumns are the columns slots are read with, nullable references are read as 0.
+var slotColumns = []string{"id", "name", "description", "cost", "capacity", "start_date",
+	"end_date", "purchaseable_from", "purchaseable_until", "available_to_public",
+	"COALESCE(event_id, 0) AS event_id", "COALESCE(depends_on_id, 0) AS depends_on_id"}
+
 // ReadEventSlotByID returns an event slot identified by the passed ID.
-func (s *SQLStorage) ReadEventSlotByID(id uint64) (*EventSlot, error) {
-	return s.readEventSlotByID(id, true)
+func (s *SQLStorage) ReadEventSlotByID(ctx context.Context, id uint64) (*EventSlot, error) {
+	span, conn := s.trace(ctx, "ReadEventSlotByID")
+	defer span.Finish()
+	slots, err := loadSlots(conn, []uint64{id})
+	if err != nil {
+		return nil, err
+	}
+	return slots[id], nil
 }
 
-func (s *SQLStorage) readEventSlotByID(id uint64, loadDeps bool) (*EventSlot, error) {
-	results := []wrapEventSlot{}
-	err := chain.New(s.conn).Select("*").
+// loadSlots reads the slots with the IDs along with their event and the slot they depend
+// on, if any, whose own dependency is not loaded; it takes up to three queries however
+// many slots are read.
+func loadSlots(conn connection.DB, ids []uint64) (map[uint64]*EventSlot, error) {
+	slots := map[uint64]*EventSlot{}
+	if len(ids) == 0 {
+		return slots, nil
+	}
+	rows := []wrapEventSlot{}
+	err := chain.New(conn).Select(slotColumns...).
 		From(eventSlotTable).
-		AndWhere("id = ?", id).Fetch(&results)
+		AndWhere("id IN (?)", ids).Fetch(&rows)
 	if err != nil {
 		return nil, fmt.Errorf("reading event slots by id: %w", err)
 	}
-	if len(results) == 0 {
-		return nil, nil
+	read := map[uint64]bool{}
+	for _, row := range rows {
+		read[row.ID] = true
 	}
-	slot := results[0].EventSlot
-	if results[0].DependsOnID != 0 && loadDeps {
-		slot.DependsOn, err = s.readEventSlotByID(results[0].DependsOnID, false)
+	dependencyIDs := []uint64{}
+	for _, row := range rows {
+		if row.DependsOnID != 0 && !read[row.DependsOnID] {
+			read[row.DependsOnID] = true
+			dependencyIDs = append(dependencyIDs, row.DependsOnID)
+		}
+	}
+	dependencies := []wrapEventSlot{}
+	if len(dependencyIDs) != 0 {
+		err = chain.New(conn).Select(slotColumns...).
+			From(eventSlotTable).
+			AndWhere("id IN (?)", dependencyIDs).Fetch(&dependencies)
 		if err != nil {
-			return nil, fmt.Errorf("loading dependency: %w", err)
+			return nil, fmt.Errorf("loading dependencies: %w", err)
 		}
 	}
 
+	everyRow := append(append([]wrapEventSlot{}, rows...), dependencies...)
+	eventIDs := []uint64{}
+	seenEvents := map[uint64]bool{}
+	for _, row := range everyRow {
+		if row.EventID != 0 && !seenEvents[row.EventID] {
+			seenEvents[row.EventID] = true
+			eventIDs = append(eventIDs, row.EventID)
+		}
+	}
 	events := []def.Event{}
-	err = chain.New(s.conn).Select("*").
-		From("event").
-		AndWhere("id = ?", results[0].EventID).Fetch(&events)
+	if len(eventIDs) != 0 {
+		err = chain.New(conn).Select("*").
+			From("event").
+			AndWhere("id IN (?)", eventIDs).Fetch(&events)
+		if err != nil {
+			return nil, fmt.Errorf("reading events by id: %w", err)
+		}
+	}
+	eventsByID := map[uint64]def.Event{}
+	for _, e := range events {
+		eventsByID[uint64(e.ID)] = e
+	}
+
+	// every slot read, to find dependencies among them, which are copied without theirs;
+	// slots of no event are read without one.
+	all := map[uint64]wrapEventSlot{}
+	for _, row := range everyRow {
+		if row.EventID != 0 {
+			event, ok := eventsByID[row.EventID]
+			if !ok {
+				return nil, fmt.Errorf("could not find event for slot %d", row.ID)
+			}
+			row.Event = &event
+		}
+		all[row.ID] = row
+	}
+	for _, row := range rows {
+		slot := all[row.ID].EventSlot
+		if row.DependsOnID != 0 {
+			dependency := all[row.DependsOnID].EventSlot
+			slot.DependsOn = &dependency
+		}
+		slots[row.ID] = &slot
+	}
+	return slots, nil
+}
+
+// hydrateClaims returns the claims read along with their slots.
+func hydrateClaims(conn connection.DB, rows []wrapSlotClaim) ([]SlotClaim, error) {
+	slotIDs := make([]uint64, 0, len(rows))
+	seen := map[uint64]bool{}
+	for _, row := range rows {
+		if row.EventSlotID != 0 && !seen[row.EventSlotID] {
+			seen[row.EventSlotID] = true
+			slotIDs = append(slotIDs, row.EventSlotID)
+		}
+	}
+	slots, err := loadSlots(conn, slotIDs)
+	if err != nil {
+		return nil, fmt.Errorf("loading the slots of claims: %w", err)
+	}
+	claims := make([]SlotClaim, len(rows))
+	for i, row := range rows {
+		claims[i] = row.SlotClaim
+		if slot, ok := slots[row.EventSlotID]; ok {
+			// claims get a slot each, so changing one does not change the others.
+			copied := *slot
+			claims[i].EventSlot = &copied
+		}
+	}
+	return claims, nil
+}
+
+// ListClaimsForSlot returns the claims of the slot, in the order they were made.
+func (s *SQLStorage) ListClaimsForSlot(ctx context.Context, slotID uint64) ([]SlotClaim, error) {
+	span, conn := s.trace(ctx, "ListClaimsForSlot")
+	defer span.Finish()
+	rows := []wrapSlotClaim{}
+	tsc := chain.TablePrefix(tableSlotClaims)
+	err := chain.New(conn).Select(claimColumns()...).
+		From(tableSlotClaims).
+		AndWhere(tsc("event_slot_id = ?"), slotID).
+		OrderBy(chain.Asc(tsc("id"))).
+		Fetch(&rows)
 	if err != nil {
-		return nil, fmt.Errorf("reading event by id: %w", err)
+		return nil, fmt.Errorf("listing claims for slot: %w", err)
+	}
+	return hydrateClaims(conn, rows)
+}
+
+// ListEventSlotsForEvent returns the slots of the event, ordered by ID.
+func (s *SQLStorage) ListEventSlotsForEvent(ctx context.Context, eventID uint32) ([]EventSlot, error) {
+	span, conn := s.trace(ctx, "ListEventSlotsForEvent")
+	defer span.Finish()
+	rows := []wrapEventSlot{}
+	err := chain.New(conn).Select("id").
+		From(eventSlotTable).
+		AndWhere("event_id = ?", eventID).
+		OrderBy(chain.Asc("id")).
+		Fetch(&rows)
+	if err != nil {
+		return nil, fmt.Errorf("listing event slots for event: %w", err)
+	}
+	ids := make([]uint64, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, row.ID)
+	}
+	loaded, err := loadSlots(conn, ids)
+	if err != nil {
+		return nil, err
+	}
+	slots := make([]EventSlot, 0, len(ids))
+	for _, id := range ids {
+		if slot, ok := loaded[id]; ok {
+			slots = append(slots, *slot)
+		}
 	}
-	if len(events) == 0 {
-		return nil, fmt.Errorf("could not find event for slot")
+	return slots, nil
+}
+
+// ListClaimsForEvent returns the claims of the slots of the event, in the order they were
+// made.
+func (s *SQLStorage) ListClaimsForEvent(ctx context.Context, eventID uint32) ([]SlotClaim, error) {
+	span, conn := s.trace(ctx, "ListClaimsForEvent")
+	defer span.Finish()
+	rows := []wrapSlotClaim{}
+	tsc := chain.TablePrefix(tableSlotClaims)
+	slot := chain.TablePrefix(eventSlotTable)
+	err := chain.New(conn).Select(claimColumns()...).
+		From(tableSlotClaims).
+		Join(eventSlotTable, chain.CompareExpressions(chain.Eq, slot("id"), tsc("event_slot_id"))).
+		AndWhere(slot("event_id = ?"), eventID).
+		OrderBy(chain.Asc(tsc("id"))).
+		Fetch(&rows)
+	if err != nil {
+		return nil, fmt.Errorf("listing claims for event: %w", err)
 	}
-	slot.Event = &events[0]
-	return &slot, nil
+	return hydrateClaims(conn, rows)
 }
 
 // UpdateEventSlot updates event slot fields from the passed instance
-func (s *SQLStorage) UpdateEventSlot(e *EventSlot) error {
+func (s *SQLStorage) UpdateEventSlot(ctx context.Context, e *EventSlot) error {
+	span, conn := s.trace(ctx, "UpdateEventSlot")
+	defer span.Finish()
 	updateMap := map[string]interface{}{
 		"event_id":            e.Event.ID,
 		"name":                e.Name,
@@ -259,7 +460,7 @@ func (s *SQLStorage) UpdateEventSlot(e *EventSlot) error {
 	if e.Event != nil {
 		updateMap["event_id"] = e.Event.ID
 	}
-	affected, err := chain.New(s.conn).UpdateMap(updateMap).
+	affected, err := chain.New(conn).UpdateMap(updateMap).
 		Table(eventSlotTable).AndWhere("id = ?", e.ID).
 		ExecResult()
 	if err != nil {
@@ -272,11 +473,13 @@ func (s *SQLStorage) UpdateEventSlot(e *EventSlot) error {
 }
 
 // CreateSlotClaim saves a slot claim and returns it with the populated ID
-func (s *SQLStorage) CreateSlotClaim(slotClaim *SlotClaim) (*SlotClaim, error) {
+func (s *SQLStorage) CreateSlotClaim(ctx context.Context, slotClaim *SlotClaim) (*SlotClaim, error) {
+	span, conn := s.trace(ctx, "CreateSlotClaim")
+	defer span.Finish()
 	var err error
 	// FIXME: Add a check for capacity not exceeded on event.
 	for i := 0; i < 3; i++ {
-		q := chain.New(s.conn)
+		q := chain.New(conn)
 		results := []SlotClaim{}
 		err = q.Insert(map[string]interface{}{
 			"ticket_id":     slotClaim.TicketID,
@@ -289,12 +492,10 @@ func (s *SQLStorage) CreateSlotClaim(slotClaim *SlotClaim) (*SlotClaim, error) {
 		if err != nil {
 			// there is no SQL for "on error change the inserting statement", only to change
 			// the existing one.
-			if err, ok := err.(pgx.PgError); ok {
-				if pgerrcode.IsIntegrityConstraintViolation(err.Code) {
-					// if this clashes again entropy might be broken, check if not 2020
-					slotClaim.TicketID = uuid.NewV4().String()
-					continue
-				}
+			if database.Violates(err, ticketIDUniqueConstraint) {
+				// if this clashes again entropy might be broken, check if not 2020
+				slotClaim.TicketID = uuid.NewV4().String()
+				continue
 			}
 			return nil, fmt.Errorf("saving slot claim: %w", err)
 		}
@@ -314,9 +515,13 @@ const (
 )
 
 // UpdateAttendee saves the passed attendee attributes on top of the existing one.
-func (s *SQLStorage) UpdateAttendee(attendee *Attendee) (*Attendee, error) {
-	rows, err := chain.New(s.conn).UpdateMap(map[string]interface{}{
-		"email":        attendee.Email,
+func (s *SQLStorage) UpdateAttendee(ctx context.Context, attendee *Attendee) (*Attendee, error) {
+	span, conn := s.trace(ctx, "UpdateAttendee")
+	defer span.Finish()
+	updated := *attendee
+	updated.Email = NormalizeEmail(attendee.Email)
+	rows, err := chain.New(conn).UpdateMap(map[string]interface{}{
+		"email":        updated.Email,
 		"coc_accepted": attendee.CoCAccepted,
 	}).Table(tableAttendee).
 		AndWhere("id = ?", attendee.ID).ExecResult()
@@ -329,9 +534,9 @@ func (s *SQLStorage) UpdateAttendee(attendee *Attendee) (*Attendee, error) {
 
 	for i := range attendee.Claims {
 		c := attendee.Claims[i]
-		err := chain.New(s.conn).Insert(map[string]interface{}{
+		err := chain.New(conn).Insert(map[string]interface{}{
 			"attendee_id":   attendee.ID,
-			"slot_claim_id": c.Redeemed,
+			"slot_claim_id": c.ID,
 		}).Table(tableAttendeeSlotClaims).
 			OnConflict(func(c *chain.OnConflict) {
 				// This claim was someone else's, this might be the result of transfering.
@@ -343,7 +548,7 @@ func (s *SQLStorage) UpdateAttendee(attendee *Attendee) (*Attendee, error) {
 			return nil, fmt.Errorf("updating attendee claims: %w", err)
 		}
 	}
-	return attendee, nil
+	return &updated, nil
 }
 
 const (
@@ -439,34 +644,45 @@ func insertCreditPayment(conn connection.DB, claimPaymentID uint64, payment *Pay
 }
 
 // CreateClaimPayment Creates c ClaimPayment record and asociates it with all the relevant payments.
-func (s *SQLStorage) CreateClaimPayment(c *ClaimPayment) (*ClaimPayment, error) {
+func (s *SQLStorage) CreateClaimPayment(ctx context.Context, c *ClaimPayment) (*ClaimPayment, error) {
+	span, conn := s.trace(ctx, "CreateClaimPayment")
+	defer span.Finish()
 	claimPayments := []ClaimPayment{}
-	err := chain.New(s.conn).Insert(map[string]interface{}{
-		"invoice": c.Invoice,
-	}).Table(tableClaimPayment).Returning("*").Fetch(&claimPayments)
+	insertMap := map[string]interface{}{
+		"invoice":    c.Invoice,
+		"created_at": createdAt(c),
+	}
+	if c.AttendeeID != 0 {
+		insertMap["attendee_id"] = c.AttendeeID
+	}
+	err := chain.New(conn).Insert(insertMap).
+		Table(tableClaimPayment).Returning("id, invoice, created_at").Fetch(&claimPayments)
 	if err != nil {
 		return nil, fmt.Errorf("inserting payment for claims: %w", err)
 	}
 	if len(claimPayments) == 0 {
 		return nil, fmt.Errorf("claim payment was not created")
 	}
+	if err := linkClaims(conn, claimPayments[0].ID, c.ClaimsPayed); err != nil {
+		return nil, err
+	}
 
 	processedPayments := make([]FinancialInstrument, len(c.Payment), len(c.Payment))
 
 	for i, cp := range c.Payment {
 		switch payment := cp.(type) {
 		case *PaymentMethodMoney:
-			processedPayments[i], err = insertMoneyPayment(s.conn, claimPayments[0].ID, payment)
+			processedPayments[i], err = insertMoneyPayment(conn, claimPayments[0].ID, payment)
 			if err != nil {
 				return nil, fmt.Errorf("inserting money payment: %w", err)
 			}
 		case *PaymentMethodConferenceDiscount:
-			processedPayments[i], err = insertDiscountPayment(s.conn, claimPayments[0].ID, payment)
+			processedPayments[i], err = insertDiscountPayment(conn, claimPayments[0].ID, payment)
 			if err != nil {
 				return nil, fmt.Errorf("inserting discount payment: %w", err)
 			}
 		case *PaymentMethodCreditNote:
-			processedPayments[i], err = insertCreditPayment(s.conn, claimPayments[0].ID, payment)
+			processedPayments[i], err = insertCreditPayment(conn, claimPayments[0].ID, payment)
 			if err != nil {
 				return nil, fmt.Errorf("inserting credit payment: %w", err)
 			}
@@ -475,14 +691,17 @@ func (s *SQLStorage) CreateClaimPayment(c *ClaimPayment) (*ClaimPayment, error)
 		}
 	}
 	newClaim := claimPayments[0]
+	newClaim.AttendeeID = c.AttendeeID
 	newClaim.ClaimsPayed = c.ClaimsPayed
 	newClaim.Payment = processedPayments
 	return &newClaim, nil
 }
 
 // UpdateClaimPayment saves the invoice and payments of this claim payment assuming it exists
-func (s *SQLStorage) UpdateClaimPayment(c *ClaimPayment) (*ClaimPayment, error) {
-	updated, err := chain.New(s.conn).UpdateMap(map[string]interface{}{
+func (s *SQLStorage) UpdateClaimPayment(ctx context.Context, c *ClaimPayment) (*ClaimPayment, error) {
+	span, conn := s.trace(ctx, "UpdateClaimPayment")
+	defer span.Finish()
+	updated, err := chain.New(conn).UpdateMap(map[string]interface{}{
 		"invoice": c.Invoice,
 	}).Table(tableClaimPayment).
 		AndWhere("id = ?", c.ID).
@@ -493,23 +712,26 @@ func (s *SQLStorage) UpdateClaimPayment(c *ClaimPayment) (*ClaimPayment, error)
 	if updated == 0 {
 		return nil, fmt.Errorf("claim payment was not found")
 	}
+	if err := linkClaims(conn, c.ID, c.ClaimsPayed); err != nil {
+		return nil, err
+	}
 
 	processedPayments := make([]FinancialInstrument, len(c.Payment), len(c.Payment))
 
 	for i, cp := range c.Payment {
 		switch payment := cp.(type) {
 		case *PaymentMethodMoney:
-			processedPayments[i], err = insertMoneyPayment(s.conn, c.ID, payment)
+			processedPayments[i], err = insertMoneyPayment(conn, c.ID, payment)
 			if err != nil {
 				return nil, fmt.Errorf("processing financial instrument money to payment: %w", err)
 			}
 		case *PaymentMethodConferenceDiscount:
-			processedPayments[i], err = insertDiscountPayment(s.conn, c.ID, payment)
+			processedPayments[i], err = insertDiscountPayment(conn, c.ID, payment)
 			if err != nil {
 				return nil, fmt.Errorf("processing financial instrument discount to payment: %w", err)
 			}
 		case *PaymentMethodCreditNote:
-			processedPayments[i], err = insertCreditPayment(s.conn, c.ID, payment)
+			processedPayments[i], err = insertCreditPayment(conn, c.ID, payment)
 			if err != nil {
 				return nil, fmt.Errorf("processing financial instrument credit to payment: %w", err)
 			}
@@ -519,17 +741,205 @@ func (s *SQLStorage) UpdateClaimPayment(c *ClaimPayment) (*ClaimPayment, error)
 	}
 	newClaim := ClaimPayment{
 		ID:          c.ID,
+		AttendeeID:  c.AttendeeID,
 		ClaimsPayed: c.ClaimsPayed,
 		Payment:     processedPayments,
 		Invoice:     c.Invoice,
+		CreatedAt:   c.CreatedAt,
 	}
 	return &newClaim, nil
 }
 
-const attendeesToSlotTable = "attendees_to_slot_claims"
+const (
+	tableClaimToPayment        = "slot_claim_to_claim_payment"
+	claimToPaymentPKConstraint = "slot_claim_to_claim_payment_pk"
+)
+
+// linkClaims records that the payment pays for the claims, those linked already are
+// skipped.
+func linkClaims(conn connection.DB, claimPaymentID uint64, claims []*SlotClaim) error {
+	for _, c := range claims {
+		if c == nil || c.ID == 0 {
+			return fmt.Errorf("some slot claims lack IDs, perhaps the have not been saved yet")
+		}
+		err := chain.New(conn).Insert(map[string]interface{}{
+			"slot_claim_id":    c.ID,
+			"claim_payment_id": claimPaymentID,
+		}).Table(tableClaimToPayment).
+			OnConflict(func(c *chain.OnConflict) {
+				c.OnConstraint(claimToPaymentPKConstraint).DoNothing()
+			}).Exec()
+		if err != nil {
+			return fmt.Errorf("relating claim %d to payment: %w", c.ID, err)
+		}
+	}
+	return nil
+}
+
+// claimPaymentColumns are the columns payments are read with, payments made before
+// their payer was recorded have attendee 0.
+var claimPaymentColumns = []string{"id", "invoice", "COALESCE(attendee_id, 0) AS attendee_id", "created_at"}
+
+// ReadClaimPaymentByID returns the payment with the claims it pays for and the
+// instruments it was paid with, nil if it does not exist.
+func (s *SQLStorage) ReadClaimPaymentByID(ctx context.Context, id uint64) (*ClaimPayment, error) {
+	span, conn := s.trace(ctx, "ReadClaimPaymentByID")
+	defer span.Finish()
+	payments := []ClaimPayment{}
+	err := chain.New(conn).Select(claimPaymentColumns...).
+		From(tableClaimPayment).
+		AndWhere("id = ?", id).Fetch(&payments)
+	if err != nil {
+		return nil, fmt.Errorf("reading claim payment by id: %w", err)
+	}
+	if len(payments) == 0 {
+		return nil, nil
+	}
+	if err := hydratePayments(conn, payments); err != nil {
+		return nil, err
+	}
+	return &payments[0], nil
+}
+
+// ListClaimPaymentsForAttendee returns the payments made by the attendee, in the order
+// they were made, with their claims and instruments.
+func (s *SQLStorage) ListClaimPaymentsForAttendee(ctx context.Context, attendeeID uint64) ([]ClaimPayment, error) {
+	span, conn := s.trace(ctx, "ListClaimPaymentsForAttendee")
+	defer span.Finish()
+	payments := []ClaimPayment{}
+	err := chain.New(conn).Select(claimPaymentColumns...).
+		From(tableClaimPayment).
+		AndWhere("attendee_id = ?", attendeeID).
+		OrderBy(chain.Asc("id")).Fetch(&payments)
+	if err != nil {
+		return nil, fmt.Errorf("listing claim payments for attendee: %w", err)
+	}
+	if err := hydratePayments(conn, payments); err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
+
+type paidClaim struct {
+	wrapSlotClaim
+	ClaimPaymentID uint64 `gaum:"field_name:claim_payment_id"`
+}
+
+type paidMoney struct {
+	PaymentMethodMoney
+	ClaimPaymentID uint64 `gaum:"field_name:claim_payment_id"`
+}
+
+type paidDiscount struct {
+	PaymentMethodConferenceDiscount
+	ClaimPaymentID uint64 `gaum:"field_name:claim_payment_id"`
+}
+
+type paidCredit struct {
+	PaymentMethodCreditNote
+	ClaimPaymentID uint64 `gaum:"field_name:claim_payment_id"`
+}
+
+// hydratePayments loads the claims and instruments of the payments, with a query per
+// kind of row however many payments there are; instruments are in the order of money,
+// discounts then credit.
+func hydratePayments(conn connection.DB, payments []ClaimPayment) error {
+	if len(payments) == 0 {
+		return nil
+	}
+	ids := make([]uint64, len(payments))
+	byID := map[uint64]*ClaimPayment{}
+	for i := range payments {
+		ids[i] = payments[i].ID
+		byID[payments[i].ID] = &payments[i]
+		payments[i].ClaimsPayed = []*SlotClaim{}
+		payments[i].Payment = []FinancialInstrument{}
+	}
+
+	link := chain.TablePrefix(tableClaimToPayment)
+	tsc := chain.TablePrefix(tableSlotClaims)
+	claimRows := []paidClaim{}
+	err := chain.New(conn).Select(append(claimColumns(), link("claim_payment_id"))...).
+		From(tableSlotClaims).
+		Join(tableClaimToPayment,
+			chain.CompareExpressions(chain.Eq, tsc("id"), link("slot_claim_id"))).
+		AndWhere(link("claim_payment_id IN (?)"), ids).
+		OrderBy(chain.Asc(tsc("id"))).
+		Fetch(&claimRows)
+	if err != nil {
+		return fmt.Errorf("reading the claims of payments: %w", err)
+	}
+	rows := make([]wrapSlotClaim, len(claimRows))
+	for i := range claimRows {
+		rows[i] = claimRows[i].wrapSlotClaim
+	}
+	claims, err := hydrateClaims(conn, rows)
+	if err != nil {
+		return err
+	}
+	for i := range claims {
+		p := byID[claimRows[i].ClaimPaymentID]
+		p.ClaimsPayed = append(p.ClaimsPayed, &claims[i])
+	}
+
+	money := []paidMoney{}
+	if err := selectPaid(conn, tableFinancialInstrumentMoney, tableMoneyToPayment,
+		"payment_method_money_id", []string{"amount", "ref"}, ids, &money); err != nil {
+		return err
+	}
+	for i := range money {
+		p := byID[money[i].ClaimPaymentID]
+		p.Payment = append(p.Payment, &money[i].PaymentMethodMoney)
+	}
+	discounts := []paidDiscount{}
+	if err := selectPaid(conn, tableFinancialInstrumentDiscount, tableDiscountToPayment,
+		"payment_method_event_discount_id", []string{"amount", "detail"}, ids, &discounts); err != nil {
+		return err
+	}
+	for i := range discounts {
+		p := byID[discounts[i].ClaimPaymentID]
+		p.Payment = append(p.Payment, &discounts[i].PaymentMethodConferenceDiscount)
+	}
+	credits := []paidCredit{}
+	if err := selectPaid(conn, tableFinancialInstrumentCredit, tableCreditToPayment,
+		"payment_method_credit_note_id", []string{"amount", "detail"}, ids, &credits); err != nil {
+		return err
+	}
+	for i := range credits {
+		p := byID[credits[i].ClaimPaymentID]
+		p.Payment = append(p.Payment, &credits[i].PaymentMethodCreditNote)
+	}
+	return nil
+}
+
+// selectPaid reads the instruments of a kind which paid the payments with the IDs into
+// receiver, along with the payment they paid.
+func selectPaid(conn connection.DB, table, linkTable, linkColumn string, columns []string,
+	paymentIDs []uint64, receiver interface{}) error {
+	instrument := chain.TablePrefix(table)
+	link := chain.TablePrefix(linkTable)
+	fields := []string{instrument("id")}
+	for _, c := range columns {
+		fields = append(fields, instrument(c))
+	}
+	fields = append(fields, link("claim_payment_id"))
+	err := chain.New(conn).Select(fields...).
+		From(table).
+		Join(linkTable,
+			chain.CompareExpressions(chain.Eq, instrument("id"), link(linkColumn))).
+		AndWhere(link("claim_payment_id IN (?)"), paymentIDs).
+		OrderBy(chain.Asc(instrument("id"))).
+		Fetch(receiver)
+	if err != nil {
+		return fmt.Errorf("reading the %s of payments: %w", table, err)
+	}
+	return nil
+}
 
 // ChangeSlotClaimOwner changes the passed claims owner from source to target
-func (s *SQLStorage) ChangeSlotClaimOwner(slots []SlotClaim, source *Attendee, target *Attendee) (*Attendee, *Attendee, error) {
+func (s *SQLStorage) ChangeSlotClaimOwner(ctx context.Context, slots []SlotClaim, source *Attendee, target *Attendee) (*Attendee, *Attendee, error) {
+	span, conn := s.trace(ctx, "ChangeSlotClaimOwner")
+	defer span.Finish()
 	if source == nil || target == nil {
 		return nil, nil, fmt.Errorf("either source or target is undefined")
 	}
@@ -551,9 +961,10 @@ func (s *SQLStorage) ChangeSlotClaimOwner(slots []SlotClaim, source *Attendee, t
 		claimIDs = append(claimIDs, slot.ID)
 		claimIDsIndex[slot.ID] = true
 	}
-	affected, err := chain.New(s.conn).UpdateMap(map[string]interface{}{
+	affected, err := chain.New(conn).UpdateMap(map[string]interface{}{
 		"attendee_id": target.ID,
-	}).AndWhere("attendee_id = ?", source.ID).
+	}).Table(tableAttendeeSlotClaims).
+		AndWhere("attendee_id = ?", source.ID).
 		AndWhere("slot_claim_id IN (?)", claimIDs).ExecResult()
 	if err != nil {
 		return nil, nil, fmt.Errorf("chaingin slot claims ownershio: %w", err)
@@ -573,3 +984,297 @@ func (s *SQLStorage) ChangeSlotClaimOwner(slots []SlotClaim, source *Attendee, t
 	source.Claims = newClaims
 	return source, target, nil
 }
+
+// ListClaimPaymentsForEvent returns a page of the payments for claims of slots of the
+// event, with their claims and instruments.
+func (s *SQLStorage) ListClaimPaymentsForEvent(ctx context.Context, eventID uint32, page Page) ([]ClaimPayment, error) {
+	span, conn := s.trace(ctx, "ListClaimPaymentsForEvent")
+	defer span.Finish()
+	link := chain.TablePrefix(tableClaimToPayment)
+	tsc := chain.TablePrefix(tableSlotClaims)
+	slot := chain.TablePrefix(eventSlotTable)
+	payments := []ClaimPayment{}
+	q := chain.New(conn).Select(claimPaymentColumns...).
+		From(tableClaimPayment).
+		AndWhere(fmt.Sprintf("id IN (SELECT %s FROM %s JOIN %s ON %s = %s JOIN %s ON %s = %s WHERE %s = ?)",
+			link("claim_payment_id"), tableClaimToPayment,
+			tableSlotClaims, tsc("id"), link("slot_claim_id"),
+			eventSlotTable, slot("id"), tsc("event_slot_id"), slot("event_id")), eventID).
+		AndWhere("id > ?", page.AfterID).
+		OrderBy(chain.Asc("id"))
+	if page.Limit > 0 {
+		q.Limit(int64(page.Limit))
+	}
+	if err := q.Fetch(&payments); err != nil {
+		return nil, fmt.Errorf("listing claim payments for event: %w", err)
+	}
+	if err := hydratePayments(conn, payments); err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
+
+type ownedClaim struct {
+	wrapSlotClaim
+	AttendeeID uint64 `gaum:"field_name:attendee_id"`
+}
+
+// ListAttendeesForEvent returns a page of the attendees holding claims of slots of the
+// event, with those claims in the order they were made; it takes a query for the
+// attendees and one for their claims, besides those loading slots.
+func (s *SQLStorage) ListAttendeesForEvent(ctx context.Context, eventID uint32, page Page) ([]Attendee, error) {
+	span, conn := s.trace(ctx, "ListAttendeesForEvent")
+	defer span.Finish()
+	ats := chain.TablePrefix(tableAttendeeSlotClaims)
+	tsc := chain.TablePrefix(tableSlotClaims)
+	slot := chain.TablePrefix(eventSlotTable)
+	attendees := []Attendee{}
+	q := chain.New(conn).Select("*").
+		From(tableAttendee).
+		AndWhere(fmt.Sprintf("id IN (SELECT %s FROM %s JOIN %s ON %s = %s JOIN %s ON %s = %s WHERE %s = ?)",
+			ats("attendee_id"), tableAttendeeSlotClaims,
+			tableSlotClaims, tsc("id"), ats("slot_claim_id"),
+			eventSlotTable, slot("id"), tsc("event_slot_id"), slot("event_id")), eventID).
+		AndWhere("id > ?", page.AfterID).
+		OrderBy(chain.Asc("id"))
+	if page.Limit > 0 {
+		q.Limit(int64(page.Limit))
+	}
+	if err := q.Fetch(&attendees); err != nil {
+		return nil, fmt.Errorf("listing attendees for event: %w", err)
+	}
+	if len(attendees) == 0 {
+		return attendees, nil
+	}
+	ids := make([]uint64, len(attendees))
+	byID := map[uint64]*Attendee{}
+	for i := range attendees {
+		ids[i] = attendees[i].ID
+		byID[attendees[i].ID] = &attendees[i]
+	}
+
+	owned := []ownedClaim{}
+	err := chain.New(conn).Select(append(claimColumns(), ats("attendee_id"))...).
+		From(tableSlotClaims).
+		Join(tableAttendeeSlotClaims,
+			chain.CompareExpressions(chain.Eq, tsc("id"), ats("slot_claim_id"))).
+		Join(eventSlotTable,
+			chain.CompareExpressions(chain.Eq, slot("id"), tsc("event_slot_id"))).
+		AndWhere(ats("attendee_id IN (?)"), ids).
+		AndWhere(slot("event_id = ?"), eventID).
+		OrderBy(chain.Asc(tsc("id"))).
+		Fetch(&owned)
+	if err != nil {
+		return nil, fmt.Errorf("reading the claims of attendees: %w", err)
+	}
+	rows := make([]wrapSlotClaim, len(owned))
+	for i := range owned {
+		rows[i] = owned[i].wrapSlotClaim
+	}
+	claims, err := hydrateClaims(conn, rows)
+	if err != nil {
+		return nil, err
+	}
+	for i := range claims {
+		a := byID[owned[i].AttendeeID]
+		a.Claims = append(a.Claims, claims[i])
+	}
+	return attendees, nil
+}
+
+const (
+	tableJournalEntry = "journal_entry"
+	tableJournalLine  = "journal_line"
+)
+
+// journalEntryColumns are the columns entries are read with, those of no payment have
+// payment 0.
+var journalEntryColumns = []string{"id", "kind", "event_id",
+	"COALESCE(claim_payment_id, 0) AS claim_payment_id", "memo", "posted_at"}
+
+type journalLine struct {
+	JournalLine
+	EntryID uint64 `gaum:"field_name:journal_entry_id"`
+}
+
+// PostJournalEntry implements PurchaseStore
+func (s *SQLStorage) PostJournalEntry(ctx context.Context, entry *JournalEntry) (*JournalEntry, error) {
+	if err := entry.Validate(); err != nil {
+		return nil, err
+	}
+	span, conn := s.trace(ctx, "PostJournalEntry")
+	defer span.Finish()
+	insertMap := map[string]interface{}{
+		"kind":      entry.Kind,
+		"event_id":  entry.EventID,
+		"memo":      entry.Memo,
+		"posted_at": entry.PostedAt,
+	}
+	if entry.PaymentID != 0 {
+		insertMap["claim_payment_id"] = entry.PaymentID
+	}
+	posted := []JournalEntry{}
+	err := chain.New(conn).Insert(insertMap).
+		Table(tableJournalEntry).Returning("id").Fetch(&posted)
+	if err != nil {
+		return nil, fmt.Errorf("inserting journal entry: %w", err)
+	}
+	if len(posted) == 0 {
+		return nil, fmt.Errorf("journal entry was not posted")
+	}
+	for _, l := range entry.Lines {
+		err := chain.New(conn).Insert(map[string]interface{}{
+			"journal_entry_id": posted[0].ID,
+			"account":          l.Account,
+			"debit":            l.Debit,
+			"credit":           l.Credit,
+		}).Table(tableJournalLine).Exec()
+		if err != nil {
+			return nil, fmt.Errorf("inserting journal line: %w", err)
+		}
+	}
+	newEntry := *entry
+	newEntry.ID = posted[0].ID
+	newEntry.Lines = append([]JournalLine{}, entry.Lines...)
+	return &newEntry, nil
+}
+
+// ListJournalEntriesForPayment implements PurchaseStore
+func (s *SQLStorage) ListJournalEntriesForPayment(ctx context.Context, paymentID uint64) ([]JournalEntry, error) {
+	span, conn := s.trace(ctx, "ListJournalEntriesForPayment")
+	defer span.Finish()
+	entries := []JournalEntry{}
+	err := chain.New(conn).Select(journalEntryColumns...).
+		From(tableJournalEntry).
+		AndWhere("claim_payment_id = ?", paymentID).
+		OrderBy(chain.Asc("id")).Fetch(&entries)
+	if err != nil {
+		return nil, fmt.Errorf("listing journal entries for payment: %w", err)
+	}
+	if len(entries) == 0 {
+		return entries, nil
+	}
+	ids := make([]uint64, len(entries))
+	byID := map[uint64]*JournalEntry{}
+	for i := range entries {
+		ids[i] = entries[i].ID
+		byID[entries[i].ID] = &entries[i]
+		entries[i].Lines = []JournalLine{}
+	}
+	lines := []journalLine{}
+	err = chain.New(conn).Select("journal_entry_id", "account", "debit", "credit").
+		From(tableJournalLine).
+		AndWhere("journal_entry_id IN (?)", ids).
+		OrderBy(chain.Asc("id")).Fetch(&lines)
+	if err != nil {
+		return nil, fmt.Errorf("reading the lines of journal entries: %w", err)
+	}
+	for _, l := range lines {
+		e := byID[l.EntryID]
+		e.Lines = append(e.Lines, l.JournalLine)
+	}
+	return entries, nil
+}
+
+// LockClaimPayment implements PurchaseStore, the row of the payment is locked until the
+// transaction ends.
+func (s *SQLStorage) LockClaimPayment(ctx context.Context, id uint64) (bool, error) {
+	span, conn := s.trace(ctx, "LockClaimPayment")
+	defer span.Finish()
+	ids := []uint64{}
+	err := chain.New(conn).Select("id").From(tableClaimPayment).
+		AndWhere("id = ?", id).ForUpdate().FetchIntoPrimitive(&ids)
+	if err != nil {
+		return false, fmt.Errorf("locking claim payment: %w", err)
+	}
+	return len(ids) != 0, nil
+}
+
+// SumJournal implements PurchaseStore
+func (s *SQLStorage) SumJournal(ctx context.Context, eventID uint32) ([]AccountBalance, error) {
+	span, conn := s.trace(ctx, "SumJournal")
+	defer span.Finish()
+	line := chain.TablePrefix(tableJournalLine)
+	entry := chain.TablePrefix(tableJournalEntry)
+	query := chain.New(conn).Select(line("account"),
+		"CAST(SUM("+line("debit")+") AS BIGINT) AS debit",
+		"CAST(SUM("+line("credit")+") AS BIGINT) AS credit").
+		From(tableJournalLine)
+	if eventID != 0 {
+		query = query.Join(tableJournalEntry,
+			chain.CompareExpressions(chain.Eq, entry("id"), line("journal_entry_id"))).
+			AndWhere(entry("event_id = ?"), eventID)
+	}
+	sums := []AccountBalance{}
+	err := query.GroupBy(line("account")).
+		OrderBy(chain.Asc(line("account"))).Fetch(&sums)
+	if err != nil {
+		return nil, fmt.Errorf("summing the journal: %w", err)
+	}
+	return sums, nil
+}
+
+// ListClaimPaymentsOnCredit returns the payments made with credit notes, covered or not,
+// in the order they were made, with their claims and instruments.
+func (s *SQLStorage) ListClaimPaymentsOnCredit(ctx context.Context) ([]ClaimPayment, error) {
+	span, conn := s.trace(ctx, "ListClaimPaymentsOnCredit")
+	defer span.Finish()
+	payments := []ClaimPayment{}
+	err := chain.New(conn).Select(claimPaymentColumns...).
+		From(tableClaimPayment).
+		AndWhere("id IN (SELECT claim_payment_id FROM " + tableCreditToPayment + ")").
+		OrderBy(chain.Asc("id")).Fetch(&payments)
+	if err != nil {
+		return nil, fmt.Errorf("listing claim payments on credit: %w", err)
+	}
+	if err := hydratePayments(conn, payments); err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
+
+// SuspendClaims implements PurchaseStore
+func (s *SQLStorage) SuspendClaims(ctx context.Context, claimIDs []uint64, suspended bool) error {
+	if len(claimIDs) == 0 {
+		return nil
+	}
+	span, conn := s.trace(ctx, "SuspendClaims")
+	defer span.Finish()
+	err := chain.New(conn).UpdateMap(map[string]interface{}{
+		"suspended": suspended,
+	}).Table(tableSlotClaims).
+		AndWhere("id IN (?)", claimIDs).Exec()
+	if err != nil {
+		return fmt.Errorf("suspending claims: %w", err)
+	}
+	return nil
+}
+
+// ListRecordedMoney implements PurchaseStore
+func (s *SQLStorage) ListRecordedMoney(ctx context.Context, refs []string, from, to uint64) ([]RecordedMoney, error) {
+	span, conn := s.trace(ctx, "ListRecordedMoney")
+	defer span.Finish()
+	money := chain.TablePrefix(tableFinancialInstrumentMoney)
+	link := chain.TablePrefix(tableMoneyToPayment)
+	payment := chain.TablePrefix(tableClaimPayment)
+	recorded := []RecordedMoney{}
+	q := chain.New(conn).Select(money("id"), money("amount"), money("ref"),
+		link("claim_payment_id"), payment("created_at")+" AS paid_at").
+		From(tableFinancialInstrumentMoney).
+		Join(tableMoneyToPayment,
+			chain.CompareExpressions(chain.Eq, money("id"), link("payment_method_money_id"))).
+		Join(tableClaimPayment,
+			chain.CompareExpressions(chain.Eq, payment("id"), link("claim_payment_id")))
+	switch {
+	case to == 0:
+	case len(refs) == 0:
+		q.AndWhere(payment("created_at BETWEEN ? AND ?"), from, to)
+	default:
+		q.AndWhere("("+money("ref IN (?)")+" OR "+payment("created_at BETWEEN ? AND ?")+")", refs, from, to)
+	}
+	err := q.OrderBy(chain.Asc(money("id"))).Fetch(&recorded)
+	if err != nil {
+		return nil, fmt.Errorf("listing recorded money: %w", err)
+	}
+	return recorded, nil
+}
@@ -60,3 +60,12 @@ func (c *HTTPClient) GetJSON(ctx context.Context, endpoint string, url string, o
 	decoder := json.NewDecoder(res.Body)
 	return decoder.Decode(out)
 }
+
+// Do executes req in a span named after endpoint, the caller must close the body of the
+// response.
+func (c *HTTPClient) Do(ctx context.Context, endpoint string, req *http.Request) (*http.Response, error) {
+	req = req.WithContext(ctx)
+	req, ht := nethttp.TraceRequest(c.Tracer, req, nethttp.OperationName("HTTP "+req.Method+": "+endpoint))
+	defer ht.Finish()
+	return c.Client.Do(req)
+}
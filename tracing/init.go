@@ -17,6 +17,7 @@ package tracing
 
 import (
 	"fmt"
+	"io"
 	"time"
 
 	"github.com/opentracing/opentracing-go"
@@ -28,8 +29,9 @@ import (
 	"github.com/gopheracademy/manager/log"
 )
 
-// Init creates a new instance of Jaeger tracer.
-func Init(serviceName string, metricsFactory metrics.Factory, logger log.Factory) opentracing.Tracer {
+// Init creates a new instance of Jaeger tracer, closing it flushes the spans not yet
+// reported.
+func Init(serviceName string, metricsFactory metrics.Factory, logger log.Factory) (opentracing.Tracer, io.Closer) {
 	cfg, err := config.FromEnv()
 	if err != nil {
 		logger.Bg().Fatal("cannot parse Jaeger env vars", zap.Error(err))
@@ -43,7 +45,7 @@ func Init(serviceName string, metricsFactory metrics.Factory, logger log.Factory
 	jaegerLogger := jaegerLoggerAdapter{logger.Bg()}
 
 	metricsFactory = metricsFactory.Namespace(metrics.NSOptions{Name: serviceName, Tags: nil})
-	tracer, _, err := cfg.NewTracer(
+	tracer, closer, err := cfg.NewTracer(
 		config.Logger(jaegerLogger),
 		config.Metrics(metricsFactory),
 		config.Observer(rpcmetrics.NewObserver(metricsFactory, rpcmetrics.DefaultNameNormalizer)),
@@ -51,7 +53,7 @@ func Init(serviceName string, metricsFactory metrics.Factory, logger log.Factory
 	if err != nil {
 		logger.Bg().Fatal("cannot initialize Jaeger Tracer", zap.Error(err))
 	}
-	return tracer
+	return tracer, closer
 }
 
 type jaegerLoggerAdapter struct {
@@ -0,0 +1,56 @@
+package tracing
+
+import (
+	"time"
+
+	"github.com/uber/jaeger-lib/metrics"
+
+	"github.com/gopheracademy/manager/errs"
+)
+
+// MethodMetrics are the rate, errors and duration of calls to a service method.
+type MethodMetrics struct {
+	requests metrics.Counter
+	latency  metrics.Timer
+	errors   map[errs.Kind]metrics.Counter
+}
+
+// NewMethodMetrics returns the metrics of a service method, tagged with its service and
+// method names; errors are also tagged with their kind.
+func NewMethodMetrics(metricsFactory metrics.Factory, service, method string) *MethodMetrics {
+	tags := func(extra ...string) map[string]string {
+		t := map[string]string{"service": service, "method": method}
+		for i := 0; i+1 < len(extra); i += 2 {
+			t[extra[i]] = extra[i+1]
+		}
+		return t
+	}
+	m := &MethodMetrics{
+		requests: metricsFactory.Counter(metrics.Options{
+			Name: "requests", Tags: tags(), Help: "Calls to the method",
+		}),
+		latency: metricsFactory.Timer(metrics.TimerOptions{
+			Name: "latency", Tags: tags(), Help: "Time taken to answer calls to the method",
+		}),
+		errors: make(map[errs.Kind]metrics.Counter, len(errs.Kinds)),
+	}
+	for _, kind := range errs.Kinds {
+		m.errors[kind] = metricsFactory.Counter(metrics.Options{
+			Name: "errors", Tags: tags("kind", string(kind)), Help: "Calls to the method that failed, by kind of error",
+		})
+	}
+	return m
+}
+
+// Observe records a call that started at start and failed with err, if not nil.
+func (m *MethodMetrics) Observe(start time.Time, err error) {
+	m.requests.Inc(1)
+	m.latency.Record(time.Since(start))
+	if err != nil {
+		counter, ok := m.errors[errs.KindOf(err)]
+		if !ok {
+			counter = m.errors[errs.Internal]
+		}
+		counter.Inc(1)
+	}
+}
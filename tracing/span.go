@@ -0,0 +1,19 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/opentracing/opentracing-go"
+)
+
+// ChildSpan starts a span for the operation as a child of the span in ctx, with its
+// tracer, and returns it along with ctx carrying it. Without a span in ctx the span is a
+// noop, so code traced when called from a request can be called from anywhere.
+func ChildSpan(ctx context.Context, operation string) (opentracing.Span, context.Context) {
+	parent := opentracing.SpanFromContext(ctx)
+	if parent == nil {
+		return opentracing.NoopTracer{}.StartSpan(operation), ctx
+	}
+	span := parent.Tracer().StartSpan(operation, opentracing.ChildOf(parent.Context()))
+	return span, opentracing.ContextWithSpan(ctx, span)
+}
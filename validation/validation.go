@@ -0,0 +1,57 @@
+// Package validation holds what the generated Validate methods need to check the rules
+// annotated on def fields.
+package validation
+
+import (
+	"fmt"
+	"regexp"
+	"unicode/utf8"
+
+	"github.com/gopheracademy/manager/errs"
+)
+
+// Patterns are the named formats a field can be required to match with the pattern
+// annotation, keep them in sync with the ones in templates/client.js.plush.
+var Patterns = map[string]*regexp.Regexp{
+	// slug is what we use in URLs (ie gophercon-2021).
+	"slug": regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`),
+	// email is a loose check, only delivering to the address proves it is valid.
+	"email": regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`),
+	// url is an absolute http or https URL.
+	"url": regexp.MustCompile(`^https?://[^\s/?#]+[^\s]*$`),
+	// https url is an absolute https URL, ie where webhooks post.
+	"https url": regexp.MustCompile(`^https://[^\s/?#]+[^\s]*$`),
+}
+
+// Matches returns true if s matches the named pattern, unknown patterns match nothing.
+func Matches(pattern, s string) bool {
+	re, ok := Patterns[pattern]
+	return ok && re.MatchString(s)
+}
+
+// Length returns the length of s in characters, as the JS client counts them.
+func Length(s string) int {
+	return utf8.RuneCountInString(s)
+}
+
+// Path is the location of a field in a request using the JSON names (ie
+// conference.events[0].name), the empty Path is the request itself.
+type Path string
+
+// Field returns the path of the named field of the object at p.
+func (p Path) Field(name string) Path {
+	if p == "" {
+		return Path(name)
+	}
+	return p + "." + Path(name)
+}
+
+// Index returns the path of the ith element of the list at p.
+func (p Path) Index(i int) Path {
+	return Path(fmt.Sprintf("%s[%d]", p, i))
+}
+
+// Violation returns a violation of the field at p.
+func (p Path) Violation(format string, args ...interface{}) errs.FieldViolation {
+	return errs.FieldViolation{Field: string(p), Description: fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/gopheracademy/manager/errs"
+	"github.com/gopheracademy/manager/log"
+	"github.com/gopheracademy/manager/webhooks"
+)
+
+// defaultDeliveriesLimit is how many deliveries ListDeliveries returns unless told.
+const defaultDeliveriesLimit = 50
+
+type webhookService struct {
+	logger log.Factory
+	// store is nil without a database, every call then fails with errs.Unavailable.
+	store *webhooks.SQLStore
+}
+
+func newWebhookService(logger log.Factory, store *webhooks.SQLStore) *webhookService {
+	return &webhookService{logger: logger, store: store}
+}
+
+func (s webhookService) available() error {
+	if s.store == nil {
+		return errs.New(errs.Unavailable, "webhooks need a database")
+	}
+	return nil
+}
+
+func (s webhookService) Create(ctx context.Context, r CreateWebhookRequest) (*CreateWebhookResponse, error) {
+	loggerFor(s.logger, ctx).Info("webhookService.Create", zap.Uint32("conference", r.ConferenceID))
+	if err := s.available(); err != nil {
+		return nil, err
+	}
+	if err := webhooks.CheckURL(r.Webhook.URL); err != nil {
+		return nil, errs.Invalid(errs.FieldViolation{Field: "webhook.url", Description: err.Error()})
+	}
+	secret := r.Webhook.Secret
+	if secret == "" {
+		var err error
+		if secret, err = webhooks.NewSecret(); err != nil {
+			return nil, err
+		}
+	}
+	created, err := s.store.CreateWebhook(ctx, webhooks.Webhook{
+		ConferenceID: r.ConferenceID,
+		URL:          r.Webhook.URL,
+		EventTypes:   r.Webhook.EventTypes,
+		Secret:       secret,
+		CreatedAt:    time.Now().Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	// the secret is only ever shown now.
+	return &CreateWebhookResponse{Webhook: webhookFrom(*created, true)}, nil
+}
+
+func (s webhookService) List(ctx context.Context, r ListWebhooksRequest) (*ListWebhooksResponse, error) {
+	loggerFor(s.logger, ctx).Info("webhookService.List", zap.Uint32("conference", r.ConferenceID))
+	if err := s.available(); err != nil {
+		return nil, err
+	}
+	found, err := s.store.ListWebhooks(ctx, r.ConferenceID)
+	if err != nil {
+		return nil, err
+	}
+	resp := &ListWebhooksResponse{Webhooks: make([]Webhook, 0, len(found))}
+	for _, w := range found {
+		resp.Webhooks = append(resp.Webhooks, webhookFrom(w, false))
+	}
+	return resp, nil
+}
+
+func (s webhookService) Delete(ctx context.Context, r DeleteWebhookRequest) (*DeleteWebhookResponse, error) {
+	loggerFor(s.logger, ctx).Info("webhookService.Delete", zap.Uint32("conference", r.ConferenceID), zap.Uint64("webhook", r.ID))
+	if err := s.available(); err != nil {
+		return nil, err
+	}
+	if err := s.store.DeleteWebhook(ctx, r.ConferenceID, r.ID); err != nil {
+		return nil, err
+	}
+	return &DeleteWebhookResponse{}, nil
+}
+
+func (s webhookService) ListDeliveries(ctx context.Context, r ListWebhookDeliveriesRequest) (*ListWebhookDeliveriesResponse, error) {
+	loggerFor(s.logger, ctx).Info("webhookService.ListDeliveries", zap.Uint32("conference", r.ConferenceID), zap.Uint64("webhook", r.WebhookID))
+	if err := s.available(); err != nil {
+		return nil, err
+	}
+	if _, err := s.webhookOf(ctx, r.ConferenceID, r.WebhookID); err != nil {
+		return nil, err
+	}
+	limit := r.Limit
+	if limit == 0 {
+		limit = defaultDeliveriesLimit
+	}
+	found, err := s.store.ListDeliveries(ctx, r.WebhookID, r.Status, limit)
+	if err != nil {
+		return nil, err
+	}
+	resp := &ListWebhookDeliveriesResponse{Deliveries: make([]WebhookDelivery, 0, len(found))}
+	for _, d := range found {
+		resp.Deliveries = append(resp.Deliveries, deliveryFrom(d))
+	}
+	return resp, nil
+}
+
+func (s webhookService) GetDelivery(ctx context.Context, r GetWebhookDeliveryRequest) (*GetWebhookDeliveryResponse, error) {
+	loggerFor(s.logger, ctx).Info("webhookService.GetDelivery", zap.Uint32("conference", r.ConferenceID), zap.Uint64("delivery", r.ID))
+	if err := s.available(); err != nil {
+		return nil, err
+	}
+	d, err := s.deliveryOf(ctx, r.ConferenceID, r.ID)
+	if err != nil {
+		return nil, err
+	}
+	attempts, err := s.store.ListAttempts(ctx, d.ID)
+	if err != nil {
+		return nil, err
+	}
+	resp := &GetWebhookDeliveryResponse{
+		Delivery: deliveryFrom(*d),
+		Attempts: make([]WebhookAttempt, 0, len(attempts)),
+	}
+	for _, a := range attempts {
+		resp.Attempts = append(resp.Attempts, WebhookAttempt{
+			ID:              a.ID,
+			DeliveryID:      a.DeliveryID,
+			AttemptedAt:     uint64(a.AttemptedAt),
+			RequestHeaders:  a.RequestHeaders,
+			RequestBody:     a.RequestBody,
+			ResponseStatus:  a.ResponseStatus,
+			ResponseHeaders: a.ResponseHeaders,
+			ResponseBody:    a.ResponseBody,
+			Error:           a.Error,
+			DurationMS:      a.DurationMS,
+		})
+	}
+	return resp, nil
+}
+
+func (s webhookService) Redeliver(ctx context.Context, r RedeliverWebhookRequest) (*RedeliverWebhookResponse, error) {
+	loggerFor(s.logger, ctx).Info("webhookService.Redeliver", zap.Uint32("conference", r.ConferenceID), zap.Uint64("delivery", r.ID))
+	if err := s.available(); err != nil {
+		return nil, err
+	}
+	if _, err := s.deliveryOf(ctx, r.ConferenceID, r.ID); err != nil {
+		return nil, err
+	}
+	d, err := s.store.Redeliver(ctx, r.ID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return &RedeliverWebhookResponse{Delivery: deliveryFrom(*d)}, nil
+}
+
+// webhookOf returns the webhook if it belongs to the conference, organisers are scoped to
+// their conference so the webhooks of others are reported as not found.
+func (s webhookService) webhookOf(ctx context.Context, conferenceID uint32, id uint64) (*webhooks.Webhook, error) {
+	w, err := s.store.ReadWebhook(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if w == nil || w.ConferenceID != conferenceID {
+		return nil, errs.New(errs.NotFound, "webhook %d not found", id)
+	}
+	return w, nil
+}
+
+// deliveryOf returns the delivery if its webhook belongs to the conference.
+func (s webhookService) deliveryOf(ctx context.Context, conferenceID uint32, id uint64) (*webhooks.Delivery, error) {
+	d, err := s.store.ReadDelivery(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if d == nil {
+		return nil, errs.New(errs.NotFound, "webhook delivery %d not found", id)
+	}
+	if _, err := s.webhookOf(ctx, conferenceID, d.WebhookID); err != nil {
+		if errs.Is(err, errs.NotFound) {
+			return nil, errs.New(errs.NotFound, "webhook delivery %d not found", id)
+		}
+		return nil, err
+	}
+	return d, nil
+}
+
+func webhookFrom(w webhooks.Webhook, withSecret bool) Webhook {
+	converted := Webhook{
+		ID:           w.ID,
+		ConferenceID: w.ConferenceID,
+		URL:          w.URL,
+		EventTypes:   w.EventTypes,
+		CreatedAt:    uint64(w.CreatedAt),
+	}
+	if withSecret {
+		converted.Secret = w.Secret
+	}
+	return converted
+}
+
+func deliveryFrom(d webhooks.Delivery) WebhookDelivery {
+	return WebhookDelivery{
+		ID:            d.ID,
+		WebhookID:     d.WebhookID,
+		EventID:       d.EventID,
+		EventType:     d.EventType,
+		Payload:       d.Payload,
+		Status:        d.Status,
+		Attempts:      d.Attempts,
+		NextAttemptAt: uint64(d.NextAttemptAt),
+		CreatedAt:     uint64(d.CreatedAt),
+	}
+}
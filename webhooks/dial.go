@@ -0,0 +1,106 @@
+package webhooks
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// blockedNetworks are the destinations webhooks may not be posted to, so organisers can
+// not make the server reach what only it can, ie the db, the metrics or a cloud metadata
+// endpoint, and read the answer in the log of the delivery.
+var blockedNetworks = func() []*net.IPNet {
+	var networks []*net.IPNet
+	for _, cidr := range []string{
+		"0.0.0.0/8",      // this network
+		"10.0.0.0/8",     // private
+		"100.64.0.0/10",  // carrier grade NAT
+		"127.0.0.0/8",    // loopback
+		"169.254.0.0/16", // link local, ie cloud metadata
+		"172.16.0.0/12",  // private
+		"192.0.0.0/24",   // IETF protocol assignments
+		"192.168.0.0/16", // private
+		"198.18.0.0/15",  // benchmarking
+		"224.0.0.0/4",    // multicast
+		"240.0.0.0/4",    // reserved, broadcast included
+		"::/128",         // unspecified
+		"::1/128",        // loopback
+		"64:ff9b::/96",   // IPv4 translation
+		"fc00::/7",       // unique local
+		"fe80::/10",      // link local
+		"ff00::/8",       // multicast
+	} {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}()
+
+// CheckDestination returns an error if webhooks may not be posted to ip.
+func CheckDestination(ip net.IP) error {
+	if v4 := ip.To4(); v4 != nil {
+		ip = v4
+	}
+	for _, network := range blockedNetworks {
+		if network.Contains(ip) {
+			return fmt.Errorf("%s is not a public address", ip)
+		}
+	}
+	return nil
+}
+
+// CheckURL returns an error if webhooks may not be posted to rawURL, only https URLs
+// whose host, when it is an address, is public are.
+func CheckURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("%s is not an https URL", rawURL)
+	}
+	if ip := net.ParseIP(u.Hostname()); ip != nil {
+		return CheckDestination(ip)
+	}
+	return nil
+}
+
+// newHTTPClient returns a client which only dials public addresses, checked once the
+// host is resolved so a name can not point it elsewhere, and does not follow redirects.
+func newHTTPClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   timeout,
+		KeepAlive: 30 * time.Second,
+		Control: func(network, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil {
+				return fmt.Errorf("dialing %s: not an address", address)
+			}
+			return CheckDestination(ip)
+		},
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			// no proxy, it would be dialed instead of the webhook.
+			DialContext:         dialer.DialContext,
+			ForceAttemptHTTP2:   true,
+			MaxIdleConns:        100,
+			IdleConnTimeout:     90 * time.Second,
+			TLSHandshakeTimeout: 10 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
@@ -0,0 +1,56 @@
+package webhooks
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckURL(t *testing.T) {
+	for url, ok := range map[string]bool{
+		"https://hooks.example.com/showrunner": true,
+		"https://93.184.216.34/hook":           true,
+		"http://hooks.example.com/showrunner":  false,
+		"ftp://hooks.example.com":              false,
+		"https://127.0.0.1:8000/oto/":          false,
+		"https://10.1.2.3/":                    false,
+		"https://169.254.169.254/latest/":      false,
+		"https://[::1]/":                       false,
+		"https://[fd00::1]/":                   false,
+		"https://[::ffff:192.168.0.1]/":        false,
+		"https:///no-host":                     false,
+		"https://0.0.0.0/":                     false,
+	} {
+		if err := CheckURL(url); (err == nil) != ok {
+			t.Errorf("CheckURL(%s) = %v, want ok %v", url, err, ok)
+		}
+	}
+}
+
+func TestHTTPClient(t *testing.T) {
+	posted := false
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posted = true
+	}))
+	defer target.Close()
+	redirect := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusTemporaryRedirect))
+	defer redirect.Close()
+
+	// the test servers listen on loopback, which the client refuses to dial.
+	client := newHTTPClient(time.Second)
+	if _, err := client.Post(target.URL, "application/json", strings.NewReader("{}")); err == nil || posted {
+		t.Fatalf("posted to %s, want the loopback address refused", target.URL)
+	}
+
+	client.Transport = http.DefaultTransport
+	res, err := client.Post(redirect.URL, "application/json", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusTemporaryRedirect || posted {
+		t.Errorf("answered %d, want the redirect not followed", res.StatusCode)
+	}
+}
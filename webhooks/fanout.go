@@ -0,0 +1,68 @@
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gopheracademy/manager/outbox"
+)
+
+// ConferenceOf returns the conference a conference event belongs to, 0 if there is none.
+type ConferenceOf func(ctx context.Context, eventID uint32) (uint32, error)
+
+// Fanout is an outbox.Subscriber, subscribed to outbox.AllEvents, which enqueues a
+// delivery of each event to the webhooks of its conference that want it. Events are
+// matched to a conference through the eventID of their payload, those without one are
+// not delivered to webhooks.
+type Fanout struct {
+	store        *SQLStore
+	conferenceOf ConferenceOf
+}
+
+var _ outbox.Subscriber = &Fanout{}
+
+// NewFanout returns a Fanout enqueuing deliveries in store.
+func NewFanout(store *SQLStore, conferenceOf ConferenceOf) *Fanout {
+	return &Fanout{store: store, conferenceOf: conferenceOf}
+}
+
+// Handle implements outbox.Subscriber
+func (f *Fanout) Handle(ctx context.Context, e outbox.Event) error {
+	var scope struct {
+		EventID uint32 `json:"eventID"`
+	}
+	if err := e.Decode(&scope); err != nil {
+		return err
+	}
+	if scope.EventID == 0 {
+		return nil
+	}
+	conferenceID, err := f.conferenceOf(ctx, scope.EventID)
+	if err != nil {
+		return fmt.Errorf("finding the conference of event %d: %w", scope.EventID, err)
+	}
+	if conferenceID == 0 {
+		return nil
+	}
+	webhooks, err := f.store.ListWebhooks(ctx, conferenceID)
+	if err != nil {
+		return err
+	}
+	now := time.Now().Unix()
+	var deliveries []Delivery
+	for _, w := range webhooks {
+		if !w.Wants(e.Type) {
+			continue
+		}
+		deliveries = append(deliveries, Delivery{
+			WebhookID:     w.ID,
+			EventID:       e.ID,
+			EventType:     e.Type,
+			Payload:       e.Payload,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+		})
+	}
+	return f.store.Enqueue(ctx, deliveries...)
+}
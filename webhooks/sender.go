@@ -0,0 +1,178 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/opentracing-contrib/go-stdlib/nethttp"
+	"github.com/opentracing/opentracing-go"
+	"go.uber.org/zap"
+
+	"github.com/gopheracademy/manager/log"
+	"github.com/gopheracademy/manager/poller"
+	"github.com/gopheracademy/manager/pool"
+	"github.com/gopheracademy/manager/tracing"
+)
+
+// maxLoggedBody is how much of a response body is kept in the log of an attempt.
+const maxLoggedBody = 64 << 10
+
+// Options configure a Sender, the zero value of each is replaced by its default.
+type Options struct {
+	poller.Options
+	// HTTPClient posts the deliveries, defaults to a traced client with a 10s timeout
+	// which only dials public addresses and does not follow redirects.
+	HTTPClient *tracing.HTTPClient
+}
+
+// Sender posts the pending deliveries using the workers of a pool.
+type Sender struct {
+	*poller.Poller
+	store  *SQLStore
+	opts   Options
+	logger log.Factory
+}
+
+// NewSender returns a Sender posting the deliveries of store on the workers of p, call
+// Start to begin sending and Stop, before stopping the pool, to end.
+func NewSender(store *SQLStore, p *pool.Pool, opts Options, tracer opentracing.Tracer, logger log.Factory) *Sender {
+	if opts.HTTPClient == nil {
+		client := newHTTPClient(10 * time.Second)
+		client.Transport = &nethttp.Transport{RoundTripper: client.Transport}
+		opts.HTTPClient = &tracing.HTTPClient{Tracer: tracer, Client: client}
+	}
+	s := &Sender{
+		store:  store,
+		opts:   opts,
+		logger: logger,
+	}
+	s.Poller = poller.New("webhook deliveries", s.lease, p, opts.Options, logger)
+	return s
+}
+
+// lease leases the deliveries due and returns their attempts.
+func (s *Sender) lease(ctx context.Context, now, until time.Time, limit int) ([]poller.Job, error) {
+	deliveries, err := s.store.Lease(ctx, now, until, limit)
+	if err != nil {
+		return nil, err
+	}
+	jobs := make([]poller.Job, len(deliveries))
+	for i := range deliveries {
+		d := deliveries[i]
+		jobs[i] = func(ctx context.Context) { s.send(ctx, d) }
+	}
+	return jobs, nil
+}
+
+// send attempts the delivery and records the outcome.
+func (s *Sender) send(ctx context.Context, d Delivery) {
+	logger := s.logger.Bg().With(zap.Uint64("delivery", d.ID), zap.Uint64("webhook", d.WebhookID))
+
+	w, err := s.store.ReadWebhook(ctx, d.WebhookID)
+	if err != nil {
+		logger.Error("reading webhook", zap.Error(err))
+		return
+	}
+	if w == nil {
+		// deleted since, its deliveries went with it.
+		return
+	}
+
+	attempt := s.post(ctx, *w, d)
+	if attempt.Succeeded() {
+		d.Status = StatusDelivered
+	} else {
+		d.Attempts++
+		next, retry := s.Retry(d.Attempts)
+		d.NextAttemptAt = next.Unix()
+		if !retry {
+			d.Status = StatusDead
+		}
+		logger.Info("delivering to webhook", zap.Int("attempts", d.Attempts),
+			zap.String("status", d.Status), zap.Int("responseStatus", attempt.ResponseStatus),
+			zap.String("error", attempt.Error))
+	}
+	if err := s.store.Record(ctx, d, attempt); err != nil {
+		logger.Error("recording webhook attempt", zap.Error(err))
+	}
+}
+
+// body is what is posted to webhooks, the event as the outbox holds it.
+type body struct {
+	ID        uint64          `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt int64           `json:"createdAt"`
+	Payload   json.RawMessage `json:"payload"`
+}
+
+// post attempts the delivery to w and returns the log of the attempt.
+func (s *Sender) post(ctx context.Context, w Webhook, d Delivery) (attempt Attempt) {
+	start := time.Now()
+	attempt = Attempt{DeliveryID: d.ID, AttemptedAt: start.Unix()}
+	defer func() {
+		attempt.DurationMS = int(time.Since(start) / time.Millisecond)
+	}()
+
+	raw, err := json.Marshal(body{ID: d.EventID, Type: d.EventType, CreatedAt: d.CreatedAt, Payload: json.RawMessage(d.Payload)})
+	if err != nil {
+		attempt.Error = fmt.Sprintf("encoding the event: %v", err)
+		return attempt
+	}
+	attempt.RequestBody = string(raw)
+	if err := CheckURL(w.URL); err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	req, err := http.NewRequest(http.MethodPost, w.URL, bytes.NewReader(raw))
+	if err != nil {
+		attempt.Error = fmt.Sprintf("creating the request: %v", err)
+		return attempt
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "showrunner-webhooks")
+	req.Header.Set("X-Showrunner-Event", d.EventType)
+	req.Header.Set("X-Showrunner-Delivery", fmt.Sprint(d.ID))
+	req.Header.Set(SignatureHeader, Sign(w.Secret, start, raw))
+	attempt.RequestHeaders = formatHeaders(req.Header)
+
+	res, err := s.opts.HTTPClient.Do(ctx, "webhook", req)
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	defer res.Body.Close()
+	attempt.ResponseStatus = res.StatusCode
+	attempt.ResponseHeaders = formatHeaders(res.Header)
+	responseBody, err := ioutil.ReadAll(io.LimitReader(res.Body, maxLoggedBody))
+	if err != nil {
+		attempt.Error = fmt.Sprintf("reading the response: %v", err)
+	}
+	attempt.ResponseBody = string(responseBody)
+	// drain what was not logged so the connection is reused.
+	io.Copy(ioutil.Discard, io.LimitReader(res.Body, maxLoggedBody))
+	return attempt
+}
+
+// formatHeaders returns one "Name: value" line per header value, sorted by name.
+func formatHeaders(h http.Header) string {
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var lines []string
+	for _, name := range names {
+		for _, value := range h[name] {
+			lines = append(lines, name+": "+value)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
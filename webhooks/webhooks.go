@@ -0,0 +1,357 @@
+// Package webhooks posts the domain events of a conference to the URLs its organisers
+// subscribed, ie to Slack, a CRM or a badge vendor.
+//
+// A Fanout subscribed to the outbox records a Delivery per webhook wanting an event, and
+// a Sender posts them, signed with the secret of the webhook, retrying with exponential
+// backoff until they are delivered or dead. Every attempt is logged so organisers can
+// tell what went wrong and redeliver.
+package webhooks
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ShiftLeftSecurity/gaum/db/chain"
+	"github.com/ShiftLeftSecurity/gaum/db/connection"
+
+	"github.com/gopheracademy/manager/database"
+	"github.com/gopheracademy/manager/errs"
+)
+
+const (
+	tableWebhook  = "webhook"
+	tableDelivery = "webhook_delivery"
+	tableAttempt  = "webhook_attempt"
+
+	deliveryEventConstraint = "webhook_delivery_event"
+)
+
+// The status of a Delivery.
+const (
+	StatusPending   = "pending"
+	StatusDelivered = "delivered"
+	// StatusDead deliveries failed every attempt, they are only attempted again when
+	// redelivered.
+	StatusDead = "dead"
+)
+
+// SignatureHeader carries the signature of a delivery, see Sign.
+const SignatureHeader = "X-Showrunner-Signature"
+
+// Webhook is an URL the events of a conference are posted to.
+type Webhook struct {
+	ID           uint64
+	ConferenceID uint32
+	URL          string
+	// EventTypes are the types of the events posted, all of them if empty.
+	EventTypes []string
+	Secret     string
+	// CreatedAt is a Unix timestamp.
+	CreatedAt int64
+}
+
+// Wants returns whether events of the type are posted to the webhook.
+func (w Webhook) Wants(eventType string) bool {
+	if len(w.EventTypes) == 0 {
+		return true
+	}
+	for _, t := range w.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// webhookRow is a row of the webhook table, gaum does not map slices so the event types
+// are stored comma separated.
+type webhookRow struct {
+	ID           uint64 `gaum:"field_name:id"`
+	ConferenceID uint32 `gaum:"field_name:conference_id"`
+	URL          string `gaum:"field_name:url"`
+	EventTypes   string `gaum:"field_name:event_types"`
+	Secret       string `gaum:"field_name:secret"`
+	CreatedAt    int64  `gaum:"field_name:created_at"`
+}
+
+func (r webhookRow) webhook() Webhook {
+	w := Webhook{
+		ID:           r.ID,
+		ConferenceID: r.ConferenceID,
+		URL:          r.URL,
+		Secret:       r.Secret,
+		CreatedAt:    r.CreatedAt,
+	}
+	if r.EventTypes != "" {
+		w.EventTypes = strings.Split(r.EventTypes, ",")
+	}
+	return w
+}
+
+// Delivery is an event to post to a webhook.
+type Delivery struct {
+	ID        uint64 `gaum:"field_name:id"`
+	WebhookID uint64 `gaum:"field_name:webhook_id"`
+	// EventID is the ID of the outbox event, it is the same in every delivery of it.
+	EventID   uint64 `gaum:"field_name:event_id"`
+	EventType string `gaum:"field_name:event_type"`
+	// Payload is the JSON posted.
+	Payload string `gaum:"field_name:payload"`
+	Status  string `gaum:"field_name:status"`
+	// Attempts counts the failed attempts.
+	Attempts int `gaum:"field_name:attempts"`
+	// NextAttemptAt and CreatedAt are Unix timestamps.
+	NextAttemptAt int64 `gaum:"field_name:next_attempt_at"`
+	CreatedAt     int64 `gaum:"field_name:created_at"`
+}
+
+// Attempt is the log of one attempt of a delivery.
+type Attempt struct {
+	ID          uint64 `gaum:"field_name:id"`
+	DeliveryID  uint64 `gaum:"field_name:delivery_id"`
+	AttemptedAt int64  `gaum:"field_name:attempted_at"`
+	// RequestHeaders and ResponseHeaders hold one header per line.
+	RequestHeaders string `gaum:"field_name:request_headers"`
+	RequestBody    string `gaum:"field_name:request_body"`
+	// ResponseStatus is 0 if no response was received, see Error.
+	ResponseStatus  int    `gaum:"field_name:response_status"`
+	ResponseHeaders string `gaum:"field_name:response_headers"`
+	ResponseBody    string `gaum:"field_name:response_body"`
+	Error           string `gaum:"field_name:error"`
+	DurationMS      int    `gaum:"field_name:duration_ms"`
+}
+
+// Succeeded returns whether the webhook acknowledged the delivery with a 2xx.
+func (a Attempt) Succeeded() bool {
+	return a.Error == "" && a.ResponseStatus >= 200 && a.ResponseStatus <= 299
+}
+
+// Sign returns the signature of a body posted at t, receivers recompute the v1 value as
+// the hex HMAC-SHA256 of "<t>.<body>" keyed with the secret of the webhook and compare
+// it, rejecting old timestamps to prevent replays.
+func Sign(secret string, t time.Time, body []byte) string {
+	timestamp := fmt.Sprint(t.Unix())
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "t=" + timestamp + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// NewSecret returns a random secret to sign deliveries with.
+func NewSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating a webhook secret: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// SQLStore keeps webhooks and their deliveries in a postgres-like db.
+type SQLStore struct {
+	conn connection.DB
+}
+
+// NewSQLStore returns a SQLStore using the passed connection.
+func NewSQLStore(conn connection.DB) *SQLStore {
+	return &SQLStore{conn: conn}
+}
+
+// CreateWebhook saves the webhook and returns it with its ID.
+func (s *SQLStore) CreateWebhook(ctx context.Context, w Webhook) (*Webhook, error) {
+	results := []webhookRow{}
+	err := chain.New(s.conn).Insert(map[string]interface{}{
+		"conference_id": w.ConferenceID,
+		"url":           w.URL,
+		"event_types":   strings.Join(w.EventTypes, ","),
+		"secret":        w.Secret,
+		"created_at":    w.CreatedAt,
+	}).Table(tableWebhook).Returning("*").Fetch(&results)
+	if err != nil {
+		return nil, database.Wrap(err, "creating webhook")
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("webhook was not created")
+	}
+	created := results[0].webhook()
+	return &created, nil
+}
+
+// ListWebhooks returns the webhooks of the conference.
+func (s *SQLStore) ListWebhooks(ctx context.Context, conferenceID uint32) ([]Webhook, error) {
+	results := []webhookRow{}
+	err := chain.New(s.conn).Select("*").From(tableWebhook).
+		AndWhere("conference_id = ?", conferenceID).
+		OrderBy(chain.Asc("id")).Fetch(&results)
+	if err != nil {
+		return nil, fmt.Errorf("listing webhooks: %w", err)
+	}
+	webhooks := make([]Webhook, 0, len(results))
+	for _, r := range results {
+		webhooks = append(webhooks, r.webhook())
+	}
+	return webhooks, nil
+}
+
+// ReadWebhook returns the webhook with the passed ID, or nil if there is none.
+func (s *SQLStore) ReadWebhook(ctx context.Context, id uint64) (*Webhook, error) {
+	results := []webhookRow{}
+	err := chain.New(s.conn).Select("*").From(tableWebhook).
+		AndWhere("id = ?", id).Fetch(&results)
+	if err != nil {
+		return nil, fmt.Errorf("reading webhook: %w", err)
+	}
+	if len(results) == 0 {
+		return nil, nil
+	}
+	w := results[0].webhook()
+	return &w, nil
+}
+
+// DeleteWebhook removes the webhook of the conference with its deliveries, it fails with
+// errs.NotFound if it does not exist.
+func (s *SQLStore) DeleteWebhook(ctx context.Context, conferenceID uint32, id uint64) error {
+	deleted, err := chain.New(s.conn).Delete().Table(tableWebhook).
+		AndWhere("id = ?", id).AndWhere("conference_id = ?", conferenceID).ExecResult()
+	if err != nil {
+		return fmt.Errorf("deleting webhook: %w", err)
+	}
+	if deleted == 0 {
+		return errs.New(errs.NotFound, "webhook %d not found", id)
+	}
+	return nil
+}
+
+// Enqueue records the deliveries due now, a delivery of an event already recorded for
+// the webhook is ignored so that an event delivered again by the outbox is only posted
+// once.
+func (s *SQLStore) Enqueue(ctx context.Context, deliveries ...Delivery) error {
+	for _, d := range deliveries {
+		err := chain.New(s.conn).Insert(map[string]interface{}{
+			"webhook_id":      d.WebhookID,
+			"event_id":        d.EventID,
+			"event_type":      d.EventType,
+			"payload":         d.Payload,
+			"status":          StatusPending,
+			"next_attempt_at": d.NextAttemptAt,
+			"created_at":      d.CreatedAt,
+		}).Table(tableDelivery).
+			OnConflict(func(c *chain.OnConflict) {
+				c.OnConstraint(deliveryEventConstraint).DoNothing()
+			}).Exec()
+		if err != nil {
+			return fmt.Errorf("enqueuing delivery of event %d to webhook %d: %w", d.EventID, d.WebhookID, err)
+		}
+	}
+	return nil
+}
+
+// Lease returns up to limit pending deliveries due at now and makes them due again at
+// until, so that several senders do not post them at once.
+func (s *SQLStore) Lease(ctx context.Context, now, until time.Time, limit int) ([]Delivery, error) {
+	results := []Delivery{}
+	err := chain.New(s.conn).UpdateMap(map[string]interface{}{"next_attempt_at": until.Unix()}).
+		Table(tableDelivery).
+		AndWhere("id IN (SELECT id FROM "+tableDelivery+
+			" WHERE status = ? AND next_attempt_at <= ? ORDER BY id LIMIT ? FOR UPDATE SKIP LOCKED)",
+			StatusPending, now.Unix(), limit).
+		Returning("*").Fetch(&results)
+	if err != nil {
+		return nil, fmt.Errorf("leasing webhook deliveries: %w", err)
+	}
+	return results, nil
+}
+
+// Record logs the attempt and updates the delivery with the outcome: its status, attempts
+// and when it is attempted next.
+func (s *SQLStore) Record(ctx context.Context, d Delivery, a Attempt) error {
+	err := chain.New(s.conn).Insert(map[string]interface{}{
+		"delivery_id":      d.ID,
+		"attempted_at":     a.AttemptedAt,
+		"request_headers":  a.RequestHeaders,
+		"request_body":     a.RequestBody,
+		"response_status":  a.ResponseStatus,
+		"response_headers": a.ResponseHeaders,
+		"response_body":    a.ResponseBody,
+		"error":            a.Error,
+		"duration_ms":      a.DurationMS,
+	}).Table(tableAttempt).Exec()
+	if err != nil {
+		return fmt.Errorf("logging attempt of webhook delivery %d: %w", d.ID, err)
+	}
+	err = chain.New(s.conn).UpdateMap(map[string]interface{}{
+		"status":          d.Status,
+		"attempts":        d.Attempts,
+		"next_attempt_at": d.NextAttemptAt,
+	}).Table(tableDelivery).AndWhere("id = ?", d.ID).Exec()
+	if err != nil {
+		return fmt.Errorf("updating webhook delivery %d: %w", d.ID, err)
+	}
+	return nil
+}
+
+// ListDeliveries returns up to limit deliveries of the webhook, the latest first, only
+// those with the status unless it is empty.
+func (s *SQLStore) ListDeliveries(ctx context.Context, webhookID uint64, status string, limit int) ([]Delivery, error) {
+	results := []Delivery{}
+	query := chain.New(s.conn).Select("*").From(tableDelivery).
+		AndWhere("webhook_id = ?", webhookID)
+	if status != "" {
+		query = query.AndWhere("status = ?", status)
+	}
+	err := query.OrderBy(chain.Desc("id")).Limit(int64(limit)).Fetch(&results)
+	if err != nil {
+		return nil, fmt.Errorf("listing webhook deliveries: %w", err)
+	}
+	return results, nil
+}
+
+// ReadDelivery returns the delivery with the passed ID, or nil if there is none.
+func (s *SQLStore) ReadDelivery(ctx context.Context, id uint64) (*Delivery, error) {
+	results := []Delivery{}
+	err := chain.New(s.conn).Select("*").From(tableDelivery).
+		AndWhere("id = ?", id).Fetch(&results)
+	if err != nil {
+		return nil, fmt.Errorf("reading webhook delivery: %w", err)
+	}
+	if len(results) == 0 {
+		return nil, nil
+	}
+	return &results[0], nil
+}
+
+// ListAttempts returns the attempts of the delivery in the order they were made.
+func (s *SQLStore) ListAttempts(ctx context.Context, deliveryID uint64) ([]Attempt, error) {
+	results := []Attempt{}
+	err := chain.New(s.conn).Select("*").From(tableAttempt).
+		AndWhere("delivery_id = ?", deliveryID).
+		OrderBy(chain.Asc("id")).Fetch(&results)
+	if err != nil {
+		return nil, fmt.Errorf("listing webhook attempts: %w", err)
+	}
+	return results, nil
+}
+
+// Redeliver makes the delivery pending and due at now with its attempts reset, whatever
+// its status, and returns it.
+func (s *SQLStore) Redeliver(ctx context.Context, id uint64, now time.Time) (*Delivery, error) {
+	results := []Delivery{}
+	err := chain.New(s.conn).UpdateMap(map[string]interface{}{
+		"status":          StatusPending,
+		"attempts":        0,
+		"next_attempt_at": now.Unix(),
+	}).Table(tableDelivery).AndWhere("id = ?", id).Returning("*").Fetch(&results)
+	if err != nil {
+		return nil, fmt.Errorf("redelivering webhook delivery %d: %w", id, err)
+	}
+	if len(results) == 0 {
+		return nil, errs.New(errs.NotFound, "webhook delivery %d not found", id)
+	}
+	return &results[0], nil
+}
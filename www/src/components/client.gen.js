@@ -2,8 +2,574 @@
 
 'use strict';
 
+// APIError is thrown for every failed call, code is one of the error kinds the server
+// answers with and fields, for invalid arguments, lists what is wrong with each field.
+export class APIError extends Error {
+	constructor(message, code, status, fields) {
+		super(message)
+		this.name = this.constructor.name
+		this.code = code || 'internal'
+		this.status = status
+		this.fields = fields || []
+	}
+}
+export class NotFoundError extends APIError {}
+export class InvalidArgumentError extends APIError {}
+export class ConflictError extends APIError {}
+export class UnauthenticatedError extends APIError {}
+export class PermissionDeniedError extends APIError {}
+export class UnavailableError extends APIError {}
+
+const errorsByCode = {
+	'not_found':		NotFoundError,
+	'invalid_argument':	InvalidArgumentError,
+	'conflict':		ConflictError,
+	'unauthenticated':	UnauthenticatedError,
+	'permission_denied':	PermissionDeniedError,
+	'unavailable':		UnavailableError,
+}
+
+function apiError(response, json) {
+	const ErrorClass = errorsByCode[json.code] || APIError
+	return new ErrorClass(json.error, json.code, response.status, json.fields)
+}
+
+// patterns are the named formats fields can be required to match, keep them in sync
+// with validation.Patterns.
+const patterns = {
+	'slug':		/^[a-z0-9]+(?:-[a-z0-9]+)*$/,
+	'email':	/^[^@\s]+@[^@\s]+\.[^@\s]+$/,
+	'url':		/^https?:\/\/[^\s/?#]+[^\s]*$/,
+	'https url':	/^https:\/\/[^\s/?#]+[^\s]*$/,
+}
+
+function field(path, name) {
+	return path ? path + '.' + name : name
+}
+
+function length(s) {
+	return [...s].length
+}
+
+function matches(pattern, s) {
+	return pattern in patterns && patterns[pattern].test(s)
+}
+
+// validateEventSalesRequest returns the violations of the rules annotated on the fields
+// of eventSalesRequest, the server checks them too.
+export function validateEventSalesRequest(eventSalesRequest, path = '') {
+	const o = eventSalesRequest || {}
+	const violations = []
+	if (!o.conferenceID || o.conferenceID.length === 0) {
+		violations.push({ field: field(path, 'conferenceID'), description: 'is required' })
+	}
+	if (!o.eventID || o.eventID.length === 0) {
+		violations.push({ field: field(path, 'eventID'), description: 'is required' })
+	}
+	return violations
+}
+
+// validateDailySales returns the violations of the rules annotated on the fields
+// of dailySales, the server checks them too.
+export function validateDailySales(dailySales, path = '') {
+	const o = dailySales || {}
+	const violations = []
+	return violations
+}
+
+// validateSlotSales returns the violations of the rules annotated on the fields
+// of slotSales, the server checks them too.
+export function validateSlotSales(slotSales, path = '') {
+	const o = slotSales || {}
+	const violations = []
+	for (const [i, item] of (o.daily || []).entries()) {
+		violations.push(...validateDailySales(item, `${field(path, 'daily')}[${i}]`))
+	}
+	return violations
+}
+
+// validatePromoUsage returns the violations of the rules annotated on the fields
+// of promoUsage, the server checks them too.
+export function validatePromoUsage(promoUsage, path = '') {
+	const o = promoUsage || {}
+	const violations = []
+	return violations
+}
+
+// validateEventSalesResponse returns the violations of the rules annotated on the fields
+// of eventSalesResponse, the server checks them too.
+export function validateEventSalesResponse(eventSalesResponse, path = '') {
+	const o = eventSalesResponse || {}
+	const violations = []
+	for (const [i, item] of (o.slots || []).entries()) {
+		violations.push(...validateSlotSales(item, `${field(path, 'slots')}[${i}]`))
+	}
+	if (o.total) {
+		violations.push(...validateSlotSales(o.total, field(path, 'total')))
+	}
+	for (const [i, item] of (o.promos || []).entries()) {
+		violations.push(...validatePromoUsage(item, `${field(path, 'promos')}[${i}]`))
+	}
+	return violations
+}
+
+// validateAttendeeFeedRequest returns the violations of the rules annotated on the fields
+// of attendeeFeedRequest, the server checks them too.
+export function validateAttendeeFeedRequest(attendeeFeedRequest, path = '') {
+	const o = attendeeFeedRequest || {}
+	const violations = []
+	return violations
+}
+
+// validateAttendeeFeedResponse returns the violations of the rules annotated on the fields
+// of attendeeFeedResponse, the server checks them too.
+export function validateAttendeeFeedResponse(attendeeFeedResponse, path = '') {
+	const o = attendeeFeedResponse || {}
+	const violations = []
+	return violations
+}
+
+// validateEventSlot returns the violations of the rules annotated on the fields
+// of eventSlot, the server checks them too.
+export function validateEventSlot(eventSlot, path = '') {
+	const o = eventSlot || {}
+	const violations = []
+	if (!o.name || o.name.length === 0) {
+		violations.push({ field: field(path, 'name'), description: 'is required' })
+	}
+	if (o.cost < 0) {
+		violations.push({ field: field(path, 'cost'), description: 'must be at least 0' })
+	}
+	if (o.capacity < 0) {
+		violations.push({ field: field(path, 'capacity'), description: 'must be at least 0' })
+	}
+	if (o.startDate && o.endDate && o.startDate >= o.endDate) {
+		violations.push({ field: field(path, 'startDate'), description: 'must be before endDate' })
+	}
+	if (o.purchaseableFrom && o.purchaseableUntil && o.purchaseableFrom >= o.purchaseableUntil) {
+		violations.push({ field: field(path, 'purchaseableFrom'), description: 'must be before purchaseableUntil' })
+	}
+	return violations
+}
+
+// validateSponsorContact returns the violations of the rules annotated on the fields
+// of sponsorContact, the server checks them too.
+export function validateSponsorContact(sponsorContact, path = '') {
+	const o = sponsorContact || {}
+	const violations = []
+	if (o.email && !matches('email', o.email)) {
+		violations.push({ field: field(path, 'email'), description: 'must be a valid email' })
+	}
+	return violations
+}
+
+// validateSponsor returns the violations of the rules annotated on the fields
+// of sponsor, the server checks them too.
+export function validateSponsor(sponsor, path = '') {
+	const o = sponsor || {}
+	const violations = []
+	if (!o.name || o.name.length === 0) {
+		violations.push({ field: field(path, 'name'), description: 'is required' })
+	}
+	for (const [i, item] of (o.contacts || []).entries()) {
+		violations.push(...validateSponsorContact(item, `${field(path, 'contacts')}[${i}]`))
+	}
+	return violations
+}
+
+// validateEvent returns the violations of the rules annotated on the fields
+// of event, the server checks them too.
+export function validateEvent(event, path = '') {
+	const o = event || {}
+	const violations = []
+	if (!o.name || o.name.length === 0) {
+		violations.push({ field: field(path, 'name'), description: 'is required' })
+	}
+	if (o.name && length(o.name) > 100) {
+		violations.push({ field: field(path, 'name'), description: 'must be at most 100 characters long' })
+	}
+	if (!o.slug || o.slug.length === 0) {
+		violations.push({ field: field(path, 'slug'), description: 'is required' })
+	}
+	if (o.slug && length(o.slug) > 50) {
+		violations.push({ field: field(path, 'slug'), description: 'must be at most 50 characters long' })
+	}
+	if (o.slug && !matches('slug', o.slug)) {
+		violations.push({ field: field(path, 'slug'), description: 'must be a valid slug' })
+	}
+	if (o.startDate && o.endDate && o.startDate >= o.endDate) {
+		violations.push({ field: field(path, 'startDate'), description: 'must be before endDate' })
+	}
+	for (const [i, item] of (o.slots || []).entries()) {
+		violations.push(...validateEventSlot(item, `${field(path, 'slots')}[${i}]`))
+	}
+	for (const [i, item] of (o.sponsors || []).entries()) {
+		violations.push(...validateSponsor(item, `${field(path, 'sponsors')}[${i}]`))
+	}
+	return violations
+}
+
+// validateConference returns the violations of the rules annotated on the fields
+// of conference, the server checks them too.
+export function validateConference(conference, path = '') {
+	const o = conference || {}
+	const violations = []
+	if (!o.name || o.name.length === 0) {
+		violations.push({ field: field(path, 'name'), description: 'is required' })
+	}
+	if (o.name && length(o.name) > 100) {
+		violations.push({ field: field(path, 'name'), description: 'must be at most 100 characters long' })
+	}
+	if (!o.slug || o.slug.length === 0) {
+		violations.push({ field: field(path, 'slug'), description: 'is required' })
+	}
+	if (o.slug && length(o.slug) > 50) {
+		violations.push({ field: field(path, 'slug'), description: 'must be at most 50 characters long' })
+	}
+	if (o.slug && !matches('slug', o.slug)) {
+		violations.push({ field: field(path, 'slug'), description: 'must be a valid slug' })
+	}
+	for (const [i, item] of (o.events || []).entries()) {
+		violations.push(...validateEvent(item, `${field(path, 'events')}[${i}]`))
+	}
+	return violations
+}
+
+// validateCreateConferenceRequest returns the violations of the rules annotated on the fields
+// of createConferenceRequest, the server checks them too.
+export function validateCreateConferenceRequest(createConferenceRequest, path = '') {
+	const o = createConferenceRequest || {}
+	const violations = []
+	if (o.conference) {
+		violations.push(...validateConference(o.conference, field(path, 'conference')))
+	}
+	return violations
+}
+
+// validateCreateConferenceResponse returns the violations of the rules annotated on the fields
+// of createConferenceResponse, the server checks them too.
+export function validateCreateConferenceResponse(createConferenceResponse, path = '') {
+	const o = createConferenceResponse || {}
+	const violations = []
+	if (o.conference) {
+		violations.push(...validateConference(o.conference, field(path, 'conference')))
+	}
+	return violations
+}
+
+// validateDeleteConferenceRequest returns the violations of the rules annotated on the fields
+// of deleteConferenceRequest, the server checks them too.
+export function validateDeleteConferenceRequest(deleteConferenceRequest, path = '') {
+	const o = deleteConferenceRequest || {}
+	const violations = []
+	return violations
+}
+
+// validateDeleteConferenceResponse returns the violations of the rules annotated on the fields
+// of deleteConferenceResponse, the server checks them too.
+export function validateDeleteConferenceResponse(deleteConferenceResponse, path = '') {
+	const o = deleteConferenceResponse || {}
+	const violations = []
+	return violations
+}
+
+// validateGetConferenceRequest returns the violations of the rules annotated on the fields
+// of getConferenceRequest, the server checks them too.
+export function validateGetConferenceRequest(getConferenceRequest, path = '') {
+	const o = getConferenceRequest || {}
+	const violations = []
+	return violations
+}
+
+// validateGetConferenceResponse returns the violations of the rules annotated on the fields
+// of getConferenceResponse, the server checks them too.
+export function validateGetConferenceResponse(getConferenceResponse, path = '') {
+	const o = getConferenceResponse || {}
+	const violations = []
+	if (o.conference) {
+		violations.push(...validateConference(o.conference, field(path, 'conference')))
+	}
+	return violations
+}
+
+// validateGetConferenceBySlugRequest returns the violations of the rules annotated on the fields
+// of getConferenceBySlugRequest, the server checks them too.
+export function validateGetConferenceBySlugRequest(getConferenceBySlugRequest, path = '') {
+	const o = getConferenceBySlugRequest || {}
+	const violations = []
+	if (!o.slug || o.slug.length === 0) {
+		violations.push({ field: field(path, 'slug'), description: 'is required' })
+	}
+	if (o.slug && !matches('slug', o.slug)) {
+		violations.push({ field: field(path, 'slug'), description: 'must be a valid slug' })
+	}
+	return violations
+}
+
+// validateListConferenceRequest returns the violations of the rules annotated on the fields
+// of listConferenceRequest, the server checks them too.
+export function validateListConferenceRequest(listConferenceRequest, path = '') {
+	const o = listConferenceRequest || {}
+	const violations = []
+	return violations
+}
+
+// validateListConferenceResponse returns the violations of the rules annotated on the fields
+// of listConferenceResponse, the server checks them too.
+export function validateListConferenceResponse(listConferenceResponse, path = '') {
+	const o = listConferenceResponse || {}
+	const violations = []
+	for (const [i, item] of (o.conferences || []).entries()) {
+		violations.push(...validateConference(item, `${field(path, 'conferences')}[${i}]`))
+	}
+	return violations
+}
+
+// validateWebhook returns the violations of the rules annotated on the fields
+// of webhook, the server checks them too.
+export function validateWebhook(webhook, path = '') {
+	const o = webhook || {}
+	const violations = []
+	if (!o.url || o.url.length === 0) {
+		violations.push({ field: field(path, 'url'), description: 'is required' })
+	}
+	if (o.url && length(o.url) > 2000) {
+		violations.push({ field: field(path, 'url'), description: 'must be at most 2000 characters long' })
+	}
+	if (o.url && !matches('https url', o.url)) {
+		violations.push({ field: field(path, 'url'), description: 'must be a valid https url' })
+	}
+	if (o.secret && length(o.secret) > 200) {
+		violations.push({ field: field(path, 'secret'), description: 'must be at most 200 characters long' })
+	}
+	return violations
+}
+
+// validateCreateWebhookRequest returns the violations of the rules annotated on the fields
+// of createWebhookRequest, the server checks them too.
+export function validateCreateWebhookRequest(createWebhookRequest, path = '') {
+	const o = createWebhookRequest || {}
+	const violations = []
+	if (!o.conferenceID || o.conferenceID.length === 0) {
+		violations.push({ field: field(path, 'conferenceID'), description: 'is required' })
+	}
+	if (o.webhook) {
+		violations.push(...validateWebhook(o.webhook, field(path, 'webhook')))
+	}
+	return violations
+}
+
+// validateCreateWebhookResponse returns the violations of the rules annotated on the fields
+// of createWebhookResponse, the server checks them too.
+export function validateCreateWebhookResponse(createWebhookResponse, path = '') {
+	const o = createWebhookResponse || {}
+	const violations = []
+	if (o.webhook) {
+		violations.push(...validateWebhook(o.webhook, field(path, 'webhook')))
+	}
+	return violations
+}
+
+// validateDeleteWebhookRequest returns the violations of the rules annotated on the fields
+// of deleteWebhookRequest, the server checks them too.
+export function validateDeleteWebhookRequest(deleteWebhookRequest, path = '') {
+	const o = deleteWebhookRequest || {}
+	const violations = []
+	if (!o.conferenceID || o.conferenceID.length === 0) {
+		violations.push({ field: field(path, 'conferenceID'), description: 'is required' })
+	}
+	if (!o.id || o.id.length === 0) {
+		violations.push({ field: field(path, 'id'), description: 'is required' })
+	}
+	return violations
+}
+
+// validateDeleteWebhookResponse returns the violations of the rules annotated on the fields
+// of deleteWebhookResponse, the server checks them too.
+export function validateDeleteWebhookResponse(deleteWebhookResponse, path = '') {
+	const o = deleteWebhookResponse || {}
+	const violations = []
+	return violations
+}
+
+// validateGetWebhookDeliveryRequest returns the violations of the rules annotated on the fields
+// of getWebhookDeliveryRequest, the server checks them too.
+export function validateGetWebhookDeliveryRequest(getWebhookDeliveryRequest, path = '') {
+	const o = getWebhookDeliveryRequest || {}
+	const violations = []
+	if (!o.conferenceID || o.conferenceID.length === 0) {
+		violations.push({ field: field(path, 'conferenceID'), description: 'is required' })
+	}
+	if (!o.id || o.id.length === 0) {
+		violations.push({ field: field(path, 'id'), description: 'is required' })
+	}
+	return violations
+}
+
+// validateWebhookDelivery returns the violations of the rules annotated on the fields
+// of webhookDelivery, the server checks them too.
+export function validateWebhookDelivery(webhookDelivery, path = '') {
+	const o = webhookDelivery || {}
+	const violations = []
+	return violations
+}
+
+// validateWebhookAttempt returns the violations of the rules annotated on the fields
+// of webhookAttempt, the server checks them too.
+export function validateWebhookAttempt(webhookAttempt, path = '') {
+	const o = webhookAttempt || {}
+	const violations = []
+	return violations
+}
+
+// validateGetWebhookDeliveryResponse returns the violations of the rules annotated on the fields
+// of getWebhookDeliveryResponse, the server checks them too.
+export function validateGetWebhookDeliveryResponse(getWebhookDeliveryResponse, path = '') {
+	const o = getWebhookDeliveryResponse || {}
+	const violations = []
+	if (o.delivery) {
+		violations.push(...validateWebhookDelivery(o.delivery, field(path, 'delivery')))
+	}
+	for (const [i, item] of (o.attempts || []).entries()) {
+		violations.push(...validateWebhookAttempt(item, `${field(path, 'attempts')}[${i}]`))
+	}
+	return violations
+}
+
+// validateListWebhookDeliveriesRequest returns the violations of the rules annotated on the fields
+// of listWebhookDeliveriesRequest, the server checks them too.
+export function validateListWebhookDeliveriesRequest(listWebhookDeliveriesRequest, path = '') {
+	const o = listWebhookDeliveriesRequest || {}
+	const violations = []
+	if (!o.conferenceID || o.conferenceID.length === 0) {
+		violations.push({ field: field(path, 'conferenceID'), description: 'is required' })
+	}
+	if (!o.webhookID || o.webhookID.length === 0) {
+		violations.push({ field: field(path, 'webhookID'), description: 'is required' })
+	}
+	if (o.limit < 0) {
+		violations.push({ field: field(path, 'limit'), description: 'must be at least 0' })
+	}
+	if (o.limit > 500) {
+		violations.push({ field: field(path, 'limit'), description: 'must be at most 500' })
+	}
+	return violations
+}
+
+// validateListWebhookDeliveriesResponse returns the violations of the rules annotated on the fields
+// of listWebhookDeliveriesResponse, the server checks them too.
+export function validateListWebhookDeliveriesResponse(listWebhookDeliveriesResponse, path = '') {
+	const o = listWebhookDeliveriesResponse || {}
+	const violations = []
+	for (const [i, item] of (o.deliveries || []).entries()) {
+		violations.push(...validateWebhookDelivery(item, `${field(path, 'deliveries')}[${i}]`))
+	}
+	return violations
+}
+
+// validateListWebhooksRequest returns the violations of the rules annotated on the fields
+// of listWebhooksRequest, the server checks them too.
+export function validateListWebhooksRequest(listWebhooksRequest, path = '') {
+	const o = listWebhooksRequest || {}
+	const violations = []
+	if (!o.conferenceID || o.conferenceID.length === 0) {
+		violations.push({ field: field(path, 'conferenceID'), description: 'is required' })
+	}
+	return violations
+}
+
+// validateListWebhooksResponse returns the violations of the rules annotated on the fields
+// of listWebhooksResponse, the server checks them too.
+export function validateListWebhooksResponse(listWebhooksResponse, path = '') {
+	const o = listWebhooksResponse || {}
+	const violations = []
+	for (const [i, item] of (o.webhooks || []).entries()) {
+		violations.push(...validateWebhook(item, `${field(path, 'webhooks')}[${i}]`))
+	}
+	return violations
+}
+
+// validateRedeliverWebhookRequest returns the violations of the rules annotated on the fields
+// of redeliverWebhookRequest, the server checks them too.
+export function validateRedeliverWebhookRequest(redeliverWebhookRequest, path = '') {
+	const o = redeliverWebhookRequest || {}
+	const violations = []
+	if (!o.conferenceID || o.conferenceID.length === 0) {
+		violations.push({ field: field(path, 'conferenceID'), description: 'is required' })
+	}
+	if (!o.id || o.id.length === 0) {
+		violations.push({ field: field(path, 'id'), description: 'is required' })
+	}
+	return violations
+}
+
+// validateRedeliverWebhookResponse returns the violations of the rules annotated on the fields
+// of redeliverWebhookResponse, the server checks them too.
+export function validateRedeliverWebhookResponse(redeliverWebhookResponse, path = '') {
+	const o = redeliverWebhookResponse || {}
+	const violations = []
+	if (o.delivery) {
+		violations.push(...validateWebhookDelivery(o.delivery, field(path, 'delivery')))
+	}
+	return violations
+}
+
+ 
+export class AnalyticsService {
+	
+	async eventSales(eventSalesRequest) {
+		const headers = {
+			'Accept':		'application/json',
+			'Accept-Encoding':	'gzip',
+			'Content-Type':		'application/json',
+		}
+		eventSalesRequest = eventSalesRequest || {}
+		const violations = validateEventSalesRequest(eventSalesRequest)
+		if (violations.length !== 0) {
+			throw new InvalidArgumentError('invalid request', 'invalid_argument', 400, violations)
+		}
+		const response = await fetch('/oto/AnalyticsService.EventSales', {
+			method: 'POST',
+			headers: headers,
+			body: JSON.stringify(eventSalesRequest)
+		})
+		const json = await response.json().catch(() => ({}))
+		if (!response.ok || json.error) {
+			throw apiError(response, json.error ? json : { error: response.statusText })
+		}
+		return json
+	}
+	
+}
+ 
+export class CalendarService {
+	
+	async attendeeFeed(attendeeFeedRequest) {
+		const headers = {
+			'Accept':		'application/json',
+			'Accept-Encoding':	'gzip',
+			'Content-Type':		'application/json',
+		}
+		attendeeFeedRequest = attendeeFeedRequest || {}
+		const violations = validateAttendeeFeedRequest(attendeeFeedRequest)
+		if (violations.length !== 0) {
+			throw new InvalidArgumentError('invalid request', 'invalid_argument', 400, violations)
+		}
+		const response = await fetch('/oto/CalendarService.AttendeeFeed', {
+			method: 'POST',
+			headers: headers,
+			body: JSON.stringify(attendeeFeedRequest)
+		})
+		const json = await response.json().catch(() => ({}))
+		if (!response.ok || json.error) {
+			throw apiError(response, json.error ? json : { error: response.statusText })
+		}
+		return json
+	}
+	
+}
  
-export default class ConferenceService {
+export class ConferenceService {
 	
 	async create(createConferenceRequest) {
 		const headers = {
@@ -12,17 +578,20 @@ export default class ConferenceService {
 			'Content-Type':		'application/json',
 		}
 		createConferenceRequest = createConferenceRequest || {}
+		const violations = validateCreateConferenceRequest(createConferenceRequest)
+		if (violations.length !== 0) {
+			throw new InvalidArgumentError('invalid request', 'invalid_argument', 400, violations)
+		}
 		const response = await fetch('/oto/ConferenceService.Create', {
 			method: 'POST',
 			headers: headers,
 			body: JSON.stringify(createConferenceRequest)
 		})
-		return response.json().then((json) => {
-			if (json.error) {
-				throw new Error(json.error)
-			}
-			return json
-		})
+		const json = await response.json().catch(() => ({}))
+		if (!response.ok || json.error) {
+			throw apiError(response, json.error ? json : { error: response.statusText })
+		}
+		return json
 	}
 	
 	async delete(deleteConferenceRequest) {
@@ -32,17 +601,20 @@ export default class ConferenceService {
 			'Content-Type':		'application/json',
 		}
 		deleteConferenceRequest = deleteConferenceRequest || {}
+		const violations = validateDeleteConferenceRequest(deleteConferenceRequest)
+		if (violations.length !== 0) {
+			throw new InvalidArgumentError('invalid request', 'invalid_argument', 400, violations)
+		}
 		const response = await fetch('/oto/ConferenceService.Delete', {
 			method: 'POST',
 			headers: headers,
 			body: JSON.stringify(deleteConferenceRequest)
 		})
-		return response.json().then((json) => {
-			if (json.error) {
-				throw new Error(json.error)
-			}
-			return json
-		})
+		const json = await response.json().catch(() => ({}))
+		if (!response.ok || json.error) {
+			throw apiError(response, json.error ? json : { error: response.statusText })
+		}
+		return json
 	}
 	
 	async get(getConferenceRequest) {
@@ -52,17 +624,20 @@ export default class ConferenceService {
 			'Content-Type':		'application/json',
 		}
 		getConferenceRequest = getConferenceRequest || {}
+		const violations = validateGetConferenceRequest(getConferenceRequest)
+		if (violations.length !== 0) {
+			throw new InvalidArgumentError('invalid request', 'invalid_argument', 400, violations)
+		}
 		const response = await fetch('/oto/ConferenceService.Get', {
 			method: 'POST',
 			headers: headers,
 			body: JSON.stringify(getConferenceRequest)
 		})
-		return response.json().then((json) => {
-			if (json.error) {
-				throw new Error(json.error)
-			}
-			return json
-		})
+		const json = await response.json().catch(() => ({}))
+		if (!response.ok || json.error) {
+			throw apiError(response, json.error ? json : { error: response.statusText })
+		}
+		return json
 	}
 	
 	async getBySlug(getConferenceBySlugRequest) {
@@ -72,17 +647,20 @@ export default class ConferenceService {
 			'Content-Type':		'application/json',
 		}
 		getConferenceBySlugRequest = getConferenceBySlugRequest || {}
+		const violations = validateGetConferenceBySlugRequest(getConferenceBySlugRequest)
+		if (violations.length !== 0) {
+			throw new InvalidArgumentError('invalid request', 'invalid_argument', 400, violations)
+		}
 		const response = await fetch('/oto/ConferenceService.GetBySlug', {
 			method: 'POST',
 			headers: headers,
 			body: JSON.stringify(getConferenceBySlugRequest)
 		})
-		return response.json().then((json) => {
-			if (json.error) {
-				throw new Error(json.error)
-			}
-			return json
-		})
+		const json = await response.json().catch(() => ({}))
+		if (!response.ok || json.error) {
+			throw apiError(response, json.error ? json : { error: response.statusText })
+		}
+		return json
 	}
 	
 	async list(listConferenceRequest) {
@@ -92,17 +670,162 @@ export default class ConferenceService {
 			'Content-Type':		'application/json',
 		}
 		listConferenceRequest = listConferenceRequest || {}
+		const violations = validateListConferenceRequest(listConferenceRequest)
+		if (violations.length !== 0) {
+			throw new InvalidArgumentError('invalid request', 'invalid_argument', 400, violations)
+		}
 		const response = await fetch('/oto/ConferenceService.List', {
 			method: 'POST',
 			headers: headers,
 			body: JSON.stringify(listConferenceRequest)
 		})
-		return response.json().then((json) => {
-			if (json.error) {
-				throw new Error(json.error)
-			}
-			return json
+		const json = await response.json().catch(() => ({}))
+		if (!response.ok || json.error) {
+			throw apiError(response, json.error ? json : { error: response.statusText })
+		}
+		return json
+	}
+	
+}
+ 
+export class WebhookService {
+	
+	async create(createWebhookRequest) {
+		const headers = {
+			'Accept':		'application/json',
+			'Accept-Encoding':	'gzip',
+			'Content-Type':		'application/json',
+		}
+		createWebhookRequest = createWebhookRequest || {}
+		const violations = validateCreateWebhookRequest(createWebhookRequest)
+		if (violations.length !== 0) {
+			throw new InvalidArgumentError('invalid request', 'invalid_argument', 400, violations)
+		}
+		const response = await fetch('/oto/WebhookService.Create', {
+			method: 'POST',
+			headers: headers,
+			body: JSON.stringify(createWebhookRequest)
 		})
+		const json = await response.json().catch(() => ({}))
+		if (!response.ok || json.error) {
+			throw apiError(response, json.error ? json : { error: response.statusText })
+		}
+		return json
+	}
+	
+	async delete(deleteWebhookRequest) {
+		const headers = {
+			'Accept':		'application/json',
+			'Accept-Encoding':	'gzip',
+			'Content-Type':		'application/json',
+		}
+		deleteWebhookRequest = deleteWebhookRequest || {}
+		const violations = validateDeleteWebhookRequest(deleteWebhookRequest)
+		if (violations.length !== 0) {
+			throw new InvalidArgumentError('invalid request', 'invalid_argument', 400, violations)
+		}
+		const response = await fetch('/oto/WebhookService.Delete', {
+			method: 'POST',
+			headers: headers,
+			body: JSON.stringify(deleteWebhookRequest)
+		})
+		const json = await response.json().catch(() => ({}))
+		if (!response.ok || json.error) {
+			throw apiError(response, json.error ? json : { error: response.statusText })
+		}
+		return json
+	}
+	
+	async getDelivery(getWebhookDeliveryRequest) {
+		const headers = {
+			'Accept':		'application/json',
+			'Accept-Encoding':	'gzip',
+			'Content-Type':		'application/json',
+		}
+		getWebhookDeliveryRequest = getWebhookDeliveryRequest || {}
+		const violations = validateGetWebhookDeliveryRequest(getWebhookDeliveryRequest)
+		if (violations.length !== 0) {
+			throw new InvalidArgumentError('invalid request', 'invalid_argument', 400, violations)
+		}
+		const response = await fetch('/oto/WebhookService.GetDelivery', {
+			method: 'POST',
+			headers: headers,
+			body: JSON.stringify(getWebhookDeliveryRequest)
+		})
+		const json = await response.json().catch(() => ({}))
+		if (!response.ok || json.error) {
+			throw apiError(response, json.error ? json : { error: response.statusText })
+		}
+		return json
+	}
+	
+	async list(listWebhooksRequest) {
+		const headers = {
+			'Accept':		'application/json',
+			'Accept-Encoding':	'gzip',
+			'Content-Type':		'application/json',
+		}
+		listWebhooksRequest = listWebhooksRequest || {}
+		const violations = validateListWebhooksRequest(listWebhooksRequest)
+		if (violations.length !== 0) {
+			throw new InvalidArgumentError('invalid request', 'invalid_argument', 400, violations)
+		}
+		const response = await fetch('/oto/WebhookService.List', {
+			method: 'POST',
+			headers: headers,
+			body: JSON.stringify(listWebhooksRequest)
+		})
+		const json = await response.json().catch(() => ({}))
+		if (!response.ok || json.error) {
+			throw apiError(response, json.error ? json : { error: response.statusText })
+		}
+		return json
+	}
+	
+	async listDeliveries(listWebhookDeliveriesRequest) {
+		const headers = {
+			'Accept':		'application/json',
+			'Accept-Encoding':	'gzip',
+			'Content-Type':		'application/json',
+		}
+		listWebhookDeliveriesRequest = listWebhookDeliveriesRequest || {}
+		const violations = validateListWebhookDeliveriesRequest(listWebhookDeliveriesRequest)
+		if (violations.length !== 0) {
+			throw new InvalidArgumentError('invalid request', 'invalid_argument', 400, violations)
+		}
+		const response = await fetch('/oto/WebhookService.ListDeliveries', {
+			method: 'POST',
+			headers: headers,
+			body: JSON.stringify(listWebhookDeliveriesRequest)
+		})
+		const json = await response.json().catch(() => ({}))
+		if (!response.ok || json.error) {
+			throw apiError(response, json.error ? json : { error: response.statusText })
+		}
+		return json
+	}
+	
+	async redeliver(redeliverWebhookRequest) {
+		const headers = {
+			'Accept':		'application/json',
+			'Accept-Encoding':	'gzip',
+			'Content-Type':		'application/json',
+		}
+		redeliverWebhookRequest = redeliverWebhookRequest || {}
+		const violations = validateRedeliverWebhookRequest(redeliverWebhookRequest)
+		if (violations.length !== 0) {
+			throw new InvalidArgumentError('invalid request', 'invalid_argument', 400, violations)
+		}
+		const response = await fetch('/oto/WebhookService.Redeliver', {
+			method: 'POST',
+			headers: headers,
+			body: JSON.stringify(redeliverWebhookRequest)
+		})
+		const json = await response.json().catch(() => ({}))
+		if (!response.ok || json.error) {
+			throw apiError(response, json.error ? json : { error: response.statusText })
+		}
+		return json
 	}
 	
 }